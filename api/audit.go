@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/ipfs-force-community/sophon-auth/core"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/service"
+)
+
+// auditMethods are the administrative apis which change state, every call of them is recorded in audit log.
+var auditMethods = map[string]struct{}{
+	"ActiveAddress":           {},
+	"AuthBindSigner":          {},
+	"AuthUserAdd":             {},
+	"CancelMessage":           {},
+	"ClearUnFillMessage":      {},
+	"DeleteAddress":           {},
+	"DeleteAddressPolicy":     {},
+	"DeleteNode":              {},
+	"ForbiddenAddress":        {},
+	"MarkBadMessage":          {},
+	"NetConnect":              {},
+	"PushEthTransaction":      {},
+	"PushSignedMessage":       {},
+	"RecoverFailedMsg":        {},
+	"ReloadConfig":            {},
+	"ReplaceMessage":          {},
+	"RepublishMessage":        {},
+	"SaveActorCfg":            {},
+	"SaveAddressPolicy":       {},
+	"SaveNode":                {},
+	"SetFeeParams":            {},
+	"SetLogLevel":             {},
+	"SetSelectMsgNum":         {},
+	"SetSharedParams":         {},
+	"UpdateActorCfg":          {},
+	"UpdateAllFilledMessage":  {},
+	"UpdateFilledMessageByID": {},
+	"UpdateMessageStateByID":  {},
+	"UpdateNonce":             {},
+}
+
+// auditRedactors replace the secrets in the results of methods before they are recorded
+var auditRedactors = map[string]func(result interface{}) interface{}{
+	"AuthUserAdd": func(result interface{}) interface{} {
+		user, ok := result.(*mtypes.AuthUser)
+		if !ok || user == nil {
+			return result
+		}
+		redacted := *user
+		redacted.Token = redactedValue
+		return &redacted
+	},
+}
+
+const redactedValue = "***"
+
+// maxAuditFieldLen limit the length of arguments and result stored in audit log
+const maxAuditFieldLen = 4096
+
+// AuditProxy copy the api functions of `in` to `out`, the methods in auditMethods are wrapped to record audit log,
+// `in` and `out` should be the same api struct, like WraperLimiter of rate limit.
+func AuditProxy(auditSrv *service.AuditService, in interface{}, out interface{}) {
+	vin := reflect.ValueOf(in)
+	rout := reflect.ValueOf(out).Elem()
+
+	for i := 0; i < vin.NumField(); i++ {
+		fieldName := vin.Type().Field(i).Name
+
+		if vin.Field(i).Type().Kind() == reflect.Struct {
+			field := rout.FieldByName(fieldName)
+			if field.IsValid() && field.Type().Kind() == reflect.Struct {
+				AuditProxy(auditSrv, vin.Field(i).Interface(), field.Addr().Interface())
+			} else {
+				AuditProxy(auditSrv, vin.Field(i).Interface(), out)
+			}
+			continue
+		}
+
+		field, exists := rout.Type().FieldByName(fieldName)
+		if !exists || field.Type.Kind() != reflect.Func {
+			continue
+		}
+		fn := vin.Field(i)
+		if fn.Kind() != reflect.Func || fn.IsNil() {
+			continue
+		}
+		if _, ok := auditMethods[fieldName]; !ok {
+			rout.FieldByName(fieldName).Set(fn)
+			continue
+		}
+
+		method := fieldName
+		rout.FieldByName(fieldName).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) []reflect.Value {
+			results := fn.Call(args)
+			ctx := args[0].Interface().(context.Context)
+			if err := auditSrv.Record(context.WithoutCancel(ctx), newAuditLog(ctx, method, args[1:], results)); err != nil {
+				log.Errorf("record audit log of %s failed: %v", method, err)
+			}
+			return results
+		}))
+	}
+}
+
+func newAuditLog(ctx context.Context, method string, args []reflect.Value, results []reflect.Value) *mtypes.AuditLog {
+	caller, _ := core.CtxGetName(ctx)
+	auditLog := &mtypes.AuditLog{
+		Caller: caller,
+		Method: method,
+	}
+
+	params := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		params = append(params, arg.Interface())
+	}
+	auditLog.Args = marshalAuditField(params)
+
+	if errV := results[len(results)-1]; !errV.IsNil() {
+		auditLog.Error = errV.Interface().(error).Error()
+	} else if len(results) == 2 {
+		result := results[0].Interface()
+		if redact, ok := auditRedactors[method]; ok {
+			result = redact(result)
+		}
+		auditLog.Result = marshalAuditField(result)
+	}
+
+	return auditLog
+}
+
+func marshalAuditField(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err.Error()
+	}
+	if len(data) > maxAuditFieldLen {
+		return string(data[:maxAuditFieldLen]) + "..."
+	}
+	return string(data)
+}
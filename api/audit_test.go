@@ -0,0 +1,71 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs-force-community/sophon-auth/core"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ipfs-force-community/sophon-messager/api"
+	"github.com/ipfs-force-community/sophon-messager/api/ext"
+	"github.com/ipfs-force-community/sophon-messager/filestore"
+	"github.com/ipfs-force-community/sophon-messager/models"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/service"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestAuditProxy(t *testing.T) {
+	ctx := context.Background()
+	fsRepo := filestore.NewMockFileStore(t.TempDir())
+	repo, err := models.SetDataBase(fsRepo)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.AutoMigrate())
+	auditSrv := service.NewAuditService(repo)
+
+	addr := testhelper.RandAddresses(t, 1)[0]
+	var in ext.IMessagerExtStruct
+	in.IMessagerStruct.Internal.UpdateNonce = func(ctx context.Context, addr address.Address, nonce uint64) error {
+		return nil
+	}
+	in.IMessagerStruct.Internal.ForbiddenAddress = func(ctx context.Context, addr address.Address) error {
+		return errors.New("address not exist")
+	}
+	in.IMessagerStruct.Internal.HasAddress = func(ctx context.Context, addr address.Address) (bool, error) {
+		return true, nil
+	}
+	in.IAuthStruct.Internal.AuthUserAdd = func(ctx context.Context, name string, perm string) (*mtypes.AuthUser, error) {
+		return &mtypes.AuthUser{Name: name, Perm: perm, Token: "secret-token"}, nil
+	}
+
+	var out ext.IMessagerExtStruct
+	api.AuditProxy(auditSrv, in, &out)
+
+	ctx = core.CtxWithName(ctx, "admin")
+	assert.NoError(t, out.UpdateNonce(ctx, addr, 10))
+	assert.EqualError(t, out.ForbiddenAddress(ctx, addr), "address not exist")
+	has, err := out.HasAddress(ctx, addr)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	// the token is returned to the caller, but not recorded
+	user, err := out.AuthUserAdd(ctx, "alice", core.PermWrite)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-token", user.Token)
+
+	logs, err := auditSrv.ListAuditLog(ctx, &mtypes.AuditLogQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 3)
+	methods := map[string]*mtypes.AuditLog{}
+	for _, l := range logs {
+		assert.Equal(t, "admin", l.Caller)
+		methods[l.Method] = l
+	}
+	assert.Equal(t, `["`+addr.String()+`",10]`, methods["UpdateNonce"].Args)
+	assert.Empty(t, methods["UpdateNonce"].Error)
+	assert.Equal(t, "address not exist", methods["ForbiddenAddress"].Error)
+	assert.NotContains(t, methods["AuthUserAdd"].Result, "secret-token")
+	assert.Contains(t, methods["AuthUserAdd"].Result, `"token":"***"`)
+}
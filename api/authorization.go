@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/ipfs-force-community/sophon-auth/core"
+	"github.com/ipfs-force-community/sophon-auth/jwtclient"
+)
+
+// The apis touching messages, addresses and address policies are scoped to the signers bound to the account of the
+// caller, an admin can access all of them. The methods of MessageImp check the signers through the helpers below.
+
+// unscopedMethods are the apis without admin permission which don't touch any data of an account, so they are not
+// scoped to the signers of the caller.
+var unscopedMethods = map[string]struct{}{
+	"GetActorCfgByID": {},
+	"GetSharedParams": {},
+	"ListActorCfg":    {},
+	"NetTopicStatus":  {},
+	"NodePoolStatus":  {},
+	"Version":         {},
+}
+
+var errLocalAuthDisabled = errors.New("local auth mode is not enabled, the users are managed by sophon-auth")
+
+// isAdmin check if the user is admin
+func isAdmin(ctx context.Context) bool {
+	return core.HasPerm(ctx, nil, core.PermAdmin)
+}
+
+func getSigners(ctx context.Context, client jwtclient.IAuthClient) ([]address.Address, error) {
+	signers := []address.Address{}
+
+	user, exit := core.CtxGetName(ctx)
+	if !exit {
+		return nil, fmt.Errorf("user not found")
+	}
+	resp, err := client.ListSigners(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range resp {
+		signers = append(signers, res.Signer)
+	}
+
+	return signers, nil
+}
+
+// checkSigners returns an error wrapping jwtclient.ErrorPermissionDeny if any of the signers isn't bound to the caller
+func (m *MessageImp) checkSigners(ctx context.Context, signers ...address.Address) error {
+	return jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, signers...)
+}
+
+// getMessage returns the message of the id if the caller can access it
+func (m *MessageImp) getMessage(ctx context.Context, id string) (*types.Message, error) {
+	msg, err := m.MessageSrv.GetMessageByUid(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get message by id error: %w", err)
+	}
+	if err := m.checkSigners(ctx, msg.From); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// scopeSigners returns the signers a query should be limited to. The given signers are checked and returned as is;
+// if none is given, nil is returned for an admin which means no limit, and the signers of the caller for others.
+// ok is false if the caller has no signer, there is nothing to query then.
+func (m *MessageImp) scopeSigners(ctx context.Context, signers []address.Address) ([]address.Address, bool, error) {
+	if len(signers) > 0 {
+		if err := m.checkSigners(ctx, signers...); err != nil {
+			return nil, false, err
+		}
+		return signers, true, nil
+	}
+	if isAdmin(ctx) {
+		return nil, true, nil
+	}
+	signers, err := getSigners(ctx, m.AuthClient)
+	if err != nil {
+		return nil, false, err
+	}
+	return signers, len(signers) > 0, nil
+}
+
+// filterBySigner keeps the items whose signer can be accessed by the caller
+func filterBySigner[T any](ctx context.Context, m *MessageImp, items []T, signer func(T) address.Address) []T {
+	var result []T
+	for _, item := range items {
+		if err := m.checkSigners(ctx, signer(item)); err == nil {
+			result = append(result, item)
+		}
+	}
+	return result
+}
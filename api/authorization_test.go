@@ -0,0 +1,546 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/builtin"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/ipfs-force-community/sophon-auth/core"
+	"github.com/ipfs-force-community/sophon-auth/jwtclient"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/api/ext"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/service"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+// account is the data owned by an account
+type account struct {
+	name    string
+	addr    address.Address
+	msg     *types.Message
+	ethHash venusTypes.EthHash
+	policy  *mtypes.AddressPolicy
+}
+
+type authCase struct {
+	// name is the method, followed by `/<variant>` if a method has several cases
+	name string
+	// list is true if the method returns the data of the caller instead of denying
+	list bool
+	// adminOnly is true if the method is denied for any account which isn't an admin
+	adminOnly bool
+	call      func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error)
+}
+
+var authCases = []authCase{
+	{name: "HasMessageByUid", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.HasMessageByUid(ctx, acc.msg.ID)
+	}},
+	{name: "WaitMessage", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.WaitMessage(ctx, acc.msg.ID, 1)
+	}},
+	{name: "PushMessage", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.PushMessage(ctx, &venusTypes.Message{From: acc.addr, To: acc.addr}, nil)
+	}},
+	{name: "PushMessageWithId", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.PushMessageWithId(ctx, "id", &venusTypes.Message{From: acc.addr, To: acc.addr}, nil)
+	}},
+	{name: "PushSignedMessage", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.PushSignedMessage(ctx, &venusTypes.SignedMessage{Message: acc.msg.Message})
+	}},
+	{name: "PushEthTransaction", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		from, err := venusTypes.EthAddressFromFilecoinAddress(acc.addr)
+		if err != nil {
+			return nil, err
+		}
+		return m.PushEthTransaction(ctx, &mtypes.EthTxParams{From: &from})
+	}},
+	{name: "Send", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.Send(ctx, types.QuickSendParams{From: acc.addr, To: acc.addr})
+	}},
+	{name: "GetMessageByUid", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.GetMessageByUid(ctx, acc.msg.ID)
+	}},
+	{name: "GetMessageBySignedCid", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.GetMessageBySignedCid(ctx, *acc.msg.SignedCid)
+	}},
+	{name: "GetMessageByUnsignedCid", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.GetMessageByUnsignedCid(ctx, *acc.msg.UnsignedCid)
+	}},
+	{name: "GetMessageByFromAndNonce", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.GetMessageByFromAndNonce(ctx, acc.addr, acc.msg.Nonce)
+	}},
+	{name: "GetMessageByEthTxHash", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.GetMessageByEthTxHash(ctx, acc.ethHash)
+	}},
+	{name: "GetEthTxHash", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.GetEthTxHash(ctx, acc.msg.ID)
+	}},
+	{name: "GetMessagePublishStatus", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.GetMessagePublishStatus(ctx, acc.msg.ID)
+	}},
+	{name: "ListMessage", list: true, call: func(ctx context.Context, m *MessageImp, _ *account) (interface{}, error) {
+		return m.ListMessage(ctx, &types.MsgQueryParams{})
+	}},
+	{name: "ListMessage/from", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.ListMessage(ctx, &types.MsgQueryParams{From: []address.Address{acc.addr}})
+	}},
+	{name: "ListMessageByFromState", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.ListMessageByFromState(ctx, acc.addr, types.FillMsg, true, 1, 10, time.Hour)
+	}},
+	{name: "ListMessageByAddress", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.ListMessageByAddress(ctx, acc.addr)
+	}},
+	{name: "ListFailedMessage", list: true, call: func(ctx context.Context, m *MessageImp, _ *account) (interface{}, error) {
+		return m.ListFailedMessage(ctx)
+	}},
+	{name: "ListBlockedMessage", list: true, call: func(ctx context.Context, m *MessageImp, _ *account) (interface{}, error) {
+		return m.ListBlockedMessage(ctx, address.Undef, time.Hour)
+	}},
+	{name: "ListBlockedMessage/from", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.ListBlockedMessage(ctx, acc.addr, time.Hour)
+	}},
+	{name: "UpdateMessageStateByID", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return nil, m.UpdateMessageStateByID(ctx, acc.msg.ID, types.FailedMsg)
+	}},
+	{name: "UpdateAllFilledMessage", adminOnly: true, call: func(ctx context.Context, m *MessageImp, _ *account) (interface{}, error) {
+		return m.UpdateAllFilledMessage(ctx)
+	}},
+	{name: "UpdateFilledMessageByID", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.UpdateFilledMessageByID(ctx, acc.msg.ID)
+	}},
+	{name: "ReplaceMessage", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.ReplaceMessage(ctx, &types.ReplacMessageParams{ID: acc.msg.ID})
+	}},
+	{name: "RepublishMessage", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return nil, m.RepublishMessage(ctx, acc.msg.ID)
+	}},
+	{name: "CancelMessage", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.CancelMessage(ctx, acc.msg.ID)
+	}},
+	{name: "MarkBadMessage", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return nil, m.MarkBadMessage(ctx, acc.msg.ID)
+	}},
+	{name: "RecoverFailedMsg", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.RecoverFailedMsg(ctx, acc.addr)
+	}},
+	{name: "ClearUnFillMessage", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.ClearUnFillMessage(ctx, acc.addr)
+	}},
+	{name: "GetAddress", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.GetAddress(ctx, acc.addr)
+	}},
+	{name: "HasAddress", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.HasAddress(ctx, acc.addr)
+	}},
+	{name: "WalletHas", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.WalletHas(ctx, acc.addr)
+	}},
+	{name: "ListAddress", list: true, call: func(ctx context.Context, m *MessageImp, _ *account) (interface{}, error) {
+		return m.ListAddress(ctx)
+	}},
+	{name: "UpdateNonce", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return nil, m.UpdateNonce(ctx, acc.addr, 10)
+	}},
+	{name: "DeleteAddress", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return nil, m.DeleteAddress(ctx, acc.addr)
+	}},
+	{name: "ForbiddenAddress", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return nil, m.ForbiddenAddress(ctx, acc.addr)
+	}},
+	{name: "ActiveAddress", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return nil, m.ActiveAddress(ctx, acc.addr)
+	}},
+	{name: "SetSelectMsgNum", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return nil, m.SetSelectMsgNum(ctx, acc.addr, 10)
+	}},
+	{name: "SetFeeParams", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return nil, m.SetFeeParams(ctx, &types.AddressSpec{Address: acc.addr})
+	}},
+	{name: "SaveAddressPolicy", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.SaveAddressPolicy(ctx, &mtypes.AddressPolicy{From: acc.addr, To: acc.addr})
+	}},
+	{name: "ListAddressPolicy", list: true, call: func(ctx context.Context, m *MessageImp, _ *account) (interface{}, error) {
+		return m.ListAddressPolicy(ctx, address.Undef)
+	}},
+	{name: "ListAddressPolicy/from", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return m.ListAddressPolicy(ctx, acc.addr)
+	}},
+	{name: "DeleteAddressPolicy", call: func(ctx context.Context, m *MessageImp, acc *account) (interface{}, error) {
+		return nil, m.DeleteAddressPolicy(ctx, acc.policy.ID)
+	}},
+}
+
+func TestAuthorization(t *testing.T) {
+	alice := newAccount(t, "alice", 1)
+	bob := newAccount(t, "bob", 2)
+	accounts := []*account{alice, bob}
+
+	authClient := testhelper.NewMockAuthClient(t)
+	authClient.InitAccounts(map[string][]address.Address{alice.name: {alice.addr}, bob.name: {bob.addr}})
+	m := &MessageImp{
+		MessageSrv: &fakeMessageService{accounts: accounts},
+		AddressSrv: &fakeAddressService{accounts: accounts},
+		PolicySrv:  &fakePolicyService{accounts: accounts},
+		AuthClient: authClient,
+	}
+
+	aliceCtx := core.CtxWithPerm(core.CtxWithName(context.Background(), alice.name), core.PermWrite)
+	adminCtx := core.CtxWithPerm(core.CtxWithName(context.Background(), "admin"), core.PermAdmin)
+	leaked := func(res interface{}, acc *account) bool {
+		data, err := json.Marshal(res)
+		require.NoError(t, err)
+		return strings.Contains(string(data), acc.addr.String()) || strings.Contains(string(data), acc.msg.ID)
+	}
+
+	for _, c := range authCases {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := c.call(aliceCtx, m, bob)
+			if c.list {
+				require.NoError(t, err)
+				assert.False(t, leaked(res, bob), "the data of bob is returned: %v", res)
+			} else {
+				assert.ErrorIs(t, err, jwtclient.ErrorPermissionDeny)
+			}
+
+			res, err = c.call(aliceCtx, m, alice)
+			if c.adminOnly {
+				assert.ErrorIs(t, err, jwtclient.ErrorPermissionDeny)
+			} else {
+				require.NoError(t, err)
+				if c.list {
+					assert.True(t, leaked(res, alice), "the data of alice isn't returned: %v", res)
+				}
+			}
+
+			res, err = c.call(adminCtx, m, bob)
+			require.NoError(t, err)
+			if c.list {
+				assert.True(t, leaked(res, alice) && leaked(res, bob), "admin should get all data: %v", res)
+			}
+		})
+	}
+
+	t.Run("no signer", func(t *testing.T) {
+		ctx := core.CtxWithPerm(core.CtxWithName(context.Background(), "carol"), core.PermWrite)
+		for _, c := range authCases {
+			if !c.list {
+				continue
+			}
+			res, err := c.call(ctx, m, nil)
+			require.NoError(t, err, c.name)
+			assert.False(t, leaked(res, alice) || leaked(res, bob), "%s returns data of others: %v", c.name, res)
+		}
+	})
+
+	// every api without admin permission should be either scoped and covered above, or known to be unscoped
+	t.Run("all methods covered", func(t *testing.T) {
+		covered := map[string]struct{}{}
+		for _, c := range authCases {
+			covered[strings.Split(c.name, "/")[0]] = struct{}{}
+		}
+		perms := map[string]string{}
+		apiPerms(reflect.TypeOf(ext.IMessagerExtStruct{}), perms)
+		require.NotEmpty(t, perms)
+		for method, perm := range perms {
+			_, isCovered := covered[method]
+			_, isUnscoped := unscopedMethods[method]
+			if perm != string(core.PermAdmin) {
+				assert.True(t, isCovered || isUnscoped, "method %s with perm %s is not covered", method, perm)
+			}
+			assert.False(t, isCovered && isUnscoped, "method %s is both covered and unscoped", method)
+		}
+		for method := range unscopedMethods {
+			assert.Contains(t, perms, method)
+		}
+	})
+}
+
+// apiPerms collect the permissions of the methods of an api struct
+func apiPerms(typ reflect.Type, perms map[string]string) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous {
+			apiPerms(field.Type, perms)
+			continue
+		}
+		if field.Name != "Internal" {
+			continue
+		}
+		for j := 0; j < field.Type.NumField(); j++ {
+			method := field.Type.Field(j)
+			perms[method.Name] = method.Tag.Get("perm")
+		}
+	}
+}
+
+func newAccount(t *testing.T, name string, idx byte) *account {
+	buf := make([]byte, 20)
+	_, err := rand.Read(buf)
+	require.NoError(t, err)
+	addr, err := address.NewDelegatedAddress(builtin.EthereumAddressManagerActorID, buf)
+	require.NoError(t, err)
+
+	msg := &types.Message{
+		ID:      venusTypes.NewUUID().String(),
+		Message: venusTypes.Message{From: addr, To: addr, Nonce: uint64(idx)},
+		State:   types.FillMsg,
+	}
+	c := msg.Message.Cid()
+	msg.UnsignedCid = &c
+	msg.SignedCid = &c
+
+	return &account{
+		name:    name,
+		addr:    addr,
+		msg:     msg,
+		ethHash: venusTypes.EthHash{idx},
+		policy:  &mtypes.AddressPolicy{ID: venusTypes.NewUUID(), From: addr, To: addr},
+	}
+}
+
+func listMessages(accounts []*account, from []address.Address) []*types.Message {
+	var msgs []*types.Message
+	for _, acc := range accounts {
+		if len(from) == 0 || containsAddr(from, acc.addr) {
+			msgs = append(msgs, acc.msg)
+		}
+	}
+	return msgs
+}
+
+func containsAddr(addrs []address.Address, addr address.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeMessageService struct {
+	service.IMessageService
+	accounts []*account
+}
+
+func (f *fakeMessageService) find(match func(*account) bool) (*types.Message, error) {
+	for _, acc := range f.accounts {
+		if match(acc) {
+			return acc.msg, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (f *fakeMessageService) HasMessageByUid(_ context.Context, id string) (bool, error) {
+	_, err := f.find(func(acc *account) bool { return acc.msg.ID == id })
+	return err == nil, nil
+}
+
+func (f *fakeMessageService) GetMessageByUid(_ context.Context, id string) (*types.Message, error) {
+	return f.find(func(acc *account) bool { return acc.msg.ID == id })
+}
+
+func (f *fakeMessageService) GetMessageBySignedCid(_ context.Context, c cid.Cid) (*types.Message, error) {
+	return f.find(func(acc *account) bool { return acc.msg.SignedCid.Equals(c) })
+}
+
+func (f *fakeMessageService) GetMessageByUnsignedCid(_ context.Context, c cid.Cid) (*types.Message, error) {
+	return f.find(func(acc *account) bool { return acc.msg.UnsignedCid.Equals(c) })
+}
+
+func (f *fakeMessageService) GetMessageByFromAndNonce(_ context.Context, from address.Address, nonce uint64) (*types.Message, error) {
+	return f.find(func(acc *account) bool { return acc.addr == from && acc.msg.Nonce == nonce })
+}
+
+func (f *fakeMessageService) GetMessageByEthTxHash(_ context.Context, txHash venusTypes.EthHash) (*types.Message, error) {
+	return f.find(func(acc *account) bool { return acc.ethHash == txHash })
+}
+
+func (f *fakeMessageService) GetEthTxHash(_ context.Context, id string) (venusTypes.EthHash, error) {
+	for _, acc := range f.accounts {
+		if acc.msg.ID == id {
+			return acc.ethHash, nil
+		}
+	}
+	return venusTypes.EmptyEthHash, errors.New("not found")
+}
+
+func (f *fakeMessageService) WaitMessage(ctx context.Context, id string, _ uint64) (*types.Message, error) {
+	return f.GetMessageByUid(ctx, id)
+}
+
+func (f *fakeMessageService) GetMessagePublishStatus(_ context.Context, _ string) ([]*mtypes.PublishReceipt, error) {
+	return nil, nil
+}
+
+func (f *fakeMessageService) PushMessage(_ context.Context, _ *venusTypes.Message, _ *types.SendSpec) (string, error) {
+	return "id", nil
+}
+
+func (f *fakeMessageService) PushMessageWithId(_ context.Context, id string, _ *venusTypes.Message, _ *types.SendSpec) (string, error) {
+	return id, nil
+}
+
+func (f *fakeMessageService) PushSignedMessage(_ context.Context, _ *venusTypes.SignedMessage) (string, error) {
+	return "id", nil
+}
+
+func (f *fakeMessageService) PushEthTransaction(_ context.Context, _ *mtypes.EthTxParams) (*mtypes.EthTxResult, error) {
+	return &mtypes.EthTxResult{ID: "id"}, nil
+}
+
+func (f *fakeMessageService) Send(_ context.Context, _ types.QuickSendParams) (string, error) {
+	return "id", nil
+}
+
+func (f *fakeMessageService) ListMessage(_ context.Context, params *types.MsgQueryParams) ([]*types.Message, error) {
+	return listMessages(f.accounts, params.From), nil
+}
+
+func (f *fakeMessageService) ListMessageByFromState(_ context.Context, from address.Address, _ types.MessageState, _ bool, _, _ int, _ time.Duration) ([]*types.Message, error) {
+	return listMessages(f.accounts, []address.Address{from}), nil
+}
+
+func (f *fakeMessageService) ListMessageByAddress(_ context.Context, addr address.Address) ([]*types.Message, error) {
+	return listMessages(f.accounts, []address.Address{addr}), nil
+}
+
+func (f *fakeMessageService) ListFailedMessage(_ context.Context, params *types.MsgQueryParams) ([]*types.Message, error) {
+	return listMessages(f.accounts, params.From), nil
+}
+
+func (f *fakeMessageService) ListBlockedMessage(_ context.Context, params *types.MsgQueryParams, _ time.Duration) ([]*types.Message, error) {
+	return listMessages(f.accounts, params.From), nil
+}
+
+func (f *fakeMessageService) UpdateMessageStateByID(_ context.Context, _ string, _ types.MessageState) error {
+	return nil
+}
+
+func (f *fakeMessageService) UpdateAllFilledMessage(_ context.Context) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeMessageService) UpdateFilledMessageByID(_ context.Context, id string) (string, error) {
+	return id, nil
+}
+
+func (f *fakeMessageService) ReplaceMessage(_ context.Context, _ *types.ReplacMessageParams) (cid.Cid, error) {
+	return cid.Undef, nil
+}
+
+func (f *fakeMessageService) RepublishMessage(_ context.Context, _ string) error {
+	return nil
+}
+
+func (f *fakeMessageService) CancelMessage(_ context.Context, id string) (string, error) {
+	return id, nil
+}
+
+func (f *fakeMessageService) MarkBadMessage(_ context.Context, _ string) error {
+	return nil
+}
+
+func (f *fakeMessageService) RecoverFailedMsg(_ context.Context, _ address.Address) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeMessageService) ClearUnFillMessage(_ context.Context, _ address.Address) (int, error) {
+	return 0, nil
+}
+
+type fakeAddressService struct {
+	service.IAddressService
+	accounts []*account
+}
+
+func (f *fakeAddressService) GetAddress(_ context.Context, addr address.Address) (*types.Address, error) {
+	return &types.Address{Addr: addr}, nil
+}
+
+func (f *fakeAddressService) HasAddress(_ context.Context, _ address.Address) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeAddressService) WalletHas(_ context.Context, _ address.Address) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeAddressService) ListAddress(_ context.Context) ([]*types.Address, error) {
+	var addrs []*types.Address
+	for _, acc := range f.accounts {
+		addrs = append(addrs, &types.Address{Addr: acc.addr})
+	}
+	return addrs, nil
+}
+
+func (f *fakeAddressService) UpdateNonce(_ context.Context, _ address.Address, _ uint64) error {
+	return nil
+}
+
+func (f *fakeAddressService) DeleteAddress(_ context.Context, _ address.Address) error {
+	return nil
+}
+
+func (f *fakeAddressService) ForbiddenAddress(_ context.Context, _ address.Address) error {
+	return nil
+}
+
+func (f *fakeAddressService) ActiveAddress(_ context.Context, _ address.Address) error {
+	return nil
+}
+
+func (f *fakeAddressService) SetSelectMsgNum(_ context.Context, _ address.Address, _ uint64) error {
+	return nil
+}
+
+func (f *fakeAddressService) SetFeeParams(_ context.Context, _ *types.AddressSpec) error {
+	return nil
+}
+
+type fakePolicyService struct {
+	service.IAddressPolicyService
+	accounts []*account
+}
+
+func (f *fakePolicyService) SaveAddressPolicy(_ context.Context, _ *mtypes.AddressPolicy) (venusTypes.UUID, error) {
+	return venusTypes.NewUUID(), nil
+}
+
+func (f *fakePolicyService) GetAddressPolicy(_ context.Context, id venusTypes.UUID) (*mtypes.AddressPolicy, error) {
+	for _, acc := range f.accounts {
+		if acc.policy.ID == id {
+			return acc.policy, nil
+		}
+	}
+	return nil, fmt.Errorf("address policy %s not found", id)
+}
+
+func (f *fakePolicyService) ListAddressPolicy(_ context.Context, from address.Address) ([]*mtypes.AddressPolicy, error) {
+	var policies []*mtypes.AddressPolicy
+	for _, acc := range f.accounts {
+		if from.Empty() || acc.addr == from {
+			policies = append(policies, acc.policy)
+		}
+	}
+	return policies, nil
+}
+
+func (f *fakePolicyService) DeleteAddressPolicy(_ context.Context, _ venusTypes.UUID) error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package ext
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/venus/venus-shared/api/messager"
+	"github.com/filecoin-project/venus/venus-shared/types"
+	messagerTypes "github.com/filecoin-project/venus/venus-shared/types/messager"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+// IMessagerExt is the full api served by sophon-messager, it contains all methods of messager.IMessager
+// and the methods which have not been included in venus-shared yet.
+type IMessagerExt interface {
+	messager.IMessager
+	IAddressPolicy
+	IAuditLog
+	IAuth
+	IConfig
+	IEth
+	IMessagePublish
+	INodePool
+	IMpool
+	IPubsub
+	IWallet
+}
+
+type IAddressPolicy interface {
+	SaveAddressPolicy(ctx context.Context, policy *mtypes.AddressPolicy) (types.UUID, error)      //perm:admin
+	ListAddressPolicy(ctx context.Context, from address.Address) ([]*mtypes.AddressPolicy, error) //perm:read
+	DeleteAddressPolicy(ctx context.Context, id types.UUID) error                                 //perm:admin
+}
+
+type IAuditLog interface {
+	ListAuditLog(ctx context.Context, query *mtypes.AuditLogQuery) ([]*mtypes.AuditLog, error) //perm:admin
+}
+
+// IAuth manages the users of the local auth mode, the methods return an error if sophon-auth is used
+type IAuth interface {
+	// AuthUserAdd add a user with the permission, returns the user with a new token, the token is only returned here
+	AuthUserAdd(ctx context.Context, name string, perm string) (*mtypes.AuthUser, error) //perm:admin
+	// AuthListUser returns all the users without their tokens
+	AuthListUser(ctx context.Context) ([]*mtypes.AuthUser, error) //perm:admin
+	// AuthBindSigner bind the signers to the user, then the user can access their messages and addresses
+	AuthBindSigner(ctx context.Context, name string, signers []address.Address) error //perm:admin
+	// AuthListSigner returns the signers bound to the user
+	AuthListSigner(ctx context.Context, name string) ([]*mtypes.AuthSigner, error) //perm:admin
+}
+
+type IEth interface {
+	// PushEthTransaction push an EIP-1559 transaction, either the signed rlp, or an unsigned one with a delegated
+	// from which is signed by messager right away, returns the id of message and the tx hash
+	PushEthTransaction(ctx context.Context, params *mtypes.EthTxParams) (*mtypes.EthTxResult, error) //perm:write
+	// GetMessageByEthTxHash returns the message of the eth tx hash
+	GetMessageByEthTxHash(ctx context.Context, txHash types.EthHash) (*messagerTypes.Message, error) //perm:read
+	// GetEthTxHash returns the latest eth tx hash of a message signed by a delegated address, the hash changes if the
+	// message is signed again, eg. replaced with new gas
+	GetEthTxHash(ctx context.Context, id string) (types.EthHash, error) //perm:read
+}
+
+type IMessagePublish interface {
+	// GetMessagePublishStatus returns the result of the last publishing of the message to each node
+	GetMessagePublishStatus(ctx context.Context, id string) ([]*mtypes.PublishReceipt, error) //perm:read
+	// PushSignedMessage push a message signed outside messager, the nonce should be the next nonce of the address,
+	// then it is published and tracked like other messages, returns the id of message
+	PushSignedMessage(ctx context.Context, msg *types.SignedMessage) (string, error) //perm:write
+	// CancelMessage cancel a message not on chain, a filled message is replaced by a zero-value self-send with
+	// the same nonce, returns the id of the replacement, empty if the message is unfilled
+	CancelMessage(ctx context.Context, id string) (string, error) //perm:write
+}
+
+type INodePool interface {
+	// NodePoolStatus returns the health of nodes in the node pool, the selected one is the first
+	NodePoolStatus(ctx context.Context) ([]*mtypes.NodeStatus, error) //perm:read
+}
+
+type IMpool interface {
+	// MpoolDiff compare the filled messages with the pending messages in the mpool of each node
+	MpoolDiff(ctx context.Context) ([]*mtypes.MpoolDiff, error) //perm:admin
+}
+
+type IPubsub interface {
+	// NetTopicStatus returns the mesh, peer scores and publishing result of the message topic
+	NetTopicStatus(ctx context.Context) (*mtypes.TopicStatus, error) //perm:read
+}
+
+type IConfig interface {
+	ReloadConfig(ctx context.Context) (*config.ReloadResult, error) //perm:admin
+	// GetConfig returns the config in use, with secrets redacted
+	GetConfig(ctx context.Context) (*config.Config, error) //perm:admin
+}
+
+type IWallet interface {
+	// ListWalletInventory returns the gateway and wallet serving each address, the addresses without online signer
+	// and the signable addresses which are not in messager
+	ListWalletInventory(ctx context.Context) (*mtypes.WalletInventory, error) //perm:admin
+	// ListSignerHealth returns the latency, consecutive failures and circuit state of each gateway for each address
+	ListSignerHealth(ctx context.Context) ([]*mtypes.SignerHealth, error) //perm:admin
+}
@@ -0,0 +1,174 @@
+package ext
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/venus/venus-shared/api/messager"
+	"github.com/filecoin-project/venus/venus-shared/types"
+	messagerTypes "github.com/filecoin-project/venus/venus-shared/types/messager"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+type IMessagerExtStruct struct {
+	messager.IMessagerStruct
+	IAddressPolicyStruct
+	IAuditLogStruct
+	IAuthStruct
+	IConfigStruct
+	IEthStruct
+	IMessagePublishStruct
+	INodePoolStruct
+	IMpoolStruct
+	IPubsubStruct
+	IWalletStruct
+}
+
+type IAddressPolicyStruct struct {
+	Internal struct {
+		DeleteAddressPolicy func(ctx context.Context, id types.UUID) error                                   `perm:"admin"`
+		ListAddressPolicy   func(ctx context.Context, from address.Address) ([]*mtypes.AddressPolicy, error) `perm:"read"`
+		SaveAddressPolicy   func(ctx context.Context, policy *mtypes.AddressPolicy) (types.UUID, error)      `perm:"admin"`
+	}
+}
+
+func (s *IAddressPolicyStruct) DeleteAddressPolicy(p0 context.Context, p1 types.UUID) error {
+	return s.Internal.DeleteAddressPolicy(p0, p1)
+}
+func (s *IAddressPolicyStruct) ListAddressPolicy(p0 context.Context, p1 address.Address) ([]*mtypes.AddressPolicy, error) {
+	return s.Internal.ListAddressPolicy(p0, p1)
+}
+func (s *IAddressPolicyStruct) SaveAddressPolicy(p0 context.Context, p1 *mtypes.AddressPolicy) (types.UUID, error) {
+	return s.Internal.SaveAddressPolicy(p0, p1)
+}
+
+type IAuditLogStruct struct {
+	Internal struct {
+		ListAuditLog func(ctx context.Context, query *mtypes.AuditLogQuery) ([]*mtypes.AuditLog, error) `perm:"admin"`
+	}
+}
+
+func (s *IAuditLogStruct) ListAuditLog(p0 context.Context, p1 *mtypes.AuditLogQuery) ([]*mtypes.AuditLog, error) {
+	return s.Internal.ListAuditLog(p0, p1)
+}
+
+type IAuthStruct struct {
+	Internal struct {
+		AuthBindSigner func(ctx context.Context, name string, signers []address.Address) error       `perm:"admin"`
+		AuthListSigner func(ctx context.Context, name string) ([]*mtypes.AuthSigner, error)          `perm:"admin"`
+		AuthListUser   func(ctx context.Context) ([]*mtypes.AuthUser, error)                         `perm:"admin"`
+		AuthUserAdd    func(ctx context.Context, name string, perm string) (*mtypes.AuthUser, error) `perm:"admin"`
+	}
+}
+
+func (s *IAuthStruct) AuthBindSigner(p0 context.Context, p1 string, p2 []address.Address) error {
+	return s.Internal.AuthBindSigner(p0, p1, p2)
+}
+func (s *IAuthStruct) AuthListSigner(p0 context.Context, p1 string) ([]*mtypes.AuthSigner, error) {
+	return s.Internal.AuthListSigner(p0, p1)
+}
+func (s *IAuthStruct) AuthListUser(p0 context.Context) ([]*mtypes.AuthUser, error) {
+	return s.Internal.AuthListUser(p0)
+}
+func (s *IAuthStruct) AuthUserAdd(p0 context.Context, p1 string, p2 string) (*mtypes.AuthUser, error) {
+	return s.Internal.AuthUserAdd(p0, p1, p2)
+}
+
+type IConfigStruct struct {
+	Internal struct {
+		GetConfig    func(ctx context.Context) (*config.Config, error)       `perm:"admin"`
+		ReloadConfig func(ctx context.Context) (*config.ReloadResult, error) `perm:"admin"`
+	}
+}
+
+func (s *IConfigStruct) GetConfig(p0 context.Context) (*config.Config, error) {
+	return s.Internal.GetConfig(p0)
+}
+func (s *IConfigStruct) ReloadConfig(p0 context.Context) (*config.ReloadResult, error) {
+	return s.Internal.ReloadConfig(p0)
+}
+
+type IEthStruct struct {
+	Internal struct {
+		GetEthTxHash          func(ctx context.Context, id string) (types.EthHash, error)                        `perm:"read"`
+		GetMessageByEthTxHash func(ctx context.Context, txHash types.EthHash) (*messagerTypes.Message, error)    `perm:"read"`
+		PushEthTransaction    func(ctx context.Context, params *mtypes.EthTxParams) (*mtypes.EthTxResult, error) `perm:"write"`
+	}
+}
+
+func (s *IEthStruct) GetEthTxHash(p0 context.Context, p1 string) (types.EthHash, error) {
+	return s.Internal.GetEthTxHash(p0, p1)
+}
+func (s *IEthStruct) GetMessageByEthTxHash(p0 context.Context, p1 types.EthHash) (*messagerTypes.Message, error) {
+	return s.Internal.GetMessageByEthTxHash(p0, p1)
+}
+func (s *IEthStruct) PushEthTransaction(p0 context.Context, p1 *mtypes.EthTxParams) (*mtypes.EthTxResult, error) {
+	return s.Internal.PushEthTransaction(p0, p1)
+}
+
+type IMessagePublishStruct struct {
+	Internal struct {
+		CancelMessage           func(ctx context.Context, id string) (string, error)                   `perm:"write"`
+		GetMessagePublishStatus func(ctx context.Context, id string) ([]*mtypes.PublishReceipt, error) `perm:"read"`
+		PushSignedMessage       func(ctx context.Context, msg *types.SignedMessage) (string, error)    `perm:"write"`
+	}
+}
+
+func (s *IMessagePublishStruct) CancelMessage(p0 context.Context, p1 string) (string, error) {
+	return s.Internal.CancelMessage(p0, p1)
+}
+
+func (s *IMessagePublishStruct) GetMessagePublishStatus(p0 context.Context, p1 string) ([]*mtypes.PublishReceipt, error) {
+	return s.Internal.GetMessagePublishStatus(p0, p1)
+}
+
+func (s *IMessagePublishStruct) PushSignedMessage(p0 context.Context, p1 *types.SignedMessage) (string, error) {
+	return s.Internal.PushSignedMessage(p0, p1)
+}
+
+type INodePoolStruct struct {
+	Internal struct {
+		NodePoolStatus func(ctx context.Context) ([]*mtypes.NodeStatus, error) `perm:"read"`
+	}
+}
+
+func (s *INodePoolStruct) NodePoolStatus(p0 context.Context) ([]*mtypes.NodeStatus, error) {
+	return s.Internal.NodePoolStatus(p0)
+}
+
+type IMpoolStruct struct {
+	Internal struct {
+		MpoolDiff func(ctx context.Context) ([]*mtypes.MpoolDiff, error) `perm:"admin"`
+	}
+}
+
+func (s *IMpoolStruct) MpoolDiff(p0 context.Context) ([]*mtypes.MpoolDiff, error) {
+	return s.Internal.MpoolDiff(p0)
+}
+
+type IPubsubStruct struct {
+	Internal struct {
+		NetTopicStatus func(ctx context.Context) (*mtypes.TopicStatus, error) `perm:"read"`
+	}
+}
+
+func (s *IPubsubStruct) NetTopicStatus(p0 context.Context) (*mtypes.TopicStatus, error) {
+	return s.Internal.NetTopicStatus(p0)
+}
+
+type IWalletStruct struct {
+	Internal struct {
+		ListSignerHealth    func(ctx context.Context) ([]*mtypes.SignerHealth, error)  `perm:"admin"`
+		ListWalletInventory func(ctx context.Context) (*mtypes.WalletInventory, error) `perm:"admin"`
+	}
+}
+
+func (s *IWalletStruct) ListSignerHealth(p0 context.Context) ([]*mtypes.SignerHealth, error) {
+	return s.Internal.ListSignerHealth(p0)
+}
+
+func (s *IWalletStruct) ListWalletInventory(p0 context.Context) (*mtypes.WalletInventory, error) {
+	return s.Internal.ListWalletInventory(p0)
+}
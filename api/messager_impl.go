@@ -12,64 +12,93 @@ import (
 
 	"github.com/filecoin-project/go-address"
 	v1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1"
-	"github.com/filecoin-project/venus/venus-shared/api/messager"
 	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
 	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/ipfs-force-community/sophon-messager/api/ext"
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/nodepool"
+	"github.com/ipfs-force-community/sophon-messager/publisher"
 	"github.com/ipfs-force-community/sophon-messager/publisher/pubsub"
 
 	"github.com/ipfs-force-community/sophon-messager/service"
 	"github.com/ipfs-force-community/sophon-messager/version"
 
-	"github.com/ipfs-force-community/sophon-auth/core"
 	"github.com/ipfs-force-community/sophon-auth/jwtclient"
 )
 
 type ImplParams struct {
 	fx.In
 	AddressService      *service.AddressService
+	PolicyService       *service.AddressPolicyService
+	AuditService        *service.AuditService
+	ConfigReloader      *service.ConfigReloader
+	MpoolReconciler     *service.MpoolReconciler
 	MessageService      *service.MessageService
 	NodeService         service.INodeService
 	SharedParamsService *service.SharedParamsService
 	Net                 pubsub.INet
+	P2pPublisher        *publisher.P2pPublisher
 	AuthClient          jwtclient.IAuthClient
-	NodeClient          v1.FullNode
+	// LocalAuth is nil if sophon-auth is used
+	LocalAuth  *service.LocalAuthService `optional:"true"`
+	NodeClient v1.FullNode
+	// NodePool is nil if not enabled
+	NodePool *nodepool.NodePool `optional:"true"`
 }
 
 func NewMessageImp(implParams ImplParams) *MessageImp {
 	return &MessageImp{
 		AddressSrv: implParams.AddressService,
+		PolicySrv:  implParams.PolicyService,
+		AuditSrv:   implParams.AuditService,
+		Reloader:   implParams.ConfigReloader,
+		Reconciler: implParams.MpoolReconciler,
 		MessageSrv: implParams.MessageService,
 		NodeSrv:    implParams.NodeService,
 		ParamsSrv:  implParams.SharedParamsService,
 		Net:        implParams.Net,
+		Publisher:  implParams.P2pPublisher,
 		AuthClient: implParams.AuthClient,
+		LocalAuth:  implParams.LocalAuth,
 		NodeClient: implParams.NodeClient,
+		NodePool:   implParams.NodePool,
 	}
 }
 
 type MessageImp struct {
 	AddressSrv service.IAddressService
+	PolicySrv  service.IAddressPolicyService
+	AuditSrv   *service.AuditService
+	Reloader   *service.ConfigReloader
+	Reconciler *service.MpoolReconciler
 	MessageSrv service.IMessageService
 	NodeSrv    service.INodeService
 	ParamsSrv  *service.SharedParamsService
 	Net        pubsub.INet
+	Publisher  *publisher.P2pPublisher
 	AuthClient jwtclient.IAuthClient
+	LocalAuth  *service.LocalAuthService
 	NodeClient v1.FullNode
+	NodePool   *nodepool.NodePool
 }
 
-var _ messager.IMessager = (*MessageImp)(nil)
+var _ ext.IMessagerExt = (*MessageImp)(nil)
 
 func (m *MessageImp) HasMessageByUid(ctx context.Context, id string) (bool, error) {
-	return m.MessageSrv.HasMessageByUid(ctx, id)
+	has, err := m.MessageSrv.HasMessageByUid(ctx, id)
+	if err != nil || !has {
+		return has, err
+	}
+	if _, err := m.getMessage(ctx, id); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 func (m *MessageImp) WaitMessage(ctx context.Context, id string, confidence uint64) (*types.Message, error) {
-	msg, err := m.MessageSrv.GetMessageByUid(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("get message by id error: %w", err)
-	}
-	if checkErr := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); checkErr != nil {
-		return nil, checkErr
+	if _, err := m.getMessage(ctx, id); err != nil {
+		return nil, err
 	}
 	return m.MessageSrv.WaitMessage(ctx, id, confidence)
 }
@@ -94,7 +123,7 @@ func (m MessageImp) PushMessage(ctx context.Context, msg *venusTypes.Message, me
 	if err != nil {
 		return "", err
 	}
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); err != nil {
+	if err := m.checkSigners(ctx, msg.From); err != nil {
 		return "", err
 	}
 
@@ -107,7 +136,7 @@ func (m MessageImp) PushMessageWithId(ctx context.Context, id string, msg *venus
 	if err != nil {
 		return "", err
 	}
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); err != nil {
+	if err := m.checkSigners(ctx, msg.From); err != nil {
 		return "", err
 	}
 
@@ -115,22 +144,83 @@ func (m MessageImp) PushMessageWithId(ctx context.Context, id string, msg *venus
 }
 
 func (m *MessageImp) GetMessageByUid(ctx context.Context, id string) (*types.Message, error) {
-	msg, err := m.MessageSrv.GetMessageByUid(ctx, id)
+	return m.getMessage(ctx, id)
+}
+
+func (m *MessageImp) GetMessagePublishStatus(ctx context.Context, id string) ([]*mtypes.PublishReceipt, error) {
+	if _, err := m.getMessage(ctx, id); err != nil {
+		return nil, err
+	}
+	return m.MessageSrv.GetMessagePublishStatus(ctx, id)
+}
+
+func (m *MessageImp) CancelMessage(ctx context.Context, id string) (string, error) {
+	if _, err := m.getMessage(ctx, id); err != nil {
+		return "", err
+	}
+	return m.MessageSrv.CancelMessage(ctx, id)
+}
+
+func (m *MessageImp) PushSignedMessage(ctx context.Context, msg *venusTypes.SignedMessage) (string, error) {
+	if msg == nil {
+		return "", fmt.Errorf("message is nil")
+	}
+	if checkErr := m.checkSigners(ctx, msg.Message.From); checkErr != nil {
+		return "", checkErr
+	}
+	return m.MessageSrv.PushSignedMessage(ctx, msg)
+}
+
+func (m *MessageImp) PushEthTransaction(ctx context.Context, params *mtypes.EthTxParams) (*mtypes.EthTxResult, error) {
+	if params == nil {
+		return nil, fmt.Errorf("params is nil")
+	}
+	var from address.Address
+	var err error
+	if len(params.Raw) > 0 {
+		tx, parseErr := venusTypes.ParseEthTransaction(params.Raw)
+		if parseErr != nil {
+			return nil, fmt.Errorf("parse transaction: %w", parseErr)
+		}
+		from, err = tx.Sender()
+	} else if params.From != nil {
+		from, err = params.From.ToFilecoinAddress()
+	}
 	if err != nil {
-		return nil, fmt.Errorf("get message by id error: %w", err)
+		return nil, err
+	}
+	if !from.Empty() {
+		if checkErr := m.checkSigners(ctx, from); checkErr != nil {
+			return nil, checkErr
+		}
 	}
-	if checkErr := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); checkErr != nil {
+	return m.MessageSrv.PushEthTransaction(ctx, params)
+}
+
+func (m *MessageImp) GetMessageByEthTxHash(ctx context.Context, txHash venusTypes.EthHash) (*types.Message, error) {
+	msg, err := m.MessageSrv.GetMessageByEthTxHash(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if checkErr := m.checkSigners(ctx, msg.From); checkErr != nil {
 		return nil, checkErr
 	}
 	return msg, nil
 }
 
+func (m *MessageImp) GetEthTxHash(ctx context.Context, id string) (venusTypes.EthHash, error) {
+	if _, err := m.getMessage(ctx, id); err != nil {
+		return venusTypes.EmptyEthHash, err
+	}
+	return m.MessageSrv.GetEthTxHash(ctx, id)
+}
+
 func (m *MessageImp) GetMessageBySignedCid(ctx context.Context, cid cid.Cid) (*types.Message, error) {
 	msg, err := m.MessageSrv.GetMessageBySignedCid(ctx, cid)
 	if err != nil {
 		return nil, fmt.Errorf("get message by id error: %w", err)
 	}
-	if checkErr := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); checkErr != nil {
+	if checkErr := m.checkSigners(ctx, msg.From); checkErr != nil {
 		return nil, checkErr
 	}
 	return msg, nil
@@ -141,7 +231,7 @@ func (m *MessageImp) GetMessageByUnsignedCid(ctx context.Context, cid cid.Cid) (
 	if err != nil {
 		return nil, fmt.Errorf("get message by id error: %w", err)
 	}
-	if checkErr := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); checkErr != nil {
+	if checkErr := m.checkSigners(ctx, msg.From); checkErr != nil {
 		return nil, checkErr
 	}
 	return msg, nil
@@ -152,7 +242,7 @@ func (m *MessageImp) GetMessageByFromAndNonce(ctx context.Context, from address.
 	if err != nil {
 		return nil, fmt.Errorf("get message by id error: %w", err)
 	}
-	if checkErr := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); checkErr != nil {
+	if checkErr := m.checkSigners(ctx, msg.From); checkErr != nil {
 		return nil, checkErr
 	}
 	return msg, nil
@@ -160,139 +250,120 @@ func (m *MessageImp) GetMessageByFromAndNonce(ctx context.Context, from address.
 
 func (m *MessageImp) ListMessage(ctx context.Context, p *types.MsgQueryParams) ([]*types.Message, error) {
 	// only admin can list all message
-	if len(p.From) == 0 {
-		if !isAdmin(ctx) {
-			signers, err := getSigners(ctx, m.AuthClient)
-			if err != nil {
-				return nil, err
-			}
-			if len(signers) == 0 {
-				return nil, nil
-			}
-			p.From = signers
-		}
-	} else {
-		if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, p.From...); err != nil {
-			return nil, err
-		}
+	from, ok, err := m.scopeSigners(ctx, p.From)
+	if err != nil || !ok {
+		return nil, err
 	}
+	p.From = from
 	return m.MessageSrv.ListMessage(ctx, p)
 }
 
 func (m *MessageImp) ListMessageByFromState(ctx context.Context, from address.Address, state types.MessageState, isAsc bool, pageIndex, pageSize int, d time.Duration) ([]*types.Message, error) {
+	if err := m.checkSigners(ctx, from); err != nil {
+		return nil, err
+	}
 	return m.MessageSrv.ListMessageByFromState(ctx, from, state, isAsc, pageIndex, pageSize, d)
 }
 
 func (m *MessageImp) ListMessageByAddress(ctx context.Context, addr address.Address) ([]*types.Message, error) {
+	if err := m.checkSigners(ctx, addr); err != nil {
+		return nil, err
+	}
 	return m.MessageSrv.ListMessageByAddress(ctx, addr)
 }
 
 func (m *MessageImp) ListFailedMessage(ctx context.Context) ([]*types.Message, error) {
-	if isAdmin(ctx) {
-		return m.MessageSrv.ListFailedMessage(ctx, &types.MsgQueryParams{})
-	}
-	signers, err := getSigners(ctx, m.AuthClient)
-	if err != nil {
+	from, ok, err := m.scopeSigners(ctx, nil)
+	if err != nil || !ok {
 		return nil, err
 	}
-	if len(signers) == 0 {
-		return nil, nil
-	}
-	return m.MessageSrv.ListFailedMessage(ctx, &types.MsgQueryParams{From: signers})
+	return m.MessageSrv.ListFailedMessage(ctx, &types.MsgQueryParams{From: from})
 }
 
 func (m *MessageImp) ListBlockedMessage(ctx context.Context, addr address.Address, d time.Duration) ([]*types.Message, error) {
-	// todo: if the address is empty, all failed messages will be returned, bypassing the permission check
-	params := &types.MsgQueryParams{}
+	var addrs []address.Address
 	if !addr.Empty() {
-		if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
-			return nil, err
-		}
-		params.From = []address.Address{addr}
+		addrs = []address.Address{addr}
 	}
-
-	return m.MessageSrv.ListBlockedMessage(ctx, params, d)
+	from, ok, err := m.scopeSigners(ctx, addrs)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return m.MessageSrv.ListBlockedMessage(ctx, &types.MsgQueryParams{From: from}, d)
 }
 
 func (m *MessageImp) UpdateMessageStateByID(ctx context.Context, id string, state types.MessageState) error {
-	msg, err := m.MessageSrv.GetMessageByUid(ctx, id)
+	msg, err := m.getMessage(ctx, id)
 	if err != nil {
-		return fmt.Errorf("get message by id error: %w", err)
-	}
-	if checkErr := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); checkErr != nil {
-		return checkErr
+		return err
 	}
 	log.Infof("update message(%s) state, from %s to %s, ", msg.ID, msg.State.String(), state.String())
 	return m.MessageSrv.UpdateMessageStateByID(ctx, id, state)
 }
 
 func (m *MessageImp) UpdateAllFilledMessage(ctx context.Context) (int, error) {
+	// it isn't scoped to any signer
+	if !isAdmin(ctx) {
+		return 0, fmt.Errorf("update all filled messages: %w", jwtclient.ErrorPermissionDeny)
+	}
 	return m.MessageSrv.UpdateAllFilledMessage(ctx)
 }
 
 func (m *MessageImp) UpdateFilledMessageByID(ctx context.Context, id string) (string, error) {
-	msg, err := m.MessageSrv.GetMessageByUid(ctx, id)
+	msg, err := m.getMessage(ctx, id)
 	if err != nil {
-		return "", fmt.Errorf("get message by id error: %w", err)
-	}
-	if checkErr := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); checkErr != nil {
-		return "", checkErr
+		return "", err
 	}
-	if msg.State == types.OnChainMsg || msg.State == types.NonceConflictMsg {
+	if msg.State == types.OnChainMsg || msg.State == types.NonceConflictMsg || msg.State == mtypes.CancelledMsg {
 		return "", fmt.Errorf("message state(%s) has been final, can not update", msg.State)
 	}
 	return m.MessageSrv.UpdateFilledMessageByID(ctx, id)
 }
 
 func (m *MessageImp) ReplaceMessage(ctx context.Context, params *types.ReplacMessageParams) (cid.Cid, error) {
-	msg, err := m.MessageSrv.GetMessageByUid(ctx, params.ID)
-	if err != nil {
-		return cid.Undef, fmt.Errorf("get message by id error: %w", err)
-	}
-	if checkErr := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); checkErr != nil {
-		return cid.Undef, checkErr
+	if _, err := m.getMessage(ctx, params.ID); err != nil {
+		return cid.Undef, err
 	}
 	return m.MessageSrv.ReplaceMessage(ctx, params)
 }
 
 func (m *MessageImp) RepublishMessage(ctx context.Context, id string) error {
+	if _, err := m.getMessage(ctx, id); err != nil {
+		return err
+	}
 	return m.MessageSrv.RepublishMessage(ctx, id)
 }
 
 func (m *MessageImp) MarkBadMessage(ctx context.Context, id string) error {
-	msg, err := m.MessageSrv.GetMessageByUid(ctx, id)
-	if err != nil {
-		return fmt.Errorf("get message by id error: %w", err)
-	}
-	if checkErr := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.From); checkErr != nil {
-		return checkErr
+	if _, err := m.getMessage(ctx, id); err != nil {
+		return err
 	}
 	return m.MessageSrv.MarkBadMessage(ctx, id)
 }
 
 func (m *MessageImp) RecoverFailedMsg(ctx context.Context, addr address.Address) ([]string, error) {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
+	if err := m.checkSigners(ctx, addr); err != nil {
 		return nil, err
 	}
 	return m.MessageSrv.RecoverFailedMsg(ctx, addr)
 }
 
 func (m *MessageImp) GetAddress(ctx context.Context, addr address.Address) (*types.Address, error) {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
+	if err := m.checkSigners(ctx, addr); err != nil {
 		return nil, err
 	}
 	return m.AddressSrv.GetAddress(ctx, addr)
 }
 
 func (m *MessageImp) HasAddress(ctx context.Context, addr address.Address) (bool, error) {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
+	if err := m.checkSigners(ctx, addr); err != nil {
 		return false, err
 	}
 	return m.AddressSrv.HasAddress(ctx, addr)
 }
 
 func (m *MessageImp) WalletHas(ctx context.Context, addr address.Address) (bool, error) {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
+	if err := m.checkSigners(ctx, addr); err != nil {
 		return false, err
 	}
 	return m.AddressSrv.WalletHas(ctx, addr)
@@ -303,59 +374,147 @@ func (m *MessageImp) ListAddress(ctx context.Context) ([]*types.Address, error)
 	if err != nil {
 		return nil, err
 	}
-	var result []*types.Address
-	for _, msg := range msgs {
-		if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, msg.Addr); err == nil {
-			result = append(result, msg)
-		}
-	}
-	return result, nil
+	return filterBySigner(ctx, m, msgs, func(a *types.Address) address.Address { return a.Addr }), nil
 }
 
 func (m *MessageImp) UpdateNonce(ctx context.Context, addr address.Address, nonce uint64) error {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
+	if err := m.checkSigners(ctx, addr); err != nil {
 		return err
 	}
 	return m.AddressSrv.UpdateNonce(ctx, addr, nonce)
 }
 
 func (m *MessageImp) DeleteAddress(ctx context.Context, addr address.Address) error {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
+	if err := m.checkSigners(ctx, addr); err != nil {
 		return err
 	}
 	return m.AddressSrv.DeleteAddress(ctx, addr)
 }
 
 func (m *MessageImp) ForbiddenAddress(ctx context.Context, addr address.Address) error {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
+	if err := m.checkSigners(ctx, addr); err != nil {
 		return err
 	}
 	return m.AddressSrv.ForbiddenAddress(ctx, addr)
 }
 
 func (m *MessageImp) ActiveAddress(ctx context.Context, addr address.Address) error {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
+	if err := m.checkSigners(ctx, addr); err != nil {
 		return err
 	}
 	return m.AddressSrv.ActiveAddress(ctx, addr)
 }
 
 func (m *MessageImp) SetSelectMsgNum(ctx context.Context, addr address.Address, num uint64) error {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
+	if err := m.checkSigners(ctx, addr); err != nil {
 		return err
 	}
 	return m.AddressSrv.SetSelectMsgNum(ctx, addr, num)
 }
 
 func (m *MessageImp) SetFeeParams(ctx context.Context, params *types.AddressSpec) error {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, params.Address); err != nil {
+	if err := m.checkSigners(ctx, params.Address); err != nil {
 		return err
 	}
 	return m.AddressSrv.SetFeeParams(ctx, params)
 }
 
+func (m *MessageImp) SaveAddressPolicy(ctx context.Context, policy *mtypes.AddressPolicy) (venusTypes.UUID, error) {
+	if policy == nil {
+		return venusTypes.UUID{}, fmt.Errorf("policy is nil")
+	}
+	if err := m.checkSigners(ctx, policy.From); err != nil {
+		return venusTypes.UUID{}, err
+	}
+	return m.PolicySrv.SaveAddressPolicy(ctx, policy)
+}
+
+func (m *MessageImp) ListAddressPolicy(ctx context.Context, from address.Address) ([]*mtypes.AddressPolicy, error) {
+	if !from.Empty() {
+		if err := m.checkSigners(ctx, from); err != nil {
+			return nil, err
+		}
+		return m.PolicySrv.ListAddressPolicy(ctx, from)
+	}
+	policies, err := m.PolicySrv.ListAddressPolicy(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	return filterBySigner(ctx, m, policies, func(p *mtypes.AddressPolicy) address.Address { return p.From }), nil
+}
+
+func (m *MessageImp) DeleteAddressPolicy(ctx context.Context, id venusTypes.UUID) error {
+	policy, err := m.PolicySrv.GetAddressPolicy(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := m.checkSigners(ctx, policy.From); err != nil {
+		return err
+	}
+	return m.PolicySrv.DeleteAddressPolicy(ctx, id)
+}
+
+func (m *MessageImp) ListAuditLog(ctx context.Context, query *mtypes.AuditLogQuery) ([]*mtypes.AuditLog, error) {
+	return m.AuditSrv.ListAuditLog(ctx, query)
+}
+
+func (m *MessageImp) AuthUserAdd(ctx context.Context, name string, perm string) (*mtypes.AuthUser, error) {
+	if m.LocalAuth == nil {
+		return nil, errLocalAuthDisabled
+	}
+	return m.LocalAuth.AddUser(ctx, name, perm)
+}
+
+func (m *MessageImp) AuthListUser(ctx context.Context) ([]*mtypes.AuthUser, error) {
+	if m.LocalAuth == nil {
+		return nil, errLocalAuthDisabled
+	}
+	return m.LocalAuth.ListAuthUser(ctx)
+}
+
+func (m *MessageImp) AuthBindSigner(ctx context.Context, name string, signers []address.Address) error {
+	if m.LocalAuth == nil {
+		return errLocalAuthDisabled
+	}
+	return m.LocalAuth.BindSigners(ctx, name, signers)
+}
+
+func (m *MessageImp) AuthListSigner(ctx context.Context, name string) ([]*mtypes.AuthSigner, error) {
+	if m.LocalAuth == nil {
+		return nil, errLocalAuthDisabled
+	}
+	return m.LocalAuth.ListAuthSigner(ctx, name)
+}
+
+func (m *MessageImp) ReloadConfig(ctx context.Context) (*config.ReloadResult, error) {
+	return m.Reloader.Reload(ctx)
+}
+
+func (m *MessageImp) GetConfig(_ context.Context) (*config.Config, error) {
+	return m.Reloader.Config().Redacted(), nil
+}
+
+func (m *MessageImp) NodePoolStatus(_ context.Context) ([]*mtypes.NodeStatus, error) {
+	if m.NodePool == nil {
+		return nil, fmt.Errorf("node pool is not enabled")
+	}
+	return m.NodePool.Status(), nil
+}
+
+func (m *MessageImp) MpoolDiff(ctx context.Context) ([]*mtypes.MpoolDiff, error) {
+	return m.Reconciler.Diff(ctx)
+}
+
+func (m *MessageImp) ListWalletInventory(ctx context.Context) (*mtypes.WalletInventory, error) {
+	return m.AddressSrv.ListWalletInventory(ctx)
+}
+
+func (m *MessageImp) ListSignerHealth(ctx context.Context) ([]*mtypes.SignerHealth, error) {
+	return m.AddressSrv.ListSignerHealth(ctx)
+}
+
 func (m *MessageImp) ClearUnFillMessage(ctx context.Context, addr address.Address) (int, error) {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, addr); err != nil {
+	if err := m.checkSigners(ctx, addr); err != nil {
 		return 0, err
 	}
 	return m.MessageSrv.ClearUnFillMessage(ctx, addr)
@@ -390,7 +549,7 @@ func (m *MessageImp) DeleteNode(ctx context.Context, name string) error {
 }
 
 func (m *MessageImp) Send(ctx context.Context, params types.QuickSendParams) (string, error) {
-	if err := jwtclient.CheckPermissionBySigner(ctx, m.AuthClient, params.From); err != nil {
+	if err := m.checkSigners(ctx, params.From); err != nil {
 		return "", err
 	}
 	return m.MessageSrv.Send(ctx, params)
@@ -412,6 +571,10 @@ func (m *MessageImp) NetAddrsListen(ctx context.Context) (peer.AddrInfo, error)
 	return m.Net.AddrListen(ctx)
 }
 
+func (m *MessageImp) NetTopicStatus(ctx context.Context) (*mtypes.TopicStatus, error) {
+	return m.Publisher.TopicStatus(ctx)
+}
+
 func (m *MessageImp) Version(_ context.Context) (venusTypes.Version, error) {
 	return venusTypes.Version{
 		Version: version.Version,
@@ -426,29 +589,6 @@ func (m *MessageImp) LogList(_ context.Context) ([]string, error) {
 	return logging.GetSubsystems(), nil
 }
 
-// isAdmin check if the user is admin and return signers of the user
-func isAdmin(ctx context.Context) bool {
-	return core.HasPerm(ctx, nil, core.PermAdmin)
-}
-
-func getSigners(ctx context.Context, client jwtclient.IAuthClient) ([]address.Address, error) {
-	signers := []address.Address{}
-
-	user, exit := core.CtxGetName(ctx)
-	if !exit {
-		return nil, fmt.Errorf("user not found")
-	}
-	resp, err := client.ListSigners(ctx, user)
-	if err != nil {
-		return nil, err
-	}
-	for _, res := range resp {
-		signers = append(signers, res.Signer)
-	}
-
-	return signers, nil
-}
-
 func (m *MessageImp) SaveActorCfg(ctx context.Context, actorCfg *types.ActorCfg) error {
 	return m.MessageSrv.SaveActorCfg(ctx, actorCfg)
 }
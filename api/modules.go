@@ -8,7 +8,6 @@ import (
 
 	"github.com/etherlabsio/healthcheck/v2"
 	"github.com/filecoin-project/go-jsonrpc"
-	"github.com/filecoin-project/venus/venus-shared/api/messager"
 	"github.com/filecoin-project/venus/venus-shared/api/permission"
 	"github.com/ipfs-force-community/metrics/ratelimit"
 	"github.com/ipfs-force-community/sophon-auth/core"
@@ -16,14 +15,24 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	"go.uber.org/fx"
 
+	"github.com/ipfs-force-community/sophon-messager/api/ext"
 	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/service"
 )
 
 var log = logging.Logger("api")
 
-func BindRateLimit(msgImp *MessageImp, remoteAuthCli jwtclient.IAuthClient, rateLimitCfg *config.RateLimitConfig) (messager.IMessager, error) {
-	var msgAPI messager.IMessagerStruct
-	permission.PermissionProxy(msgImp, &msgAPI)
+func BindRateLimit(msgImp *MessageImp,
+	auditSrv *service.AuditService,
+	remoteAuthCli jwtclient.IAuthClient,
+	rateLimitCfg *config.RateLimitConfig,
+) (ext.IMessagerExt, error) {
+	var permAPI ext.IMessagerExtStruct
+	permission.PermissionProxy(msgImp, &permAPI)
+
+	// calls without permission are recorded too
+	var msgAPI ext.IMessagerExtStruct
+	AuditProxy(auditSrv, permAPI, &msgAPI)
 
 	if len(rateLimitCfg.Redis) != 0 && remoteAuthCli != nil {
 		limiter, err := ratelimit.NewRateLimitHandler(
@@ -36,8 +45,8 @@ func BindRateLimit(msgImp *MessageImp, remoteAuthCli jwtclient.IAuthClient, rate
 		if err != nil {
 			return nil, err
 		}
-		var rateLimitAPI messager.IMessagerStruct
-		limiter.WraperLimiter(msgAPI.Internal, &rateLimitAPI.Internal)
+		var rateLimitAPI ext.IMessagerExtStruct
+		limiter.WraperLimiter(msgAPI, &rateLimitAPI)
 		msgAPI = rateLimitAPI
 	}
 	return &msgAPI, nil
@@ -45,8 +54,8 @@ func BindRateLimit(msgImp *MessageImp, remoteAuthCli jwtclient.IAuthClient, rate
 
 // RunAPI bind rpc call and start rpc
 // todo
-func RunAPI(lc fx.Lifecycle, localAuthCli *jwtclient.LocalAuthClient, remoteAuthCli jwtclient.IAuthClient, lst net.Listener, msgImp messager.IMessager) error {
-	srv := jsonrpc.NewServer()
+func RunAPI(lc fx.Lifecycle, localAuthCli *jwtclient.LocalAuthClient, remoteAuthCli jwtclient.IAuthClient, lst net.Listener, msgImp ext.IMessagerExt) error {
+	srv := jsonrpc.NewServer(paramDecoderOptions()...)
 	srv.Register("Message", msgImp)
 	authMux := jwtclient.NewAuthMux(localAuthCli, jwtclient.WarpIJwtAuthClient(remoteAuthCli), srv)
 
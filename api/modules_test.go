@@ -4,10 +4,11 @@ import (
 	"context"
 	"testing"
 
-	"github.com/filecoin-project/venus/venus-shared/api/messager"
 	"github.com/ipfs-force-community/sophon-auth/jwtclient"
 	"github.com/ipfs-force-community/sophon-messager/api"
+	"github.com/ipfs-force-community/sophon-messager/api/ext"
 	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/service"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/fx"
 )
@@ -25,8 +26,9 @@ func TestLimitWrap(t *testing.T) {
 			return &jwtclient.AuthClient{}
 		}),
 		fx.Supply(&api.MessageImp{}),
+		fx.Supply(&service.AuditService{}),
 		fx.Provide(api.BindRateLimit),
 	)
-	app := fx.New(provider, fx.Invoke(func(_ messager.IMessager) error { return nil }))
+	app := fx.New(provider, fx.Invoke(func(_ ext.IMessagerExt) error { return nil }))
 	assert.Nil(t, app.Start(context.Background()))
 }
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/utils"
+)
+
+// paramDecoderOptions accept the eth addresses with the prefix 0x wherever an address is taken by the apis, they are
+// converted to the f410 addresses, or the ID addresses if they are masked ID addresses.
+func paramDecoderOptions() []jsonrpc.ServerOption {
+	return []jsonrpc.ServerOption{
+		jsonrpc.WithParamDecoder(new(address.Address), decodeAddress),
+		jsonrpc.WithParamDecoder(new(*venusTypes.Message), addressFieldsDecoder[venusTypes.Message]("From", "To")),
+		jsonrpc.WithParamDecoder(new(types.QuickSendParams), valueDecoder(addressFieldsDecoder[types.QuickSendParams]("From", "To"))),
+		jsonrpc.WithParamDecoder(new(*types.MsgQueryParams), addressFieldsDecoder[types.MsgQueryParams]("From")),
+		jsonrpc.WithParamDecoder(new(*types.AddressSpec), addressFieldsDecoder[types.AddressSpec]("address")),
+		jsonrpc.WithParamDecoder(new(*mtypes.AddressPolicy), addressFieldsDecoder[mtypes.AddressPolicy]("from", "to")),
+	}
+}
+
+func decodeAddress(_ context.Context, data []byte) (reflect.Value, error) {
+	data, err := normalizeAddress(data)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	var addr address.Address
+	if err := json.Unmarshal(data, &addr); err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(addr), nil
+}
+
+// addressFieldsDecoder decode a *T whose fields are addresses or slices of addresses, the fields are matched case
+// insensitively like encoding/json.
+func addressFieldsDecoder[T any](fields ...string) jsonrpc.ParamDecoder {
+	return func(_ context.Context, data []byte) (reflect.Value, error) {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return reflect.Value{}, err
+		}
+		if obj != nil {
+			for key, val := range obj {
+				for _, field := range fields {
+					if !strings.EqualFold(key, field) {
+						continue
+					}
+					normalized, err := normalizeAddresses(val)
+					if err != nil {
+						return reflect.Value{}, fmt.Errorf("field %s: %w", key, err)
+					}
+					obj[key] = normalized
+				}
+			}
+			var err error
+			if data, err = json.Marshal(obj); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+
+		out := new(T)
+		if err := json.Unmarshal(data, &out); err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(out), nil
+	}
+}
+
+// valueDecoder turns a decoder of *T into a decoder of T
+func valueDecoder(dec jsonrpc.ParamDecoder) jsonrpc.ParamDecoder {
+	return func(ctx context.Context, data []byte) (reflect.Value, error) {
+		val, err := dec(ctx, data)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if val.IsNil() {
+			return reflect.Zero(val.Type().Elem()), nil
+		}
+		return val.Elem(), nil
+	}
+}
+
+// normalizeAddresses normalize an address or a list of addresses
+func normalizeAddresses(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return normalizeAddress(data)
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for i := range list {
+		var err error
+		if list[i], err = normalizeAddress(list[i]); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(list)
+}
+
+// normalizeAddress replace an eth address with its filecoin address, others are returned as is
+func normalizeAddress(data []byte) ([]byte, error) {
+	var s string
+	if json.Unmarshal(data, &s) != nil || !utils.IsEthAddress(s) {
+		return data, nil
+	}
+	addr, err := utils.ParseAddress(s)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(addr)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/utils"
+)
+
+func TestParamDecoder(t *testing.T) {
+	ctx := context.Background()
+	ethAddr := "0xd4c5fb16488aa48081296299d54b0c648c9333da"
+	f4Addr, err := utils.ParseAddress(ethAddr)
+	require.NoError(t, err)
+	idAddr, err := address.NewIDAddress(1024)
+	require.NoError(t, err)
+
+	t.Run("address", func(t *testing.T) {
+		for _, c := range []struct {
+			in     string
+			expect address.Address
+		}{
+			{in: `"` + ethAddr + `"`, expect: f4Addr},
+			{in: `"0xff00000000000000000000000000000000000400"`, expect: idAddr},
+			{in: `"` + idAddr.String() + `"`, expect: idAddr},
+			{in: `"<empty>"`, expect: address.Undef},
+		} {
+			val, err := decodeAddress(ctx, []byte(c.in))
+			require.NoError(t, err, c.in)
+			assert.Equal(t, c.expect, val.Interface(), c.in)
+		}
+
+		_, err := decodeAddress(ctx, []byte(`"0x1234"`))
+		assert.Error(t, err)
+	})
+
+	t.Run("message", func(t *testing.T) {
+		dec := addressFieldsDecoder[venusTypes.Message]("From", "To")
+		val, err := dec(ctx, []byte(`{"from":"`+ethAddr+`","To":"`+idAddr.String()+`","Nonce":10,"Params":"AQI="}`))
+		require.NoError(t, err)
+		msg := val.Interface().(*venusTypes.Message)
+		assert.Equal(t, f4Addr, msg.From)
+		assert.Equal(t, idAddr, msg.To)
+		assert.Equal(t, uint64(10), msg.Nonce)
+		assert.Equal(t, []byte{1, 2}, msg.Params)
+
+		val, err = dec(ctx, []byte(`null`))
+		require.NoError(t, err)
+		assert.Nil(t, val.Interface())
+	})
+
+	t.Run("list of addresses", func(t *testing.T) {
+		dec := addressFieldsDecoder[types.MsgQueryParams]("From")
+		val, err := dec(ctx, []byte(`{"From":["`+ethAddr+`","`+idAddr.String()+`"],"Limit":1}`))
+		require.NoError(t, err)
+		params := val.Interface().(*types.MsgQueryParams)
+		assert.Equal(t, []address.Address{f4Addr, idAddr}, params.From)
+		assert.Equal(t, uint(1), params.Limit)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		dec := valueDecoder(addressFieldsDecoder[types.QuickSendParams]("From", "To"))
+		val, err := dec(ctx, []byte(`{"From":"`+ethAddr+`","To":"`+ethAddr+`","Method":3844450837}`))
+		require.NoError(t, err)
+		params := val.Interface().(types.QuickSendParams)
+		assert.Equal(t, f4Addr, params.From)
+		assert.Equal(t, f4Addr, params.To)
+
+		_, err = dec(ctx, []byte(`{"From":"0x12"}`))
+		assert.ErrorContains(t, err, "field From")
+	})
+}
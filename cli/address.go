@@ -1,12 +1,17 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"time"
 
-	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/venus/venus-shared/types/messager"
 	"github.com/urfave/cli/v2"
+
+	"github.com/ipfs-force-community/sophon-messager/cli/tablewriter"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/utils"
 )
 
 var AddrCmds = &cli.Command{
@@ -20,9 +25,25 @@ var AddrCmds = &cli.Command{
 		activeAddrCmd,
 		setAddrSelMsgNumCmd,
 		setFeeParamsCmd,
+		addrPolicyCmd,
+		walletsAddrCmd,
+		signerHealthCmd,
 	},
 }
 
+// addressOutput is the address with its eth address if it's a delegated address
+type addressOutput struct {
+	*messager.Address
+	EthAddress string `json:"ethAddress,omitempty"`
+}
+
+func newAddressOutput(addr *messager.Address) *addressOutput {
+	if addr == nil {
+		return nil
+	}
+	return &addressOutput{Address: addr, EthAddress: utils.EthAddressString(addr.Addr)}
+}
+
 var searchAddrCmd = &cli.Command{
 	Name:      "search",
 	Usage:     "search address",
@@ -38,7 +59,7 @@ var searchAddrCmd = &cli.Command{
 			return fmt.Errorf("must pass address")
 		}
 
-		addr, err := address.NewFromString(ctx.Args().First())
+		addr, err := utils.ParseAddress(ctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -46,7 +67,7 @@ var searchAddrCmd = &cli.Command{
 		if err != nil {
 			return err
 		}
-		bytes, err := json.MarshalIndent(addrInfo, " ", "\t")
+		bytes, err := json.MarshalIndent(newAddressOutput(addrInfo), " ", "\t")
 		if err != nil {
 			return err
 		}
@@ -70,7 +91,11 @@ var listAddrCmd = &cli.Command{
 			return err
 		}
 
-		bytes, err := json.MarshalIndent(addrs, " ", "\t")
+		out := make([]*addressOutput, 0, len(addrs))
+		for _, addr := range addrs {
+			out = append(out, newAddressOutput(addr))
+		}
+		bytes, err := json.MarshalIndent(out, " ", "\t")
 		if err != nil {
 			return err
 		}
@@ -94,7 +119,7 @@ var deleteAddrCmd = &cli.Command{
 			return fmt.Errorf("must pass address")
 		}
 
-		addr, err := address.NewFromString(ctx.Args().First())
+		addr, err := utils.ParseAddress(ctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -122,7 +147,7 @@ var forbiddenAddrCmd = &cli.Command{
 			return fmt.Errorf("must pass address")
 		}
 
-		addr, err := address.NewFromString(ctx.Args().First())
+		addr, err := utils.ParseAddress(ctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -160,7 +185,7 @@ var activeAddrCmd = &cli.Command{
 			return fmt.Errorf("must pass address")
 		}
 
-		addr, err := address.NewFromString(ctx.Args().First())
+		addr, err := utils.ParseAddress(ctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -204,7 +229,7 @@ var setAddrSelMsgNumCmd = &cli.Command{
 		if !ctx.Args().Present() {
 			return fmt.Errorf("must pass address")
 		}
-		addr, err := address.NewFromString(ctx.Args().First())
+		addr, err := utils.ParseAddress(ctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -245,7 +270,7 @@ var setFeeParamsCmd = &cli.Command{
 			GasFeeCapStr:      ctx.String(gasFeeCapFlag.Name),
 			BaseFeeStr:        ctx.String(basefeeFlag.Name),
 		}
-		params.Address, err = address.NewFromString(ctx.Args().First())
+		params.Address, err = utils.ParseAddress(ctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -253,3 +278,156 @@ var setFeeParamsCmd = &cli.Command{
 		return client.SetFeeParams(ctx.Context, params)
 	},
 }
+
+var walletsAddrCmd = &cli.Command{
+	Name:  "wallets",
+	Usage: "show the gateway and wallet serving each address",
+	Description: `served: the address is served by the wallet
+   no-signer: the address is in messager, but no online wallet can sign for it
+   unknown: the address can be signed by the wallet, but it is not in messager`,
+	Flags: []cli.Flag{
+		outputTypeFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		inventory, err := client.ListWalletInventory(ctx.Context)
+		if err != nil {
+			return err
+		}
+
+		if ctx.String(outputTypeFlag.Name) == "table" {
+			return outputWalletInventoryWithTable(inventory)
+		}
+
+		bytes, err := json.MarshalIndent(inventory, " ", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+		return nil
+	},
+}
+
+var walletInventoryTw = tablewriter.New(
+	tablewriter.Col("Address"),
+	tablewriter.Col("State"),
+	tablewriter.Col("Gateway"),
+	tablewriter.Col("Account"),
+	tablewriter.Col("WalletIP"),
+	tablewriter.NewLineCol("Error"),
+)
+
+func outputWalletInventoryWithTable(inventory *mtypes.WalletInventory) error {
+	for _, signer := range inventory.Signers {
+		state := "served"
+		if !signer.Known {
+			state = "unknown"
+		}
+		walletInventoryTw.Write(map[string]interface{}{
+			"Address":  signer.Address,
+			"State":    state,
+			"Gateway":  signer.Gateway,
+			"Account":  signer.Account,
+			"WalletIP": signer.WalletIP,
+		})
+	}
+	for _, addr := range inventory.NoSigner {
+		walletInventoryTw.Write(map[string]interface{}{
+			"Address": addr,
+			"State":   "no-signer",
+		})
+	}
+	for gateway, err := range inventory.Errors {
+		walletInventoryTw.Write(map[string]interface{}{
+			"Gateway": gateway,
+			"Error":   err,
+		})
+	}
+
+	buf := new(bytes.Buffer)
+	if err := walletInventoryTw.Flush(buf); err != nil {
+		return err
+	}
+	fmt.Println(buf)
+	return nil
+}
+
+var signerHealthCmd = &cli.Command{
+	Name:  "signer-health",
+	Usage: "show the latency, consecutive failures and circuit state of each gateway for each address",
+	Description: `closed: signing is routed to the gateway
+   open: the gateway failed too many times in a row, signing is not routed to it until it is half-open
+   half-open: signing is allowed to probe the gateway, the circuit is closed after a success
+   quarantined: the gateway returned an invalid signature, signing is not routed to it until the quarantine ends`,
+	Flags: []cli.Flag{
+		outputTypeFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		list, err := client.ListSignerHealth(ctx.Context)
+		if err != nil {
+			return err
+		}
+
+		if ctx.String(outputTypeFlag.Name) == "table" {
+			return outputSignerHealthWithTable(list)
+		}
+
+		bytes, err := json.MarshalIndent(list, " ", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+		return nil
+	},
+}
+
+var signerHealthTw = tablewriter.New(
+	tablewriter.Col("Address"),
+	tablewriter.Col("Gateway"),
+	tablewriter.Col("Has"),
+	tablewriter.Col("Circuit"),
+	tablewriter.Col("Failures"),
+	tablewriter.Col("Latency"),
+	tablewriter.Col("UnreachableSince"),
+	tablewriter.Col("QuarantinedUntil"),
+	tablewriter.NewLineCol("LastError"),
+)
+
+func outputSignerHealthWithTable(list []*mtypes.SignerHealth) error {
+	for _, h := range list {
+		row := map[string]interface{}{
+			"Address":   h.Address,
+			"Gateway":   h.Gateway,
+			"Has":       h.Has,
+			"Circuit":   h.Circuit,
+			"Failures":  h.Failures,
+			"Latency":   h.Latency.Truncate(time.Millisecond),
+			"LastError": h.LastError,
+		}
+		if !h.UnreachableSince.IsZero() {
+			row["UnreachableSince"] = h.UnreachableSince.Format("2006-01-02 15:04:05")
+		}
+		if h.Circuit == mtypes.CircuitQuarantined {
+			row["QuarantinedUntil"] = h.QuarantinedUntil.Format("2006-01-02 15:04:05")
+		}
+		signerHealthTw.Write(row)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := signerHealthTw.Flush(buf); err != nil {
+		return err
+	}
+	fmt.Println(buf)
+	return nil
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	types2 "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/ipfs/go-cid"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ipfs-force-community/sophon-messager/cli/tablewriter"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/utils"
+)
+
+var addrPolicyCmd = &cli.Command{
+	Name:  "policy",
+	Usage: "manage the destinations and methods an address may target",
+	Description: `An address without any rule can send messages to anywhere. Once an address has rules,
+a message matched by a deny rule is rejected, and if the address has allow rules, the message
+must match one of them.`,
+	Subcommands: []*cli.Command{
+		addAddrPolicyCmd,
+		listAddrPolicyCmd,
+		delAddrPolicyCmd,
+	},
+}
+
+var addAddrPolicyCmd = &cli.Command{
+	Name:      "add",
+	Usage:     "add a rule to address",
+	ArgsUsage: "<address>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "action",
+			Usage: "allow or deny",
+			Value: "allow",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "destination address the rule match, empty means any address",
+		},
+		&cli.StringFlag{
+			Name:  "actor-code",
+			Usage: "actor code cid of destination the rule match, empty means any actor, see `actor list-builtin-actors`",
+		},
+		&cli.StringFlag{
+			Name:  "methods",
+			Usage: "comma separated method numbers the rule match, empty means any method, eg. 6,7,25",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if !ctx.Args().Present() {
+			return fmt.Errorf("must pass address")
+		}
+
+		policy := &mtypes.AddressPolicy{}
+		if policy.From, err = utils.ParseAddress(ctx.Args().First()); err != nil {
+			return err
+		}
+		if policy.Action, err = mtypes.ParsePolicyAction(ctx.String("action")); err != nil {
+			return err
+		}
+		if ctx.IsSet("to") {
+			if policy.To, err = utils.ParseAddress(ctx.String("to")); err != nil {
+				return err
+			}
+		}
+		if ctx.IsSet("actor-code") {
+			if policy.ActorCode, err = cid.Decode(ctx.String("actor-code")); err != nil {
+				return err
+			}
+		}
+		if ctx.IsSet("methods") {
+			if policy.Methods, err = mtypes.ParseMethods(ctx.String("methods")); err != nil {
+				return err
+			}
+		}
+		if policy.To.Empty() && !policy.ActorCode.Defined() && len(policy.Methods) == 0 && policy.Action == mtypes.PolicyAllow {
+			return errors.New("allow rule without to, actor-code or methods matches everything, please specify at least one")
+		}
+
+		id, err := client.SaveAddressPolicy(ctx.Context, policy)
+		if err != nil {
+			return err
+		}
+		fmt.Println(id)
+
+		return nil
+	},
+}
+
+var listAddrPolicyCmd = &cli.Command{
+	Name:      "list",
+	Usage:     "list rules of address, list all rules if address not set",
+	ArgsUsage: "[address]",
+	Flags: []cli.Flag{
+		outputTypeFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		addr := address.Undef
+		if ctx.Args().Present() {
+			if addr, err = utils.ParseAddress(ctx.Args().First()); err != nil {
+				return err
+			}
+		}
+
+		policies, err := client.ListAddressPolicy(ctx.Context, addr)
+		if err != nil {
+			return err
+		}
+
+		if ctx.String(outputTypeFlag.Name) == "table" {
+			return outputAddrPolicyWithTable(policies)
+		}
+
+		bytes, err := json.MarshalIndent(policies, " ", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+		return nil
+	},
+}
+
+var delAddrPolicyCmd = &cli.Command{
+	Name:      "del",
+	Usage:     "delete a rule",
+	ArgsUsage: "<id>",
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if ctx.NArg() != 1 {
+			return errors.New("must specify one id argument")
+		}
+		id, err := types2.ParseUUID(ctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return client.DeleteAddressPolicy(ctx.Context, id)
+	},
+}
+
+var addrPolicyTw = tablewriter.New(
+	tablewriter.Col("ID"),
+	tablewriter.Col("From"),
+	tablewriter.Col("Action"),
+	tablewriter.Col("To"),
+	tablewriter.Col("ActorCode"),
+	tablewriter.Col("Methods"),
+	tablewriter.Col("CreateAt"),
+)
+
+func outputAddrPolicyWithTable(policies []*mtypes.AddressPolicy) error {
+	for _, p := range policies {
+		row := map[string]interface{}{
+			"ID":        p.ID,
+			"From":      p.From,
+			"Action":    p.Action,
+			"To":        "*",
+			"ActorCode": "*",
+			"Methods":   "*",
+			"CreateAt":  p.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if !p.To.Empty() {
+			row["To"] = p.To
+		}
+		if p.ActorCode.Defined() {
+			row["ActorCode"] = p.ActorCode
+		}
+		if len(p.Methods) > 0 {
+			row["Methods"] = mtypes.FormatMethods(p.Methods)
+		}
+		addrPolicyTw.Write(row)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := addrPolicyTw.Flush(buf); err != nil {
+		return err
+	}
+	fmt.Println(buf)
+	return nil
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ipfs-force-community/sophon-messager/cli/tablewriter"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+var AuditCmds = &cli.Command{
+	Name:  "audit",
+	Usage: "audit log of administrative api calls",
+	Subcommands: []*cli.Command{
+		listAuditLogCmd,
+	},
+}
+
+var listAuditLogCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list audit logs, newest first",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "caller",
+			Usage: "only show the calls of this account",
+		},
+		&cli.StringFlag{
+			Name:  "method",
+			Usage: "only show the calls of this method, eg. UpdateNonce",
+		},
+		&cli.DurationFlag{
+			Name:  "since",
+			Usage: "only show the calls in this period, eg. 24h",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "max number of logs to show",
+			Value: 100,
+		},
+		outputTypeFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		query := &mtypes.AuditLogQuery{
+			Caller: ctx.String("caller"),
+			Method: ctx.String("method"),
+			Limit:  ctx.Int("limit"),
+		}
+		if ctx.IsSet("since") {
+			query.Since = time.Now().Add(-ctx.Duration("since"))
+		}
+
+		logs, err := client.ListAuditLog(ctx.Context, query)
+		if err != nil {
+			return err
+		}
+
+		if ctx.String(outputTypeFlag.Name) == "table" {
+			return outputAuditLogWithTable(logs)
+		}
+
+		bytes, err := json.MarshalIndent(logs, " ", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+		return nil
+	},
+}
+
+var auditLogTw = tablewriter.New(
+	tablewriter.Col("Time"),
+	tablewriter.Col("Caller"),
+	tablewriter.Col("Method"),
+	tablewriter.Col("Args"),
+	tablewriter.Col("Result"),
+	tablewriter.Col("Error"),
+)
+
+func outputAuditLogWithTable(logs []*mtypes.AuditLog) error {
+	for _, l := range logs {
+		auditLogTw.Write(map[string]interface{}{
+			"Time":   l.CreatedAt.Format("2006-01-02 15:04:05"),
+			"Caller": l.Caller,
+			"Method": l.Method,
+			"Args":   l.Args,
+			"Result": l.Result,
+			"Error":  l.Error,
+		})
+	}
+
+	buf := new(bytes.Buffer)
+	if err := auditLogTw.Flush(buf); err != nil {
+		return err
+	}
+	fmt.Println(buf)
+	return nil
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs-force-community/sophon-auth/core"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ipfs-force-community/sophon-messager/cli/tablewriter"
+	"github.com/ipfs-force-community/sophon-messager/utils"
+)
+
+var AuthCmds = &cli.Command{
+	Name:  "auth",
+	Usage: "manage the users of the local auth mode",
+	Subcommands: []*cli.Command{
+		authUserCmds,
+	},
+}
+
+var authUserCmds = &cli.Command{
+	Name:  "user",
+	Usage: "manage users, their tokens and signers",
+	Subcommands: []*cli.Command{
+		addAuthUserCmd,
+		bindAuthSignerCmd,
+		listAuthUserCmd,
+	},
+}
+
+var addAuthUserCmd = &cli.Command{
+	Name:      "add",
+	Usage:     "add a user and print its token, the token is only printed once",
+	ArgsUsage: "<name>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "perm",
+			Usage: fmt.Sprintf("permission of the user, one of %v", core.PermArr),
+			Value: core.PermWrite,
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if !ctx.Args().Present() {
+			return fmt.Errorf("must pass name")
+		}
+
+		user, err := client.AuthUserAdd(ctx.Context, ctx.Args().First(), ctx.String("perm"))
+		if err != nil {
+			return err
+		}
+		fmt.Println(user.Token)
+		return nil
+	},
+}
+
+var bindAuthSignerCmd = &cli.Command{
+	Name:      "bind-signer",
+	Usage:     "bind signers to a user, then the user can access their messages and addresses",
+	ArgsUsage: "<name> <address>...",
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if ctx.NArg() < 2 {
+			return fmt.Errorf("must pass name and address")
+		}
+
+		signers := make([]address.Address, 0, ctx.NArg()-1)
+		for _, arg := range ctx.Args().Slice()[1:] {
+			addr, err := utils.ParseAddress(arg)
+			if err != nil {
+				return err
+			}
+			signers = append(signers, addr)
+		}
+
+		return client.AuthBindSigner(ctx.Context, ctx.Args().First(), signers)
+	},
+}
+
+type authUserOutput struct {
+	Name    string            `json:"name"`
+	Perm    string            `json:"perm"`
+	Signers []address.Address `json:"signers"`
+}
+
+var listAuthUserCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list users with their signers",
+	Flags: []cli.Flag{
+		outputTypeFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		users, err := client.AuthListUser(ctx.Context)
+		if err != nil {
+			return err
+		}
+		outputs := make([]*authUserOutput, 0, len(users))
+		for _, user := range users {
+			bindings, err := client.AuthListSigner(ctx.Context, user.Name)
+			if err != nil {
+				return err
+			}
+			output := &authUserOutput{
+				Name:    user.Name,
+				Perm:    user.Perm,
+				Signers: make([]address.Address, 0, len(bindings)),
+			}
+			for _, b := range bindings {
+				output.Signers = append(output.Signers, b.Signer)
+			}
+			outputs = append(outputs, output)
+		}
+
+		if ctx.String(outputTypeFlag.Name) == "table" {
+			return outputAuthUserWithTable(outputs)
+		}
+
+		bytes, err := json.MarshalIndent(outputs, " ", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+		return nil
+	},
+}
+
+var authUserTw = tablewriter.New(
+	tablewriter.Col("Name"),
+	tablewriter.Col("Perm"),
+	tablewriter.Col("Signers"),
+)
+
+func outputAuthUserWithTable(users []*authUserOutput) error {
+	for _, u := range users {
+		signers := make([]string, 0, len(u.Signers))
+		for _, s := range u.Signers {
+			signers = append(signers, s.String())
+		}
+		authUserTw.Write(map[string]interface{}{
+			"Name":    u.Name,
+			"Perm":    u.Perm,
+			"Signers": strings.Join(signers, ","),
+		})
+	}
+
+	buf := new(bytes.Buffer)
+	if err := authUserTw.Flush(buf); err != nil {
+		return err
+	}
+	fmt.Println(buf)
+	return nil
+}
@@ -14,9 +14,8 @@ import (
 	"github.com/mitchellh/go-homedir"
 	"github.com/urfave/cli/v2"
 
+	"github.com/ipfs-force-community/sophon-messager/api/ext"
 	"github.com/ipfs-force-community/sophon-messager/config"
-
-	"github.com/filecoin-project/venus/venus-shared/api/messager"
 )
 
 const (
@@ -26,7 +25,7 @@ const (
 
 var log = logging.Logger("cli")
 
-func getAPI(ctx *cli.Context) (messager.IMessager, jsonrpc.ClientCloser, error) {
+func getAPI(ctx *cli.Context) (ext.IMessagerExt, jsonrpc.ClientCloser, error) {
 	repo, err := getRepo(ctx)
 	if err != nil {
 		return nil, func() {}, err
@@ -38,7 +37,7 @@ func getAPI(ctx *cli.Context) (messager.IMessager, jsonrpc.ClientCloser, error)
 
 	cfg := repo.Config()
 
-	return messager.DialIMessagerRPC(ctx.Context, cfg.API.Address, string(token), nil)
+	return ext.DialIMessagerExtRPC(ctx.Context, cfg.API.Address, string(token), nil)
 }
 
 func getNodeAPI(ctx *cli.Context) (v1.FullNode, jsonrpc.ClientCloser, error) {
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/filestore"
+)
+
+var ConfigCmds = &cli.Command{
+	Name:  "config",
+	Usage: "config commands",
+	Subcommands: []*cli.Command{
+		reloadConfigCmd,
+		checkConfigCmd,
+		showConfigCmd,
+	},
+}
+
+var reloadConfigCmd = &cli.Command{
+	Name:  "reload",
+	Usage: "reload config file of the running daemon",
+	Description: `Reload config.toml without restart, the same as sending SIGHUP to the daemon.
+Only part of the fields can take effect immediately, the other changed fields are reported
+and take effect after restart.`,
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		res, err := client.ReloadConfig(ctx.Context)
+		if err != nil {
+			return err
+		}
+
+		if len(res.Applied) == 0 && len(res.NeedRestart) == 0 {
+			fmt.Println("config not changed")
+			return nil
+		}
+		if len(res.Applied) > 0 {
+			fmt.Printf("applied: %s\n", strings.Join(res.Applied, ", "))
+		}
+		if len(res.NeedRestart) > 0 {
+			fmt.Printf("need restart: %s\n", strings.Join(res.NeedRestart, ", "))
+		}
+
+		return nil
+	},
+}
+
+var checkConfigCmd = &cli.Command{
+	Name:      "check",
+	Usage:     "validate a config file without starting the daemon",
+	ArgsUsage: "[config file, default is the config of repo]",
+	Action: func(ctx *cli.Context) error {
+		path := ctx.Args().First()
+		if len(path) == 0 {
+			repoPath, err := GetRepoPath(ctx)
+			if err != nil {
+				return err
+			}
+			path = filepath.Join(repoPath, filestore.ConfigFile)
+		}
+
+		cfg, err := filestore.LoadConfigFile(path)
+		if err != nil {
+			return fmt.Errorf("load %s failed: %w", path, err)
+		}
+		if err := cfg.Validate(); err != nil {
+			var errs config.ValidationErrors
+			if errors.As(err, &errs) {
+				for _, e := range errs {
+					fmt.Println(e)
+				}
+				return fmt.Errorf("found %d problems in %s", len(errs), path)
+			}
+			return err
+		}
+		fmt.Printf("%s is valid\n", path)
+
+		return nil
+	},
+}
+
+var showConfigCmd = &cli.Command{
+	Name:  "show",
+	Usage: "print config, tokens and passwords are redacted",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "effective",
+			Usage: "print the config used by the running daemon, which contains the overrides of flags and environment variables",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		var cfg *config.Config
+		if ctx.Bool("effective") {
+			client, closer, err := getAPI(ctx)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			if cfg, err = client.GetConfig(ctx.Context); err != nil {
+				return err
+			}
+		} else {
+			repoPath, err := GetRepoPath(ctx)
+			if err != nil {
+				return err
+			}
+			if cfg, err = filestore.LoadConfigFile(filepath.Join(repoPath, filestore.ConfigFile)); err != nil {
+				return err
+			}
+			cfg = cfg.Redacted()
+		}
+
+		data, err := toml.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+
+		return nil
+	},
+}
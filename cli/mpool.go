@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ipfs-force-community/sophon-messager/cli/tablewriter"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+var mpoolDiffCmd = &cli.Command{
+	Name:  "mpool-diff",
+	Usage: "compare the filled messages with the pending messages in the mpool of each node",
+	Description: `missing: the filled messages which are not in the mpool of node, they are pushed again periodically
+   foreign: the pending messages from local addresses which are not created by messager`,
+	Flags: []cli.Flag{
+		outputTypeFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		diffs, err := client.MpoolDiff(ctx.Context)
+		if err != nil {
+			return err
+		}
+
+		if ctx.String(outputTypeFlag.Name) == "table" {
+			return outputMpoolDiffWithTable(diffs)
+		}
+
+		bytes, err := json.MarshalIndent(diffs, " ", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+		return nil
+	},
+}
+
+var mpoolDiffTw = tablewriter.New(
+	tablewriter.Col("Node"),
+	tablewriter.Col("Type"),
+	tablewriter.Col("ID"),
+	tablewriter.Col("Cid"),
+	tablewriter.Col("From"),
+	tablewriter.Col("Nonce"),
+	tablewriter.NewLineCol("Error"),
+)
+
+func outputMpoolDiffWithTable(diffs []*mtypes.MpoolDiff) error {
+	for _, diff := range diffs {
+		if len(diff.Error) > 0 {
+			mpoolDiffTw.Write(map[string]interface{}{
+				"Node":  diff.Node,
+				"Error": diff.Error,
+			})
+			continue
+		}
+		if len(diff.Missing) == 0 && len(diff.Foreign) == 0 {
+			mpoolDiffTw.Write(map[string]interface{}{
+				"Node": diff.Node,
+				"Type": "consistent",
+			})
+			continue
+		}
+		for i, msgs := range [][]*mtypes.MpoolMessage{diff.Missing, diff.Foreign} {
+			typ := "missing"
+			if i == 1 {
+				typ = "foreign"
+			}
+			for _, msg := range msgs {
+				mpoolDiffTw.Write(map[string]interface{}{
+					"Node":  diff.Node,
+					"Type":  typ,
+					"ID":    msg.ID,
+					"Cid":   msg.Cid,
+					"From":  msg.From,
+					"Nonce": msg.Nonce,
+				})
+			}
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := mpoolDiffTw.Flush(buf); err != nil {
+		return err
+	}
+	fmt.Println(buf)
+	return nil
+}
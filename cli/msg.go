@@ -1,19 +1,26 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/urfave/cli/v2"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
 	"github.com/ipfs-force-community/sophon-messager/utils"
 
 	"github.com/filecoin-project/venus/pkg/constants"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
 	types "github.com/filecoin-project/venus/venus-shared/types/messager"
 	msgparser "github.com/filecoin-project/venus/venus-shared/utils/msg_parser"
 )
@@ -31,10 +38,14 @@ var MsgCmds = &cli.Command{
 		replaceCmd,
 		waitMessagerCmd,
 		republishCmd,
+		pushSignedCmd,
+		pushEthCmd,
+		cancelCmd,
 		markBadCmd,
 		clearUnFillMessageCmd,
 		recoverFailedMsgCmd,
 		updateMessageStateCmd,
+		mpoolDiffCmd,
 	},
 }
 
@@ -50,6 +61,10 @@ var searchCmd = &cli.Command{
 			Name:  "cid",
 			Usage: "message cid",
 		},
+		&cli.StringFlag{
+			Name:  "eth-hash",
+			Usage: "eth tx hash of the message from a delegated address",
+		},
 	},
 	Action: func(ctx *cli.Context) error {
 		client, closer, err := getAPI(ctx)
@@ -83,6 +98,15 @@ var searchCmd = &cli.Command{
 			if err != nil {
 				return err
 			}
+		} else if hashStr := ctx.String("eth-hash"); len(hashStr) > 0 {
+			txHash, err := venusTypes.ParseEthHash(hashStr)
+			if err != nil {
+				return err
+			}
+			msg, err = client.GetMessageByEthTxHash(ctx.Context, txHash)
+			if err != nil {
+				return err
+			}
 		} else {
 			return fmt.Errorf("value of query must be entered")
 		}
@@ -93,6 +117,17 @@ var searchCmd = &cli.Command{
 		}
 		fmt.Printf("- message information:\n%s\n", string(bytes))
 
+		receipts, err := client.GetMessagePublishStatus(ctx.Context, msg.ID)
+		if err != nil {
+			fmt.Printf("get publish status failed %v\n", err)
+		} else if len(receipts) > 0 {
+			bytes, err = json.MarshalIndent(receipts, "", "\t")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("- publish status:\n%s\n", string(bytes))
+		}
+
 		paser, err := msgparser.NewMessageParser(nodeAPI)
 		if err != nil {
 			return nil
@@ -196,6 +231,7 @@ state:
   4:  FailedMsg
   5:  NonceConflictMsg
   6:  NoWalletMsg
+  7:  CancelledMsg
 `,
 		},
 	},
@@ -218,7 +254,7 @@ state:
 
 		var from address.Address
 		if addrStr := ctx.String("from"); len(addrStr) > 0 {
-			from, err = address.NewFromString(addrStr)
+			from, err = utils.ParseAddress(addrStr)
 			if err != nil {
 				return err
 			}
@@ -291,9 +327,13 @@ var listFailedCmd = &cli.Command{
 		}
 
 		if addrStr := ctx.String("from"); len(addrStr) > 0 {
+			from, err := utils.ParseAddress(addrStr)
+			if err != nil {
+				return err
+			}
 			newMsgs := make([]*types.Message, 0, len(msgs))
 			for _, msg := range msgs {
-				if msg.From.String() == addrStr {
+				if msg.From == from {
 					newMsgs = append(newMsgs, msg)
 				}
 			}
@@ -356,7 +396,7 @@ var ListBlockedMessageCmd = &cli.Command{
 			return err
 		}
 		if ctx.IsSet("from") {
-			addr, err = address.NewFromString(ctx.String("from"))
+			addr, err = utils.ParseAddress(ctx.String("from"))
 			if err != nil {
 				return err
 			}
@@ -505,7 +545,7 @@ var replaceCmd = &cli.Command{
 		case 1:
 			id = ctx.Args().First()
 		case 2:
-			f, err := address.NewFromString(ctx.Args().Get(0))
+			f, err := utils.ParseAddress(ctx.Args().Get(0))
 			if err != nil {
 				return err
 			}
@@ -563,6 +603,211 @@ var republishCmd = &cli.Command{
 	},
 }
 
+var pushSignedCmd = &cli.Command{
+	Name:      "push-signed",
+	Usage:     "push a message signed outside messager, the nonce should be the next nonce of the address",
+	ArgsUsage: "<hex of the cbor encoded signed message | json file of the signed message, - for stdin>",
+	Action: func(cctx *cli.Context) error {
+		client, closer, err := getAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if cctx.NArg() != 1 {
+			return errors.New("must has one argument")
+		}
+
+		msg, err := parseSignedMessage(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+		id, err := client.PushSignedMessage(cctx.Context, msg)
+		if err != nil {
+			return err
+		}
+		fmt.Println(id)
+		return nil
+	},
+}
+
+// parseSignedMessage decode signed message from the hex of cbor, or a json file
+func parseSignedMessage(arg string) (*venusTypes.SignedMessage, error) {
+	msg := &venusTypes.SignedMessage{}
+	if data, err := hex.DecodeString(strings.TrimPrefix(arg, "0x")); err == nil {
+		if err := msg.UnmarshalCBOR(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("decode signed message: %w", err)
+		}
+		return msg, nil
+	}
+
+	var data []byte
+	var err error
+	if arg == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(arg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("decode signed message: %w", err)
+	}
+	return msg, nil
+}
+
+var pushEthCmd = &cli.Command{
+	Name:  "push-eth",
+	Usage: "push an EIP-1559 transaction, either a signed one by --raw or an unsigned one by --from which is signed by messager",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "raw",
+			Usage: "hex of the rlp encoded signed transaction",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "eth address or f410 address of the sender",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "eth address or f410 address of the receiver, create a contract if not set",
+		},
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "value to transfer, in FIL",
+			Value: "0",
+		},
+		&cli.StringFlag{
+			Name:  "input",
+			Usage: "hex of the input data",
+		},
+		&cli.Uint64Flag{
+			Name:  "gas",
+			Usage: "gas limit, estimated by messager if not set",
+		},
+		&cli.StringFlag{
+			Name:  "max-fee-per-gas",
+			Usage: "max fee per gas in attoFIL, estimated by messager if not set",
+		},
+		&cli.StringFlag{
+			Name:  "max-priority-fee-per-gas",
+			Usage: "max priority fee per gas in attoFIL, estimated by messager if not set",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		client, closer, err := getAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		params, err := parseEthTxParams(cctx)
+		if err != nil {
+			return err
+		}
+		res, err := client.PushEthTransaction(cctx.Context, params)
+		if err != nil {
+			return err
+		}
+		fmt.Println("id:", res.ID)
+		if res.TxHash != nil {
+			fmt.Println("tx hash:", res.TxHash)
+		}
+		return nil
+	},
+}
+
+func parseEthTxParams(cctx *cli.Context) (*mtypes.EthTxParams, error) {
+	params := &mtypes.EthTxParams{}
+	if raw := cctx.String("raw"); len(raw) > 0 {
+		data, err := venusTypes.DecodeHexString(raw)
+		if err != nil {
+			return nil, fmt.Errorf("decode raw transaction: %w", err)
+		}
+		params.Raw = data
+		return params, nil
+	}
+
+	parseEthAddr := func(name string) (*venusTypes.EthAddress, error) {
+		addr, err := utils.ParseAddress(cctx.String(name))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+		ethAddr, err := venusTypes.EthAddressFromFilecoinAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+		return &ethAddr, nil
+	}
+	if !cctx.IsSet("from") {
+		return nil, errors.New("either --raw or --from should be set")
+	}
+	var err error
+	if params.From, err = parseEthAddr("from"); err != nil {
+		return nil, err
+	}
+	if cctx.IsSet("to") {
+		if params.To, err = parseEthAddr("to"); err != nil {
+			return nil, err
+		}
+	}
+	val, err := venusTypes.ParseFIL(cctx.String("value"))
+	if err != nil {
+		return nil, fmt.Errorf("parse value: %w", err)
+	}
+	params.Value = venusTypes.EthBigInt(val)
+	if input := cctx.String("input"); len(input) > 0 {
+		if params.Input, err = venusTypes.DecodeHexString(input); err != nil {
+			return nil, fmt.Errorf("decode input: %w", err)
+		}
+	}
+	params.Gas = venusTypes.EthUint64(cctx.Uint64("gas"))
+	for name, dst := range map[string]*venusTypes.EthBigInt{
+		"max-fee-per-gas":          &params.MaxFeePerGas,
+		"max-priority-fee-per-gas": &params.MaxPriorityFeePerGas,
+	} {
+		if !cctx.IsSet(name) {
+			continue
+		}
+		v, err := venusTypes.BigFromString(cctx.String(name))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+		*dst = venusTypes.EthBigInt(v)
+	}
+	return params, nil
+}
+
+var cancelCmd = &cli.Command{
+	Name:      "cancel",
+	Usage:     "cancel a message not on chain, a filled message is replaced by a zero-value self-send with the same nonce",
+	ArgsUsage: "id",
+	Action: func(cctx *cli.Context) error {
+		client, closer, err := getAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if cctx.NArg() == 0 {
+			return errors.New("must has id argument")
+		}
+
+		id := cctx.Args().Get(0)
+		replaceID, err := client.CancelMessage(cctx.Context, id)
+		if err != nil {
+			return err
+		}
+		if len(replaceID) == 0 {
+			fmt.Printf("message %s is cancelled\n", id)
+			return nil
+		}
+		fmt.Printf("message %s will be cancelled by message %s\n", id, replaceID)
+		return nil
+	},
+}
+
 var markBadCmd = &cli.Command{
 	Name:  "mark-bad",
 	Usage: "mark bad message",
@@ -586,7 +831,7 @@ var markBadCmd = &cli.Command{
 		}
 
 		if cctx.IsSet("from") {
-			fromAddr, err := address.NewFromString(cctx.String("from"))
+			fromAddr, err := utils.ParseAddress(cctx.String("from"))
 			if err != nil {
 				return err
 			}
@@ -647,7 +892,7 @@ var recoverFailedMsgCmd = &cli.Command{
 		}
 
 		if cctx.IsSet("from") {
-			fromAddr, err := address.NewFromString(cctx.String("from"))
+			fromAddr, err := utils.ParseAddress(cctx.String("from"))
 			if err != nil {
 				return err
 			}
@@ -712,7 +957,7 @@ var clearUnFillMessageCmd = &cli.Command{
 			return fmt.Errorf("must pass address")
 		}
 
-		addr, err := address.NewFromString(ctx.Args().First())
+		addr, err := utils.ParseAddress(ctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -747,6 +992,7 @@ state:
   4:  FailedMsg
   5:  NonceConflictMsg
   6:  NoWalletMsg
+  7:  CancelledMsg
 `,
 		},
 		reallyDoItFlag,
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
@@ -8,6 +9,9 @@ import (
 	"github.com/urfave/cli/v2"
 
 	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+
+	"github.com/ipfs-force-community/sophon-messager/cli/tablewriter"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
 )
 
 var NodeCmds = &cli.Command{
@@ -18,6 +22,7 @@ var NodeCmds = &cli.Command{
 		searchNodeCmd,
 		listNodeCmd,
 		deleteNodeCmd,
+		nodePoolStatusCmd,
 	},
 }
 
@@ -150,3 +155,69 @@ var deleteNodeCmd = &cli.Command{
 		return nil
 	},
 }
+
+var nodePoolStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "show the health of nodes in node pool, the selected one is the first",
+	Flags: []cli.Flag{
+		outputTypeFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		client, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		list, err := client.NodePoolStatus(ctx.Context)
+		if err != nil {
+			return err
+		}
+
+		if ctx.String(outputTypeFlag.Name) == "table" {
+			return outputNodeStatusWithTable(list)
+		}
+
+		bytes, err := json.MarshalIndent(list, " ", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+		return nil
+	},
+}
+
+var nodeStatusTw = tablewriter.New(
+	tablewriter.Col("Name"),
+	tablewriter.Col("Selected"),
+	tablewriter.Col("Healthy"),
+	tablewriter.Col("InSync"),
+	tablewriter.Col("Height"),
+	tablewriter.Col("Latency"),
+	tablewriter.Col("ErrorRate"),
+	tablewriter.Col("Weight"),
+	tablewriter.Col("LastError"),
+)
+
+func outputNodeStatusWithTable(list []*mtypes.NodeStatus) error {
+	for _, s := range list {
+		nodeStatusTw.Write(map[string]interface{}{
+			"Name":      s.Name,
+			"Selected":  s.Selected,
+			"Healthy":   s.Healthy,
+			"InSync":    s.InSync,
+			"Height":    s.Height,
+			"Latency":   s.Latency,
+			"ErrorRate": fmt.Sprintf("%.2f", s.ErrorRate),
+			"Weight":    s.Weight,
+			"LastError": s.LastError,
+		})
+	}
+
+	buf := new(bytes.Buffer)
+	if err := nodeStatusTw.Flush(buf); err != nil {
+		return err
+	}
+	fmt.Println(buf)
+	return nil
+}
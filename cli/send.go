@@ -2,14 +2,16 @@ package cli
 
 import (
 	"fmt"
+	"strconv"
 
-	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
-	"github.com/filecoin-project/venus/venus-shared/actors/builtin"
+	"github.com/filecoin-project/go-state-types/builtin"
 	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
 	"github.com/urfave/cli/v2"
 
 	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+
+	"github.com/ipfs-force-community/sophon-messager/utils"
 )
 
 var SendCmd = &cli.Command{
@@ -50,6 +52,10 @@ var SendCmd = &cli.Command{
 			Name:  "params-hex",
 			Usage: "specify invocation parameters in hex",
 		},
+		&cli.StringFlag{
+			Name:  "calldata",
+			Usage: "specify the calldata in hex to invoke an evm contract, eg. 0xa9059cbb...",
+		},
 		&cli.StringFlag{
 			Name:     "account",
 			Usage:    "optionally specify the account to send",
@@ -69,7 +75,7 @@ var SendCmd = &cli.Command{
 
 		var params types.QuickSendParams
 
-		params.To, err = address.NewFromString(ctx.Args().Get(0))
+		params.To, err = utils.ParseAddress(ctx.Args().Get(0))
 		if err != nil {
 			return fmt.Errorf("failed to parse target address: %w", err)
 		}
@@ -80,7 +86,7 @@ var SendCmd = &cli.Command{
 		}
 		params.Val = abi.TokenAmount(val)
 
-		addr, err := address.NewFromString(ctx.String("from"))
+		addr, err := utils.ParseAddress(ctx.String("from"))
 		if err != nil {
 			return fmt.Errorf("failed to parse from address: %w", err)
 		}
@@ -113,6 +119,17 @@ var SendCmd = &cli.Command{
 
 		params.Method = abi.MethodNum(ctx.Uint64("method"))
 
+		if ctx.IsSet("calldata") {
+			if ctx.IsSet("params-json") || ctx.IsSet("params-hex") {
+				return fmt.Errorf("can't specify 'calldata' with 'params-json' or 'params-hex'")
+			}
+			if ctx.IsSet("method") && params.Method != builtin.MethodsEVM.InvokeContract {
+				return fmt.Errorf("'calldata' is only for the method InvokeContract(%d)", builtin.MethodsEVM.InvokeContract)
+			}
+			params.Method = builtin.MethodsEVM.InvokeContract
+			params.Params = strconv.Quote(ctx.String("calldata"))
+			params.ParamsType = types.QuickSendParamsCodecJSON
+		}
 		if ctx.IsSet("params-json") {
 			params.Params = ctx.String("params-json")
 			params.ParamsType = types.QuickSendParamsCodecJSON
@@ -1,8 +1,12 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 
+	"github.com/ipfs-force-community/sophon-messager/cli/tablewriter"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
 	"github.com/ipfs-force-community/sophon-messager/utils"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/urfave/cli/v2"
@@ -12,10 +16,12 @@ var SwarmCmds = &cli.Command{
 	Name:  "swarm",
 	Usage: "swarm commands",
 	Subcommands: []*cli.Command{
+		idCmd,
 		addressListenCmd,
 		connectByIdCmd,
 		connectByMutiAddrCmd,
 		peersCmd,
+		topicStatusCmd,
 	},
 }
 
@@ -107,6 +113,26 @@ var peersCmd = &cli.Command{
 	},
 }
 
+var idCmd = &cli.Command{
+	Name:  "id",
+	Usage: "output the peer id, which is kept across restarts",
+	Action: func(ctx *cli.Context) error {
+		api, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		peerInfo, err := api.NetAddrsListen(ctx.Context)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(peerInfo.ID)
+		return nil
+	},
+}
+
 var addressListenCmd = &cli.Command{
 	Name:  "listen",
 	Usage: "output the listen addresses",
@@ -126,3 +152,80 @@ var addressListenCmd = &cli.Command{
 		return nil
 	},
 }
+
+var topicStatusCmd = &cli.Command{
+	Name:  "topic-status",
+	Usage: "show the mesh, peer scores and publishing result of the message topic",
+	Flags: []cli.Flag{
+		outputTypeFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		api, closer, err := getAPI(ctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		status, err := api.NetTopicStatus(ctx.Context)
+		if err != nil {
+			return err
+		}
+
+		if ctx.String(outputTypeFlag.Name) == "table" {
+			return outputTopicStatusWithTable(status)
+		}
+
+		bytes, err := json.MarshalIndent(status, " ", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+		return nil
+	},
+}
+
+var peerScoreTw = tablewriter.New(
+	tablewriter.Col("Peer"),
+	tablewriter.Col("InMesh"),
+	tablewriter.Col("Score"),
+	tablewriter.Col("TimeInMesh"),
+	tablewriter.Col("FirstDeliveries"),
+	tablewriter.Col("InvalidDeliveries"),
+)
+
+func outputTopicStatusWithTable(status *mtypes.TopicStatus) error {
+	fmt.Println("topic:", status.Topic)
+	fmt.Println("mesh peers:", len(status.MeshPeers))
+	fmt.Println("subscribed peers:", len(status.Peers))
+	fmt.Println("bootstrap reconnects:", status.BootstrapReconnects)
+	fmt.Println("published:", status.Published)
+	if !status.LastPublishAt.IsZero() {
+		fmt.Println("last published at:", status.LastPublishAt.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Println("publish failed:", status.PublishFailed)
+	if len(status.LastPublishError) > 0 {
+		fmt.Printf("last publish error: %s (at %s)\n", status.LastPublishError, status.LastPublishErrorAt.Format("2006-01-02 15:04:05"))
+	}
+	if len(status.Peers) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	for _, p := range status.Peers {
+		peerScoreTw.Write(map[string]interface{}{
+			"Peer":              p.ID,
+			"InMesh":            p.InMesh,
+			"Score":             fmt.Sprintf("%.2f", p.Score),
+			"TimeInMesh":        p.TimeInMesh,
+			"FirstDeliveries":   fmt.Sprintf("%.2f", p.FirstMessageDeliveries),
+			"InvalidDeliveries": fmt.Sprintf("%.2f", p.InvalidMessageDeliveries),
+		})
+	}
+
+	buf := new(bytes.Buffer)
+	if err := peerScoreTw.Flush(buf); err != nil {
+		return err
+	}
+	fmt.Println(buf)
+	return nil
+}
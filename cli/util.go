@@ -15,6 +15,8 @@ import (
 	types "github.com/filecoin-project/venus/venus-shared/types/messager"
 	"github.com/filecoin-project/venus/venus-shared/utils"
 	"github.com/ipfs-force-community/sophon-messager/cli/tablewriter"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	messagerUtils "github.com/ipfs-force-community/sophon-messager/utils"
 	"github.com/ipfs/go-cid"
 )
 
@@ -51,7 +53,10 @@ func outputWithTable(msgs []*types.Message, verbose bool, nodeAPI v1.FullNode) e
 			"ErrorMsg":   msg.ErrorMsg,
 			"CreateAt":   msg.CreatedAt.Format("2006-01-02 15:04:05"),
 		}
-		if !verbose {
+		if verbose {
+			row["From"] = messagerUtils.FormatAddress(msg.Msg.From)
+			row["To"] = messagerUtils.FormatAddress(msg.Msg.To)
+		} else {
 			if from := msg.Msg.From.String(); len(from) > 9 {
 				row["From"] = from[:9] + "..."
 			}
@@ -82,7 +87,9 @@ func outputWithTable(msgs []*types.Message, verbose bool, nodeAPI v1.FullNode) e
 type msgTmp struct {
 	Version    uint64
 	To         address.Address
+	ToEth      string `json:",omitempty"`
 	From       address.Address
+	FromEth    string `json:",omitempty"`
 	Nonce      uint64
 	Value      abi.TokenAmount
 	GasLimit   int64
@@ -136,7 +143,7 @@ func transformMessage(msg *types.Message, nodeAPI v1.FullNode) *message {
 		TipSetKey:   msg.TipSetKey,
 		Meta:        msg.Meta,
 		WalletName:  msg.WalletName,
-		State:       msg.State.String(),
+		State:       mtypes.MessageStateString(msg.State),
 		ErrorMsg:    msg.ErrorMsg,
 
 		UpdatedAt: msg.UpdatedAt,
@@ -152,7 +159,9 @@ func transformMessage(msg *types.Message, nodeAPI v1.FullNode) *message {
 	m.Msg = msgTmp{
 		Version:    msg.Version,
 		To:         msg.To,
+		ToEth:      messagerUtils.EthAddressString(msg.To),
 		From:       msg.From,
+		FromEth:    messagerUtils.EthAddressString(msg.From),
 		Nonce:      msg.Nonce,
 		Value:      msg.Value,
 		GasLimit:   msg.GasLimit,
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/urfave/cli/v2"
+
+	"github.com/ipfs-force-community/sophon-messager/utils"
+	"github.com/ipfs-force-community/sophon-messager/wallet"
+)
+
+var WalletCmds = &cli.Command{
+	Name: "wallet",
+	Usage: fmt.Sprintf("manage the keys of local signer, the passphrase of keystore is read from %s or %s, "+
+		"otherwise it's prompted", wallet.PassphraseEnv, wallet.PassphraseFileEnv),
+	Subcommands: []*cli.Command{
+		walletImportCmd,
+		walletListCmd,
+		walletExportCmd,
+	},
+}
+
+func openKeystore(cctx *cli.Context) (*wallet.Keystore, error) {
+	repo, err := getRepo(cctx)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := wallet.ReadPassphrase(os.Stdin, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.OpenKeystore(wallet.KeystoreDir(repo.Path(), &repo.Config().Signer), passphrase)
+}
+
+var walletImportCmd = &cli.Command{
+	Name:      "import",
+	Usage:     "import a key into local keystore, the key is the hex of json key info, same as the export of lotus and venus",
+	ArgsUsage: "<key file>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return errors.New("must has key file argument")
+		}
+		data, err := os.ReadFile(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+		ki, err := decodeKeyInfo(strings.TrimSpace(string(data)))
+		if err != nil {
+			return err
+		}
+
+		ks, err := openKeystore(cctx)
+		if err != nil {
+			return err
+		}
+		addr, err := ks.Import(ki)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("imported key %s\n", addr)
+		return nil
+	},
+}
+
+// decodeKeyInfo decode key info in hex of json, or json
+func decodeKeyInfo(s string) (*venusTypes.KeyInfo, error) {
+	data := []byte(s)
+	if decoded, err := hex.DecodeString(s); err == nil {
+		data = decoded
+	}
+	var ki venusTypes.KeyInfo
+	if err := json.Unmarshal(data, &ki); err != nil {
+		return nil, fmt.Errorf("decode key info: %w", err)
+	}
+	return &ki, nil
+}
+
+var walletListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the addresses in local keystore",
+	Action: func(cctx *cli.Context) error {
+		repo, err := getRepo(cctx)
+		if err != nil {
+			return err
+		}
+		addrs, err := wallet.ListKeys(wallet.KeystoreDir(repo.Path(), &repo.Config().Signer))
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			fmt.Println(addr)
+		}
+		return nil
+	},
+}
+
+var walletExportCmd = &cli.Command{
+	Name:      "export",
+	Usage:     "export a key in local keystore as the hex of json key info",
+	ArgsUsage: "<address>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "specify this flag to confirm printing the private key",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 1 {
+			return errors.New("must has address argument")
+		}
+		if !cctx.Bool("really-do-it") {
+			return errors.New("the private key will be printed, specify --really-do-it to confirm")
+		}
+		addr, err := utils.ParseAddress(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		ks, err := openKeystore(cctx)
+		if err != nil {
+			return err
+		}
+		ki, err := ks.Get(addr)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(ki)
+		if err != nil {
+			return err
+		}
+		fmt.Println(hex.EncodeToString(data))
+		return nil
+	},
+}
@@ -14,6 +14,7 @@ type Config struct {
 	Node           NodeConfig             `toml:"node"`
 	MessageService MessageServiceConfig   `toml:"messageService"`
 	Gateway        GatewayConfig          `toml:"gateway"`
+	Signer         SignerConfig           `toml:"signer"`
 	RateLimit      RateLimitConfig        `toml:"rateLimit"`
 	Trace          *metrics.TraceConfig   `toml:"tracing"`
 	Metrics        *metrics.MetricsConfig `toml:"metrics"`
@@ -22,8 +23,24 @@ type Config struct {
 }
 
 type NodeConfig struct {
-	Url   string `toml:"url"`
-	Token string `toml:"token"`
+	Url   string         `toml:"url"`
+	Token string         `toml:"token"`
+	Pool  NodePoolConfig `toml:"pool"`
+}
+
+// NodePoolConfig route the calls to chain to the best node among the main node and the nodes added by `node add`,
+// and fail over to the other nodes when the selected one is unavailable.
+type NodePoolConfig struct {
+	Enable bool `toml:"enable"`
+	// CheckInterval is the interval to check the head height and latency of nodes
+	CheckInterval time.Duration `toml:"checkInterval"`
+	// MaxHeightLag a node is out of sync when its head is more than MaxHeightLag epochs behind the highest one
+	MaxHeightLag int64 `toml:"maxHeightLag"`
+	// MaxErrorRate a node is unhealthy when the recent rate of failed calls is higher than it
+	MaxErrorRate float64 `toml:"maxErrorRate"`
+	// Weights of nodes keyed by node name, the main node is named mainNode, the default weight is 1,
+	// the node with the highest weight * success rate / latency is selected
+	Weights map[string]int `toml:"weights"`
 }
 
 type LogConfig struct {
@@ -54,20 +71,46 @@ type MySqlConfig struct {
 }
 
 type JWTConfig struct {
+	// Mode is where the tokens are verified, remote (sophon-auth) or local (users stored in the database of messager)
+	Mode    string `toml:"mode"`
 	AuthURL string `toml:"authURL"`
 	Token   string `toml:"token"`
 }
 
+const (
+	JWTModeRemote = "remote"
+	JWTModeLocal  = "local"
+)
+
+// IsLocal returns true if the users, tokens and signers are managed by messager itself
+func (c *JWTConfig) IsLocal() bool {
+	return c.Mode == JWTModeLocal
+}
+
 const (
 	MinWaitingChainHeadStableDuration = time.Second * 2
 	MaxWaitingChainHeadStableDuration = time.Second * 25
 	DefWaitingChainHeadStableDuration = time.Second * 8
 )
 
+// WaitingChainHeadStableDurationOf returns half of the block delay, limited to the range accepted by Validate,
+// it's used when the configured one is not less than the block delay, eg. in a devnet.
+func WaitingChainHeadStableDurationOf(blockDelaySecs uint64) time.Duration {
+	d := time.Duration(blockDelaySecs) * time.Second / 2
+	if d < MinWaitingChainHeadStableDuration {
+		return MinWaitingChainHeadStableDuration
+	}
+	if d > MaxWaitingChainHeadStableDuration {
+		return MaxWaitingChainHeadStableDuration
+	}
+	return d
+}
+
 const (
 	DefaultTimeout         = time.Second
 	SignMessageTimeout     = time.Second * 3
 	EstimateMessageTimeout = time.Second * 30
+	DefaultSignConcurrency = 10
 )
 
 type MessageServiceConfig struct {
@@ -76,9 +119,16 @@ type MessageServiceConfig struct {
 	DefaultTimeout         time.Duration `toml:"DefaultTimeout"`
 	SignMessageTimeout     time.Duration `toml:"SignMessageTimeout"`
 	EstimateMessageTimeout time.Duration `toml:"EstimateMessageTimeout"`
+	// SignConcurrency is the max number of messages of an address signed concurrently,
+	// when the signer doesn't support signing in batch
+	SignConcurrency int `toml:"signConcurrency"`
 
 	SkipProcessHead bool `toml:"skipProcessHead"`
 	SkipPushMessage bool `toml:"skipPushMessage"`
+
+	// MpoolReconcileInterval is the interval to compare the filled messages with the mpool of nodes,
+	// and push the missing messages again, 0 means disable.
+	MpoolReconcileInterval time.Duration `toml:"mpoolReconcileInterval"`
 }
 
 type Libp2pNetConfig struct {
@@ -93,6 +143,21 @@ type Libp2pNetConfig struct {
 	// default set to "0s" which means use network default config.
 	// otherwise, it should be a duration string like "5s", "30s".
 	ExpandPeriod time.Duration `toml:"expandPeriod"`
+
+	// MinMeshPeers determine when to reconnect to bootstrap peers, checked every ExpandPeriod.
+	// reconnect if the gossipsub mesh of a joined topic has less peers, 0 means disable.
+	MinMeshPeers int `toml:"minMeshPeers"`
+
+	// StaticPeers are the addresses of trusted peers, eg. our own chain nodes, like /ip4/1.2.3.4/tcp/34567/p2p/12D3KooW...,
+	// they are always connected, reconnected when disconnected, and protected from being trimmed.
+	StaticPeers []string `toml:"staticPeers"`
+	// PrivatePeering connect to StaticPeers only, the DHT, the bootstrap addresses and
+	// the default bootstrap peers of the network are not used.
+	PrivatePeering bool `toml:"privatePeering"`
+	// EnableConnectionGater reject the connections with peers out of StaticPeers and AllowedPeers, in both directions.
+	EnableConnectionGater bool `toml:"enableConnectionGater"`
+	// AllowedPeers are the ids of peers allowed to connect besides StaticPeers, when EnableConnectionGater is true.
+	AllowedPeers []string `toml:"allowedPeers"`
 	// TODO: EnableRelay
 }
 
@@ -109,6 +174,38 @@ type PublisherConfig struct {
 
 	EnableP2P       bool `toml:"enablePubsub"`
 	EnableMultiNode bool `toml:"enableMultiNode"`
+
+	// the messages to publish are saved in the outbox of database first, and removed after published.
+	// OutboxBatchSize is the max number of messages published from outbox at a time.
+	OutboxBatchSize int `toml:"outboxBatchSize"`
+	// OutboxRetryInterval is the delay before retrying a failed publishing, it doubles for each failure up to 10 minutes.
+	OutboxRetryInterval time.Duration `toml:"outboxRetryInterval"`
+	// OutboxMaxAttempts is the max number of failed publishing before a message is removed from outbox,
+	// the message will be pushed again by the message selector if it is still not on chain.
+	OutboxMaxAttempts int `toml:"outboxMaxAttempts"`
+
+	// ReceiptRetention is how long the results of pushing messages to each node are kept, 0 means forever.
+	ReceiptRetention time.Duration `toml:"receiptRetention"`
+
+	// Pipeline compose the publishers, a wrapper stage like cache and concurrent wraps the stages after it,
+	// and the backend stages at the end, like rpc, pubsub, http and file, publish messages in parallel.
+	// when it is empty, the pipeline is built from Concurrency, CacheReleasePeriod and EnableP2P.
+	Pipeline []PublisherStage `toml:"pipeline"`
+}
+
+// PublisherStage is a stage of the publisher pipeline, Options are specific to Type.
+type PublisherStage struct {
+	// Name is used in logs, default is Type
+	Name    string            `toml:"name"`
+	Type    string            `toml:"type"`
+	Options map[string]string `toml:"options"`
+}
+
+func (s PublisherStage) StageName() string {
+	if len(s.Name) > 0 {
+		return s.Name
+	}
+	return s.Type
 }
 
 type MessageStateConfig struct {
@@ -118,8 +215,58 @@ type MessageStateConfig struct {
 }
 
 type GatewayConfig struct {
-	Token string   `toml:"token"`
-	Url   []string `toml:"url"`
+	Token  string             `toml:"token"`
+	Url    []string           `toml:"url"`
+	Health SignerHealthConfig `toml:"health"`
+}
+
+// SignerHealthConfig track the health of each gateway for each address, signing is routed to the healthy gateway
+// with the lowest latency.
+type SignerHealthConfig struct {
+	// FailureThreshold the circuit of a gateway for an address is opened after FailureThreshold consecutive failures
+	FailureThreshold int `toml:"failureThreshold"`
+	// OpenDuration is how long an opened circuit rejects signing, then a call is allowed to probe the gateway
+	OpenDuration time.Duration `toml:"openDuration"`
+	// UnreachableAlert alert when no signer has been reachable for an address for longer than it, 0 to disable
+	UnreachableAlert time.Duration `toml:"unreachableAlert"`
+	// QuarantineDuration is how long a gateway returning an invalid signature for an address is not used for it
+	QuarantineDuration time.Duration `toml:"quarantineDuration"`
+}
+
+const (
+	// SignerGateway sign messages by the wallets connected to sophon-gateway
+	SignerGateway = "gateway"
+	// SignerLocal sign messages by the keys in local keystore
+	SignerLocal = "local"
+	// SignerRemote sign messages by a http service, see RemoteSignerConfig
+	SignerRemote = "remote"
+)
+
+type SignerConfig struct {
+	// Type is gateway, local or remote
+	Type string `toml:"type"`
+	// Keystore is the directory of local keys, relative to the repo if it's not absolute
+	Keystore string             `toml:"keystore"`
+	Remote   RemoteSignerConfig `toml:"remote"`
+}
+
+// RemoteSignerConfig sign messages by a http service speaking json, eg. a bridge to HSM or KMS,
+// the protocol is described in gateway.RemoteSigner.
+type RemoteSignerConfig struct {
+	URL string `toml:"url"`
+	// CACert is the PEM file of CA to verify the server, the system pool is used if empty
+	CACert string `toml:"caCert"`
+	// Cert and Key are the PEM files of client certificate for mTLS
+	Cert string `toml:"cert"`
+	Key  string `toml:"key"`
+	// HMACSecret signs each request by HMAC-SHA256 if not empty
+	HMACSecret string `toml:"hmacSecret"`
+	// Timeout is the timeout of each request
+	Timeout time.Duration `toml:"timeout"`
+	// MaxRetries is the max number of retries of a request failed by network or 5xx status
+	MaxRetries int `toml:"maxRetries"`
+	// RetryInterval is the interval before the first retry, doubled for each later retry
+	RetryInterval time.Duration `toml:"retryInterval"`
 }
 
 type RateLimitConfig struct {
@@ -139,6 +286,7 @@ func DefaultConfig() *Config {
 			},
 		},
 		JWT: JWTConfig{
+			Mode:    JWTModeRemote,
 			AuthURL: "http://127.0.0.1:8989",
 		},
 		Log: LogConfig{
@@ -151,6 +299,13 @@ func DefaultConfig() *Config {
 		Node: NodeConfig{
 			Url:   "/ip4/127.0.0.1/tcp/3453",
 			Token: "",
+			Pool: NodePoolConfig{
+				Enable:        false,
+				CheckInterval: 10 * time.Second,
+				MaxHeightLag:  2,
+				MaxErrorRate:  0.5,
+				Weights:       map[string]int{},
+			},
 		},
 		MessageService: MessageServiceConfig{
 			WaitingChainHeadStableDuration: DefWaitingChainHeadStableDuration,
@@ -158,13 +313,31 @@ func DefaultConfig() *Config {
 			DefaultTimeout:         DefaultTimeout,
 			SignMessageTimeout:     SignMessageTimeout,
 			EstimateMessageTimeout: EstimateMessageTimeout,
+			SignConcurrency:        DefaultSignConcurrency,
 
 			SkipProcessHead: false,
 			SkipPushMessage: false,
+
+			MpoolReconcileInterval: time.Minute,
 		},
 		Gateway: GatewayConfig{
 			Token: "",
 			Url:   []string{"/ip4/127.0.0.1/tcp/45132"},
+			Health: SignerHealthConfig{
+				FailureThreshold:   3,
+				OpenDuration:       30 * time.Second,
+				UnreachableAlert:   time.Minute,
+				QuarantineDuration: time.Hour,
+			},
+		},
+		Signer: SignerConfig{
+			Type:     SignerGateway,
+			Keystore: "keystore",
+			Remote: RemoteSignerConfig{
+				Timeout:       5 * time.Second,
+				MaxRetries:    2,
+				RetryInterval: 200 * time.Millisecond,
+			},
 		},
 		RateLimit: RateLimitConfig{Redis: ""},
 		Trace:     metrics.DefaultTraceConfig(),
@@ -174,12 +347,20 @@ func DefaultConfig() *Config {
 			BootstrapAddresses: []string{},
 			MinPeerThreshold:   0,
 			ExpandPeriod:       0 * time.Second,
+			MinMeshPeers:       1,
+			StaticPeers:        []string{},
+			AllowedPeers:       []string{},
 		},
 		Publisher: &PublisherConfig{
 			Concurrency:        5,
 			CacheReleasePeriod: 0,
 			EnableP2P:          false,
 			EnableMultiNode:    true,
+
+			OutboxBatchSize:     500,
+			OutboxRetryInterval: 10 * time.Second,
+			OutboxMaxAttempts:   10,
+			ReceiptRetention:    72 * time.Hour,
 		},
 	}
 }
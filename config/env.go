@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of environment variables which override config fields.
+const EnvPrefix = "SOPHON_MESSAGER"
+
+// envFileSuffix is appended to the name of environment variable to read the value from a file, used for secrets.
+const envFileSuffix = "_FILE"
+
+// EnvOverride is a config field overridden by environment variable.
+type EnvOverride struct {
+	Field string
+	Env   string
+}
+
+// ApplyEnv override config fields with environment variables. The name of variable is derived from the toml keys,
+// eg. SOPHON_MESSAGER_DB_MYSQL_CONNECTIONSTRING for db.mysql.connectionString, and SOPHON_MESSAGER_DB_MYSQL_CONNECTIONSTRING_FILE
+// reads the value from a file. Slices are comma separated.
+func ApplyEnv(cfg *Config) ([]EnvOverride, error) {
+	return applyEnv(cfg, os.LookupEnv)
+}
+
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) ([]EnvOverride, error) {
+	var overrides []EnvOverride
+	err := applyEnvValue(reflect.ValueOf(cfg).Elem(), "", EnvPrefix, lookup, &overrides)
+
+	return overrides, err
+}
+
+func applyEnvValue(v reflect.Value, path, env string, lookup func(string) (string, bool), overrides *[]EnvOverride) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return applyEnvValue(v.Elem(), path, env, lookup, overrides)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			return nil
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("toml"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if len(name) == 0 {
+				name = field.Name
+			}
+			fieldPath := name
+			if len(path) > 0 {
+				fieldPath = path + "." + name
+			}
+			if err := applyEnvValue(v.Field(i), fieldPath, env+"_"+strings.ToUpper(name), lookup, overrides); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	val, ok := lookup(env)
+	fileName, fileOk := lookup(env + envFileSuffix)
+	if ok && fileOk {
+		return fmt.Errorf("both %s and %s are set", env, env+envFileSuffix)
+	}
+	if fileOk {
+		data, err := os.ReadFile(fileName)
+		if err != nil {
+			return fmt.Errorf("read %s failed: %w", env+envFileSuffix, err)
+		}
+		val = strings.TrimRight(string(data), "\r\n")
+		env += envFileSuffix
+	} else if !ok {
+		return nil
+	}
+
+	if err := setValue(v, val); err != nil {
+		return fmt.Errorf("parse %s failed: %w", env, err)
+	}
+	*overrides = append(*overrides, EnvOverride{Field: path, Env: env})
+
+	return nil
+}
+
+func setValue(v reflect.Value, val string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		if len(val) > 0 {
+			items = strings.Split(val, ",")
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
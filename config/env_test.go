@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyEnv(t *testing.T) {
+	dsnFile := filepath.Join(t.TempDir(), "dsn")
+	assert.NoError(t, os.WriteFile(dsnFile, []byte("root:secret@(127.0.0.1:3306)/messager\n"), 0o600))
+
+	env := map[string]string{
+		"SOPHON_MESSAGER_DB_TYPE":                        "mysql",
+		"SOPHON_MESSAGER_DB_MYSQL_CONNECTIONSTRING_FILE": dsnFile,
+		"SOPHON_MESSAGER_DB_MYSQL_MAXOPENCONN":           "20",
+		"SOPHON_MESSAGER_DB_MYSQL_CONNMAXLIFETIME":       "5m",
+		"SOPHON_MESSAGER_GATEWAY_URL":                    "/ip4/127.0.0.1/tcp/45132, /ip4/127.0.0.2/tcp/45132",
+		"SOPHON_MESSAGER_TRACING_PROBABILITYSAMPLER":     "0.5",
+		"SOPHON_MESSAGER_LIBP2P_BOOTSTRAPADDRESSES":      "",
+	}
+	lookup := func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	}
+
+	cfg := DefaultConfig()
+	cfg.Libp2pNet.BootstrapAddresses = []string{"/ip4/127.0.0.1/tcp/34567/p2p/12D3KooWQZDJ4x5Mr4DBt8dW6JzXYnLNGYD5bLd9aDwy8JqXW2mQ"}
+	overrides, err := applyEnv(cfg, lookup)
+	assert.NoError(t, err)
+	assert.Len(t, overrides, len(env))
+	assert.Contains(t, overrides, EnvOverride{Field: "db.mysql.connectionString", Env: "SOPHON_MESSAGER_DB_MYSQL_CONNECTIONSTRING_FILE"})
+	assert.Contains(t, overrides, EnvOverride{Field: "gateway.url", Env: "SOPHON_MESSAGER_GATEWAY_URL"})
+
+	assert.Equal(t, "mysql", cfg.DB.Type)
+	assert.Equal(t, "root:secret@(127.0.0.1:3306)/messager", cfg.DB.MySql.ConnectionString)
+	assert.Equal(t, 20, cfg.DB.MySql.MaxOpenConn)
+	assert.Equal(t, 5*time.Minute, cfg.DB.MySql.ConnMaxLifeTime)
+	assert.Equal(t, []string{"/ip4/127.0.0.1/tcp/45132", "/ip4/127.0.0.2/tcp/45132"}, cfg.Gateway.Url)
+	assert.Equal(t, 0.5, cfg.Trace.ProbabilitySampler)
+	assert.Empty(t, cfg.Libp2pNet.BootstrapAddresses)
+	assert.NoError(t, cfg.Validate())
+
+	// both value and file are set
+	env["SOPHON_MESSAGER_DB_MYSQL_CONNECTIONSTRING"] = "root@(127.0.0.1:3306)/messager"
+	_, err = applyEnv(DefaultConfig(), lookup)
+	assert.Error(t, err)
+	delete(env, "SOPHON_MESSAGER_DB_MYSQL_CONNECTIONSTRING")
+
+	env["SOPHON_MESSAGER_DB_MYSQL_MAXOPENCONN"] = "many"
+	_, err = applyEnv(DefaultConfig(), lookup)
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redactedMark = "******"
+
+// the password part of mysql dsn, eg. user:password@tcp(127.0.0.1:3306)/messager
+var dsnPasswordRegexp = regexp.MustCompile(`^([^:@/]*):[^@]*@`)
+
+// Redacted returns a copy of config with tokens and passwords hidden, it is safe to print.
+func (c *Config) Redacted() *Config {
+	cp := *c
+
+	cp.JWT.Token = redactString(cp.JWT.Token)
+	cp.Node.Token = redactString(cp.Node.Token)
+	cp.Gateway.Token = redactString(cp.Gateway.Token)
+	cp.Signer.Remote.HMACSecret = redactString(cp.Signer.Remote.HMACSecret)
+	cp.DB.MySql.ConnectionString = dsnPasswordRegexp.ReplaceAllString(cp.DB.MySql.ConnectionString, "${1}:"+redactedMark+"@")
+	if len(cp.RateLimit.Redis) > 0 {
+		if u, err := url.Parse(cp.RateLimit.Redis); err == nil {
+			cp.RateLimit.Redis = u.Redacted()
+		} else {
+			cp.RateLimit.Redis = redactedMark
+		}
+	}
+	if cp.Publisher != nil {
+		// headers of http relay may contain credentials
+		publisher := *cp.Publisher
+		publisher.Pipeline = make([]PublisherStage, 0, len(cp.Publisher.Pipeline))
+		for _, stage := range cp.Publisher.Pipeline {
+			opts := make(map[string]string, len(stage.Options))
+			for k, v := range stage.Options {
+				if strings.HasPrefix(k, "header.") {
+					v = redactString(v)
+				}
+				opts[k] = v
+			}
+			stage.Options = opts
+			publisher.Pipeline = append(publisher.Pipeline, stage)
+		}
+		cp.Publisher = &publisher
+	}
+
+	return &cp
+}
+
+func redactString(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	return redactedMark
+}
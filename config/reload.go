@@ -0,0 +1,89 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// hotReloadFields are the fields which take effect without restart, keyed by the path of toml keys.
+var hotReloadFields = map[string]struct{}{
+	"log.level": {},
+
+	"messageService.WaitingChainHeadStableDuration": {},
+	"messageService.DefaultTimeout":                 {},
+	"messageService.SignMessageTimeout":             {},
+	"messageService.EstimateMessageTimeout":         {},
+	"messageService.signConcurrency":                {},
+	"messageService.skipProcessHead":                {},
+
+	"gateway.url":                       {},
+	"gateway.token":                     {},
+	"gateway.health.failureThreshold":   {},
+	"gateway.health.openDuration":       {},
+	"gateway.health.unreachableAlert":   {},
+	"gateway.health.quarantineDuration": {},
+
+	"publisher.concurrency": {},
+}
+
+// IsHotReloadable returns whether the field in path can be changed without restart.
+func IsHotReloadable(path string) bool {
+	_, ok := hotReloadFields[path]
+	return ok
+}
+
+// ReloadResult describe the changed fields after reloading the config file.
+type ReloadResult struct {
+	// Applied are the fields which have taken effect
+	Applied []string `json:"applied"`
+	// NeedRestart are the fields which only take effect after restart
+	NeedRestart []string `json:"needRestart"`
+}
+
+// DiffConfig returns the paths of toml keys whose values are different between old and new, sorted.
+func DiffConfig(old, new *Config) []string {
+	var changed []string
+	diffValue("", reflect.ValueOf(old), reflect.ValueOf(new), &changed)
+	sort.Strings(changed)
+
+	return changed
+}
+
+func diffValue(path string, old, new reflect.Value, changed *[]string) {
+	if old.Kind() == reflect.Ptr {
+		if old.IsNil() || new.IsNil() {
+			if old.IsNil() != new.IsNil() {
+				*changed = append(*changed, path)
+			}
+			return
+		}
+		diffValue(path, old.Elem(), new.Elem(), changed)
+		return
+	}
+
+	if old.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+			*changed = append(*changed, path)
+		}
+		return
+	}
+
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		if len(path) > 0 {
+			name = path + "." + name
+		}
+		diffValue(name, old.Field(i), new.Field(i), changed)
+	}
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/filecoin-project/venus/venus-shared/api"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// FieldError is a problem of one config field, Field is the path of toml keys.
+type FieldError struct {
+	Field  string
+	Value  interface{}
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s, got %v", e.Field, e.Reason, e.Value)
+}
+
+// ValidationErrors are all problems found in a config.
+type ValidationErrors []*FieldError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(es))
+	for _, e := range es {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Validate check all fields of config, it returns ValidationErrors containing every problem, or nil.
+func (c *Config) Validate() error {
+	var errs ValidationErrors
+	check := func(ok bool, field string, value interface{}, reason string) {
+		if !ok {
+			errs = append(errs, &FieldError{Field: field, Value: value, Reason: reason})
+		}
+	}
+	checkErr := func(err error, field string, value interface{}) {
+		if err != nil {
+			errs = append(errs, &FieldError{Field: field, Value: value, Reason: err.Error()})
+		}
+	}
+
+	switch c.DB.Type {
+	case "sqlite":
+	case "mysql":
+		check(len(c.DB.MySql.ConnectionString) > 0, "db.mysql.connectionString", `""`, "required when db type is mysql")
+		check(c.DB.MySql.MaxOpenConn >= 0, "db.mysql.maxOpenConn", c.DB.MySql.MaxOpenConn, "should not be negative")
+		check(c.DB.MySql.MaxIdleConn >= 0, "db.mysql.maxIdleConn", c.DB.MySql.MaxIdleConn, "should not be negative")
+		check(c.DB.MySql.ConnMaxLifeTime >= 0, "db.mysql.connMaxLifeTime", c.DB.MySql.ConnMaxLifeTime, "should not be negative")
+	default:
+		check(false, "db.type", c.DB.Type, "should be sqlite or mysql")
+	}
+
+	switch c.JWT.Mode {
+	case "", JWTModeRemote, JWTModeLocal:
+	default:
+		check(false, "jwt.mode", c.JWT.Mode, "should be remote or local")
+	}
+	if len(c.JWT.AuthURL) > 0 {
+		checkErr(validateURL(c.JWT.AuthURL), "jwt.authURL", c.JWT.AuthURL)
+	}
+
+	if len(c.Log.Level) > 0 {
+		_, err := logging.LevelFromString(c.Log.Level)
+		checkErr(err, "log.level", c.Log.Level)
+	}
+
+	_, err := multiaddr.NewMultiaddr(c.API.Address)
+	checkErr(err, "api.Address", c.API.Address)
+
+	checkErr(validateAPIAddr(c.Node.Url), "node.url", c.Node.Url)
+	if pool := c.Node.Pool; pool.Enable {
+		check(pool.CheckInterval > 0, "node.pool.checkInterval", pool.CheckInterval, "should be positive")
+		check(pool.MaxHeightLag >= 0, "node.pool.maxHeightLag", pool.MaxHeightLag, "should not be negative")
+		check(pool.MaxErrorRate >= 0 && pool.MaxErrorRate <= 1, "node.pool.maxErrorRate", pool.MaxErrorRate, "should be in [0, 1]")
+		names := make([]string, 0, len(pool.Weights))
+		for name := range pool.Weights {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			check(pool.Weights[name] > 0, fmt.Sprintf("node.pool.weights.%s", name), pool.Weights[name], "should be positive")
+		}
+	}
+
+	ms := c.MessageService
+	check(ms.WaitingChainHeadStableDuration >= MinWaitingChainHeadStableDuration && ms.WaitingChainHeadStableDuration <= MaxWaitingChainHeadStableDuration,
+		"messageService.WaitingChainHeadStableDuration", ms.WaitingChainHeadStableDuration,
+		fmt.Sprintf("should be in [%v, %v]", MinWaitingChainHeadStableDuration, MaxWaitingChainHeadStableDuration))
+	check(ms.DefaultTimeout > 0, "messageService.DefaultTimeout", ms.DefaultTimeout, "should be positive")
+	check(ms.SignMessageTimeout > 0, "messageService.SignMessageTimeout", ms.SignMessageTimeout, "should be positive")
+	check(ms.EstimateMessageTimeout > 0, "messageService.EstimateMessageTimeout", ms.EstimateMessageTimeout, "should be positive")
+	check(ms.SignConcurrency > 0, "messageService.signConcurrency", ms.SignConcurrency, "should be positive")
+	check(ms.MpoolReconcileInterval >= 0, "messageService.mpoolReconcileInterval", ms.MpoolReconcileInterval, "should not be negative")
+
+	switch c.Signer.Type {
+	case SignerGateway:
+		check(len(c.Gateway.Url) > 0, "gateway.url", c.Gateway.Url, "at least one url is required")
+	case SignerLocal:
+		check(len(c.Signer.Keystore) > 0, "signer.keystore", c.Signer.Keystore, "should not be empty")
+	case SignerRemote:
+		remote := c.Signer.Remote
+		checkErr(validateURL(remote.URL), "signer.remote.url", remote.URL)
+		check(len(remote.Cert) > 0 == (len(remote.Key) > 0), "signer.remote.key", remote.Key, "cert and key should be set together")
+		check(remote.Timeout > 0, "signer.remote.timeout", remote.Timeout, "should be positive")
+		check(remote.MaxRetries >= 0, "signer.remote.maxRetries", remote.MaxRetries, "should not be negative")
+		check(remote.RetryInterval >= 0, "signer.remote.retryInterval", remote.RetryInterval, "should not be negative")
+	default:
+		check(false, "signer.type", c.Signer.Type, fmt.Sprintf("should be %s, %s or %s", SignerGateway, SignerLocal, SignerRemote))
+	}
+	for i, addr := range c.Gateway.Url {
+		checkErr(validateAPIAddr(addr), fmt.Sprintf("gateway.url[%d]", i), addr)
+	}
+	health := c.Gateway.Health
+	check(health.FailureThreshold > 0, "gateway.health.failureThreshold", health.FailureThreshold, "should be positive")
+	check(health.OpenDuration > 0, "gateway.health.openDuration", health.OpenDuration, "should be positive")
+	check(health.UnreachableAlert >= 0, "gateway.health.unreachableAlert", health.UnreachableAlert, "should not be negative")
+	check(health.QuarantineDuration > 0, "gateway.health.quarantineDuration", health.QuarantineDuration, "should be positive")
+
+	if len(c.RateLimit.Redis) > 0 {
+		_, err := url.Parse(c.RateLimit.Redis)
+		checkErr(err, "rateLimit.redis", c.RateLimit.Redis)
+	}
+
+	if c.Libp2pNet != nil {
+		_, err := multiaddr.NewMultiaddr(c.Libp2pNet.ListenAddress)
+		checkErr(err, "libp2p.listenAddresses", c.Libp2pNet.ListenAddress)
+		for i, addr := range c.Libp2pNet.BootstrapAddresses {
+			_, err := peer.AddrInfoFromString(addr)
+			checkErr(err, fmt.Sprintf("libp2p.bootstrapAddresses[%d]", i), addr)
+		}
+		check(c.Libp2pNet.MinPeerThreshold >= 0, "libp2p.minPeerThreshold", c.Libp2pNet.MinPeerThreshold, "should not be negative")
+		check(c.Libp2pNet.ExpandPeriod >= 0, "libp2p.expandPeriod", c.Libp2pNet.ExpandPeriod, "should not be negative")
+		check(c.Libp2pNet.MinMeshPeers >= 0, "libp2p.minMeshPeers", c.Libp2pNet.MinMeshPeers, "should not be negative")
+		for i, addr := range c.Libp2pNet.StaticPeers {
+			_, err := peer.AddrInfoFromString(addr)
+			checkErr(err, fmt.Sprintf("libp2p.staticPeers[%d]", i), addr)
+		}
+		check(!c.Libp2pNet.PrivatePeering || len(c.Libp2pNet.StaticPeers) > 0, "libp2p.staticPeers", c.Libp2pNet.StaticPeers,
+			"should not be empty when privatePeering is enabled")
+		for i, id := range c.Libp2pNet.AllowedPeers {
+			_, err := peer.Decode(id)
+			checkErr(err, fmt.Sprintf("libp2p.allowedPeers[%d]", i), id)
+		}
+		check(!c.Libp2pNet.EnableConnectionGater || len(c.Libp2pNet.StaticPeers)+len(c.Libp2pNet.AllowedPeers) > 0,
+			"libp2p.allowedPeers", c.Libp2pNet.AllowedPeers, "should not be empty with staticPeers when enableConnectionGater is enabled")
+	}
+
+	if c.Publisher != nil {
+		check(c.Publisher.Concurrency >= 0, "publisher.concurrency", c.Publisher.Concurrency, "should not be negative")
+		check(c.Publisher.OutboxBatchSize > 0, "publisher.outboxBatchSize", c.Publisher.OutboxBatchSize, "should be positive")
+		check(c.Publisher.OutboxRetryInterval > 0, "publisher.outboxRetryInterval", c.Publisher.OutboxRetryInterval, "should be positive")
+		check(c.Publisher.OutboxMaxAttempts > 0, "publisher.outboxMaxAttempts", c.Publisher.OutboxMaxAttempts, "should be positive")
+		check(c.Publisher.ReceiptRetention >= 0, "publisher.receiptRetention", c.Publisher.ReceiptRetention, "should not be negative")
+		names := make(map[string]struct{}, len(c.Publisher.Pipeline))
+		for i, stage := range c.Publisher.Pipeline {
+			check(len(stage.Type) > 0, fmt.Sprintf("publisher.pipeline[%d].type", i), `""`, "required")
+			_, dup := names[stage.StageName()]
+			check(!dup, fmt.Sprintf("publisher.pipeline[%d].name", i), stage.StageName(), "duplicated stage name")
+			names[stage.StageName()] = struct{}{}
+		}
+	}
+
+	if c.Trace != nil && c.Trace.JaegerTracingEnabled {
+		check(len(c.Trace.JaegerEndpoint) > 0, "tracing.JaegerEndpoint", `""`, "required when tracing is enabled")
+		check(c.Trace.ProbabilitySampler >= 0 && c.Trace.ProbabilitySampler <= 1, "tracing.ProbabilitySampler",
+			c.Trace.ProbabilitySampler, "should be in [0, 1]")
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateAPIAddr accept the address format of venus api, a multiaddr or an url
+func validateAPIAddr(addr string) error {
+	if len(addr) == 0 {
+		return fmt.Errorf("empty address")
+	}
+	if _, err := multiaddr.NewMultiaddr(addr); err == nil {
+		_, err = api.DialArgs(addr, "v1")
+		return err
+	}
+	return validateURL(addr)
+}
+
+func validateURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if len(u.Scheme) == 0 || len(u.Host) == 0 {
+		return fmt.Errorf("not a valid url")
+	}
+	return nil
+}
@@ -1,40 +1,75 @@
 package filestore
 
 import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
 
 	"github.com/ipfs-force-community/sophon-messager/config"
 	"github.com/ipfs-force-community/sophon-messager/utils"
 )
 
 const (
-	ConfigFile = "config.toml"
-	TipsetFile = "tipset.json"
-	SqliteFile = "message.db"
-	TokenFile  = "token"
+	ConfigFile    = "config.toml"
+	TipsetFile    = "tipset.json"
+	SqliteFile    = "message.db"
+	TokenFile     = "token"
+	Libp2pKeyFile = "libp2p.key"
+	PeersFile     = "peers.json"
 )
 
 type FSRepo interface {
 	Path() string
 	Config() *config.Config
 	ReplaceConfig(cfg *config.Config) error
+	// LoadConfig read config from file, it doesn't change the config in use
+	LoadConfig() (*config.Config, error)
+	// SetConfig replace the config in use without writing file
+	SetConfig(cfg *config.Config)
 	TipsetFile() string
 	SqliteFile() string
 	GetToken() ([]byte, error)
 	SaveToken([]byte) error
+	// Libp2pIdentity returns the private key of libp2p host, it is generated at the first call
+	Libp2pIdentity() (crypto.PrivKey, error)
+	// LoadPeers returns the known peers saved by SavePeers
+	LoadPeers() ([]peer.AddrInfo, error)
+	SavePeers(peers []peer.AddrInfo) error
 }
 
 type fsRepo struct {
 	path string
-	cfg  *config.Config
+
+	lk  sync.RWMutex
+	cfg *config.Config
 }
 
 func NewFSRepo(repoPath string) (FSRepo, error) {
 	r := &fsRepo{path: repoPath}
+	cfg, err := r.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	r.cfg = cfg
+
+	return r, nil
+}
+
+func (r *fsRepo) LoadConfig() (*config.Config, error) {
+	return LoadConfigFile(filepath.Join(r.path, ConfigFile))
+}
+
+// LoadConfigFile read config from path, the fields not in file use default values.
+func LoadConfigFile(path string) (*config.Config, error) {
 	cfg := config.DefaultConfig()
-	err := utils.ReadConfig(filepath.Join(repoPath, ConfigFile), cfg)
+	err := utils.ReadConfig(path, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -47,9 +82,8 @@ func NewFSRepo(repoPath string) (FSRepo, error) {
 	if cfg.MessageService.EstimateMessageTimeout <= 0 {
 		cfg.MessageService.EstimateMessageTimeout = config.EstimateMessageTimeout
 	}
-	r.cfg = cfg
 
-	return r, nil
+	return cfg, nil
 }
 
 func InitFSRepo(repoPath string, cfg *config.Config) (FSRepo, error) {
@@ -69,9 +103,19 @@ func (r *fsRepo) Path() string {
 }
 
 func (r *fsRepo) Config() *config.Config {
+	r.lk.RLock()
+	defer r.lk.RUnlock()
+
 	return r.cfg
 }
 
+func (r *fsRepo) SetConfig(cfg *config.Config) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	r.cfg = cfg
+}
+
 func (r *fsRepo) TipsetFile() string {
 	return filepath.Join(r.path, TipsetFile)
 }
@@ -84,7 +128,7 @@ func (r *fsRepo) ReplaceConfig(cfg *config.Config) error {
 	if err := utils.WriteConfig(filepath.Join(r.path, ConfigFile), cfg); err != nil {
 		return err
 	}
-	r.cfg = cfg
+	r.SetConfig(cfg)
 
 	return nil
 }
@@ -100,3 +144,58 @@ func (r *fsRepo) SaveToken(token []byte) error {
 func (r *fsRepo) GetToken() ([]byte, error) {
 	return os.ReadFile(filepath.Join(r.path, TokenFile))
 }
+
+func (r *fsRepo) Libp2pIdentity() (crypto.PrivKey, error) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	path := filepath.Join(r.path, Libp2pKeyFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return crypto.UnmarshalPrivateKey(data)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read libp2p key failed: %w", err)
+	}
+
+	pk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	data, err = crypto.MarshalPrivateKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("write libp2p key failed: %w", err)
+	}
+	return pk, nil
+}
+
+func (r *fsRepo) LoadPeers() ([]peer.AddrInfo, error) {
+	data, err := os.ReadFile(filepath.Join(r.path, PeersFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var peers []peer.AddrInfo
+	if err := json.Unmarshal(data, &peers); err != nil {
+		return nil, fmt.Errorf("decode peers failed: %w", err)
+	}
+	return peers, nil
+}
+
+func (r *fsRepo) SavePeers(peers []peer.AddrInfo) error {
+	data, err := json.MarshalIndent(peers, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temp file first, so a crash doesn't leave a broken file
+	path := filepath.Join(r.path, PeersFile)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
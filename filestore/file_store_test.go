@@ -1,9 +1,12 @@
 package filestore
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/libp2p/go-libp2p/core/peer"
+
 	"github.com/ipfs-force-community/sophon-messager/config"
 	"github.com/ipfs-force-community/sophon-messager/utils"
 	"github.com/stretchr/testify/assert"
@@ -55,3 +58,33 @@ func TestInitFSRepo(t *testing.T) {
 
 	assert.Equal(t, defCfg, fsRepo.Config())
 }
+
+func TestLibp2pIdentity(t *testing.T) {
+	path := t.TempDir()
+	fsRepo, err := InitFSRepo(path, config.DefaultConfig())
+	assert.NoError(t, err)
+
+	pk, err := fsRepo.Libp2pIdentity()
+	assert.NoError(t, err)
+	info, err := os.Stat(filepath.Join(path, Libp2pKeyFile))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// the same identity after restarting
+	fsRepo, err = NewFSRepo(path)
+	assert.NoError(t, err)
+	pk2, err := fsRepo.Libp2pIdentity()
+	assert.NoError(t, err)
+	assert.True(t, pk.Equals(pk2))
+
+	peers, err := fsRepo.LoadPeers()
+	assert.NoError(t, err)
+	assert.Empty(t, peers)
+
+	pi, err := peer.AddrInfoFromString("/ip4/127.0.0.1/tcp/34567/p2p/12D3KooWJmuHYtPwbZTj5Y6mE5EkNQ5PoqpbTEgyGKyfBv5xKpsb")
+	assert.NoError(t, err)
+	assert.NoError(t, fsRepo.SavePeers([]peer.AddrInfo{*pi}))
+	peers, err = fsRepo.LoadPeers()
+	assert.NoError(t, err)
+	assert.Equal(t, []peer.AddrInfo{*pi}, peers)
+}
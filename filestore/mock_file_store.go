@@ -1,9 +1,13 @@
 package filestore
 
 import (
+	"crypto/rand"
 	"fmt"
 	"path/filepath"
 
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
 	"github.com/ipfs-force-community/sophon-messager/config"
 )
 
@@ -11,6 +15,8 @@ type mockFileStore struct {
 	path  string
 	cfg   *config.Config
 	token []byte
+	key   crypto.PrivKey
+	peers []peer.AddrInfo
 }
 
 func NewMockFileStore(path string) FSRepo {
@@ -34,6 +40,14 @@ func (mfs *mockFileStore) ReplaceConfig(cfg *config.Config) error {
 	return nil
 }
 
+func (mfs *mockFileStore) LoadConfig() (*config.Config, error) {
+	return mfs.cfg, nil
+}
+
+func (mfs *mockFileStore) SetConfig(cfg *config.Config) {
+	mfs.cfg = cfg
+}
+
 func (mfs *mockFileStore) TipsetFile() string {
 	return filepath.Join(mfs.Path(), TipsetFile)
 }
@@ -55,4 +69,24 @@ func (mfs *mockFileStore) SaveToken(token []byte) error {
 	return nil
 }
 
+func (mfs *mockFileStore) Libp2pIdentity() (crypto.PrivKey, error) {
+	if mfs.key == nil {
+		pk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		mfs.key = pk
+	}
+	return mfs.key, nil
+}
+
+func (mfs *mockFileStore) LoadPeers() ([]peer.AddrInfo, error) {
+	return mfs.peers, nil
+}
+
+func (mfs *mockFileStore) SavePeers(peers []peer.AddrInfo) error {
+	mfs.peers = peers
+	return nil
+}
+
 var _ FSRepo = (*mockFileStore)(nil)
@@ -2,8 +2,11 @@ package gateway
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-jsonrpc"
@@ -15,63 +18,54 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 
 	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/wallet"
 )
 
 var log = logging.Logger("wallet-proxy")
 
-type cacheKey string
-
-func newCacheKey(addr address.Address) cacheKey {
-	return cacheKey("walletClientCache:" + addr.String())
-}
-
+// WalletProxy sign messages by the wallets connected to the gateways, signing is routed to the healthy gateway
+// with the lowest latency for the address.
 type WalletProxy struct {
-	clients map[string]gatewayAPI.IWalletClient
+	clientsLk sync.RWMutex
+	clients   map[string]gatewayAPI.IWalletClient
+	closers   map[string]jsonrpc.ClientCloser
+	token     string
 
-	mutx                sync.RWMutex
-	avaliabeClientCache map[cacheKey]gatewayAPI.IWalletClient
+	health *healthTracker
 }
 
-func (w *WalletProxy) putCache(addr address.Address, client gatewayAPI.IWalletClient) {
-	w.mutx.Lock()
-	defer w.mutx.Unlock()
-	w.avaliabeClientCache[newCacheKey(addr)] = client
-}
-
-func (w *WalletProxy) delCache(addr address.Address) bool {
-	key := newCacheKey(addr)
-	w.mutx.Lock()
-	defer w.mutx.Unlock()
-	_, exist := w.avaliabeClientCache[key]
-	if exist {
-		delete(w.avaliabeClientCache, key)
-	}
-	return exist
+func (w *WalletProxy) getClients() map[string]gatewayAPI.IWalletClient {
+	w.clientsLk.RLock()
+	defer w.clientsLk.RUnlock()
+	return w.clients
 }
 
-func (w *WalletProxy) getCachedClient(addr address.Address) gatewayAPI.IWalletClient {
-	w.mutx.RLock()
-	defer w.mutx.RUnlock()
-
-	key := newCacheKey(addr)
-	return w.avaliabeClientCache[key]
-}
-
-// todo: think about 'fastSelectAvaGatewayClient' was called parallelly
-func (w *WalletProxy) fastSelectAvaGatewayClient(ctx context.Context, addr address.Address, accounts []string) (gatewayAPI.IWalletClient, error) {
+// fastSelectAvaGatewayClient ask the gateways which allow calls and are not skipped whether they have an online wallet
+// for addr concurrently, returns the first gateway which has.
+func (w *WalletProxy) fastSelectAvaGatewayClient(ctx context.Context,
+	clients map[string]gatewayAPI.IWalletClient,
+	addr address.Address,
+	accounts []string,
+	skip map[string]struct{},
+) (string, error) {
 	var g = &sync.WaitGroup{}
-	var ch = make(chan gatewayAPI.IWalletClient, 1)
-	for url, c := range w.clients {
+	var ch = make(chan string, len(clients))
+	for url, c := range clients {
+		if _, ok := skip[url]; ok || !w.health.allow(url, addr) {
+			continue
+		}
 		g.Add(1)
 		go func(url string, c gatewayAPI.IWalletClient) {
+			defer g.Done()
 			has, err := c.WalletHas(ctx, addr, accounts)
 			if err != nil {
 				log.Errorf("fastSelectAvaClient, call %s:'WalletHas' failed:%s", url, err)
 			}
+			w.health.recordHas(ctx, url, addr, has, err)
 			if has {
-				ch <- c
+				ch <- url
 			}
-			g.Done()
 		}(url, c)
 	}
 
@@ -80,95 +74,378 @@ func (w *WalletProxy) fastSelectAvaGatewayClient(ctx context.Context, addr addre
 		close(ch)
 	}()
 
-	c, isok := <-ch
-	if !isok || c == nil {
-		return nil, fmt.Errorf("can't find a wallet, address: %s", addr.String())
+	url, isok := <-ch
+	if !isok {
+		return "", fmt.Errorf("can't find a wallet, address: %s", addr.String())
 	}
 
-	w.putCache(addr, c)
-	return c, nil
+	return url, nil
 }
 
 func (w *WalletProxy) WalletHas(ctx context.Context, addr address.Address, accounts []string) (bool, error) {
-	c := w.getCachedClient(addr)
-	if c != nil {
+	clients := w.getClients()
+	if len(w.health.candidates(sortedURLs(clients), addr, nil)) > 0 {
 		return true, nil
 	}
-	c, err := w.fastSelectAvaGatewayClient(ctx, addr, accounts)
-	return c != nil, err
+	_, err := w.fastSelectAvaGatewayClient(ctx, clients, addr, accounts, nil)
+	if err != nil {
+		w.health.recordUnreachable(ctx, addr)
+	}
+	return err == nil, err
 }
 
+// WalletSign sign by the best gateway known to have an online wallet for addr, and fail over to the others,
+// the gateways are asked again if none of the known can sign.
 func (w *WalletProxy) WalletSign(ctx context.Context, addr address.Address, accounts []string, toSign []byte, meta venusTypes.MsgMeta) (*crypto.Signature, error) {
-	var err error
-	var useCachedClient bool
+	clients := w.getClients()
+	urls := sortedURLs(clients)
+	tried := make(map[string]struct{})
+	var lastErr error
+	for {
+		url, err := w.selectClient(ctx, clients, urls, addr, accounts, tried)
+		if err != nil {
+			if lastErr == nil {
+				lastErr = err
+			}
+			break
+		}
+		tried[url] = struct{}{}
+
+		start := time.Now()
+		s, err := clients[url].WalletSign(ctx, addr, accounts, toSign, meta)
+		if err == nil {
+			err = wallet.VerifySignatureBy(clients[url], s, addr, toSign)
+			if errors.Is(err, wallet.ErrInvalidSignature) {
+				w.health.recordInvalid(ctx, url, addr, err)
+				log.Warnf("gateway %s returned an invalid signature for %s, try the other gateways", url, addr)
+				lastErr = err
+				continue
+			}
+		}
+		w.health.recordSign(ctx, url, addr, time.Since(start), err)
+		if err == nil {
+			return s, nil
+		}
+		log.Warnf("sign for %s by %s failed:%s, try the other gateways", addr, url, err)
+		lastErr = err
+	}
 
-	c := w.getCachedClient(addr)
+	w.health.recordUnreachable(ctx, addr)
+	return nil, lastErr
+}
 
-	if c == nil {
-		if c, err = w.fastSelectAvaGatewayClient(ctx, addr, accounts); err != nil {
-			return nil, err
+// WalletSignBatch sign the messages of addr by the best gateway like WalletSign, but the gateway is selected once for
+// the batch. The gateway api has no batch signing method, so the messages are sent to the gateway together without
+// waiting for each other, and the messages failed on it are signed again by WalletSign to fail over to the others.
+func (w *WalletProxy) WalletSignBatch(ctx context.Context, addr address.Address, accounts []string, toSign [][]byte, metas []venusTypes.MsgMeta) ([]*crypto.Signature, []error) {
+	sigs := make([]*crypto.Signature, len(toSign))
+	errs := make([]error, len(toSign))
+	clients := w.getClients()
+	url, err := w.selectClient(ctx, clients, sortedURLs(clients), addr, accounts, nil)
+	if err != nil {
+		w.health.recordUnreachable(ctx, addr)
+		for i := range errs {
+			errs[i] = err
 		}
+		return sigs, errs
+	}
+
+	c := clients[url]
+	start := time.Now()
+	signConcurrently(ctx, c.WalletSign, addr, accounts, toSign, metas, allIndexes(len(toSign)), sigs, errs)
+	latency := time.Since(start)
+
+	var failed []int
+	var signErr, invalidErr error
+	for i := range toSign {
+		if errs[i] == nil {
+			errs[i] = wallet.VerifySignatureBy(c, sigs[i], addr, toSign[i])
+			if errors.Is(errs[i], wallet.ErrInvalidSignature) {
+				invalidErr = errs[i]
+			}
+		}
+		if errs[i] != nil {
+			if signErr == nil {
+				signErr = errs[i]
+			}
+			failed = append(failed, i)
+		}
+	}
+	if invalidErr != nil {
+		w.health.recordInvalid(ctx, url, addr, invalidErr)
 	} else {
-		useCachedClient = true
+		w.health.recordSign(ctx, url, addr, latency, signErr)
+	}
+	if len(failed) > 0 {
+		log.Warnf("%d of %d messages of %s failed to sign by %s:%s, try the other gateways", len(failed), len(toSign), addr, url, signErr)
+		signConcurrently(ctx, w.WalletSign, addr, accounts, toSign, metas, failed, sigs, errs)
 	}
 
-	var s *crypto.Signature
-	if s, err = c.WalletSign(ctx, addr, accounts, toSign, meta); err != nil {
-		if useCachedClient {
-			log.Warnf("sign with cached client failed:%s, will re-SelectAvaliableClient, and retry",
-				err.Error())
+	return sigs, errs
+}
 
-			w.delCache(addr)
+// selectClient returns the best gateway known to have an online wallet for addr, the gateways are asked if none of the
+// known is available, the gateways in tried are skipped.
+func (w *WalletProxy) selectClient(ctx context.Context,
+	clients map[string]gatewayAPI.IWalletClient,
+	urls []string,
+	addr address.Address,
+	accounts []string,
+	tried map[string]struct{},
+) (string, error) {
+	if candidates := w.health.candidates(urls, addr, tried); len(candidates) > 0 {
+		return candidates[0], nil
+	}
+	return w.fastSelectAvaGatewayClient(ctx, clients, addr, accounts, tried)
+}
+
+// maxBatchSignConcurrency is the max number of sign requests of a batch in flight
+const maxBatchSignConcurrency = 32
+
+type signFunc func(ctx context.Context, addr address.Address, accounts []string, toSign []byte, meta venusTypes.MsgMeta) (*crypto.Signature, error)
+
+// signConcurrently sign the messages at indexes by sign concurrently, the results are set to sigs and errs at the same indexes
+func signConcurrently(ctx context.Context,
+	sign signFunc,
+	addr address.Address,
+	accounts []string,
+	toSign [][]byte,
+	metas []venusTypes.MsgMeta,
+	indexes []int,
+	sigs []*crypto.Signature,
+	errs []error,
+) {
+	workers := make(chan struct{}, maxBatchSignConcurrency)
+	wg := sync.WaitGroup{}
+	for _, index := range indexes {
+		workers <- struct{}{}
+		wg.Add(1)
+		go func(index int) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			sigs[index], errs[index] = sign(ctx, addr, accounts, toSign[index], metas[index])
+		}(index)
+	}
+	wg.Wait()
+}
+
+func allIndexes(n int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+// SignerHealth returns the health of each gateway for each address which has been signed for
+func (w *WalletProxy) SignerHealth() []*mtypes.SignerHealth {
+	return w.health.status()
+}
+
+func sortedURLs(clients map[string]gatewayAPI.IWalletClient) []string {
+	urls := make([]string, 0, len(clients))
+	for url := range clients {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// GatewayWallets is the wallets connecting to a gateway
+type GatewayWallets struct {
+	Gateway string
+	Wallets []*gtypes.WalletDetail
+	Err     error
+}
 
-			if c, err = w.fastSelectAvaGatewayClient(ctx, addr, accounts); err != nil {
-				return nil, err
+// ListGatewayWallets list the wallets of all gateways concurrently, sorted by the url of gateway
+func (w *WalletProxy) ListGatewayWallets(ctx context.Context) []*GatewayWallets {
+	w.clientsLk.RLock()
+	clients := w.clients
+	w.clientsLk.RUnlock()
+
+	res := make([]*GatewayWallets, 0, len(clients))
+	for url := range clients {
+		res = append(res, &GatewayWallets{Gateway: url})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Gateway < res[j].Gateway
+	})
+
+	var wg sync.WaitGroup
+	for _, gw := range res {
+		wg.Add(1)
+		go func(gw *GatewayWallets) {
+			defer wg.Done()
+			gw.Wallets, gw.Err = clients[gw.Gateway].ListWalletInfo(ctx)
+			if gw.Err != nil {
+				log.Errorf("call %s:'ListWalletInfo' failed:%s", gw.Gateway, gw.Err)
 			}
-			s, err = c.WalletSign(ctx, addr, accounts, toSign, meta)
+		}(gw)
+	}
+	wg.Wait()
+
+	return res
+}
+
+// ListWalletInfo returns the wallets of all gateways, the connections of the same account are merged,
+// an error is returned only if all gateways failed.
+func (w *WalletProxy) ListWalletInfo(ctx context.Context) ([]*gtypes.WalletDetail, error) {
+	var details []*gtypes.WalletDetail
+	var lastErr error
+	succeed := false
+	for _, gw := range w.ListGatewayWallets(ctx) {
+		if gw.Err != nil {
+			lastErr = gw.Err
+			continue
 		}
+		succeed = true
+		details = mergeWalletDetails(details, gw.Wallets...)
+	}
+	if !succeed && lastErr != nil {
+		return nil, fmt.Errorf("list wallet from all gateways failed, last error: %w", lastErr)
 	}
 
-	return s, err
+	return details, nil
 }
 
-func (w *WalletProxy) ListWalletInfo(context.Context) ([]*gtypes.WalletDetail, error) {
-	panic("implement me")
+// ListWalletInfoByWallet returns the wallet of account merged from all gateways
+func (w *WalletProxy) ListWalletInfoByWallet(ctx context.Context, wallet string) (*gtypes.WalletDetail, error) {
+	details, err := w.ListWalletInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, detail := range details {
+		if detail.Account == wallet {
+			return detail, nil
+		}
+	}
+
+	return nil, fmt.Errorf("wallet %s not found", wallet)
 }
 
-func (w *WalletProxy) ListWalletInfoByWallet(context.Context, string) (*gtypes.WalletDetail, error) {
-	panic("implement me")
+func mergeWalletDetails(details []*gtypes.WalletDetail, others ...*gtypes.WalletDetail) []*gtypes.WalletDetail {
+	for _, other := range others {
+		if other == nil {
+			continue
+		}
+		idx := -1
+		for i, detail := range details {
+			if detail.Account == other.Account {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			details = append(details, &gtypes.WalletDetail{
+				Account:         other.Account,
+				SupportAccounts: append([]string(nil), other.SupportAccounts...),
+				ConnectStates:   append([]gtypes.ConnectState(nil), other.ConnectStates...),
+			})
+			continue
+		}
+
+		detail := details[idx]
+		for _, account := range other.SupportAccounts {
+			found := false
+			for _, exist := range detail.SupportAccounts {
+				if exist == account {
+					found = true
+					break
+				}
+			}
+			if !found {
+				detail.SupportAccounts = append(detail.SupportAccounts, account)
+			}
+		}
+		detail.ConnectStates = append(detail.ConnectStates, other.ConnectStates...)
+	}
+
+	return details
 }
 
 func NewWalletClient(ctx context.Context,
 	cfg *config.GatewayConfig,
 ) (*WalletProxy, jsonrpc.ClientCloser, error) {
 	var proxy = &WalletProxy{
-		clients:             make(map[string]gatewayAPI.IWalletClient),
-		avaliabeClientCache: make(map[cacheKey]gatewayAPI.IWalletClient),
+		clients: make(map[string]gatewayAPI.IWalletClient),
+		closers: make(map[string]jsonrpc.ClientCloser),
+		health:  newHealthTracker(cfg.Health),
 	}
 
-	var closers []jsonrpc.ClientCloser
+	if err := proxy.UpdateConfig(ctx, cfg); err != nil {
+		return nil, nil, err
+	}
+
+	totalCloser := func() {
+		proxy.clientsLk.Lock()
+		defer proxy.clientsLk.Unlock()
+		for _, closer := range proxy.closers {
+			closer()
+		}
+	}
+
+	return proxy, totalCloser, nil
+}
+
+// UpdateConfig connect to the gateways in cfg, the clients of removed urls are closed,
+// and all clients are reconnected if the token changed.
+func (w *WalletProxy) UpdateConfig(ctx context.Context, cfg *config.GatewayConfig) error {
+	w.clientsLk.Lock()
+	defer w.clientsLk.Unlock()
+
+	clients := make(map[string]gatewayAPI.IWalletClient, len(cfg.Url))
+	closers := make(map[string]jsonrpc.ClientCloser, len(cfg.Url))
+	closeNew := func() {
+		for url, closer := range closers {
+			if _, ok := w.closers[url]; !ok || w.token != cfg.Token {
+				closer()
+			}
+		}
+	}
 	for _, url := range cfg.Url {
+		if c, ok := w.clients[url]; ok && w.token == cfg.Token {
+			clients[url] = c
+			closers[url] = w.closers[url]
+			continue
+		}
 		c, cls, err := gatewayAPI.DialIGatewayRPC(ctx, url, cfg.Token, nil)
-
 		if err != nil {
-			return nil, nil, fmt.Errorf("create geteway client with url:%s failed: %w", url, err)
+			closeNew()
+			return fmt.Errorf("create geteway client with url:%s failed: %w", url, err)
 		}
 
-		proxy.clients[url] = c
-		closers = append(closers, cls)
+		clients[url] = c
+		closers[url] = cls
 	}
 
-	if len(proxy.clients) == 0 {
-		return nil, nil, fmt.Errorf("can't create any gateway client, please check 'GatewayConfig'")
+	if len(clients) == 0 {
+		return fmt.Errorf("can't create any gateway client, please check 'GatewayConfig'")
 	}
 
-	totalCloser := func() {
-		for _, closer := range closers {
+	for url, closer := range w.closers {
+		if _, ok := clients[url]; !ok || w.token != cfg.Token {
 			closer()
 		}
 	}
 
-	return proxy, totalCloser, nil
+	// the wallets connected to the reconnected gateways are unknown
+	retained := make(map[string]struct{}, len(clients))
+	for url := range clients {
+		if _, ok := w.closers[url]; ok && w.token == cfg.Token {
+			retained[url] = struct{}{}
+		}
+	}
+	w.health.retain(retained)
+	w.health.updateConfig(cfg.Health)
+
+	w.clients = clients
+	w.closers = closers
+	w.token = cfg.Token
+
+	return nil
 }
 
 var _ gatewayAPI.IWalletClient = &WalletProxy{}
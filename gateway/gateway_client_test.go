@@ -0,0 +1,82 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	gatewayAPI "github.com/filecoin-project/venus/venus-shared/api/gateway/v2"
+	"github.com/filecoin-project/venus/venus-shared/testutil"
+	gtypes "github.com/filecoin-project/venus/venus-shared/types/gateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+)
+
+type errWalletClient struct {
+	*MockWalletProxy
+}
+
+func (c *errWalletClient) ListWalletInfo(context.Context) ([]*gtypes.WalletDetail, error) {
+	return nil, errors.New("mock error")
+}
+
+func TestListWalletInfo(t *testing.T) {
+	ctx := context.Background()
+	addrs := make([]address.Address, 3)
+	for i := range addrs {
+		addrs[i] = testutil.SecpAddressProvider(32)(t)
+	}
+
+	gw1 := NewMockWalletProxy()
+	require.NoError(t, gw1.AddAddress("a", addrs[:1]))
+	require.NoError(t, gw1.AddAddress("b", addrs[1:2]))
+	gw2 := NewMockWalletProxy()
+	require.NoError(t, gw2.AddAddress("a", addrs[2:]))
+
+	newProxy := func(clients map[string]gatewayAPI.IWalletClient) *WalletProxy {
+		return &WalletProxy{clients: clients, health: newHealthTracker(config.DefaultConfig().Gateway.Health)}
+	}
+
+	t.Run("merge wallets of gateways", func(t *testing.T) {
+		proxy := newProxy(map[string]gatewayAPI.IWalletClient{
+			"gw1": gw1,
+			"gw2": gw2,
+			"gw3": &errWalletClient{NewMockWalletProxy()},
+		})
+
+		gws := proxy.ListGatewayWallets(ctx)
+		require.Len(t, gws, 3)
+		assert.Equal(t, "gw1", gws[0].Gateway)
+		assert.Len(t, gws[0].Wallets, 2)
+		assert.Equal(t, "gw3", gws[2].Gateway)
+		assert.Error(t, gws[2].Err)
+
+		details, err := proxy.ListWalletInfo(ctx)
+		require.NoError(t, err)
+		require.Len(t, details, 2)
+
+		detail, err := proxy.ListWalletInfoByWallet(ctx, "a")
+		require.NoError(t, err)
+		require.Len(t, detail.ConnectStates, 2)
+		var got []address.Address
+		for _, state := range detail.ConnectStates {
+			got = append(got, state.Addrs...)
+		}
+		assert.ElementsMatch(t, []address.Address{addrs[0], addrs[2]}, got)
+		assert.Equal(t, []string{"a"}, detail.SupportAccounts)
+
+		_, err = proxy.ListWalletInfoByWallet(ctx, "c")
+		assert.Error(t, err)
+	})
+
+	t.Run("all gateways failed", func(t *testing.T) {
+		proxy := newProxy(map[string]gatewayAPI.IWalletClient{
+			"gw3": &errWalletClient{NewMockWalletProxy()},
+		})
+		_, err := proxy.ListWalletInfo(ctx)
+		assert.Error(t, err)
+	})
+}
@@ -1,8 +1,10 @@
 package gateway
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/filecoin-project/go-address"
@@ -13,6 +15,7 @@ import (
 	gtypes "github.com/filecoin-project/venus/venus-shared/types/gateway"
 
 	"github.com/ipfs-force-community/sophon-messager/testhelper"
+	"github.com/ipfs-force-community/sophon-messager/wallet"
 )
 
 type MockWalletProxy struct {
@@ -104,12 +107,50 @@ func (m *MockWalletProxy) WalletSign(ctx context.Context, addr address.Address,
 	}, nil
 }
 
+// VerifySignature verify the signature of MockWalletProxy, which is the data appended with the address
+func (m *MockWalletProxy) VerifySignature(sig *crypto.Signature, addr address.Address, data []byte) error {
+	if sig == nil || sig.Type != testhelper.AddressProtocolToSignType(addr.Protocol()) ||
+		!bytes.Equal(sig.Data, append(append([]byte{}, data...), addr.Bytes()...)) {
+		return fmt.Errorf("%w: not signed by %s", wallet.ErrInvalidSignature, addr)
+	}
+	return nil
+}
+
 func (m *MockWalletProxy) ListWalletInfo(_ context.Context) ([]*gtypes.WalletDetail, error) {
-	panic("implement me")
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	details := make([]*gtypes.WalletDetail, 0, len(m.accountAddrs))
+	for account, currAddrs := range m.accountAddrs {
+		addrs := make([]address.Address, 0, len(currAddrs))
+		for addr := range currAddrs {
+			addrs = append(addrs, addr)
+		}
+		details = append(details, &gtypes.WalletDetail{
+			Account:         account,
+			SupportAccounts: []string{account},
+			ConnectStates:   []gtypes.ConnectState{{Addrs: addrs, IP: "127.0.0.1"}},
+		})
+	}
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].Account < details[j].Account
+	})
+
+	return details, nil
 }
 
-func (m *MockWalletProxy) ListWalletInfoByWallet(_ context.Context, _ string) (*gtypes.WalletDetail, error) {
-	panic("implement me")
+func (m *MockWalletProxy) ListWalletInfoByWallet(ctx context.Context, wallet string) (*gtypes.WalletDetail, error) {
+	details, err := m.ListWalletInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, detail := range details {
+		if detail.Account == wallet {
+			return detail, nil
+		}
+	}
+	return nil, fmt.Errorf("wallet %s not found", wallet)
 }
 
 var _ gatewayAPI.IWalletClient = (*MockWalletProxy)(nil)
+var _ wallet.SignatureVerifier = (*MockWalletProxy)(nil)
@@ -0,0 +1,336 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	gatewayAPI "github.com/filecoin-project/venus/venus-shared/api/gateway/v2"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	gtypes "github.com/filecoin-project/venus/venus-shared/types/gateway"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+)
+
+// RemoteAccount is the account of the wallet detail of remote signer
+const RemoteAccount = "remote"
+
+const (
+	HeaderRequestID = "X-Request-Id"
+	HeaderTimestamp = "X-Signer-Timestamp"
+	HeaderSignature = "X-Signer-Signature"
+
+	maxRemoteErrorBody = 512
+)
+
+type RemoteHasRequest struct {
+	Address  address.Address `json:"address"`
+	Accounts []string        `json:"accounts"`
+}
+
+type RemoteHasResponse struct {
+	Has bool `json:"has"`
+}
+
+type RemoteSignRequest struct {
+	Address  address.Address `json:"address"`
+	Accounts []string        `json:"accounts"`
+	// ToSign is the bytes to sign, Meta.Extra is the cbor of message, so the service can check it by its own policy
+	ToSign []byte             `json:"toSign"`
+	Meta   venusTypes.MsgMeta `json:"meta"`
+}
+
+type RemoteSignResponse struct {
+	Signature *crypto.Signature `json:"signature"`
+}
+
+type RemoteSignBatchRequest struct {
+	Address  address.Address `json:"address"`
+	Accounts []string        `json:"accounts"`
+	// ToSign and Metas are the messages to sign like RemoteSignRequest
+	ToSign [][]byte             `json:"toSign"`
+	Metas  []venusTypes.MsgMeta `json:"metas"`
+}
+
+// RemoteSignBatchResponse has a signature or an error for each message in the order of the request,
+// Errors can be omitted if all messages are signed.
+type RemoteSignBatchResponse struct {
+	Signatures []*crypto.Signature `json:"signatures"`
+	Errors     []string            `json:"errors"`
+}
+
+type RemoteAddressesResponse struct {
+	Addresses []address.Address `json:"addresses"`
+}
+
+// RemoteErrorResponse is the body of a response with non-2xx status
+type RemoteErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// RemoteSigner sign messages by a http service speaking json, eg. a bridge to HSM or KMS.
+//
+// The service serves:
+//   - POST /has with RemoteHasRequest, returns RemoteHasResponse
+//   - POST /sign with RemoteSignRequest, returns RemoteSignResponse
+//   - POST /sign-batch with RemoteSignBatchRequest, returns RemoteSignBatchResponse, it's optional, the messages are
+//     signed by /sign one by one concurrently if the service returns 404 or 405 status for it
+//   - GET /addresses, returns RemoteAddressesResponse
+//
+// A failed request returns non-2xx status with RemoteErrorResponse. The requests failed by network, 5xx or 429 status
+// are retried with the same X-Request-Id. If HMACSecret is set, each request is signed by
+// hex(HMAC-SHA256(secret, method + "\n" + path + "\n" + timestamp + "\n" + request id + "\n" + body)) in
+// X-Signer-Signature, timestamp is the unix seconds in X-Signer-Timestamp.
+type RemoteSigner struct {
+	cfg    config.RemoteSignerConfig
+	url    *url.URL
+	client *http.Client
+}
+
+func NewRemoteSigner(cfg *config.RemoteSignerConfig) (*RemoteSigner, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parse url of remote signer: %w", err)
+	}
+	tlsCfg, err := remoteTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+
+	return &RemoteSigner{
+		cfg:    *cfg,
+		url:    u,
+		client: &http.Client{Timeout: cfg.Timeout, Transport: transport},
+	}, nil
+}
+
+func remoteTLSConfig(cfg *config.RemoteSignerConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(cfg.CACert) > 0 {
+		data, err := os.ReadFile(cfg.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("read ca cert: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in %s", cfg.CACert)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if len(cfg.Cert) > 0 || len(cfg.Key) > 0 {
+		cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("load client cert: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+// Close release the idle connections
+func (s *RemoteSigner) Close() {
+	s.client.CloseIdleConnections()
+}
+
+// SignRequestBody returns the HMAC of a request, the service can verify requests by it
+func SignRequestBody(secret []byte, method, path, timestamp, requestID string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n" + requestID + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryableError is an error which the request can be retried for
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// statusError is the error of a response with non-2xx status
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+func (e *statusError) Unwrap() error { return e.err }
+
+func (s *RemoteSigner) call(ctx context.Context, method, endpoint string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	requestID := venusTypes.NewUUID().String()
+
+	interval := s.cfg.RetryInterval
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = s.do(ctx, method, endpoint, requestID, body, out)
+		var retryable *retryableError
+		if err == nil || !errors.As(err, &retryable) || attempt >= s.cfg.MaxRetries {
+			break
+		}
+		log.Warnf("call remote signer %s failed, retry after %v: %v", endpoint, interval, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+
+	return err
+}
+
+func (s *RemoteSigner) do(ctx context.Context, method, endpoint, requestID string, body []byte, out interface{}) error {
+	u := *s.url
+	u.Path = path.Join("/", s.url.Path, endpoint)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderRequestID, requestID)
+	if len(s.cfg.HMACSecret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, SignRequestBody([]byte(s.cfg.HMACSecret), method, u.Path, timestamp, requestID, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return &retryableError{err: fmt.Errorf("call remote signer %s: %w", u.Path, err)}
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxRemoteErrorBody))
+		msg := strings.TrimSpace(string(data))
+		var errResp RemoteErrorResponse
+		if json.Unmarshal(data, &errResp) == nil && len(errResp.Error) > 0 {
+			msg = errResp.Error
+		}
+		err := &statusError{status: resp.StatusCode, err: fmt.Errorf("call remote signer %s: status %d: %s", u.Path, resp.StatusCode, msg)}
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return &retryableError{err: err}
+		}
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response of remote signer %s: %w", u.Path, err)
+	}
+	return nil
+}
+
+func (s *RemoteSigner) WalletHas(ctx context.Context, addr address.Address, accounts []string) (bool, error) {
+	var resp RemoteHasResponse
+	if err := s.call(ctx, http.MethodPost, "/has", &RemoteHasRequest{Address: addr, Accounts: accounts}, &resp); err != nil {
+		return false, err
+	}
+	return resp.Has, nil
+}
+
+func (s *RemoteSigner) WalletSign(ctx context.Context, addr address.Address, accounts []string, toSign []byte, meta venusTypes.MsgMeta) (*crypto.Signature, error) {
+	var resp RemoteSignResponse
+	req := &RemoteSignRequest{Address: addr, Accounts: accounts, ToSign: toSign, Meta: meta}
+	if err := s.call(ctx, http.MethodPost, "/sign", req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Signature == nil {
+		return nil, fmt.Errorf("remote signer returns no signature for %s", addr)
+	}
+	return resp.Signature, nil
+}
+
+// WalletSignBatch sign the messages by one /sign-batch request, or by /sign for each message if the service doesn't serve it
+func (s *RemoteSigner) WalletSignBatch(ctx context.Context, addr address.Address, accounts []string, toSign [][]byte, metas []venusTypes.MsgMeta) ([]*crypto.Signature, []error) {
+	sigs := make([]*crypto.Signature, len(toSign))
+	errs := make([]error, len(toSign))
+
+	var resp RemoteSignBatchResponse
+	req := &RemoteSignBatchRequest{Address: addr, Accounts: accounts, ToSign: toSign, Metas: metas}
+	err := s.call(ctx, http.MethodPost, "/sign-batch", req, &resp)
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && (statusErr.status == http.StatusNotFound || statusErr.status == http.StatusMethodNotAllowed) {
+		log.Debugf("remote signer doesn't support signing in batch, sign one by one: %v", err)
+		signConcurrently(ctx, s.WalletSign, addr, accounts, toSign, metas, allIndexes(len(toSign)), sigs, errs)
+		return sigs, errs
+	}
+	if err == nil && (len(resp.Signatures) != len(toSign) || (len(resp.Errors) != 0 && len(resp.Errors) != len(toSign))) {
+		err = fmt.Errorf("remote signer returns %d signatures and %d errors for %d messages", len(resp.Signatures), len(resp.Errors), len(toSign))
+	}
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return sigs, errs
+	}
+
+	for i, sig := range resp.Signatures {
+		switch {
+		case len(resp.Errors) > 0 && len(resp.Errors[i]) > 0:
+			errs[i] = fmt.Errorf("remote signer failed to sign: %s", resp.Errors[i])
+		case sig == nil:
+			errs[i] = fmt.Errorf("remote signer returns no signature for %s", addr)
+		default:
+			sigs[i] = sig
+		}
+	}
+	return sigs, errs
+}
+
+func (s *RemoteSigner) ListWalletInfo(ctx context.Context) ([]*gtypes.WalletDetail, error) {
+	detail, err := s.ListWalletInfoByWallet(ctx, RemoteAccount)
+	if err != nil {
+		return nil, err
+	}
+	return []*gtypes.WalletDetail{detail}, nil
+}
+
+func (s *RemoteSigner) ListWalletInfoByWallet(ctx context.Context, wallet string) (*gtypes.WalletDetail, error) {
+	if wallet != RemoteAccount {
+		return nil, fmt.Errorf("wallet %s not found", wallet)
+	}
+	var resp RemoteAddressesResponse
+	if err := s.call(ctx, http.MethodGet, "/addresses", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &gtypes.WalletDetail{
+		Account:         RemoteAccount,
+		SupportAccounts: []string{RemoteAccount},
+		ConnectStates: []gtypes.ConnectState{{
+			Addrs: resp.Addresses,
+			IP:    s.url.Host,
+		}},
+	}, nil
+}
+
+var _ gatewayAPI.IWalletClient = (*RemoteSigner)(nil)
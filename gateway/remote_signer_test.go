@@ -0,0 +1,372 @@
+package gateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/venus/venus-shared/testutil"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	signer, parentCert := key, template
+	if parent != nil {
+		signer, parentCert = parent.key, parent.cert
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, signer)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCert) tlsCert(t *testing.T) tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	require.NoError(t, err)
+	return cert
+}
+
+// mockRemoteSigner is a remote signer service which signs by appending the address to the data like MockWalletProxy
+type mockRemoteSigner struct {
+	secret string
+	addrs  []address.Address
+	// noBatch makes the service not serve /sign-batch like the older ones
+	noBatch bool
+
+	lk sync.Mutex
+	// failures is the statuses returned before a request succeed
+	failures   []int
+	requestIDs []string
+	metas      []venusTypes.MsgMeta
+}
+
+func (s *mockRemoteSigner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+
+	writeErr := func(status int, msg string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(RemoteErrorResponse{Error: msg})
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeErr(http.StatusBadRequest, err.Error())
+		return
+	}
+	requestID := r.Header.Get(HeaderRequestID)
+	s.requestIDs = append(s.requestIDs, requestID)
+	if len(s.secret) > 0 {
+		expect := SignRequestBody([]byte(s.secret), r.Method, r.URL.Path, r.Header.Get(HeaderTimestamp), requestID, body)
+		if r.Header.Get(HeaderSignature) != expect {
+			writeErr(http.StatusUnauthorized, "invalid signature")
+			return
+		}
+	}
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+		writeErr(status, http.StatusText(status))
+		return
+	}
+
+	switch r.URL.Path {
+	case "/has":
+		var req RemoteHasRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeErr(http.StatusBadRequest, err.Error())
+			return
+		}
+		has := false
+		for _, addr := range s.addrs {
+			has = has || addr == req.Address
+		}
+		_ = json.NewEncoder(w).Encode(RemoteHasResponse{Has: has})
+	case "/sign":
+		var req RemoteSignRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeErr(http.StatusBadRequest, err.Error())
+			return
+		}
+		s.metas = append(s.metas, req.Meta)
+		sig := &crypto.Signature{Type: crypto.SigTypeSecp256k1, Data: append(req.ToSign, req.Address.Bytes()...)}
+		_ = json.NewEncoder(w).Encode(RemoteSignResponse{Signature: sig})
+	case "/sign-batch":
+		if s.noBatch {
+			writeErr(http.StatusNotFound, "not found")
+			return
+		}
+		var req RemoteSignBatchRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeErr(http.StatusBadRequest, err.Error())
+			return
+		}
+		s.metas = append(s.metas, req.Metas...)
+		resp := RemoteSignBatchResponse{
+			Signatures: make([]*crypto.Signature, len(req.ToSign)),
+			Errors:     make([]string, len(req.ToSign)),
+		}
+		for i, toSign := range req.ToSign {
+			if len(toSign) == 0 {
+				resp.Errors[i] = "empty data"
+				continue
+			}
+			resp.Signatures[i] = &crypto.Signature{Type: crypto.SigTypeSecp256k1, Data: append(toSign, req.Address.Bytes()...)}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	case "/addresses":
+		_ = json.NewEncoder(w).Encode(RemoteAddressesResponse{Addresses: s.addrs})
+	default:
+		writeErr(http.StatusNotFound, "not found")
+	}
+}
+
+func TestRemoteSigner(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	ca := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil)
+	serverCert := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}, ca)
+	clientCert := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "messager"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	writeFile := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+		return path
+	}
+	caPath := writeFile("ca.pem", ca.certPEM)
+	certPath := writeFile("client.pem", clientCert.certPEM)
+	keyPath := writeFile("client.key", clientCert.keyPEM)
+
+	addr := testutil.SecpAddressProvider(32)(t)
+	mock := &mockRemoteSigner{secret: "secret", addrs: []address.Address{addr}}
+	server := httptest.NewUnstartedServer(mock)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert.tlsCert(t)},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	newCfg := func() *config.RemoteSignerConfig {
+		cfg := config.DefaultConfig().Signer.Remote
+		cfg.URL = server.URL
+		cfg.CACert = caPath
+		cfg.Cert = certPath
+		cfg.Key = keyPath
+		cfg.HMACSecret = mock.secret
+		cfg.RetryInterval = time.Millisecond
+		return &cfg
+	}
+	newSigner := func(cfg *config.RemoteSignerConfig) *RemoteSigner {
+		signer, err := NewRemoteSigner(cfg)
+		require.NoError(t, err)
+		t.Cleanup(signer.Close)
+		return signer
+	}
+	reset := func(failures ...int) {
+		mock.lk.Lock()
+		defer mock.lk.Unlock()
+		mock.failures = failures
+		mock.noBatch = false
+		mock.requestIDs = nil
+		mock.metas = nil
+	}
+
+	t.Run("sign with meta", func(t *testing.T) {
+		reset()
+		signer := newSigner(newCfg())
+
+		has, err := signer.WalletHas(ctx, addr, []string{"a"})
+		require.NoError(t, err)
+		assert.True(t, has)
+		has, err = signer.WalletHas(ctx, testutil.SecpAddressProvider(32)(t), []string{"a"})
+		require.NoError(t, err)
+		assert.False(t, has)
+
+		toSign := []byte("to sign")
+		meta := venusTypes.MsgMeta{Type: venusTypes.MTChainMsg, Extra: []byte("cbor of message")}
+		sig, err := signer.WalletSign(ctx, addr, []string{"a"}, toSign, meta)
+		require.NoError(t, err)
+		assert.Equal(t, append(toSign, addr.Bytes()...), sig.Data)
+		require.Len(t, mock.metas, 1)
+		assert.Equal(t, meta, mock.metas[0])
+
+		details, err := signer.ListWalletInfo(ctx)
+		require.NoError(t, err)
+		require.Len(t, details, 1)
+		assert.Equal(t, RemoteAccount, details[0].Account)
+		assert.Equal(t, []address.Address{addr}, details[0].ConnectStates[0].Addrs)
+	})
+
+	t.Run("sign in batch", func(t *testing.T) {
+		reset()
+		signer := newSigner(newCfg())
+
+		toSign := [][]byte{[]byte("msg1"), {}, []byte("msg3")}
+		metas := []venusTypes.MsgMeta{{Extra: []byte("1")}, {Extra: []byte("2")}, {Extra: []byte("3")}}
+		sigs, errs := signer.WalletSignBatch(ctx, addr, []string{"a"}, toSign, metas)
+		require.Len(t, sigs, 3)
+		require.Len(t, errs, 3)
+		assert.NoError(t, errs[0])
+		assert.Equal(t, append([]byte("msg1"), addr.Bytes()...), sigs[0].Data)
+		assert.ErrorContains(t, errs[1], "empty data")
+		assert.Nil(t, sigs[1])
+		assert.NoError(t, errs[2])
+		assert.Equal(t, append([]byte("msg3"), addr.Bytes()...), sigs[2].Data)
+		assert.Len(t, mock.requestIDs, 1)
+		assert.Equal(t, metas, mock.metas)
+	})
+
+	t.Run("sign one by one without sign-batch", func(t *testing.T) {
+		reset()
+		mock.noBatch = true
+		signer := newSigner(newCfg())
+
+		toSign := [][]byte{[]byte("msg1"), []byte("msg2")}
+		sigs, errs := signer.WalletSignBatch(ctx, addr, nil, toSign, make([]venusTypes.MsgMeta, 2))
+		for i := range toSign {
+			require.NoError(t, errs[i])
+			assert.Equal(t, append(toSign[i], addr.Bytes()...), sigs[i].Data)
+		}
+		// one /sign-batch and two /sign
+		assert.Len(t, mock.requestIDs, 3)
+	})
+
+	t.Run("batch failed on client error", func(t *testing.T) {
+		reset(http.StatusForbidden)
+		signer := newSigner(newCfg())
+
+		_, errs := signer.WalletSignBatch(ctx, addr, nil, [][]byte{[]byte("msg1"), []byte("msg2")}, make([]venusTypes.MsgMeta, 2))
+		for _, err := range errs {
+			assert.ErrorContains(t, err, "status 403: Forbidden")
+		}
+		assert.Len(t, mock.requestIDs, 1)
+	})
+
+	t.Run("retry on server error", func(t *testing.T) {
+		reset(http.StatusServiceUnavailable, http.StatusTooManyRequests)
+		signer := newSigner(newCfg())
+
+		_, err := signer.WalletSign(ctx, addr, nil, []byte("data"), venusTypes.MsgMeta{})
+		require.NoError(t, err)
+		require.Len(t, mock.requestIDs, 3)
+		assert.Equal(t, mock.requestIDs[0], mock.requestIDs[1])
+		assert.Equal(t, mock.requestIDs[0], mock.requestIDs[2])
+
+		reset(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+		_, err = signer.WalletSign(ctx, addr, nil, []byte("data"), venusTypes.MsgMeta{})
+		assert.ErrorContains(t, err, "status 503")
+		assert.Len(t, mock.requestIDs, 3)
+	})
+
+	t.Run("not retry on client error", func(t *testing.T) {
+		reset(http.StatusForbidden)
+		signer := newSigner(newCfg())
+
+		_, err := signer.WalletSign(ctx, addr, nil, []byte("data"), venusTypes.MsgMeta{})
+		assert.ErrorContains(t, err, "status 403: Forbidden")
+		assert.Len(t, mock.requestIDs, 1)
+	})
+
+	t.Run("invalid hmac secret", func(t *testing.T) {
+		reset()
+		cfg := newCfg()
+		cfg.HMACSecret = "wrong"
+		signer := newSigner(cfg)
+
+		_, err := signer.WalletSign(ctx, addr, nil, []byte("data"), venusTypes.MsgMeta{})
+		assert.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("reject client without cert", func(t *testing.T) {
+		reset()
+		cfg := newCfg()
+		cfg.Cert, cfg.Key = "", ""
+		cfg.MaxRetries = 0
+		signer := newSigner(cfg)
+
+		_, err := signer.WalletHas(ctx, addr, nil)
+		assert.Error(t, err)
+		assert.Empty(t, mock.requestIDs)
+	})
+
+	t.Run("stop retrying when context done", func(t *testing.T) {
+		reset(http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+		cfg := newCfg()
+		cfg.RetryInterval = time.Hour
+		signer := newSigner(cfg)
+
+		ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		_, err := signer.WalletHas(ctx, addr, nil)
+		assert.ErrorContains(t, err, "status 503")
+		assert.Len(t, mock.requestIDs, 1)
+	})
+}
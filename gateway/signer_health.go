@@ -0,0 +1,321 @@
+package gateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/metrics"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+// the weight of the newest sample in moving averages
+const ewmaAlpha = 0.3
+
+// signerHealth is the health of a gateway signing for an address
+type signerHealth struct {
+	has      bool
+	measured bool
+	latency  time.Duration
+	failures int
+	open     bool
+	openedAt time.Time
+	lastErr  error
+
+	quarantinedUntil time.Time
+}
+
+// unreachable is the state of an address which no signer can be reached for
+type unreachable struct {
+	since   time.Time
+	alerted bool
+}
+
+// healthTracker track the health of each gateway for each address, the circuit of a gateway for an address is opened
+// after consecutive failures, and it's half-open to probe the gateway after OpenDuration. A gateway returning an
+// invalid signature is quarantined for QuarantineDuration, then it's half-open.
+type healthTracker struct {
+	lk          sync.Mutex
+	cfg         config.SignerHealthConfig
+	signers     map[string]map[address.Address]*signerHealth
+	unreachable map[address.Address]*unreachable
+
+	now func() time.Time
+}
+
+func newHealthTracker(cfg config.SignerHealthConfig) *healthTracker {
+	return &healthTracker{
+		cfg:         cfg,
+		signers:     make(map[string]map[address.Address]*signerHealth),
+		unreachable: make(map[address.Address]*unreachable),
+		now:         time.Now,
+	}
+}
+
+func (t *healthTracker) updateConfig(cfg config.SignerHealthConfig) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.cfg = cfg
+}
+
+// retain drop the health of the gateways not in gateways
+func (t *healthTracker) retain(gateways map[string]struct{}) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	for gateway := range t.signers {
+		if _, ok := gateways[gateway]; !ok {
+			delete(t.signers, gateway)
+		}
+	}
+}
+
+func (t *healthTracker) get(gateway string, addr address.Address) *signerHealth {
+	addrs, ok := t.signers[gateway]
+	if !ok {
+		addrs = make(map[address.Address]*signerHealth)
+		t.signers[gateway] = addrs
+	}
+	h, ok := addrs[addr]
+	if !ok {
+		h = &signerHealth{}
+		addrs[addr] = h
+	}
+	return h
+}
+
+// circuit returns the state of the circuit, the lock should be held
+func (t *healthTracker) circuit(h *signerHealth) string {
+	if t.now().Before(h.quarantinedUntil) {
+		return mtypes.CircuitQuarantined
+	}
+	if !h.open {
+		return mtypes.CircuitClosed
+	}
+	if t.now().Sub(h.openedAt) >= t.cfg.OpenDuration {
+		return mtypes.CircuitHalfOpen
+	}
+	return mtypes.CircuitOpen
+}
+
+func rejected(state string) bool {
+	return state == mtypes.CircuitOpen || state == mtypes.CircuitQuarantined
+}
+
+// allow returns whether the circuit of gateway for addr allows calls
+func (t *healthTracker) allow(gateway string, addr address.Address) bool {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	return !rejected(t.circuit(t.get(gateway, addr)))
+}
+
+// candidates returns the gateways which have an online wallet for addr and allow calls, excluding the skipped,
+// sorted by closed circuit first, then by latency.
+func (t *healthTracker) candidates(gateways []string, addr address.Address, skip map[string]struct{}) []string {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	type candidate struct {
+		gateway string
+		closed  bool
+		latency time.Duration
+	}
+	list := make([]candidate, 0, len(gateways))
+	for _, gateway := range gateways {
+		if _, ok := skip[gateway]; ok {
+			continue
+		}
+		h := t.get(gateway, addr)
+		state := t.circuit(h)
+		if !h.has || rejected(state) {
+			continue
+		}
+		list = append(list, candidate{gateway: gateway, closed: state == mtypes.CircuitClosed, latency: h.latency})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].closed != list[j].closed {
+			return list[i].closed
+		}
+		return list[i].latency < list[j].latency
+	})
+
+	res := make([]string, 0, len(list))
+	for _, c := range list {
+		res = append(res, c.gateway)
+	}
+	return res
+}
+
+// recordHas record the result of WalletHas, an error is a failure of the gateway
+func (t *healthTracker) recordHas(ctx context.Context, gateway string, addr address.Address, has bool, err error) {
+	if err != nil {
+		t.recordFailure(ctx, gateway, addr, err)
+		return
+	}
+
+	t.lk.Lock()
+	h := t.get(gateway, addr)
+	h.has = has
+	t.lk.Unlock()
+	if has {
+		t.reachable(ctx, addr)
+	}
+}
+
+// recordSign record the result of WalletSign and the latency of it
+func (t *healthTracker) recordSign(ctx context.Context, gateway string, addr address.Address, latency time.Duration, err error) {
+	ctx, _ = tag.New(ctx, tag.Upsert(metrics.WalletAddress, addr.String()), tag.Upsert(metrics.Gateway, gateway))
+	if err != nil {
+		stats.Record(ctx, metrics.SignFailure.M(1))
+		t.recordFailure(ctx, gateway, addr, err)
+		return
+	}
+	stats.Record(ctx, metrics.SignLatency.M(float64(latency)/float64(time.Millisecond)))
+
+	t.lk.Lock()
+	h := t.get(gateway, addr)
+	if h.open {
+		log.Infof("close the circuit of gateway %s for %s", gateway, addr)
+	}
+	h.has = true
+	h.failures = 0
+	h.open = false
+	if !h.measured {
+		h.latency = latency
+	} else {
+		h.latency = time.Duration(float64(h.latency)*(1-ewmaAlpha) + float64(latency)*ewmaAlpha)
+	}
+	h.measured = true
+	t.lk.Unlock()
+	stats.Record(ctx, metrics.SignerCircuitOpen.M(0))
+
+	t.reachable(ctx, addr)
+}
+
+func (t *healthTracker) recordFailure(ctx context.Context, gateway string, addr address.Address, err error) {
+	// not the fault of gateway
+	if ctx.Err() != nil {
+		return
+	}
+
+	t.lk.Lock()
+	h := t.get(gateway, addr)
+	h.failures++
+	h.lastErr = err
+	opened := false
+	if state := t.circuit(h); state == mtypes.CircuitHalfOpen || (state == mtypes.CircuitClosed && h.failures >= t.cfg.FailureThreshold) {
+		h.open = true
+		h.openedAt = t.now()
+		opened = true
+	}
+	failures := h.failures
+	t.lk.Unlock()
+
+	if opened {
+		log.Warnf("open the circuit of gateway %s for %s after %d consecutive failures: %v", gateway, addr, failures, err)
+		ctx, _ = tag.New(ctx, tag.Upsert(metrics.WalletAddress, addr.String()), tag.Upsert(metrics.Gateway, gateway))
+		stats.Record(ctx, metrics.SignerCircuitOpen.M(1))
+	}
+}
+
+// recordInvalid record that gateway returned an invalid signature for addr, and quarantine it
+func (t *healthTracker) recordInvalid(ctx context.Context, gateway string, addr address.Address, err error) {
+	t.lk.Lock()
+	h := t.get(gateway, addr)
+	h.failures++
+	h.lastErr = err
+	// half-open after the quarantine
+	h.open = true
+	h.openedAt = t.now()
+	h.quarantinedUntil = t.now().Add(t.cfg.QuarantineDuration)
+	until := h.quarantinedUntil
+	t.lk.Unlock()
+
+	log.Errorf("quarantine gateway %s for %s until %s: %v", gateway, addr, until.Format(time.RFC3339), err)
+	ctx, _ = tag.New(ctx, tag.Upsert(metrics.WalletAddress, addr.String()), tag.Upsert(metrics.Gateway, gateway))
+	stats.Record(ctx, metrics.SignInvalid.M(1), metrics.SignerCircuitOpen.M(1))
+}
+
+func (t *healthTracker) reachable(ctx context.Context, addr address.Address) {
+	t.lk.Lock()
+	u, ok := t.unreachable[addr]
+	delete(t.unreachable, addr)
+	t.lk.Unlock()
+
+	if ok {
+		if u.alerted {
+			log.Infof("signer of %s is reachable again after %v", addr, t.now().Sub(u.since).Truncate(time.Second))
+		}
+		ctx, _ = tag.New(ctx, tag.Upsert(metrics.WalletAddress, addr.String()))
+		stats.Record(ctx, metrics.SignerUnreachable.M(0))
+	}
+}
+
+// recordUnreachable record that no signer can be reached for addr, and alert if it lasts longer than UnreachableAlert
+func (t *healthTracker) recordUnreachable(ctx context.Context, addr address.Address) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	t.lk.Lock()
+	u, ok := t.unreachable[addr]
+	if !ok {
+		u = &unreachable{since: t.now()}
+		t.unreachable[addr] = u
+	}
+	dur := t.now().Sub(u.since)
+	alert := t.cfg.UnreachableAlert > 0 && dur > t.cfg.UnreachableAlert && !u.alerted
+	if alert {
+		u.alerted = true
+	}
+	t.lk.Unlock()
+
+	if alert {
+		log.Errorf("no signer has been reachable for %s for %v", addr, dur.Truncate(time.Second))
+	}
+	ctx, _ = tag.New(ctx, tag.Upsert(metrics.WalletAddress, addr.String()))
+	stats.Record(ctx, metrics.SignerUnreachable.M(int64(dur.Seconds())))
+}
+
+// status returns the health of all gateways for all addresses, sorted by address and gateway
+func (t *healthTracker) status() []*mtypes.SignerHealth {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	var list []*mtypes.SignerHealth
+	for gateway, addrs := range t.signers {
+		for addr, h := range addrs {
+			s := &mtypes.SignerHealth{
+				Address:  addr,
+				Gateway:  gateway,
+				Has:      h.has,
+				Latency:  h.latency,
+				Failures: h.failures,
+				Circuit:  t.circuit(h),
+			}
+			if h.lastErr != nil {
+				s.LastError = h.lastErr.Error()
+			}
+			if !h.quarantinedUntil.IsZero() {
+				s.QuarantinedUntil = h.quarantinedUntil
+			}
+			if u, ok := t.unreachable[addr]; ok {
+				s.UnreachableSince = u.since
+			}
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Address != list[j].Address {
+			return list[i].Address.String() < list[j].Address.String()
+		}
+		return list[i].Gateway < list[j].Gateway
+	})
+
+	return list
+}
@@ -0,0 +1,259 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	gatewayAPI "github.com/filecoin-project/venus/venus-shared/api/gateway/v2"
+	"github.com/filecoin-project/venus/venus-shared/testutil"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+type failingWalletClient struct {
+	*MockWalletProxy
+	fail  atomic.Bool
+	signs atomic.Int64
+}
+
+func (c *failingWalletClient) WalletSign(ctx context.Context, addr address.Address, accounts []string, toSign []byte, meta venusTypes.MsgMeta) (*crypto.Signature, error) {
+	c.signs.Add(1)
+	if c.fail.Load() {
+		return nil, errors.New("mock sign error")
+	}
+	return c.MockWalletProxy.WalletSign(ctx, addr, accounts, toSign, meta)
+}
+
+func TestHealthTracker(t *testing.T) {
+	ctx := context.Background()
+	addr := testutil.SecpAddressProvider(32)(t)
+	cfg := config.SignerHealthConfig{FailureThreshold: 2, OpenDuration: time.Minute, UnreachableAlert: time.Minute}
+	tracker := newHealthTracker(cfg)
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+	gateways := []string{"gw1", "gw2", "gw3"}
+
+	// gw3 doesn't have the address
+	tracker.recordHas(ctx, "gw1", addr, true, nil)
+	tracker.recordHas(ctx, "gw2", addr, true, nil)
+	tracker.recordHas(ctx, "gw3", addr, false, nil)
+	tracker.recordSign(ctx, "gw1", addr, 200*time.Millisecond, nil)
+	tracker.recordSign(ctx, "gw2", addr, 100*time.Millisecond, nil)
+	assert.Equal(t, []string{"gw2", "gw1"}, tracker.candidates(gateways, addr, nil))
+	assert.Equal(t, []string{"gw1"}, tracker.candidates(gateways, addr, map[string]struct{}{"gw2": {}}))
+
+	// the circuit is opened after consecutive failures
+	tracker.recordSign(ctx, "gw2", addr, 0, errors.New("mock error"))
+	assert.Equal(t, []string{"gw2", "gw1"}, tracker.candidates(gateways, addr, nil))
+	tracker.recordSign(ctx, "gw2", addr, 0, errors.New("mock error"))
+	assert.Equal(t, []string{"gw1"}, tracker.candidates(gateways, addr, nil))
+	assert.False(t, tracker.allow("gw2", addr))
+
+	// half-open after OpenDuration, the closed circuit is preferred
+	now = now.Add(time.Minute)
+	assert.True(t, tracker.allow("gw2", addr))
+	assert.Equal(t, []string{"gw1", "gw2"}, tracker.candidates(gateways, addr, nil))
+	// failed to probe, open again
+	tracker.recordSign(ctx, "gw2", addr, 0, errors.New("mock error"))
+	assert.False(t, tracker.allow("gw2", addr))
+	now = now.Add(time.Minute)
+	tracker.recordSign(ctx, "gw2", addr, 100*time.Millisecond, nil)
+	assert.Equal(t, []string{"gw2", "gw1"}, tracker.candidates(gateways, addr, nil))
+
+	// a cancelled call is not a failure of gateway
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	tracker.recordSign(cctx, "gw1", addr, 0, context.Canceled)
+	tracker.recordSign(cctx, "gw1", addr, 0, context.Canceled)
+	assert.True(t, tracker.allow("gw1", addr))
+
+	status := tracker.status()
+	require.Len(t, status, 3)
+	assert.Equal(t, "gw2", status[1].Gateway)
+	assert.Equal(t, mtypes.CircuitClosed, status[1].Circuit)
+	assert.Equal(t, 0, status[1].Failures)
+	assert.Equal(t, "mock error", status[1].LastError)
+	assert.False(t, status[2].Has)
+
+	// unreachable since the first failure, alerted once after UnreachableAlert
+	tracker.recordUnreachable(ctx, addr)
+	since := now
+	now = now.Add(2 * time.Minute)
+	tracker.recordUnreachable(ctx, addr)
+	assert.True(t, tracker.unreachable[addr].alerted)
+	assert.Equal(t, since, tracker.status()[0].UnreachableSince)
+	tracker.recordSign(ctx, "gw1", addr, 100*time.Millisecond, nil)
+	assert.True(t, tracker.status()[0].UnreachableSince.IsZero())
+}
+
+func TestWalletSignFailover(t *testing.T) {
+	ctx := context.Background()
+	addr := testutil.SecpAddressProvider(32)(t)
+	account := "a"
+
+	gw1 := &failingWalletClient{MockWalletProxy: NewMockWalletProxy()}
+	gw2 := &failingWalletClient{MockWalletProxy: NewMockWalletProxy()}
+	for _, gw := range []*failingWalletClient{gw1, gw2} {
+		require.NoError(t, gw.AddAddress(account, []address.Address{addr}))
+	}
+	proxy := &WalletProxy{
+		clients: map[string]gatewayAPI.IWalletClient{"gw1": gw1, "gw2": gw2},
+		health:  newHealthTracker(config.SignerHealthConfig{FailureThreshold: 1, OpenDuration: time.Hour}),
+	}
+
+	has, err := proxy.WalletHas(ctx, addr, []string{account})
+	require.NoError(t, err)
+	assert.True(t, has)
+	// gw1 is preferred
+	proxy.health.recordSign(ctx, "gw1", addr, time.Millisecond, nil)
+	proxy.health.recordSign(ctx, "gw2", addr, time.Second, nil)
+	require.Equal(t, []string{"gw1", "gw2"}, proxy.health.candidates([]string{"gw1", "gw2"}, addr, nil))
+
+	// fail over to the other gateway, the circuit of the failed one is opened
+	gw1.fail.Store(true)
+	for i := 0; i < 3; i++ {
+		_, err = proxy.WalletSign(ctx, addr, []string{account}, []byte("data"), venusTypes.MsgMeta{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(1), gw1.signs.Load())
+	assert.False(t, proxy.health.allow("gw1", addr))
+
+	// no signer can be reached
+	gw2.fail.Store(true)
+	_, err = proxy.WalletSign(ctx, addr, []string{account}, []byte("data"), venusTypes.MsgMeta{})
+	assert.Error(t, err)
+	assert.Contains(t, proxy.health.unreachable, addr)
+
+	// probe the recovered gateway after OpenDuration
+	gw2.fail.Store(false)
+	now := time.Now().Add(time.Hour)
+	proxy.health.now = func() time.Time { return now }
+	_, err = proxy.WalletSign(ctx, addr, []string{account}, []byte("data"), venusTypes.MsgMeta{})
+	require.NoError(t, err)
+	assert.NotContains(t, proxy.health.unreachable, addr)
+}
+
+func TestWalletSignBatch(t *testing.T) {
+	ctx := context.Background()
+	addr := testutil.SecpAddressProvider(32)(t)
+	account := "a"
+
+	gw1 := &failingWalletClient{MockWalletProxy: NewMockWalletProxy()}
+	gw2 := &failingWalletClient{MockWalletProxy: NewMockWalletProxy()}
+	for _, gw := range []*failingWalletClient{gw1, gw2} {
+		require.NoError(t, gw.AddAddress(account, []address.Address{addr}))
+	}
+	proxy := &WalletProxy{
+		clients: map[string]gatewayAPI.IWalletClient{"gw1": gw1, "gw2": gw2},
+		health:  newHealthTracker(config.SignerHealthConfig{FailureThreshold: 1, OpenDuration: time.Hour}),
+	}
+	// gw1 is preferred
+	proxy.health.recordHas(ctx, "gw1", addr, true, nil)
+	proxy.health.recordHas(ctx, "gw2", addr, true, nil)
+	proxy.health.recordSign(ctx, "gw1", addr, time.Millisecond, nil)
+	proxy.health.recordSign(ctx, "gw2", addr, time.Second, nil)
+
+	toSign := [][]byte{[]byte("msg1"), []byte("msg2"), []byte("msg3")}
+	metas := make([]venusTypes.MsgMeta, len(toSign))
+	checkSigs := func(sigs []*crypto.Signature, errs []error) {
+		require.Len(t, sigs, len(toSign))
+		require.Len(t, errs, len(toSign))
+		for i := range toSign {
+			require.NoError(t, errs[i])
+			assert.Equal(t, append(toSign[i], addr.Bytes()...), sigs[i].Data)
+		}
+	}
+
+	// all messages are signed by the selected gateway
+	checkSigs(proxy.WalletSignBatch(ctx, addr, []string{account}, toSign, metas))
+	assert.Equal(t, int64(3), gw1.signs.Load())
+	assert.Equal(t, int64(0), gw2.signs.Load())
+
+	// the failed messages fail over to the other gateway, the circuit of the failed one is opened
+	gw1.fail.Store(true)
+	checkSigs(proxy.WalletSignBatch(ctx, addr, []string{account}, toSign, metas))
+	assert.Equal(t, int64(6), gw1.signs.Load())
+	assert.Equal(t, int64(3), gw2.signs.Load())
+	assert.False(t, proxy.health.allow("gw1", addr))
+
+	// no signer can be reached
+	gw2.fail.Store(true)
+	_, errs := proxy.WalletSignBatch(ctx, addr, []string{account}, toSign, metas)
+	for _, err := range errs {
+		assert.Error(t, err)
+	}
+	assert.Contains(t, proxy.health.unreachable, addr)
+}
+
+// invalidWalletClient returns the signatures of a wrong type
+type invalidWalletClient struct {
+	*MockWalletProxy
+}
+
+func (c *invalidWalletClient) WalletSign(ctx context.Context, addr address.Address, accounts []string, toSign []byte, meta venusTypes.MsgMeta) (*crypto.Signature, error) {
+	sig, err := c.MockWalletProxy.WalletSign(ctx, addr, accounts, toSign, meta)
+	if err != nil {
+		return nil, err
+	}
+	sig.Type = crypto.SigTypeBLS
+	return sig, nil
+}
+
+func TestWalletSignQuarantine(t *testing.T) {
+	ctx := context.Background()
+	addr := testutil.SecpAddressProvider(32)(t)
+	account := "a"
+
+	gw1 := &invalidWalletClient{MockWalletProxy: NewMockWalletProxy()}
+	gw2 := &failingWalletClient{MockWalletProxy: NewMockWalletProxy()}
+	require.NoError(t, gw1.AddAddress(account, []address.Address{addr}))
+	require.NoError(t, gw2.AddAddress(account, []address.Address{addr}))
+	proxy := &WalletProxy{
+		clients: map[string]gatewayAPI.IWalletClient{"gw1": gw1, "gw2": gw2},
+		health:  newHealthTracker(config.SignerHealthConfig{FailureThreshold: 3, OpenDuration: time.Minute, QuarantineDuration: time.Hour}),
+	}
+	now := time.Now()
+	proxy.health.now = func() time.Time { return now }
+	// gw1 is preferred
+	proxy.health.recordHas(ctx, "gw1", addr, true, nil)
+	proxy.health.recordHas(ctx, "gw2", addr, true, nil)
+	proxy.health.recordSign(ctx, "gw1", addr, time.Millisecond, nil)
+	proxy.health.recordSign(ctx, "gw2", addr, time.Second, nil)
+
+	// the invalid signature is dropped, gw1 is quarantined at once and signed by gw2
+	sig, err := proxy.WalletSign(ctx, addr, []string{account}, []byte("data"), venusTypes.MsgMeta{})
+	require.NoError(t, err)
+	assert.Equal(t, crypto.SigTypeSecp256k1, sig.Type)
+	assert.Equal(t, []string{"gw2"}, proxy.health.candidates([]string{"gw1", "gw2"}, addr, nil))
+	status := proxy.health.status()
+	require.Len(t, status, 2)
+	assert.Equal(t, mtypes.CircuitQuarantined, status[0].Circuit)
+	assert.Equal(t, now.Add(time.Hour), status[0].QuarantinedUntil)
+	assert.ErrorContains(t, errors.New(status[0].LastError), "invalid signature")
+
+	// the invalid signatures of a batch are signed again by gw2
+	sigs, errs := proxy.WalletSignBatch(ctx, addr, []string{account}, [][]byte{[]byte("msg1"), []byte("msg2")}, make([]venusTypes.MsgMeta, 2))
+	for i := range sigs {
+		require.NoError(t, errs[i])
+		assert.Equal(t, crypto.SigTypeSecp256k1, sigs[i].Type)
+	}
+
+	// the quarantined gw1 is not tried
+	gw2.fail.Store(true)
+	_, err = proxy.WalletSign(ctx, addr, []string{account}, []byte("data"), venusTypes.MsgMeta{})
+	assert.ErrorContains(t, err, "mock sign error")
+
+	// half-open after the quarantine
+	now = now.Add(time.Hour)
+	assert.True(t, proxy.health.allow("gw1", addr))
+	assert.Equal(t, mtypes.CircuitHalfOpen, proxy.health.status()[0].Circuit)
+}
@@ -8,6 +8,7 @@ require (
 	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/acarl005/stripansi v0.0.0-20180116102854-5a71ef0e047d
 	github.com/asaskevich/EventBus v0.0.0-20200907212545-49d423059eef
+	github.com/cloudflare/circl v1.4.0
 	github.com/etherlabsio/healthcheck/v2 v2.0.0
 	github.com/fatih/color v1.15.0
 	github.com/filecoin-project/go-address v1.2.0
@@ -16,6 +17,7 @@ require (
 	github.com/filecoin-project/go-state-types v0.16.0-rc1
 	github.com/filecoin-project/specs-actors/v5 v5.0.6
 	github.com/filecoin-project/venus v1.18.0-rc1
+	github.com/fsnotify/fsnotify v1.6.0
 	github.com/golang/mock v1.6.0
 	github.com/google/uuid v1.6.0
 	github.com/hunjixin/automapper v0.0.0-20191127090318-9b979ce72ce2
@@ -34,6 +36,7 @@ require (
 	github.com/whyrusleeping/cbor-gen v0.2.0
 	go.opencensus.io v0.24.0
 	go.uber.org/fx v1.22.1
+	golang.org/x/crypto v0.29.0
 	gorm.io/driver/mysql v1.1.1
 	gorm.io/driver/sqlite v1.1.4
 	gorm.io/gorm v1.21.12
@@ -75,7 +78,6 @@ require (
 	github.com/filecoin-project/specs-actors/v7 v7.0.1 // indirect
 	github.com/flynn/noise v1.1.0 // indirect
 	github.com/francoispqt/gojay v1.2.13 // indirect
-	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/gbrlsnchs/jwt/v3 v3.0.1
 	github.com/gin-contrib/sse v0.1.0 // indirect
 	github.com/gin-gonic/gin v1.9.1 // indirect
@@ -190,7 +192,6 @@ require (
 	go.uber.org/dig v1.17.1 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
 	go.uber.org/zap v1.27.0
-	golang.org/x/crypto v0.29.0 // indirect
 	golang.org/x/mod v0.20.0 // indirect
 	golang.org/x/net v0.29.0 // indirect
 	golang.org/x/sync v0.9.0 // indirect
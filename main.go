@@ -33,8 +33,11 @@ import (
 	"github.com/ipfs-force-community/sophon-messager/filestore"
 	"github.com/ipfs-force-community/sophon-messager/gateway"
 	"github.com/ipfs-force-community/sophon-messager/models"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+	"github.com/ipfs-force-community/sophon-messager/nodepool"
 	"github.com/ipfs-force-community/sophon-messager/service"
 	"github.com/ipfs-force-community/sophon-messager/version"
+	"github.com/ipfs-force-community/sophon-messager/wallet"
 )
 
 var log = logging.Logger("main")
@@ -59,6 +62,10 @@ func main() {
 			ccli.LogCmds,
 			ccli.SendCmd,
 			ccli.SwarmCmds,
+			ccli.AuditCmds,
+			ccli.AuthCmds,
+			ccli.ConfigCmds,
+			ccli.WalletCmds,
 			runCmd,
 		},
 	}
@@ -80,6 +87,10 @@ var runCmd = &cli.Command{
 			Usage: "specify endpoint for listen",
 			Value: "/ip4/127.0.0.1/tcp/39812",
 		},
+		&cli.StringFlag{
+			Name:  "auth-mode",
+			Usage: "remote: verify tokens by sophon-auth, local: by the users managed with `auth user` commands",
+		},
 		&cli.StringFlag{
 			Name:  "auth-url",
 			Usage: "url for auth server",
@@ -153,27 +164,51 @@ func runAction(cctx *cli.Context) error {
 		cfg = fsRepo.Config()
 	}
 
+	envOverrides, err := config.ApplyEnv(cfg)
+	if err != nil {
+		return fmt.Errorf("apply environment variables failed: %w", err)
+	}
+	for _, o := range envOverrides {
+		log.Infof("config %s is overridden by environment variable %s", o.Field, o.Env)
+	}
 	if err = updateFlag(cfg, cctx); err != nil {
 		return err
 	}
+	if err = cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
 
 	if !hasFSRepo {
-		fsRepo, err = filestore.InitFSRepo(repoPath, cfg)
+		// values from environment variables may be secrets, so not write them to the config file
+		fileCfg := config.DefaultConfig()
+		if err = updateFlag(fileCfg, cctx); err != nil {
+			return err
+		}
+		fsRepo, err = filestore.InitFSRepo(repoPath, fileCfg)
 		if err != nil {
 			return err
 		}
+		fsRepo.SetConfig(cfg)
 	}
 
 	log.Infof("node info url: %s, token: %s\n", cfg.Node.Url, cfg.Node.Token)
-	log.Infof("auth info url: %s\n", cfg.JWT.AuthURL)
+	if cfg.JWT.IsLocal() {
+		log.Info("auth mode: local")
+	} else {
+		log.Infof("auth info url: %s\n", cfg.JWT.AuthURL)
+	}
 	log.Infof("gateway info url: %s, token: %s\n", cfg.Gateway.Url, cfg.Gateway.Token)
 	log.Infof("rate limit info: redis: %s \n", cfg.RateLimit.Redis)
 	log.Infof("default timeout: %v, sign message timeout: %v, estimate message timeout: %v", cfg.MessageService.DefaultTimeout,
 		cfg.MessageService.SignMessageTimeout, cfg.MessageService.EstimateMessageTimeout)
 
-	remoteAuthCli, err := jwtclient.NewAuthClient(cfg.JWT.AuthURL, cfg.JWT.Token)
-	if err != nil {
-		return err
+	// in local mode, the users are stored in the database and verified by service.LocalAuthService
+	var remoteAuthCli *jwtclient.AuthClient
+	if !cfg.JWT.IsLocal() {
+		remoteAuthCli, err = jwtclient.NewAuthClient(cfg.JWT.AuthURL, cfg.JWT.Token)
+		if err != nil {
+			return err
+		}
 	}
 
 	localAuthCli, token, err := jwtclient.NewLocalAuthClient()
@@ -215,10 +250,17 @@ func runAction(cctx *cli.Context) error {
 	// The 2k network block delay is 4s, which will be less than WaitingChainHeadStableDuration (8s)
 	// and will not push messages
 	if networkParams.BlockDelaySecs <= uint64(cfg.MessageService.WaitingChainHeadStableDuration/time.Second) {
-		cfg.MessageService.WaitingChainHeadStableDuration = time.Duration(networkParams.BlockDelaySecs/2) * time.Second
-		if err := fsRepo.ReplaceConfig(cfg); err != nil {
+		cfg.MessageService.WaitingChainHeadStableDuration = config.WaitingChainHeadStableDurationOf(networkParams.BlockDelaySecs)
+		// only change the field in the config file, cfg has the values from environment variables which may be secrets
+		fileCfg, err := fsRepo.LoadConfig()
+		if err != nil {
+			return err
+		}
+		fileCfg.MessageService.WaitingChainHeadStableDuration = cfg.MessageService.WaitingChainHeadStableDuration
+		if err := fsRepo.ReplaceConfig(fileCfg); err != nil {
 			return err
 		}
+		fsRepo.SetConfig(cfg)
 	}
 
 	if err := ccli.LoadBuiltinActors(ctx, client); err != nil {
@@ -233,7 +275,7 @@ func runAction(cctx *cli.Context) error {
 		return err
 	}
 
-	walletCli, walletCliCloser, err := gateway.NewWalletClient(ctx, &cfg.Gateway)
+	walletCli, walletCliCloser, err := newWalletClient(ctx, cfg, fsRepo)
 	if err != nil {
 		return err
 	}
@@ -259,16 +301,33 @@ func runAction(cctx *cli.Context) error {
 			&cfg.Gateway, &cfg.RateLimit, cfg.Trace, cfg.Metrics, cfg.Publisher),
 		fx.Supply(networkParams.NetworkName),
 		fx.Supply(networkParams),
-		fx.Supply(remoteAuthCli),
 		fx.Supply(localAuthCli),
 		fx.Provide(func() gatewayAPI.IWalletClient {
 			return walletCli
 		}),
-		fx.Provide(func() jwtclient.IAuthClient {
-			return remoteAuthCli
+		fx.Provide(func(r repo.Repo) (jwtclient.IAuthClient, *service.LocalAuthService) {
+			if cfg.JWT.IsLocal() {
+				localAuth := service.NewLocalAuthService(r)
+				return localAuth, localAuth
+			}
+			return remoteAuthCli, nil
 		}),
-		fx.Provide(func() v1.FullNode {
-			return client
+		fx.Provide(func(lc fx.Lifecycle, r repo.Repo) (v1.FullNode, *nodepool.NodePool) {
+			if !cfg.Node.Pool.Enable {
+				return client, nil
+			}
+			pool := nodepool.NewNodePool(&cfg.Node, client, r.NodeRepo())
+			lc.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					pool.Start(ctx)
+					return nil
+				},
+				OnStop: func(context.Context) error {
+					pool.Close()
+					return nil
+				},
+			})
+			return pool.FullNode(), pool
 		}),
 		fx.Provide(func() filestore.FSRepo {
 			return fsRepo
@@ -293,6 +352,8 @@ func runAction(cctx *cli.Context) error {
 	invoker := fx.Options(
 		// invoke
 		fx.Invoke(service.StartNodeEvents),
+		fx.Invoke(service.StartConfigReloader),
+		fx.Invoke(service.StartMpoolReconciler),
 		fx.Invoke(metrics.SetupJaeger),
 		fx.Invoke(metrics.SetupMetrics),
 	)
@@ -351,6 +412,10 @@ func updateFlag(cfg *config.Config, ctx *cli.Context) error {
 		cfg.MessageService.SkipProcessHead = true
 	}
 
+	if ctx.IsSet("auth-mode") {
+		cfg.JWT.Mode = ctx.String("auth-mode")
+	}
+
 	if ctx.IsSet("auth-url") {
 		cfg.JWT.AuthURL = ctx.String("auth-url")
 	}
@@ -437,3 +502,35 @@ func setEip155ChainID(networkName string) error {
 
 	return nil
 }
+
+// newWalletClient create the signer of messages, the local keystore is unlocked by the passphrase
+func newWalletClient(ctx context.Context, cfg *config.Config, fsRepo filestore.FSRepo) (gatewayAPI.IWalletClient, func(), error) {
+	switch cfg.Signer.Type {
+	case config.SignerLocal:
+	case config.SignerRemote:
+		signer, err := gateway.NewRemoteSigner(&cfg.Signer.Remote)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Infof("sign messages with remote signer %s", cfg.Signer.Remote.URL)
+		return signer, signer.Close, nil
+	default:
+		return gateway.NewWalletClient(ctx, &cfg.Gateway)
+	}
+
+	passphrase, err := wallet.ReadPassphrase(os.Stdin, os.Stdout)
+	if err != nil {
+		return nil, nil, err
+	}
+	ks, err := wallet.OpenKeystore(wallet.KeystoreDir(fsRepo.Path(), &cfg.Signer), passphrase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open keystore failed: %w", err)
+	}
+	w, err := wallet.NewLocalWallet(ks)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Infof("sign messages with local keystore")
+
+	return w, func() {}, nil
+}
@@ -12,10 +12,12 @@ import (
 // Global Tags
 var (
 	WalletAddress, _ = tag.NewKey("wallet")
+	Gateway, _       = tag.NewKey("gateway")
 )
 
 // Distribution
 var defaultSecondsDistribution = view.Distribution(8, 9, 10, 12, 14, 16, 18, 20, 25, 30, 60)
+var defaultMillisecondsDistribution = view.Distribution(10, 25, 50, 100, 200, 500, 1000, 2000, 5000, 10000, 30000)
 
 var (
 	WalletBalance    = stats.Float64("wallet_balance", "Wallet balance", stats.UnitDimensionless)
@@ -30,6 +32,12 @@ var (
 	NumOfMsgBlockedFiveMinutes  = stats.Int64("blocked_five_minutes_msgs", "Number of messages blocked for more than 5 minutes", stats.UnitDimensionless)
 	ChainHeadStableDelay        = stats.Int64("chain_head_stable_s", "Delay of chain head stabilization", stats.UnitSeconds)
 	ChainHeadStableDuration     = stats.Int64("chain_head_stable_dur_s", "Duration of chain head stabilization", stats.UnitSeconds)
+
+	SignLatency       = stats.Float64("sign_latency_ms", "Latency of signing a message by gateway", stats.UnitMilliseconds)
+	SignFailure       = stats.Int64("sign_failure", "Number of failed signing by gateway", stats.UnitDimensionless)
+	SignerCircuitOpen = stats.Int64("signer_circuit_open", "Whether the circuit of gateway for the address is open", stats.UnitDimensionless)
+	SignerUnreachable = stats.Int64("signer_unreachable_s", "Duration since no signer can be reached for the address", stats.UnitSeconds)
+	SignInvalid       = stats.Int64("sign_invalid", "Number of invalid signatures returned by signer", stats.UnitDimensionless)
 )
 
 var (
@@ -91,6 +99,32 @@ var (
 		Measure:     ChainHeadStableDuration,
 		Aggregation: defaultSecondsDistribution,
 	}
+
+	SignLatencyView = &view.View{
+		Measure:     SignLatency,
+		Aggregation: defaultMillisecondsDistribution,
+		TagKeys:     []tag.Key{WalletAddress, Gateway},
+	}
+	SignFailureView = &view.View{
+		Measure:     SignFailure,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{WalletAddress, Gateway},
+	}
+	SignerCircuitOpenView = &view.View{
+		Measure:     SignerCircuitOpen,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{WalletAddress, Gateway},
+	}
+	SignerUnreachableView = &view.View{
+		Measure:     SignerUnreachable,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{WalletAddress},
+	}
+	SignInvalidView = &view.View{
+		Measure:     SignInvalid,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{WalletAddress, Gateway},
+	}
 )
 
 var MessagerNodeViews = append([]*view.View{
@@ -107,4 +141,10 @@ var MessagerNodeViews = append([]*view.View{
 
 	ChainHeadStableDelayView,
 	ChainHeadStableDurationView,
+
+	SignLatencyView,
+	SignFailureView,
+	SignerCircuitOpenView,
+	SignerUnreachableView,
+	SignInvalidView,
 }, rpcMetrics.DefaultViews...)
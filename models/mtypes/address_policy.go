@@ -0,0 +1,114 @@
+package mtypes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+
+	shared "github.com/filecoin-project/venus/venus-shared/types"
+)
+
+type PolicyAction int
+
+const (
+	_ PolicyAction = iota
+	PolicyAllow
+	PolicyDeny
+)
+
+func (pa PolicyAction) String() string {
+	switch pa {
+	case PolicyAllow:
+		return "allow"
+	case PolicyDeny:
+		return "deny"
+	default:
+		return fmt.Sprintf("unknown action %d", pa)
+	}
+}
+
+func ParsePolicyAction(s string) (PolicyAction, error) {
+	switch strings.ToLower(s) {
+	case "allow":
+		return PolicyAllow, nil
+	case "deny":
+		return PolicyDeny, nil
+	default:
+		return 0, fmt.Errorf("unknown policy action %s, want allow or deny", s)
+	}
+}
+
+// AddressPolicy restrict which destination and methods a sending address may target.
+// An empty To, an undefined ActorCode or an empty Methods matches anything.
+type AddressPolicy struct {
+	ID        shared.UUID     `json:"id"`
+	From      address.Address `json:"from"`
+	Action    PolicyAction    `json:"action"`
+	To        address.Address `json:"to"`
+	ActorCode cid.Cid         `json:"actorCode"`
+	Methods   []abi.MethodNum `json:"methods"`
+
+	CreatedAt time.Time `json:"createAt"`
+	UpdatedAt time.Time `json:"updateAt"`
+}
+
+// Match check whether the rule applies to a message, `to` contains all known forms of the destination
+// address and `code` is the actor code of the destination, cid.Undef if the actor does not exist yet.
+func (p *AddressPolicy) Match(to []address.Address, code cid.Cid, method abi.MethodNum) bool {
+	if !p.To.Empty() {
+		found := false
+		for _, addr := range to {
+			if addr == p.To {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if p.ActorCode.Defined() && !p.ActorCode.Equals(code) {
+		return false
+	}
+	if len(p.Methods) == 0 {
+		return true
+	}
+	for _, m := range p.Methods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FormatMethods encode methods as a comma separated string, used to persist AddressPolicy.Methods.
+func FormatMethods(methods []abi.MethodNum) string {
+	strs := make([]string, 0, len(methods))
+	for _, m := range methods {
+		strs = append(strs, strconv.FormatUint(uint64(m), 10))
+	}
+	return strings.Join(strs, ",")
+}
+
+// ParseMethods decode the string produced by FormatMethods.
+func ParseMethods(s string) ([]abi.MethodNum, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	strs := strings.Split(s, ",")
+	methods := make([]abi.MethodNum, 0, len(strs))
+	for _, str := range strs {
+		m, err := strconv.ParseUint(strings.TrimSpace(str), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse method %s failed: %w", str, err)
+		}
+		methods = append(methods, abi.MethodNum(m))
+	}
+	return methods, nil
+}
@@ -0,0 +1,45 @@
+package mtypes
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressPolicyMatch(t *testing.T) {
+	idAddr, err := address.NewIDAddress(1001)
+	assert.NoError(t, err)
+	other, err := address.NewIDAddress(1002)
+	assert.NoError(t, err)
+	code, err := cid.Decode("bafk2bzacecqfcv3nqwoeyrmzksujgdpf2jyxyexzxacdr7zbwguxirykcqu5m")
+	assert.NoError(t, err)
+
+	p := &AddressPolicy{To: idAddr}
+	assert.True(t, p.Match([]address.Address{other, idAddr}, cid.Undef, builtin.MethodSend))
+	assert.False(t, p.Match([]address.Address{other}, cid.Undef, builtin.MethodSend))
+
+	p = &AddressPolicy{ActorCode: code, Methods: []abi.MethodNum{2, 6}}
+	assert.True(t, p.Match([]address.Address{other}, code, 6))
+	assert.False(t, p.Match([]address.Address{other}, code, 3))
+	assert.False(t, p.Match([]address.Address{other}, cid.Undef, 6))
+
+	assert.True(t, (&AddressPolicy{}).Match([]address.Address{other}, cid.Undef, 0))
+}
+
+func TestParseMethods(t *testing.T) {
+	methods, err := ParseMethods("2, 6,25")
+	assert.NoError(t, err)
+	assert.Equal(t, []abi.MethodNum{2, 6, 25}, methods)
+	assert.Equal(t, "2,6,25", FormatMethods(methods))
+
+	methods, err = ParseMethods("")
+	assert.NoError(t, err)
+	assert.Nil(t, methods)
+
+	_, err = ParseMethods("a")
+	assert.Error(t, err)
+}
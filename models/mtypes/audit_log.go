@@ -0,0 +1,31 @@
+package mtypes
+
+import (
+	"time"
+
+	shared "github.com/filecoin-project/venus/venus-shared/types"
+)
+
+// AuditLog record a call of an administrative api, it is append only.
+type AuditLog struct {
+	ID     shared.UUID `json:"id"`
+	Caller string      `json:"caller"`
+	Method string      `json:"method"`
+	// Args is the json encoded arguments of the call, excluding context
+	Args string `json:"args"`
+	// Result is the json encoded return value, empty if the method only return error
+	Result string `json:"result"`
+	Error  string `json:"error"`
+
+	CreatedAt time.Time `json:"createAt"`
+}
+
+// AuditLogQuery filter audit logs, zero value fields are ignored.
+type AuditLogQuery struct {
+	Caller string    `json:"caller"`
+	Method string    `json:"method"`
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until"`
+	// Limit the number of returned logs, newest first
+	Limit int `json:"limit"`
+}
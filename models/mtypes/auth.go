@@ -0,0 +1,29 @@
+package mtypes
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-address"
+)
+
+// AuthUser is a user of the local auth mode, which is used instead of sophon-auth, the token is generated when the
+// user is added, it's a JWT containing the name and permission of the user.
+type AuthUser struct {
+	Name string `json:"name"`
+	Perm string `json:"perm"`
+	// Token is only returned when the user is added, the database stores the hash of it
+	Token string `json:"token,omitempty"`
+	// TokenHash is the hex of the sha256 hash of the token, which the token is looked up by
+	TokenHash string `json:"-"`
+
+	CreatedAt time.Time `json:"createAt"`
+	UpdatedAt time.Time `json:"updateAt"`
+}
+
+// AuthSigner binds a signer to a user of the local auth mode, a signer can be bound to several users.
+type AuthSigner struct {
+	User   string          `json:"user"`
+	Signer address.Address `json:"signer"`
+
+	CreatedAt time.Time `json:"createAt"`
+}
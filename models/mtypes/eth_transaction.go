@@ -0,0 +1,41 @@
+package mtypes
+
+import (
+	"time"
+
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+)
+
+// EthTxParams is the params of PushEthTransaction, either Raw or From should be set.
+type EthTxParams struct {
+	// Raw is the signed rlp of an EIP-1559 transaction, the nonce should be the next nonce of the sender
+	Raw venusTypes.EthBytes `json:"raw,omitempty"`
+
+	// From is the sender of an unsigned transaction which is signed by messager, the nonce is assigned by messager,
+	// and the gas is estimated if it's zero like other messages
+	From *venusTypes.EthAddress `json:"from,omitempty"`
+	// To is nil to create a contract
+	To                   *venusTypes.EthAddress `json:"to,omitempty"`
+	Value                venusTypes.EthBigInt   `json:"value"`
+	Input                venusTypes.EthBytes    `json:"input,omitempty"`
+	Gas                  venusTypes.EthUint64   `json:"gas"`
+	MaxFeePerGas         venusTypes.EthBigInt   `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas venusTypes.EthBigInt   `json:"maxPriorityFeePerGas"`
+}
+
+// EthTxResult is the result of PushEthTransaction
+type EthTxResult struct {
+	// ID is the id of the message converted from the transaction
+	ID string `json:"id"`
+	// TxHash is the hash of the signed transaction
+	TxHash *venusTypes.EthHash `json:"txHash,omitempty"`
+}
+
+// EthTransaction record the eth tx hash of a message signed by a delegated address, a message has more than one hash
+// if it's signed again, eg. replaced with new gas.
+type EthTransaction struct {
+	TxHash venusTypes.EthHash `json:"txHash"`
+	MsgID  string             `json:"msgID"`
+
+	CreatedAt time.Time `json:"createAt"`
+}
@@ -0,0 +1,32 @@
+package mtypes
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+)
+
+// CancelledMsg is the terminal state of a message replaced by its cancellation, it extends the states of venus,
+// 6 is skipped because it was used by NoWalletMsg.
+const CancelledMsg types.MessageState = 7
+
+// MessageStateString returns the name of the state, including the states defined by messager
+func MessageStateString(state types.MessageState) string {
+	if state == CancelledMsg {
+		return "CancelledMsg"
+	}
+	return state.String()
+}
+
+// MessageCancel record the self-send message which replaces a message being cancelled on chain.
+type MessageCancel struct {
+	// MsgID is the id of the message being cancelled
+	MsgID string `json:"msgID"`
+	// ReplaceID is the id of the self-send message with the same nonce
+	ReplaceID string          `json:"replaceID"`
+	From      address.Address `json:"from"`
+	Nonce     uint64          `json:"nonce"`
+
+	CreatedAt time.Time `json:"createAt"`
+}
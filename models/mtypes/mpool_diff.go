@@ -0,0 +1,25 @@
+package mtypes
+
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-cid"
+)
+
+// MpoolDiff is the difference between the filled messages and the pending messages in the mpool of a node.
+type MpoolDiff struct {
+	Node string `json:"node"`
+	// Error is the reason why the mpool of node can't be compared
+	Error string `json:"error"`
+	// Missing are the filled messages which are not in the mpool
+	Missing []*MpoolMessage `json:"missing"`
+	// Foreign are the pending messages from local addresses which are not created by messager
+	Foreign []*MpoolMessage `json:"foreign"`
+}
+
+type MpoolMessage struct {
+	// ID is empty for foreign messages
+	ID    string          `json:"id"`
+	Cid   cid.Cid         `json:"cid"`
+	From  address.Address `json:"from"`
+	Nonce uint64          `json:"nonce"`
+}
@@ -0,0 +1,25 @@
+package mtypes
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// NodeStatus is the health of a node in the node pool.
+type NodeStatus struct {
+	Name   string         `json:"name"`
+	URL    string         `json:"url"`
+	Weight int            `json:"weight"`
+	Height abi.ChainEpoch `json:"height"`
+	// Latency is the moving average of the latency of health checks
+	Latency time.Duration `json:"latency"`
+	// ErrorRate is the moving average of the rate of failed calls
+	ErrorRate float64 `json:"errorRate"`
+	Healthy   bool    `json:"healthy"`
+	InSync    bool    `json:"inSync"`
+	// Selected is true for the node which the calls to chain are routed to
+	Selected  bool      `json:"selected"`
+	LastError string    `json:"lastError"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
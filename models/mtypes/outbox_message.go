@@ -0,0 +1,26 @@
+package mtypes
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/ipfs/go-cid"
+)
+
+// OutboxMessage is a signed message waiting to be published, it is removed after publishing successfully.
+type OutboxMessage struct {
+	// Cid is the cid of the signed message
+	Cid   cid.Cid              `json:"cid"`
+	From  address.Address      `json:"from"`
+	Nonce uint64               `json:"nonce"`
+	Msg   *types.SignedMessage `json:"msg"`
+
+	// Attempts is the number of failed publishing
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	LastError     string    `json:"lastError"`
+
+	CreatedAt time.Time `json:"createAt"`
+	UpdatedAt time.Time `json:"updateAt"`
+}
@@ -0,0 +1,20 @@
+package mtypes
+
+import (
+	"time"
+
+	"github.com/ipfs/go-cid"
+)
+
+// PublishReceipt record the result of the last publishing of a message to a node.
+type PublishReceipt struct {
+	// MsgCid is the cid of the signed message
+	MsgCid   cid.Cid `json:"msgCid"`
+	Node     string  `json:"node"`
+	Accepted bool    `json:"accepted"`
+	Error    string  `json:"error"`
+
+	// CreatedAt is the time of the first publishing, and UpdatedAt is the last
+	CreatedAt time.Time `json:"createAt"`
+	UpdatedAt time.Time `json:"updateAt"`
+}
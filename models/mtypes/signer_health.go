@@ -0,0 +1,35 @@
+package mtypes
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-address"
+)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+	// CircuitQuarantined is the state of a gateway which returned an invalid signature, it rejects signing
+	// until QuarantinedUntil
+	CircuitQuarantined = "quarantined"
+)
+
+// SignerHealth is the health of a gateway signing for an address.
+type SignerHealth struct {
+	Address address.Address `json:"address"`
+	Gateway string          `json:"gateway"`
+	// Has is whether the gateway had an online wallet for the address at the last check
+	Has bool `json:"has"`
+	// Latency is the moving average of the latency of signing
+	Latency time.Duration `json:"latency"`
+	// Failures is the number of consecutive failed calls
+	Failures int `json:"failures"`
+	// Circuit is closed, open, half-open or quarantined, an open circuit rejects signing until it is half-open
+	Circuit   string `json:"circuit"`
+	LastError string `json:"lastError"`
+	// QuarantinedUntil is the end of the quarantine, zero if never quarantined
+	QuarantinedUntil time.Time `json:"quarantinedUntil"`
+	// UnreachableSince is the time since when no signer can be reached for the address, zero if reachable
+	UnreachableSince time.Time `json:"unreachableSince"`
+}
@@ -0,0 +1,35 @@
+package mtypes
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// TopicStatus is the health of a pubsub topic and the publishing to it.
+type TopicStatus struct {
+	Topic string `json:"topic"`
+	// MeshPeers are the peers in the gossipsub mesh of the topic
+	MeshPeers []peer.ID `json:"meshPeers"`
+	// Peers are the peers subscribed to the topic with their gossipsub scores
+	Peers []*PeerScore `json:"peers"`
+
+	Published          uint64    `json:"published"`
+	PublishFailed      uint64    `json:"publishFailed"`
+	LastPublishAt      time.Time `json:"lastPublishAt"`
+	LastPublishError   string    `json:"lastPublishError"`
+	LastPublishErrorAt time.Time `json:"lastPublishErrorAt"`
+	// BootstrapReconnects is the number of reconnecting to bootstrap peers because the mesh is too small
+	BootstrapReconnects uint64 `json:"bootstrapReconnects"`
+}
+
+type PeerScore struct {
+	ID     peer.ID `json:"id"`
+	InMesh bool    `json:"inMesh"`
+	// Score is the gossipsub peer score, zero if it has not been calculated yet
+	Score float64 `json:"score"`
+	// the counters of the topic which the score is calculated from
+	TimeInMesh               time.Duration `json:"timeInMesh"`
+	FirstMessageDeliveries   float64       `json:"firstMessageDeliveries"`
+	InvalidMessageDeliveries float64       `json:"invalidMessageDeliveries"`
+}
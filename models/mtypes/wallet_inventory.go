@@ -0,0 +1,27 @@
+package mtypes
+
+import "github.com/filecoin-project/go-address"
+
+// WalletInventory compares the addresses in messager with the addresses signable by the online wallets.
+type WalletInventory struct {
+	// Signers are the wallets which currently serve the addresses, an address may be served by several wallets
+	Signers []*AddressSigner `json:"signers"`
+	// NoSigner are the addresses in messager which have no online signer
+	NoSigner []address.Address `json:"noSigner"`
+	// Unknown are the signable addresses which are not in messager
+	Unknown []address.Address `json:"unknown"`
+	// Errors are the reasons why the wallets of the gateways can't be listed, keyed by gateway
+	Errors map[string]string `json:"errors"`
+}
+
+type AddressSigner struct {
+	Address address.Address `json:"address"`
+	// Known is whether the address is in messager
+	Known   bool   `json:"known"`
+	Gateway string `json:"gateway"`
+	// Account is the account of the wallet in gateway
+	Account string `json:"account"`
+	// WalletIP is the ip of the wallet connecting to gateway
+	WalletIP  string `json:"walletIP"`
+	ChannelID string `json:"channelID"`
+}
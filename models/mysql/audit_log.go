@@ -0,0 +1,95 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	shared "github.com/filecoin-project/venus/venus-shared/types"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+type mysqlAuditLog struct {
+	ID     shared.UUID `gorm:"column:id;type:varchar(256);primary_key;"` // 主键
+	Caller string      `gorm:"column:caller;type:varchar(256);index;NOT NULL"`
+	Method string      `gorm:"column:method;type:varchar(256);index;NOT NULL"`
+	Args   string      `gorm:"column:args;type:text;"`
+	Result string      `gorm:"column:result;type:text;"`
+	Error  string      `gorm:"column:error;type:text;"`
+
+	CreatedAt time.Time `gorm:"column:created_at;index;NOT NULL"` // 创建时间
+}
+
+func fromAuditLog(log *mtypes.AuditLog) *mysqlAuditLog {
+	return &mysqlAuditLog{
+		ID:        log.ID,
+		Caller:    log.Caller,
+		Method:    log.Method,
+		Args:      log.Args,
+		Result:    log.Result,
+		Error:     log.Error,
+		CreatedAt: log.CreatedAt,
+	}
+}
+
+func (s mysqlAuditLog) AuditLog() *mtypes.AuditLog {
+	return &mtypes.AuditLog{
+		ID:        s.ID,
+		Caller:    s.Caller,
+		Method:    s.Method,
+		Args:      s.Args,
+		Result:    s.Result,
+		Error:     s.Error,
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+func (s mysqlAuditLog) TableName() string {
+	return "audit_logs"
+}
+
+var _ repo.AuditLogRepo = (*mysqlAuditLogRepo)(nil)
+
+type mysqlAuditLogRepo struct {
+	*gorm.DB
+}
+
+func newMysqlAuditLogRepo(db *gorm.DB) *mysqlAuditLogRepo {
+	return &mysqlAuditLogRepo{DB: db}
+}
+
+func (s *mysqlAuditLogRepo) CreateAuditLog(ctx context.Context, log *mtypes.AuditLog) error {
+	return s.DB.WithContext(ctx).Create(fromAuditLog(log)).Error
+}
+
+func (s *mysqlAuditLogRepo) ListAuditLog(ctx context.Context, query *mtypes.AuditLogQuery) ([]*mtypes.AuditLog, error) {
+	db := s.DB.WithContext(ctx)
+	if len(query.Caller) > 0 {
+		db = db.Where("caller = ?", query.Caller)
+	}
+	if len(query.Method) > 0 {
+		db = db.Where("method = ?", query.Method)
+	}
+	if !query.Since.IsZero() {
+		db = db.Where("created_at >= ?", query.Since)
+	}
+	if !query.Until.IsZero() {
+		db = db.Where("created_at < ?", query.Until)
+	}
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+
+	var list []*mysqlAuditLog
+	if err := db.Order("created_at desc").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*mtypes.AuditLog, 0, len(list))
+	for _, l := range list {
+		result = append(result, l.AuditLog())
+	}
+
+	return result, nil
+}
@@ -35,8 +35,36 @@ func (d Repo) NodeRepo() repo.NodeRepo {
 	return newMysqlNodeRepo(d.DB)
 }
 
+func (d Repo) AddressPolicyRepo() repo.AddressPolicyRepo {
+	return newMysqlAddressPolicyRepo(d.DB)
+}
+
+func (d Repo) AuditLogRepo() repo.AuditLogRepo {
+	return newMysqlAuditLogRepo(d.DB)
+}
+
+func (d Repo) PublishReceiptRepo() repo.PublishReceiptRepo {
+	return newMysqlPublishReceiptRepo(d.DB)
+}
+
+func (d Repo) OutboxRepo() repo.OutboxRepo {
+	return newMysqlOutboxRepo(d.DB)
+}
+
+func (d Repo) MessageCancelRepo() repo.MessageCancelRepo {
+	return newMysqlMessageCancelRepo(d.DB)
+}
+
+func (d Repo) EthTransactionRepo() repo.EthTransactionRepo {
+	return newMysqlEthTransactionRepo(d.DB)
+}
+
+func (d Repo) AuthRepo() repo.AuthRepo {
+	return newMysqlAuthRepo(d.DB)
+}
+
 func (d Repo) AutoMigrate() error {
-	return d.GetDb().AutoMigrate(mysqlActorCfg{}, mysqlMessage{}, mysqlAddress{}, mysqlSharedParams{}, mysqlNode{})
+	return d.GetDb().AutoMigrate(mysqlActorCfg{}, mysqlMessage{}, mysqlAddress{}, mysqlSharedParams{}, mysqlNode{}, mysqlAddressPolicy{}, mysqlAuditLog{}, mysqlPublishReceipt{}, mysqlOutboxMessage{}, mysqlMessageCancel{}, mysqlEthTransaction{}, mysqlAuthUser{}, mysqlAuthSigner{})
 }
 
 func (d Repo) GetDb() *gorm.DB {
@@ -44,9 +72,11 @@ func (d Repo) GetDb() *gorm.DB {
 }
 
 func (d Repo) DbClose() error {
-	// return d.DbClose()
-	// todo:
-	return nil
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
 
 func (d Repo) Transaction(cb func(txRepo repo.TxRepo) error) error {
@@ -74,6 +104,34 @@ func (t *TxMysqlRepo) NodeRepo() repo.NodeRepo {
 	return newMysqlNodeRepo(t.DB)
 }
 
+func (t *TxMysqlRepo) AddressPolicyRepo() repo.AddressPolicyRepo {
+	return newMysqlAddressPolicyRepo(t.DB)
+}
+
+func (t *TxMysqlRepo) AuditLogRepo() repo.AuditLogRepo {
+	return newMysqlAuditLogRepo(t.DB)
+}
+
+func (t *TxMysqlRepo) MessageCancelRepo() repo.MessageCancelRepo {
+	return newMysqlMessageCancelRepo(t.DB)
+}
+
+func (t *TxMysqlRepo) EthTransactionRepo() repo.EthTransactionRepo {
+	return newMysqlEthTransactionRepo(t.DB)
+}
+
+func (t *TxMysqlRepo) AuthRepo() repo.AuthRepo {
+	return newMysqlAuthRepo(t.DB)
+}
+
+func (t *TxMysqlRepo) OutboxRepo() repo.OutboxRepo {
+	return newMysqlOutboxRepo(t.DB)
+}
+
+func (t *TxMysqlRepo) PublishReceiptRepo() repo.PublishReceiptRepo {
+	return newMysqlPublishReceiptRepo(t.DB)
+}
+
 func (t *TxMysqlRepo) MessageRepo() repo.MessageRepo {
 	return newMysqlMessageRepo(t.DB)
 }
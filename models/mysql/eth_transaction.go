@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+type mysqlEthTransaction struct {
+	TxHash string `gorm:"column:tx_hash;type:varchar(128);primary_key;"`
+	MsgID  string `gorm:"column:msg_id;type:varchar(256);index:idx_eth_tx_msg_id;NOT NULL"`
+
+	CreatedAt time.Time `gorm:"column:created_at;NOT NULL"` // 创建时间
+}
+
+func fromEthTransaction(tx *mtypes.EthTransaction) *mysqlEthTransaction {
+	return &mysqlEthTransaction{
+		TxHash:    tx.TxHash.String(),
+		MsgID:     tx.MsgID,
+		CreatedAt: tx.CreatedAt,
+	}
+}
+
+func (s mysqlEthTransaction) EthTransaction() (*mtypes.EthTransaction, error) {
+	txHash, err := venusTypes.ParseEthHash(s.TxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mtypes.EthTransaction{
+		TxHash:    txHash,
+		MsgID:     s.MsgID,
+		CreatedAt: s.CreatedAt,
+	}, nil
+}
+
+func (s mysqlEthTransaction) TableName() string {
+	return "eth_transactions"
+}
+
+var _ repo.EthTransactionRepo = (*mysqlEthTransactionRepo)(nil)
+
+type mysqlEthTransactionRepo struct {
+	*gorm.DB
+}
+
+func newMysqlEthTransactionRepo(db *gorm.DB) *mysqlEthTransactionRepo {
+	return &mysqlEthTransactionRepo{DB: db}
+}
+
+func (s *mysqlEthTransactionRepo) SaveEthTransaction(ctx context.Context, tx *mtypes.EthTransaction) error {
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(fromEthTransaction(tx)).Error
+}
+
+func (s *mysqlEthTransactionRepo) GetEthTransaction(ctx context.Context, txHash venusTypes.EthHash) (*mtypes.EthTransaction, error) {
+	var tx mysqlEthTransaction
+	if err := s.DB.WithContext(ctx).Take(&tx, "tx_hash = ?", txHash.String()).Error; err != nil {
+		return nil, err
+	}
+	return tx.EthTransaction()
+}
+
+func (s *mysqlEthTransactionRepo) GetEthTransactionByMsgID(ctx context.Context, msgID string) (*mtypes.EthTransaction, error) {
+	var tx mysqlEthTransaction
+	if err := s.DB.WithContext(ctx).Where("msg_id = ?", msgID).Order("created_at desc").First(&tx).Error; err != nil {
+		return nil, err
+	}
+	return tx.EthTransaction()
+}
@@ -0,0 +1,96 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+type mysqlMessageCancel struct {
+	MsgID     string `gorm:"column:msg_id;type:varchar(256);primary_key;"`
+	ReplaceID string `gorm:"column:replace_id;type:varchar(256);NOT NULL"`
+	From      string `gorm:"column:from_addr;type:varchar(256);uniqueIndex:idx_cancel_from_nonce;NOT NULL"`
+	Nonce     uint64 `gorm:"column:nonce;type:bigint unsigned;uniqueIndex:idx_cancel_from_nonce;NOT NULL"`
+
+	CreatedAt time.Time `gorm:"column:created_at;NOT NULL"` // 创建时间
+}
+
+func fromMessageCancel(cancel *mtypes.MessageCancel) *mysqlMessageCancel {
+	return &mysqlMessageCancel{
+		MsgID:     cancel.MsgID,
+		ReplaceID: cancel.ReplaceID,
+		From:      cancel.From.String(),
+		Nonce:     cancel.Nonce,
+		CreatedAt: cancel.CreatedAt,
+	}
+}
+
+func (s mysqlMessageCancel) MessageCancel() (*mtypes.MessageCancel, error) {
+	from, err := address.NewFromString(s.From)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mtypes.MessageCancel{
+		MsgID:     s.MsgID,
+		ReplaceID: s.ReplaceID,
+		From:      from,
+		Nonce:     s.Nonce,
+		CreatedAt: s.CreatedAt,
+	}, nil
+}
+
+func (s mysqlMessageCancel) TableName() string {
+	return "message_cancels"
+}
+
+var _ repo.MessageCancelRepo = (*mysqlMessageCancelRepo)(nil)
+
+type mysqlMessageCancelRepo struct {
+	*gorm.DB
+}
+
+func newMysqlMessageCancelRepo(db *gorm.DB) *mysqlMessageCancelRepo {
+	return &mysqlMessageCancelRepo{DB: db}
+}
+
+func (s *mysqlMessageCancelRepo) SaveMessageCancel(ctx context.Context, cancel *mtypes.MessageCancel) error {
+	return s.DB.WithContext(ctx).Create(fromMessageCancel(cancel)).Error
+}
+
+func (s *mysqlMessageCancelRepo) GetMessageCancel(ctx context.Context, msgID string) (*mtypes.MessageCancel, error) {
+	var cancel mysqlMessageCancel
+	if err := s.DB.WithContext(ctx).Take(&cancel, "msg_id = ?", msgID).Error; err != nil {
+		return nil, err
+	}
+	return cancel.MessageCancel()
+}
+
+func (s *mysqlMessageCancelRepo) GetMessageCancelByNonce(ctx context.Context, from address.Address, nonce uint64) (*mtypes.MessageCancel, error) {
+	var cancel mysqlMessageCancel
+	if err := s.DB.WithContext(ctx).Take(&cancel, "from_addr = ? and nonce = ?", from.String(), nonce).Error; err != nil {
+		return nil, err
+	}
+	return cancel.MessageCancel()
+}
+
+func (s *mysqlMessageCancelRepo) ListMessageCancelByAddress(ctx context.Context, from address.Address, fromNonce uint64) ([]*mtypes.MessageCancel, error) {
+	var list []*mysqlMessageCancel
+	if err := s.DB.WithContext(ctx).Find(&list, "from_addr = ? and nonce >= ?", from.String(), fromNonce).Error; err != nil {
+		return nil, err
+	}
+	res := make([]*mtypes.MessageCancel, 0, len(list))
+	for _, cancel := range list {
+		c, err := cancel.MessageCancel()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
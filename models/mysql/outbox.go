@@ -0,0 +1,155 @@
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/ipfs/go-cid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+type mysqlOutboxMessage struct {
+	Cid   string `gorm:"column:cid;type:varchar(256);primary_key;"`
+	From  string `gorm:"column:from_addr;type:varchar(256);NOT NULL;index:idx_outbox_from_nonce"`
+	Nonce uint64 `gorm:"column:nonce;type:bigint unsigned;NOT NULL;index:idx_outbox_from_nonce"`
+	Msg   []byte `gorm:"column:msg;type:mediumblob;NOT NULL"`
+
+	Attempts      int       `gorm:"column:attempts;NOT NULL;default:0"`
+	NextAttemptAt time.Time `gorm:"column:next_attempt_at;NOT NULL;index"`
+	LastError     string    `gorm:"column:last_error;type:text;"`
+
+	CreatedAt time.Time `gorm:"column:created_at;NOT NULL"` // 创建时间
+	UpdatedAt time.Time `gorm:"column:updated_at;NOT NULL"` // 更新时间
+}
+
+func fromOutboxMessage(msg *mtypes.OutboxMessage) (*mysqlOutboxMessage, error) {
+	msgBytes, err := msg.Msg.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	return &mysqlOutboxMessage{
+		Cid:           msg.Cid.String(),
+		From:          msg.From.String(),
+		Nonce:         msg.Nonce,
+		Msg:           msgBytes,
+		Attempts:      msg.Attempts,
+		NextAttemptAt: msg.NextAttemptAt,
+		LastError:     msg.LastError,
+		CreatedAt:     msg.CreatedAt,
+		UpdatedAt:     msg.UpdatedAt,
+	}, nil
+}
+
+func (s mysqlOutboxMessage) OutboxMessage() (*mtypes.OutboxMessage, error) {
+	msgCid, err := cid.Decode(s.Cid)
+	if err != nil {
+		return nil, err
+	}
+	from, err := address.NewFromString(s.From)
+	if err != nil {
+		return nil, err
+	}
+	var msg venusTypes.SignedMessage
+	if err := msg.UnmarshalCBOR(bytes.NewReader(s.Msg)); err != nil {
+		return nil, err
+	}
+
+	return &mtypes.OutboxMessage{
+		Cid:           msgCid,
+		From:          from,
+		Nonce:         s.Nonce,
+		Msg:           &msg,
+		Attempts:      s.Attempts,
+		NextAttemptAt: s.NextAttemptAt,
+		LastError:     s.LastError,
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+	}, nil
+}
+
+func (s mysqlOutboxMessage) TableName() string {
+	return "outbox_messages"
+}
+
+var _ repo.OutboxRepo = (*mysqlOutboxRepo)(nil)
+
+type mysqlOutboxRepo struct {
+	*gorm.DB
+}
+
+func newMysqlOutboxRepo(db *gorm.DB) *mysqlOutboxRepo {
+	return &mysqlOutboxRepo{DB: db}
+}
+
+func (s *mysqlOutboxRepo) SaveOutboxMessages(ctx context.Context, msgs []*mtypes.OutboxMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	list := make([]*mysqlOutboxMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		m, err := fromOutboxMessage(msg)
+		if err != nil {
+			return err
+		}
+		list = append(list, m)
+	}
+
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"next_attempt_at", "updated_at"}),
+	}).Create(&list).Error
+}
+
+func (s *mysqlOutboxRepo) ListDueOutboxMessages(ctx context.Context, now time.Time, limit int) ([]*mtypes.OutboxMessage, error) {
+	var list []*mysqlOutboxMessage
+	if err := s.DB.WithContext(ctx).Where("next_attempt_at <= ?", now).
+		Order("next_attempt_at").Limit(limit).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*mtypes.OutboxMessage, 0, len(list))
+	for _, m := range list {
+		msg, err := m.OutboxMessage()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, msg)
+	}
+
+	return result, nil
+}
+
+func (s *mysqlOutboxRepo) UpdateOutboxAttempt(ctx context.Context, msgCid cid.Cid, attempts int, nextAttemptAt time.Time, lastErr string) error {
+	return s.DB.WithContext(ctx).Model(&mysqlOutboxMessage{}).Where("cid = ?", msgCid.String()).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastErr,
+			"updated_at":      time.Now(),
+		}).Error
+}
+
+func (s *mysqlOutboxRepo) DeleteOutboxMessages(ctx context.Context, msgCids []cid.Cid) error {
+	if len(msgCids) == 0 {
+		return nil
+	}
+	cids := make([]string, 0, len(msgCids))
+	for _, c := range msgCids {
+		cids = append(cids, c.String())
+	}
+	return s.DB.WithContext(ctx).Where("cid in ?", cids).Delete(&mysqlOutboxMessage{}).Error
+}
+
+func (s *mysqlOutboxRepo) CountOutboxMessages(ctx context.Context) (int64, error) {
+	var count int64
+	if err := s.DB.WithContext(ctx).Model(&mysqlOutboxMessage{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,101 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+type mysqlPublishReceipt struct {
+	MsgCid   string `gorm:"column:msg_cid;type:varchar(256);primary_key;"`
+	Node     string `gorm:"column:node;type:varchar(256);primary_key;"`
+	Accepted bool   `gorm:"column:accepted;NOT NULL"`
+	Error    string `gorm:"column:error;type:text;"`
+
+	CreatedAt time.Time `gorm:"column:created_at;NOT NULL"` // 创建时间
+	UpdatedAt time.Time `gorm:"column:updated_at;NOT NULL"` // 更新时间
+}
+
+func fromPublishReceipt(receipt *mtypes.PublishReceipt) *mysqlPublishReceipt {
+	return &mysqlPublishReceipt{
+		MsgCid:    receipt.MsgCid.String(),
+		Node:      receipt.Node,
+		Accepted:  receipt.Accepted,
+		Error:     receipt.Error,
+		CreatedAt: receipt.CreatedAt,
+		UpdatedAt: receipt.UpdatedAt,
+	}
+}
+
+func (s mysqlPublishReceipt) PublishReceipt() (*mtypes.PublishReceipt, error) {
+	msgCid, err := cid.Decode(s.MsgCid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mtypes.PublishReceipt{
+		MsgCid:    msgCid,
+		Node:      s.Node,
+		Accepted:  s.Accepted,
+		Error:     s.Error,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}, nil
+}
+
+func (s mysqlPublishReceipt) TableName() string {
+	return "publish_receipts"
+}
+
+var _ repo.PublishReceiptRepo = (*mysqlPublishReceiptRepo)(nil)
+
+type mysqlPublishReceiptRepo struct {
+	*gorm.DB
+}
+
+func newMysqlPublishReceiptRepo(db *gorm.DB) *mysqlPublishReceiptRepo {
+	return &mysqlPublishReceiptRepo{DB: db}
+}
+
+func (s *mysqlPublishReceiptRepo) SavePublishReceipts(ctx context.Context, receipts []*mtypes.PublishReceipt) error {
+	if len(receipts) == 0 {
+		return nil
+	}
+	list := make([]*mysqlPublishReceipt, 0, len(receipts))
+	for _, r := range receipts {
+		list = append(list, fromPublishReceipt(r))
+	}
+
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "msg_cid"}, {Name: "node"}},
+		DoUpdates: clause.AssignmentColumns([]string{"accepted", "error", "updated_at"}),
+	}).Create(&list).Error
+}
+
+func (s *mysqlPublishReceiptRepo) ListPublishReceipts(ctx context.Context, msgCid cid.Cid) ([]*mtypes.PublishReceipt, error) {
+	var list []*mysqlPublishReceipt
+	if err := s.DB.WithContext(ctx).Order("node").Find(&list, "msg_cid = ?", msgCid.String()).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*mtypes.PublishReceipt, 0, len(list))
+	for _, r := range list {
+		receipt, err := r.PublishReceipt()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, receipt)
+	}
+
+	return result, nil
+}
+
+func (s *mysqlPublishReceiptRepo) DeletePublishReceiptsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := s.DB.WithContext(ctx).Where("updated_at < ?", before).Delete(&mysqlPublishReceipt{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,18 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+	shared "github.com/filecoin-project/venus/venus-shared/types"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+type AddressPolicyRepo interface {
+	SaveAddressPolicy(ctx context.Context, policy *mtypes.AddressPolicy) error
+	GetAddressPolicy(ctx context.Context, id shared.UUID) (*mtypes.AddressPolicy, error)
+	ListAddressPolicy(ctx context.Context) ([]*mtypes.AddressPolicy, error)
+	ListAddressPolicyByFrom(ctx context.Context, from address.Address) ([]*mtypes.AddressPolicy, error)
+	DelAddressPolicy(ctx context.Context, id shared.UUID) error
+}
@@ -0,0 +1,12 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+type AuditLogRepo interface {
+	CreateAuditLog(ctx context.Context, log *mtypes.AuditLog) error
+	ListAuditLog(ctx context.Context, query *mtypes.AuditLogQuery) ([]*mtypes.AuditLog, error)
+}
@@ -0,0 +1,24 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+// AuthRepo stores the users and their signers of the local auth mode
+type AuthRepo interface {
+	// CreateAuthUser returns an error if the user exists
+	CreateAuthUser(ctx context.Context, user *mtypes.AuthUser) error
+	GetAuthUser(ctx context.Context, name string) (*mtypes.AuthUser, error)
+	GetAuthUserByTokenHash(ctx context.Context, tokenHash string) (*mtypes.AuthUser, error)
+	ListAuthUser(ctx context.Context) ([]*mtypes.AuthUser, error)
+
+	// BindAuthSigner ignores a signer bound already
+	BindAuthSigner(ctx context.Context, signer *mtypes.AuthSigner) error
+	UnbindAuthSigner(ctx context.Context, user string, signer address.Address) error
+	ListAuthSignerByUser(ctx context.Context, user string) ([]*mtypes.AuthSigner, error)
+	ListAuthSignerBySigner(ctx context.Context, signer address.Address) ([]*mtypes.AuthSigner, error)
+}
@@ -0,0 +1,17 @@
+package repo
+
+import (
+	"context"
+
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+type EthTransactionRepo interface {
+	// SaveEthTransaction save the tx hash of message, saving a saved hash again is ignored
+	SaveEthTransaction(ctx context.Context, tx *mtypes.EthTransaction) error
+	GetEthTransaction(ctx context.Context, txHash venusTypes.EthHash) (*mtypes.EthTransaction, error)
+	// GetEthTransactionByMsgID returns the latest tx hash of the message
+	GetEthTransactionByMsgID(ctx context.Context, msgID string) (*mtypes.EthTransaction, error)
+}
@@ -0,0 +1,17 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+type MessageCancelRepo interface {
+	SaveMessageCancel(ctx context.Context, cancel *mtypes.MessageCancel) error
+	GetMessageCancel(ctx context.Context, msgID string) (*mtypes.MessageCancel, error)
+	GetMessageCancelByNonce(ctx context.Context, from address.Address, nonce uint64) (*mtypes.MessageCancel, error)
+	// ListMessageCancelByAddress returns the cancellations of from whose nonce is not less than fromNonce
+	ListMessageCancelByAddress(ctx context.Context, from address.Address, fromNonce uint64) ([]*mtypes.MessageCancel, error)
+}
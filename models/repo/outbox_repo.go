@@ -0,0 +1,21 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+type OutboxRepo interface {
+	// SaveOutboxMessages insert messages into outbox, the existing ones are due at next_attempt_at of the new ones
+	SaveOutboxMessages(ctx context.Context, msgs []*mtypes.OutboxMessage) error
+	// ListDueOutboxMessages returns at most limit messages whose next attempt is not after now, order by next attempt
+	ListDueOutboxMessages(ctx context.Context, now time.Time, limit int) ([]*mtypes.OutboxMessage, error)
+	// UpdateOutboxAttempt record a failed publishing
+	UpdateOutboxAttempt(ctx context.Context, msgCid cid.Cid, attempts int, nextAttemptAt time.Time, lastErr string) error
+	DeleteOutboxMessages(ctx context.Context, msgCids []cid.Cid) error
+	CountOutboxMessages(ctx context.Context) (int64, error)
+}
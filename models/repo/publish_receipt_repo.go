@@ -0,0 +1,18 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+type PublishReceiptRepo interface {
+	// SavePublishReceipts insert receipts or update the result of existing ones, keyed by message cid and node
+	SavePublishReceipts(ctx context.Context, receipts []*mtypes.PublishReceipt) error
+	ListPublishReceipts(ctx context.Context, msgCid cid.Cid) ([]*mtypes.PublishReceipt, error)
+	// DeletePublishReceiptsBefore delete the receipts not updated since before, returns the number of deleted ones
+	DeletePublishReceiptsBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -31,6 +31,13 @@ type TxRepo interface {
 	AddressRepo() AddressRepo
 	SharedParamsRepo() SharedParamsRepo
 	NodeRepo() NodeRepo
+	AddressPolicyRepo() AddressPolicyRepo
+	AuditLogRepo() AuditLogRepo
+	PublishReceiptRepo() PublishReceiptRepo
+	OutboxRepo() OutboxRepo
+	MessageCancelRepo() MessageCancelRepo
+	EthTransactionRepo() EthTransactionRepo
+	AuthRepo() AuthRepo
 }
 
 type ISqlField interface {
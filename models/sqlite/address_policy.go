@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	shared "github.com/filecoin-project/venus/venus-shared/types"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+type sqliteAddressPolicy struct {
+	ID        shared.UUID         `gorm:"column:id;type:varchar(256);primary_key;"` // 主键
+	From      string              `gorm:"column:from_addr;type:varchar(256);index;NOT NULL"`
+	Action    mtypes.PolicyAction `gorm:"column:action;type:int;NOT NULL"`
+	To        string              `gorm:"column:to_addr;type:varchar(256);NOT NULL;default:''"`
+	ActorCode mtypes.DBCid        `gorm:"column:actor_code;type:varchar(256);NOT NULL;default:''"`
+	Methods   string              `gorm:"column:methods;type:varchar(1024);NOT NULL;default:''"`
+
+	CreatedAt time.Time `gorm:"column:created_at;index;NOT NULL"` // 创建时间
+	UpdatedAt time.Time `gorm:"column:updated_at;index;NOT NULL"` // 更新时间
+}
+
+func fromAddressPolicy(policy *mtypes.AddressPolicy) *sqliteAddressPolicy {
+	sp := &sqliteAddressPolicy{
+		ID:        policy.ID,
+		From:      policy.From.String(),
+		Action:    policy.Action,
+		ActorCode: mtypes.NewDBCid(policy.ActorCode),
+		Methods:   mtypes.FormatMethods(policy.Methods),
+		CreatedAt: policy.CreatedAt,
+		UpdatedAt: policy.UpdatedAt,
+	}
+	if !policy.To.Empty() {
+		sp.To = policy.To.String()
+	}
+
+	return sp
+}
+
+func (s sqliteAddressPolicy) AddressPolicy() (*mtypes.AddressPolicy, error) {
+	from, err := address.NewFromString(s.From)
+	if err != nil {
+		return nil, err
+	}
+	to := address.Undef
+	if len(s.To) > 0 {
+		if to, err = address.NewFromString(s.To); err != nil {
+			return nil, err
+		}
+	}
+	methods, err := mtypes.ParseMethods(s.Methods)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mtypes.AddressPolicy{
+		ID:        s.ID,
+		From:      from,
+		Action:    s.Action,
+		To:        to,
+		ActorCode: s.ActorCode.Cid(),
+		Methods:   methods,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}, nil
+}
+
+func (s sqliteAddressPolicy) TableName() string {
+	return "address_policies"
+}
+
+var _ repo.AddressPolicyRepo = (*sqliteAddressPolicyRepo)(nil)
+
+type sqliteAddressPolicyRepo struct {
+	*gorm.DB
+}
+
+func newSqliteAddressPolicyRepo(db *gorm.DB) *sqliteAddressPolicyRepo {
+	return &sqliteAddressPolicyRepo{DB: db}
+}
+
+func (s *sqliteAddressPolicyRepo) SaveAddressPolicy(ctx context.Context, policy *mtypes.AddressPolicy) error {
+	return s.DB.WithContext(ctx).Save(fromAddressPolicy(policy)).Error
+}
+
+func (s *sqliteAddressPolicyRepo) GetAddressPolicy(ctx context.Context, id shared.UUID) (*mtypes.AddressPolicy, error) {
+	var p sqliteAddressPolicy
+	if err := s.DB.WithContext(ctx).Take(&p, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+
+	return p.AddressPolicy()
+}
+
+func (s *sqliteAddressPolicyRepo) ListAddressPolicy(ctx context.Context) ([]*mtypes.AddressPolicy, error) {
+	var list []*sqliteAddressPolicy
+	if err := s.DB.WithContext(ctx).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	return toAddressPolicies(list)
+}
+
+func (s *sqliteAddressPolicyRepo) ListAddressPolicyByFrom(ctx context.Context, from address.Address) ([]*mtypes.AddressPolicy, error) {
+	var list []*sqliteAddressPolicy
+	if err := s.DB.WithContext(ctx).Order("created_at").Find(&list, "from_addr = ?", from.String()).Error; err != nil {
+		return nil, err
+	}
+
+	return toAddressPolicies(list)
+}
+
+func (s *sqliteAddressPolicyRepo) DelAddressPolicy(ctx context.Context, id shared.UUID) error {
+	return s.DB.WithContext(ctx).Delete(sqliteAddressPolicy{}, "id = ?", id).Error
+}
+
+func toAddressPolicies(list []*sqliteAddressPolicy) ([]*mtypes.AddressPolicy, error) {
+	result := make([]*mtypes.AddressPolicy, 0, len(list))
+	for _, r := range list {
+		p, err := r.AddressPolicy()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+
+	return result, nil
+}
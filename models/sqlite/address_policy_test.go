@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	venustypes "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestAddressPolicy(t *testing.T) {
+	ctx := context.Background()
+	policyRepo := setupRepo(t).AddressPolicyRepo()
+	addrs := testhelper.RandAddresses(t, 3)
+
+	p1 := &mtypes.AddressPolicy{
+		ID:        venustypes.NewUUID(),
+		From:      addrs[0],
+		Action:    mtypes.PolicyAllow,
+		To:        addrs[2],
+		Methods:   []abi.MethodNum{2, 6},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	p2 := &mtypes.AddressPolicy{
+		ID:        venustypes.NewUUID(),
+		From:      addrs[0],
+		Action:    mtypes.PolicyDeny,
+		CreatedAt: time.Now().Add(time.Second),
+		UpdatedAt: time.Now(),
+	}
+	p3 := &mtypes.AddressPolicy{
+		ID:        venustypes.NewUUID(),
+		From:      addrs[1],
+		Action:    mtypes.PolicyDeny,
+		Methods:   []abi.MethodNum{0},
+		CreatedAt: time.Now().Add(2 * time.Second),
+		UpdatedAt: time.Now(),
+	}
+	for _, p := range []*mtypes.AddressPolicy{p1, p2, p3} {
+		assert.NoError(t, policyRepo.SaveAddressPolicy(ctx, p))
+	}
+
+	res, err := policyRepo.GetAddressPolicy(ctx, p1.ID)
+	assert.NoError(t, err)
+	testhelper.Equal(t, p1, res)
+
+	list, err := policyRepo.ListAddressPolicy(ctx)
+	assert.NoError(t, err)
+	testhelper.Equal(t, []*mtypes.AddressPolicy{p1, p2, p3}, list)
+
+	list, err = policyRepo.ListAddressPolicyByFrom(ctx, addrs[0])
+	assert.NoError(t, err)
+	testhelper.Equal(t, []*mtypes.AddressPolicy{p1, p2}, list)
+
+	p2.Methods = []abi.MethodNum{3}
+	p2.UpdatedAt = time.Now()
+	assert.NoError(t, policyRepo.SaveAddressPolicy(ctx, p2))
+	res, err = policyRepo.GetAddressPolicy(ctx, p2.ID)
+	assert.NoError(t, err)
+	testhelper.Equal(t, p2, res)
+
+	assert.NoError(t, policyRepo.DelAddressPolicy(ctx, p1.ID))
+	_, err = policyRepo.GetAddressPolicy(ctx, p1.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	list, err = policyRepo.ListAddressPolicyByFrom(ctx, addrs[0])
+	assert.NoError(t, err)
+	testhelper.Equal(t, []*mtypes.AddressPolicy{p2}, list)
+}
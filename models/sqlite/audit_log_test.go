@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	venustypes "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	auditRepo := setupRepo(t).AuditLogRepo()
+
+	now := time.Now()
+	newLog := func(caller, method string, createdAt time.Time) *mtypes.AuditLog {
+		return &mtypes.AuditLog{
+			ID:        venustypes.NewUUID(),
+			Caller:    caller,
+			Method:    method,
+			Args:      `["f01000"]`,
+			CreatedAt: createdAt,
+		}
+	}
+	l1 := newLog("admin", "UpdateNonce", now.Add(-2*time.Hour))
+	l2 := newLog("admin", "ForbiddenAddress", now.Add(-time.Hour))
+	l3 := newLog("user", "UpdateNonce", now)
+	l3.Error = "address not found"
+	for _, l := range []*mtypes.AuditLog{l1, l2, l3} {
+		assert.NoError(t, auditRepo.CreateAuditLog(ctx, l))
+	}
+	// append only
+	assert.Error(t, auditRepo.CreateAuditLog(ctx, l1))
+
+	list, err := auditRepo.ListAuditLog(ctx, &mtypes.AuditLogQuery{})
+	assert.NoError(t, err)
+	testhelper.Equal(t, []*mtypes.AuditLog{l3, l2, l1}, list)
+
+	list, err = auditRepo.ListAuditLog(ctx, &mtypes.AuditLogQuery{Caller: "admin"})
+	assert.NoError(t, err)
+	testhelper.Equal(t, []*mtypes.AuditLog{l2, l1}, list)
+
+	list, err = auditRepo.ListAuditLog(ctx, &mtypes.AuditLogQuery{Method: "UpdateNonce", Limit: 1})
+	assert.NoError(t, err)
+	testhelper.Equal(t, []*mtypes.AuditLog{l3}, list)
+
+	list, err = auditRepo.ListAuditLog(ctx, &mtypes.AuditLogQuery{Since: now.Add(-90 * time.Minute), Until: now})
+	assert.NoError(t, err)
+	testhelper.Equal(t, []*mtypes.AuditLog{l2}, list)
+}
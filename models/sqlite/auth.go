@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+type sqliteAuthUser struct {
+	Name      string `gorm:"column:name;type:varchar(256);primary_key;"`
+	Perm      string `gorm:"column:perm;type:varchar(32);NOT NULL"`
+	TokenHash string `gorm:"column:token_hash;type:varchar(64);uniqueIndex:idx_auth_user_token_hash;NOT NULL"`
+
+	CreatedAt time.Time `gorm:"column:created_at;NOT NULL"` // 创建时间
+	UpdatedAt time.Time `gorm:"column:updated_at;NOT NULL"` // 更新时间
+}
+
+func fromAuthUser(user *mtypes.AuthUser) *sqliteAuthUser {
+	return &sqliteAuthUser{
+		Name:      user.Name,
+		Perm:      user.Perm,
+		TokenHash: user.TokenHash,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+func (s sqliteAuthUser) AuthUser() *mtypes.AuthUser {
+	return &mtypes.AuthUser{
+		Name:      s.Name,
+		Perm:      s.Perm,
+		TokenHash: s.TokenHash,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func (s sqliteAuthUser) TableName() string {
+	return "auth_users"
+}
+
+type sqliteAuthSigner struct {
+	User   string `gorm:"column:user_name;type:varchar(256);primary_key;"`
+	Signer string `gorm:"column:signer;type:varchar(256);primary_key;index:idx_auth_signer_signer"`
+
+	CreatedAt time.Time `gorm:"column:created_at;NOT NULL"` // 创建时间
+}
+
+func fromAuthSigner(signer *mtypes.AuthSigner) *sqliteAuthSigner {
+	return &sqliteAuthSigner{
+		User:      signer.User,
+		Signer:    signer.Signer.String(),
+		CreatedAt: signer.CreatedAt,
+	}
+}
+
+func (s sqliteAuthSigner) AuthSigner() (*mtypes.AuthSigner, error) {
+	signer, err := address.NewFromString(s.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mtypes.AuthSigner{
+		User:      s.User,
+		Signer:    signer,
+		CreatedAt: s.CreatedAt,
+	}, nil
+}
+
+func (s sqliteAuthSigner) TableName() string {
+	return "auth_signers"
+}
+
+var _ repo.AuthRepo = (*sqliteAuthRepo)(nil)
+
+type sqliteAuthRepo struct {
+	*gorm.DB
+}
+
+func newSqliteAuthRepo(db *gorm.DB) *sqliteAuthRepo {
+	return &sqliteAuthRepo{DB: db}
+}
+
+func (s *sqliteAuthRepo) CreateAuthUser(ctx context.Context, user *mtypes.AuthUser) error {
+	return s.DB.WithContext(ctx).Create(fromAuthUser(user)).Error
+}
+
+func (s *sqliteAuthRepo) GetAuthUser(ctx context.Context, name string) (*mtypes.AuthUser, error) {
+	var user sqliteAuthUser
+	if err := s.DB.WithContext(ctx).Take(&user, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	return user.AuthUser(), nil
+}
+
+func (s *sqliteAuthRepo) GetAuthUserByTokenHash(ctx context.Context, tokenHash string) (*mtypes.AuthUser, error) {
+	var user sqliteAuthUser
+	if err := s.DB.WithContext(ctx).Take(&user, "token_hash = ?", tokenHash).Error; err != nil {
+		return nil, err
+	}
+	return user.AuthUser(), nil
+}
+
+func (s *sqliteAuthRepo) ListAuthUser(ctx context.Context) ([]*mtypes.AuthUser, error) {
+	var list []*sqliteAuthUser
+	if err := s.DB.WithContext(ctx).Order("created_at").Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]*mtypes.AuthUser, 0, len(list))
+	for _, r := range list {
+		result = append(result, r.AuthUser())
+	}
+	return result, nil
+}
+
+func (s *sqliteAuthRepo) BindAuthSigner(ctx context.Context, signer *mtypes.AuthSigner) error {
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(fromAuthSigner(signer)).Error
+}
+
+func (s *sqliteAuthRepo) UnbindAuthSigner(ctx context.Context, user string, signer address.Address) error {
+	return s.DB.WithContext(ctx).Delete(sqliteAuthSigner{}, "user_name = ? and signer = ?", user, signer.String()).Error
+}
+
+func (s *sqliteAuthRepo) ListAuthSignerByUser(ctx context.Context, user string) ([]*mtypes.AuthSigner, error) {
+	var list []*sqliteAuthSigner
+	if err := s.DB.WithContext(ctx).Order("created_at").Find(&list, "user_name = ?", user).Error; err != nil {
+		return nil, err
+	}
+	return toAuthSigners(list)
+}
+
+func (s *sqliteAuthRepo) ListAuthSignerBySigner(ctx context.Context, signer address.Address) ([]*mtypes.AuthSigner, error) {
+	var list []*sqliteAuthSigner
+	if err := s.DB.WithContext(ctx).Order("created_at").Find(&list, "signer = ?", signer.String()).Error; err != nil {
+		return nil, err
+	}
+	return toAuthSigners(list)
+}
+
+func toAuthSigners(list []*sqliteAuthSigner) ([]*mtypes.AuthSigner, error) {
+	result := make([]*mtypes.AuthSigner, 0, len(list))
+	for _, r := range list {
+		signer, err := r.AuthSigner()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, signer)
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestAuth(t *testing.T) {
+	ctx := context.Background()
+	authRepo := setupRepo(t).AuthRepo()
+
+	now := time.Now().Truncate(time.Second)
+	users := []*mtypes.AuthUser{
+		{Name: "alice", Perm: "write", TokenHash: "hash-alice", CreatedAt: now.Add(-time.Minute), UpdatedAt: now},
+		{Name: "bob", Perm: "read", TokenHash: "hash-bob", CreatedAt: now, UpdatedAt: now},
+	}
+	for _, user := range users {
+		require.NoError(t, authRepo.CreateAuthUser(ctx, user))
+	}
+	assert.Error(t, authRepo.CreateAuthUser(ctx, &mtypes.AuthUser{Name: "alice", Perm: "admin", TokenHash: "hash-alice2"}))
+
+	checkUser := func(expect *mtypes.AuthUser, res *mtypes.AuthUser) {
+		assert.True(t, expect.CreatedAt.Equal(res.CreatedAt))
+		assert.True(t, expect.UpdatedAt.Equal(res.UpdatedAt))
+		res.CreatedAt, res.UpdatedAt = expect.CreatedAt, expect.UpdatedAt
+		assert.Equal(t, expect, res)
+	}
+	for _, user := range users {
+		res, err := authRepo.GetAuthUser(ctx, user.Name)
+		require.NoError(t, err)
+		checkUser(user, res)
+		res, err = authRepo.GetAuthUserByTokenHash(ctx, user.TokenHash)
+		require.NoError(t, err)
+		checkUser(user, res)
+	}
+	_, err := authRepo.GetAuthUser(ctx, "carol")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = authRepo.GetAuthUserByTokenHash(ctx, "hash-carol")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	list, err := authRepo.ListAuthUser(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for i := range list {
+		checkUser(users[i], list[i])
+	}
+
+	addrs := testhelper.RandAddresses(t, 2)
+	bindings := []*mtypes.AuthSigner{
+		{User: "alice", Signer: addrs[0], CreatedAt: now},
+		{User: "alice", Signer: addrs[1], CreatedAt: now.Add(time.Second)},
+		{User: "bob", Signer: addrs[0], CreatedAt: now.Add(2 * time.Second)},
+	}
+	for _, b := range bindings {
+		require.NoError(t, authRepo.BindAuthSigner(ctx, b))
+	}
+	// binding again is ignored
+	require.NoError(t, authRepo.BindAuthSigner(ctx, bindings[0]))
+
+	signersOf := func(list []*mtypes.AuthSigner, err error) []address.Address {
+		require.NoError(t, err)
+		var res []address.Address
+		for _, s := range list {
+			res = append(res, s.Signer)
+		}
+		return res
+	}
+	usersOf := func(list []*mtypes.AuthSigner, err error) []string {
+		require.NoError(t, err)
+		var res []string
+		for _, s := range list {
+			res = append(res, s.User)
+		}
+		return res
+	}
+	assert.Equal(t, addrs[:2], signersOf(authRepo.ListAuthSignerByUser(ctx, "alice")))
+	assert.Equal(t, []string{"alice", "bob"}, usersOf(authRepo.ListAuthSignerBySigner(ctx, addrs[0])))
+
+	require.NoError(t, authRepo.UnbindAuthSigner(ctx, "alice", addrs[0]))
+	assert.Equal(t, addrs[1:2], signersOf(authRepo.ListAuthSignerByUser(ctx, "alice")))
+	assert.Equal(t, []string{"bob"}, usersOf(authRepo.ListAuthSignerBySigner(ctx, addrs[0])))
+	assert.Empty(t, signersOf(authRepo.ListAuthSignerByUser(ctx, "carol")))
+}
@@ -34,8 +34,36 @@ func (d SqlLiteRepo) NodeRepo() repo.NodeRepo {
 	return newSqliteNodeRepo(d.DB)
 }
 
+func (d SqlLiteRepo) AddressPolicyRepo() repo.AddressPolicyRepo {
+	return newSqliteAddressPolicyRepo(d.DB)
+}
+
+func (d SqlLiteRepo) AuditLogRepo() repo.AuditLogRepo {
+	return newSqliteAuditLogRepo(d.DB)
+}
+
+func (d SqlLiteRepo) PublishReceiptRepo() repo.PublishReceiptRepo {
+	return newSqlitePublishReceiptRepo(d.DB)
+}
+
+func (d SqlLiteRepo) OutboxRepo() repo.OutboxRepo {
+	return newSqliteOutboxRepo(d.DB)
+}
+
+func (d SqlLiteRepo) MessageCancelRepo() repo.MessageCancelRepo {
+	return newSqliteMessageCancelRepo(d.DB)
+}
+
+func (d SqlLiteRepo) EthTransactionRepo() repo.EthTransactionRepo {
+	return newSqliteEthTransactionRepo(d.DB)
+}
+
+func (d SqlLiteRepo) AuthRepo() repo.AuthRepo {
+	return newSqliteAuthRepo(d.DB)
+}
+
 func (d SqlLiteRepo) AutoMigrate() error {
-	return d.GetDb().AutoMigrate(sqliteMessage{}, sqliteActorCfg{}, sqliteAddress{}, sqliteSharedParams{}, sqliteNode{})
+	return d.GetDb().AutoMigrate(sqliteMessage{}, sqliteActorCfg{}, sqliteAddress{}, sqliteSharedParams{}, sqliteNode{}, sqliteAddressPolicy{}, sqliteAuditLog{}, sqlitePublishReceipt{}, sqliteOutboxMessage{}, sqliteMessageCancel{}, sqliteEthTransaction{}, sqliteAuthUser{}, sqliteAuthSigner{})
 }
 
 func (d SqlLiteRepo) GetDb() *gorm.DB {
@@ -67,6 +95,34 @@ func (t *TxSqlliteRepo) NodeRepo() repo.NodeRepo {
 	return newSqliteNodeRepo(t.DB)
 }
 
+func (t *TxSqlliteRepo) AddressPolicyRepo() repo.AddressPolicyRepo {
+	return newSqliteAddressPolicyRepo(t.DB)
+}
+
+func (t *TxSqlliteRepo) AuditLogRepo() repo.AuditLogRepo {
+	return newSqliteAuditLogRepo(t.DB)
+}
+
+func (t *TxSqlliteRepo) MessageCancelRepo() repo.MessageCancelRepo {
+	return newSqliteMessageCancelRepo(t.DB)
+}
+
+func (t *TxSqlliteRepo) EthTransactionRepo() repo.EthTransactionRepo {
+	return newSqliteEthTransactionRepo(t.DB)
+}
+
+func (t *TxSqlliteRepo) AuthRepo() repo.AuthRepo {
+	return newSqliteAuthRepo(t.DB)
+}
+
+func (t *TxSqlliteRepo) OutboxRepo() repo.OutboxRepo {
+	return newSqliteOutboxRepo(t.DB)
+}
+
+func (t *TxSqlliteRepo) PublishReceiptRepo() repo.PublishReceiptRepo {
+	return newSqlitePublishReceiptRepo(t.DB)
+}
+
 func (t *TxSqlliteRepo) MessageRepo() repo.MessageRepo {
 	return newSqliteMessageRepo(t.DB)
 }
@@ -76,8 +132,11 @@ func (t *TxSqlliteRepo) AddressRepo() repo.AddressRepo {
 }
 
 func (d SqlLiteRepo) DbClose() error {
-	// todo: if '*gorm.DB' need to dispose?
-	return nil
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
 
 func OpenSqlite(fsRepo filestore.FSRepo) (repo.Repo, error) {
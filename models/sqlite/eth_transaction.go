@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+type sqliteEthTransaction struct {
+	TxHash string `gorm:"column:tx_hash;type:varchar(128);primary_key;"`
+	MsgID  string `gorm:"column:msg_id;type:varchar(256);index:idx_eth_tx_msg_id;NOT NULL"`
+
+	CreatedAt time.Time `gorm:"column:created_at;NOT NULL"` // 创建时间
+}
+
+func fromEthTransaction(tx *mtypes.EthTransaction) *sqliteEthTransaction {
+	return &sqliteEthTransaction{
+		TxHash:    tx.TxHash.String(),
+		MsgID:     tx.MsgID,
+		CreatedAt: tx.CreatedAt,
+	}
+}
+
+func (s sqliteEthTransaction) EthTransaction() (*mtypes.EthTransaction, error) {
+	txHash, err := venusTypes.ParseEthHash(s.TxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mtypes.EthTransaction{
+		TxHash:    txHash,
+		MsgID:     s.MsgID,
+		CreatedAt: s.CreatedAt,
+	}, nil
+}
+
+func (s sqliteEthTransaction) TableName() string {
+	return "eth_transactions"
+}
+
+var _ repo.EthTransactionRepo = (*sqliteEthTransactionRepo)(nil)
+
+type sqliteEthTransactionRepo struct {
+	*gorm.DB
+}
+
+func newSqliteEthTransactionRepo(db *gorm.DB) *sqliteEthTransactionRepo {
+	return &sqliteEthTransactionRepo{DB: db}
+}
+
+func (s *sqliteEthTransactionRepo) SaveEthTransaction(ctx context.Context, tx *mtypes.EthTransaction) error {
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(fromEthTransaction(tx)).Error
+}
+
+func (s *sqliteEthTransactionRepo) GetEthTransaction(ctx context.Context, txHash venusTypes.EthHash) (*mtypes.EthTransaction, error) {
+	var tx sqliteEthTransaction
+	if err := s.DB.WithContext(ctx).Take(&tx, "tx_hash = ?", txHash.String()).Error; err != nil {
+		return nil, err
+	}
+	return tx.EthTransaction()
+}
+
+func (s *sqliteEthTransactionRepo) GetEthTransactionByMsgID(ctx context.Context, msgID string) (*mtypes.EthTransaction, error) {
+	var tx sqliteEthTransaction
+	if err := s.DB.WithContext(ctx).Where("msg_id = ?", msgID).Order("created_at desc").First(&tx).Error; err != nil {
+		return nil, err
+	}
+	return tx.EthTransaction()
+}
@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+func TestEthTransaction(t *testing.T) {
+	ctx := context.Background()
+	ethTxRepo := setupRepo(t).EthTransactionRepo()
+
+	hashes := [2]types.EthHash{randEthHash(t), randEthHash(t)}
+	msgID := types.NewUUID().String()
+	txs := []*mtypes.EthTransaction{
+		{TxHash: hashes[0], MsgID: msgID, CreatedAt: time.Now().Add(-time.Minute)},
+		// signed again
+		{TxHash: hashes[1], MsgID: msgID, CreatedAt: time.Now()},
+	}
+	for _, tx := range txs {
+		assert.NoError(t, ethTxRepo.SaveEthTransaction(ctx, tx))
+	}
+	// saving again is ignored
+	assert.NoError(t, ethTxRepo.SaveEthTransaction(ctx, txs[0]))
+
+	check := func(expect *mtypes.EthTransaction, res *mtypes.EthTransaction, err error) {
+		assert.NoError(t, err)
+		assert.True(t, expect.CreatedAt.Equal(res.CreatedAt))
+		res.CreatedAt = expect.CreatedAt
+		assert.Equal(t, expect, res)
+	}
+	for _, tx := range txs {
+		res, err := ethTxRepo.GetEthTransaction(ctx, tx.TxHash)
+		check(tx, res, err)
+	}
+	res, err := ethTxRepo.GetEthTransactionByMsgID(ctx, msgID)
+	check(txs[1], res, err)
+
+	_, err = ethTxRepo.GetEthTransaction(ctx, randEthHash(t))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = ethTxRepo.GetEthTransactionByMsgID(ctx, types.NewUUID().String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func randEthHash(t *testing.T) types.EthHash {
+	var hash types.EthHash
+	_, err := rand.Read(hash[:])
+	assert.NoError(t, err)
+	return hash
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/venus/venus-shared/testutil"
+	"github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestMessageCancel(t *testing.T) {
+	ctx := context.Background()
+	cancelRepo := setupRepo(t).MessageCancelRepo()
+
+	cancel := &mtypes.MessageCancel{
+		MsgID:     types.NewUUID().String(),
+		ReplaceID: types.NewUUID().String(),
+		From:      testhelper.RandAddresses(t, 1)[0],
+		Nonce:     10,
+		CreatedAt: time.Now(),
+	}
+	assert.NoError(t, cancelRepo.SaveMessageCancel(ctx, cancel))
+
+	check := func(res *mtypes.MessageCancel, err error) {
+		assert.NoError(t, err)
+		assert.True(t, cancel.CreatedAt.Equal(res.CreatedAt))
+		res.CreatedAt = cancel.CreatedAt
+		assert.Equal(t, cancel, res)
+	}
+	check(cancelRepo.GetMessageCancel(ctx, cancel.MsgID))
+	check(cancelRepo.GetMessageCancelByNonce(ctx, cancel.From, cancel.Nonce))
+
+	_, err := cancelRepo.GetMessageCancel(ctx, types.NewUUID().String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = cancelRepo.GetMessageCancelByNonce(ctx, cancel.From, cancel.Nonce+1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	list, err := cancelRepo.ListMessageCancelByAddress(ctx, cancel.From, cancel.Nonce)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	check(list[0], nil)
+	list, err = cancelRepo.ListMessageCancelByAddress(ctx, cancel.From, cancel.Nonce+1)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+
+	// a message can only be cancelled once
+	var id string
+	testutil.Provide(t, &id)
+	dup := *cancel
+	dup.ReplaceID = id
+	assert.Error(t, cancelRepo.SaveMessageCancel(ctx, &dup))
+}
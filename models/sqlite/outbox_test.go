@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestOutbox(t *testing.T) {
+	ctx := context.Background()
+	outboxRepo := setupRepo(t).OutboxRepo()
+
+	now := time.Now()
+	signedMsgs := testhelper.NewShareSignedMessages(3)
+	msgs := make([]*mtypes.OutboxMessage, 0, len(signedMsgs))
+	for i, msg := range signedMsgs {
+		msgs = append(msgs, &mtypes.OutboxMessage{
+			Cid:           msg.Cid(),
+			From:          msg.Message.From,
+			Nonce:         msg.Message.Nonce,
+			Msg:           msg,
+			NextAttemptAt: now.Add(time.Duration(i) * time.Second),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+	assert.NoError(t, outboxRepo.SaveOutboxMessages(ctx, msgs))
+	assert.NoError(t, outboxRepo.SaveOutboxMessages(ctx, nil))
+
+	count, err := outboxRepo.CountOutboxMessages(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	checkDue := func(at time.Time, limit int, expect ...*mtypes.OutboxMessage) {
+		list, err := outboxRepo.ListDueOutboxMessages(ctx, at, limit)
+		assert.NoError(t, err)
+		assert.Len(t, list, len(expect))
+		for i := range list {
+			assert.Equal(t, expect[i].Cid, list[i].Cid)
+			assert.Equal(t, expect[i].From, list[i].From)
+			assert.Equal(t, expect[i].Nonce, list[i].Nonce)
+			assert.Equal(t, expect[i].Msg.Cid(), list[i].Msg.Cid())
+			assert.Equal(t, expect[i].Attempts, list[i].Attempts)
+			assert.Equal(t, expect[i].LastError, list[i].LastError)
+			assert.True(t, expect[i].NextAttemptAt.Equal(list[i].NextAttemptAt))
+		}
+	}
+	checkDue(now, 10, msgs[0])
+	checkDue(now.Add(time.Second), 10, msgs[0], msgs[1])
+	checkDue(now.Add(time.Minute), 2, msgs[0], msgs[1])
+
+	// a failed publishing delay the next attempt
+	msgs[0].Attempts = 1
+	msgs[0].NextAttemptAt = now.Add(time.Minute)
+	msgs[0].LastError = "no publisher available"
+	assert.NoError(t, outboxRepo.UpdateOutboxAttempt(ctx, msgs[0].Cid, msgs[0].Attempts, msgs[0].NextAttemptAt, msgs[0].LastError))
+	checkDue(now.Add(2*time.Second), 10, msgs[1], msgs[2])
+
+	// save again make it due immediately, and keep the attempts
+	msgs[0].NextAttemptAt = now
+	assert.NoError(t, outboxRepo.SaveOutboxMessages(ctx, []*mtypes.OutboxMessage{{
+		Cid:           msgs[0].Cid,
+		From:          msgs[0].From,
+		Nonce:         msgs[0].Nonce,
+		Msg:           msgs[0].Msg,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}}))
+	checkDue(now, 10, msgs[0])
+
+	assert.NoError(t, outboxRepo.DeleteOutboxMessages(ctx, []cid.Cid{msgs[0].Cid, msgs[1].Cid}))
+	assert.NoError(t, outboxRepo.DeleteOutboxMessages(ctx, nil))
+	checkDue(now.Add(time.Minute), 10, msgs[2])
+	count, err = outboxRepo.CountOutboxMessages(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
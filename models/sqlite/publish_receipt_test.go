@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/venus/venus-shared/testutil"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+func TestPublishReceipt(t *testing.T) {
+	ctx := context.Background()
+	receiptRepo := setupRepo(t).PublishReceiptRepo()
+
+	var c1, c2 cid.Cid
+	testutil.Provide(t, &c1)
+	testutil.Provide(t, &c2)
+
+	now := time.Now()
+	r1 := &mtypes.PublishReceipt{MsgCid: c1, Node: "mainNode", Accepted: true, CreatedAt: now, UpdatedAt: now}
+	r2 := &mtypes.PublishReceipt{MsgCid: c1, Node: "node2", Error: "connection refused", CreatedAt: now, UpdatedAt: now}
+	r3 := &mtypes.PublishReceipt{MsgCid: c2, Node: "mainNode", Accepted: true, CreatedAt: now, UpdatedAt: now}
+	assert.NoError(t, receiptRepo.SavePublishReceipts(ctx, []*mtypes.PublishReceipt{r1, r2, r3}))
+	assert.NoError(t, receiptRepo.SavePublishReceipts(ctx, nil))
+
+	checkReceipts := func(msgCid cid.Cid, expect ...*mtypes.PublishReceipt) {
+		list, err := receiptRepo.ListPublishReceipts(ctx, msgCid)
+		assert.NoError(t, err)
+		assert.Len(t, list, len(expect))
+		for i := range list {
+			assert.True(t, expect[i].CreatedAt.Equal(list[i].CreatedAt))
+			assert.True(t, expect[i].UpdatedAt.Equal(list[i].UpdatedAt))
+			list[i].CreatedAt, list[i].UpdatedAt = expect[i].CreatedAt, expect[i].UpdatedAt
+			assert.Equal(t, expect[i], list[i])
+		}
+	}
+	checkReceipts(c1, r1, r2)
+
+	// update the result, but keep the created time
+	later := now.Add(time.Minute)
+	assert.NoError(t, receiptRepo.SavePublishReceipts(ctx, []*mtypes.PublishReceipt{
+		{MsgCid: c1, Node: "node2", Accepted: true, CreatedAt: later, UpdatedAt: later},
+	}))
+	r2.Accepted = true
+	r2.Error = ""
+	r2.UpdatedAt = later
+	checkReceipts(c1, r1, r2)
+	checkReceipts(c2, r3)
+
+	// only the receipts updated before the time are deleted
+	deleted, err := receiptRepo.DeletePublishReceiptsBefore(ctx, later)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	checkReceipts(c1, r2)
+	checkReceipts(c2)
+}
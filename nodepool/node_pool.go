@@ -0,0 +1,531 @@
+package nodepool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-state-types/abi"
+	v1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1"
+	logging "github.com/ipfs/go-log/v2"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+var log = logging.Logger("node-pool")
+
+// MainNodeName is the name of the node in config file
+const MainNodeName = "mainNode"
+
+// the weight of the newest sample in moving averages
+const ewmaAlpha = 0.3
+
+type dialFunc func(ctx context.Context, addr, token string) (v1.FullNode, jsonrpc.ClientCloser, error)
+
+func dialFullNode(ctx context.Context, addr, token string) (v1.FullNode, jsonrpc.ClientCloser, error) {
+	return v1.DialFullNodeRPC(ctx, addr, token, http.Header{})
+}
+
+type poolNode struct {
+	name   string
+	url    string
+	token  string
+	client v1.FullNode
+	closer jsonrpc.ClientCloser
+
+	lk        sync.Mutex
+	checked   bool
+	healthy   bool
+	height    abi.ChainEpoch
+	latency   time.Duration
+	errorRate float64
+	lastErr   error
+	checkedAt time.Time
+}
+
+func (n *poolNode) recordCall(err error) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+
+	failed := 0.0
+	if err != nil {
+		failed = 1
+		n.healthy = false
+		n.lastErr = err
+	}
+	n.errorRate = n.errorRate*(1-ewmaAlpha) + failed*ewmaAlpha
+}
+
+func (n *poolNode) recordCheck(height abi.ChainEpoch, latency time.Duration, err error) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+
+	n.checkedAt = time.Now()
+	failed := 0.0
+	if err != nil {
+		failed = 1
+		n.healthy = false
+		n.lastErr = err
+	} else {
+		n.healthy = true
+		n.height = height
+		if !n.checked {
+			n.latency = latency
+		} else {
+			n.latency = time.Duration(float64(n.latency)*(1-ewmaAlpha) + float64(latency)*ewmaAlpha)
+		}
+		n.checked = true
+	}
+	n.errorRate = n.errorRate*(1-ewmaAlpha) + failed*ewmaAlpha
+}
+
+func (n *poolNode) status() mtypes.NodeStatus {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+
+	s := mtypes.NodeStatus{
+		Name:      n.name,
+		URL:       n.url,
+		Height:    n.height,
+		Latency:   n.latency,
+		ErrorRate: n.errorRate,
+		Healthy:   n.checked && n.healthy,
+		CheckedAt: n.checkedAt,
+	}
+	if n.lastErr != nil {
+		s.LastError = n.lastErr.Error()
+	}
+	return s
+}
+
+// subscription is a subscription such as ChainNotify opened on a node by the pool
+type subscription struct {
+	node   *poolNode
+	cancel context.CancelFunc
+}
+
+// NodePool track the head height, latency and error rate of the main node and the nodes in NodeRepo,
+// the calls to chain are routed to the best in-sync node, and retried on the other nodes when the connection failed.
+type NodePool struct {
+	cfg          *config.NodePoolConfig
+	mainNode     *poolNode
+	nodeProvider repo.INodeProvider
+	dial         dialFunc
+
+	lk    sync.RWMutex
+	nodes []*poolNode
+	// candidates are all nodes in the order of trying, the first one is the selected
+	candidates []*poolNode
+	subs       map[*subscription]struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewNodePool(cfg *config.NodeConfig, mainClient v1.FullNode, nodeProvider repo.INodeProvider) *NodePool {
+	mainNode := &poolNode{
+		name:   MainNodeName,
+		url:    cfg.Url,
+		token:  cfg.Token,
+		client: mainClient,
+	}
+	return &NodePool{
+		cfg:          &cfg.Pool,
+		mainNode:     mainNode,
+		nodeProvider: nodeProvider,
+		dial:         dialFullNode,
+		nodes:        []*poolNode{mainNode},
+		candidates:   []*poolNode{mainNode},
+		subs:         make(map[*subscription]struct{}),
+	}
+}
+
+// Start check the nodes every CheckInterval until ctx is done or the pool is closed.
+func (p *NodePool) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.cfg.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			p.checkNodes(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stop checking and disconnect the nodes in NodeRepo, the main node is closed by the creator.
+func (p *NodePool) Close() {
+	if p.cancel != nil {
+		p.cancel()
+		<-p.done
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	for _, n := range p.nodes {
+		if n.closer != nil {
+			n.closer()
+		}
+	}
+	p.nodes = []*poolNode{p.mainNode}
+	p.candidates = []*poolNode{p.mainNode}
+}
+
+// refreshNodes connect the nodes added to NodeRepo and disconnect the deleted.
+func (p *NodePool) refreshNodes(ctx context.Context) {
+	if p.nodeProvider == nil {
+		return
+	}
+	list, err := p.nodeProvider.ListNode()
+	if err != nil {
+		log.Warnf("list node failed: %v", err)
+		return
+	}
+
+	p.lk.RLock()
+	exists := make(map[string]*poolNode, len(p.nodes))
+	for _, n := range p.nodes[1:] {
+		exists[n.name] = n
+	}
+	p.lk.RUnlock()
+
+	nodes := []*poolNode{p.mainNode}
+	urls := map[string]struct{}{p.mainNode.url: {}}
+	for _, node := range list {
+		// the same node may be added with different names
+		if _, ok := urls[node.URL]; ok {
+			log.Debugf("skip node %s, its url is used by another node", node.Name)
+			continue
+		}
+		urls[node.URL] = struct{}{}
+		if n, ok := exists[node.Name]; ok && n.url == node.URL && n.token == node.Token {
+			nodes = append(nodes, n)
+			delete(exists, node.Name)
+			continue
+		}
+		client, closer, err := p.dial(ctx, node.URL, node.Token)
+		if err != nil {
+			log.Warnf("connect node %s failed: %v", node.Name, err)
+			continue
+		}
+		nodes = append(nodes, &poolNode{
+			name:   node.Name,
+			url:    node.URL,
+			token:  node.Token,
+			client: client,
+			closer: closer,
+		})
+	}
+
+	p.lk.Lock()
+	p.nodes = nodes
+	p.lk.Unlock()
+
+	for _, n := range exists {
+		n.closer()
+		log.Infof("remove node %s from pool", n.name)
+	}
+}
+
+// checkNodes update the status of all nodes and select the best one.
+func (p *NodePool) checkNodes(ctx context.Context) {
+	p.refreshNodes(ctx)
+
+	p.lk.RLock()
+	nodes := p.nodes
+	p.lk.RUnlock()
+
+	wg := sync.WaitGroup{}
+	for _, n := range nodes {
+		wg.Add(1)
+		go func(n *poolNode) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, p.cfg.CheckInterval)
+			defer cancel()
+			start := time.Now()
+			head, err := n.client.ChainHead(ctx)
+			if err != nil {
+				log.Debugf("check node %s failed: %v", n.name, err)
+				n.recordCheck(0, 0, err)
+				return
+			}
+			n.recordCheck(head.Height(), time.Since(start), nil)
+		}(n)
+	}
+	wg.Wait()
+
+	p.selectNode()
+}
+
+func (p *NodePool) weight(name string) int {
+	if w, ok := p.cfg.Weights[name]; ok {
+		return w
+	}
+	return 1
+}
+
+// selectNode sort the nodes by whether it is healthy and in sync, then by weight * success rate / latency.
+func (p *NodePool) selectNode() {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	type candidate struct {
+		node     *poolNode
+		eligible bool
+		score    float64
+	}
+	status := make([]mtypes.NodeStatus, len(p.nodes))
+	var maxHeight abi.ChainEpoch
+	for i, n := range p.nodes {
+		status[i] = n.status()
+		if status[i].Healthy && status[i].Height > maxHeight {
+			maxHeight = status[i].Height
+		}
+	}
+	candidates := make([]candidate, len(p.nodes))
+	for i, n := range p.nodes {
+		s := status[i]
+		latency := s.Latency
+		if latency < time.Millisecond {
+			latency = time.Millisecond
+		}
+		candidates[i] = candidate{
+			node:     n,
+			eligible: s.Healthy && int64(maxHeight-s.Height) <= p.cfg.MaxHeightLag && s.ErrorRate <= p.cfg.MaxErrorRate,
+			score:    float64(p.weight(n.name)) * (1 - s.ErrorRate) / latency.Seconds(),
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].eligible != candidates[j].eligible {
+			return candidates[i].eligible
+		}
+		return candidates[i].score > candidates[j].score
+	})
+
+	if !candidates[0].eligible {
+		log.Warnf("no healthy node in sync")
+	} else if p.candidates[0] != candidates[0].node {
+		log.Infof("route calls to node %s, previous is %s", candidates[0].node.name, p.candidates[0].name)
+	}
+	p.candidates = make([]*poolNode, 0, len(candidates))
+	eligible := make(map[*poolNode]bool, len(candidates))
+	for _, c := range candidates {
+		p.candidates = append(p.candidates, c.node)
+		eligible[c.node] = c.eligible
+	}
+
+	// close the subscriptions on the nodes out of service, the subscribers open them again on the selected node
+	if !candidates[0].eligible {
+		return
+	}
+	for sub := range p.subs {
+		if !eligible[sub.node] {
+			log.Infof("close the subscription on node %s, it is out of service", sub.node.name)
+			sub.cancel()
+			delete(p.subs, sub)
+		}
+	}
+}
+
+// Status returns the status of all nodes, the selected one is the first.
+func (p *NodePool) Status() []*mtypes.NodeStatus {
+	p.lk.RLock()
+	candidates := p.candidates
+	p.lk.RUnlock()
+
+	var maxHeight abi.ChainEpoch
+	list := make([]*mtypes.NodeStatus, 0, len(candidates))
+	for _, n := range candidates {
+		s := n.status()
+		s.Weight = p.weight(n.name)
+		if s.Healthy && s.Height > maxHeight {
+			maxHeight = s.Height
+		}
+		list = append(list, &s)
+	}
+	for i, s := range list {
+		s.InSync = s.Healthy && int64(maxHeight-s.Height) <= p.cfg.MaxHeightLag
+		s.Selected = i == 0
+	}
+
+	return list
+}
+
+// Node is a node connected by the pool.
+type Node struct {
+	Name   string
+	URL    string
+	Client v1.FullNode
+}
+
+// Nodes returns the main node and the connected nodes in NodeRepo, the connections are owned by the pool.
+func (p *NodePool) Nodes() []Node {
+	p.lk.RLock()
+	defer p.lk.RUnlock()
+
+	list := make([]Node, 0, len(p.nodes))
+	for _, n := range p.nodes {
+		list = append(list, Node{Name: n.name, URL: n.url, Client: n.client})
+	}
+	return list
+}
+
+// FullNode returns a v1.FullNode which route the calls by the pool. The read calls are retried on the other nodes
+// when the connection failed. The subscriptions such as ChainNotify are opened on the selected node in the same way,
+// and closed once the node is out of service, so the subscriber opens them again on the newly selected node. The other
+// calls, eg. pushing messages, are sent to the selected node without retrying as they are not idempotent.
+func (p *NodePool) FullNode() v1.FullNode {
+	var out v1.FullNodeStruct
+	p.proxy(reflect.ValueOf(&out).Elem())
+	return &out
+}
+
+func (p *NodePool) proxy(rv reflect.Value) {
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Field(i)
+		if rv.Type().Field(i).Name != "Internal" {
+			if field.Kind() == reflect.Struct {
+				p.proxy(field)
+			}
+			continue
+		}
+		for j := 0; j < field.NumField(); j++ {
+			method := field.Type().Field(j)
+			name := method.Name
+			call := p.callSelected
+			switch {
+			case returnsChan(method):
+				call = p.subscribe
+			case method.Tag.Get("perm") == "read":
+				call = p.call
+			}
+			field.Field(j).Set(reflect.MakeFunc(method.Type, func(args []reflect.Value) []reflect.Value {
+				return call(name, args)
+			}))
+		}
+	}
+}
+
+// returnsChan returns whether the method returns a channel, ie. it's a subscription.
+func returnsChan(method reflect.StructField) bool {
+	for i := 0; i < method.Type.NumOut(); i++ {
+		if method.Type.Out(i).Kind() == reflect.Chan {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *NodePool) call(method string, args []reflect.Value) []reflect.Value {
+	out, _ := p.callCandidates(method, args)
+	return out
+}
+
+// callCandidates call the method on the nodes in order until the connection succeeded, returns the node called last.
+func (p *NodePool) callCandidates(method string, args []reflect.Value) ([]reflect.Value, *poolNode) {
+	p.lk.RLock()
+	candidates := p.candidates
+	p.lk.RUnlock()
+
+	var out []reflect.Value
+	var last *poolNode
+	for _, n := range candidates {
+		last = n
+		out = reflect.ValueOf(n.client).MethodByName(method).Call(args)
+		if err := callError(out); isConnectionError(err) {
+			// not the fault of node
+			if ctx, ok := args[0].Interface().(context.Context); ok && ctx.Err() != nil {
+				return out, n
+			}
+			log.Warnf("call %s on node %s failed, try the next node: %v", method, n.name, err)
+			n.recordCall(err)
+			p.selectNode()
+			continue
+		}
+		n.recordCall(nil)
+		return out, n
+	}
+
+	return out, last
+}
+
+// subscribe open the subscription like call, and track it to close it when the node is out of service.
+func (p *NodePool) subscribe(method string, args []reflect.Value) []reflect.Value {
+	ctx, ok := args[0].Interface().(context.Context)
+	if !ok {
+		return p.call(method, args)
+	}
+	subCtx, cancel := context.WithCancel(ctx)
+	subArgs := append([]reflect.Value{reflect.ValueOf(subCtx)}, args[1:]...)
+	out, n := p.callCandidates(method, subArgs)
+	if n == nil || callError(out) != nil {
+		cancel()
+		return out
+	}
+
+	sub := &subscription{node: n, cancel: cancel}
+	p.lk.Lock()
+	p.subs[sub] = struct{}{}
+	p.lk.Unlock()
+	go func() {
+		<-subCtx.Done()
+		p.lk.Lock()
+		delete(p.subs, sub)
+		p.lk.Unlock()
+	}()
+	return out
+}
+
+// callSelected call the method on the selected node without retrying, a connection error makes the pool select
+// another node for the next calls.
+func (p *NodePool) callSelected(method string, args []reflect.Value) []reflect.Value {
+	p.lk.RLock()
+	n := p.candidates[0]
+	p.lk.RUnlock()
+
+	out := reflect.ValueOf(n.client).MethodByName(method).Call(args)
+	if err := callError(out); isConnectionError(err) {
+		if ctx, ok := args[0].Interface().(context.Context); ok && ctx.Err() != nil {
+			return out
+		}
+		log.Warnf("call %s on node %s failed: %v", method, n.name, err)
+		n.recordCall(err)
+		p.selectNode()
+		return out
+	}
+	n.recordCall(nil)
+	return out
+}
+
+func callError(out []reflect.Value) error {
+	if len(out) == 0 {
+		return nil
+	}
+	err, _ := out[len(out)-1].Interface().(error)
+	return err
+}
+
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var connErr *jsonrpc.RPCConnectionError
+	var clientErr *jsonrpc.ErrClient
+	return errors.As(err, &connErr) || errors.As(err, &clientErr)
+}
@@ -0,0 +1,163 @@
+package nodepool
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-jsonrpc"
+	"github.com/filecoin-project/go-state-types/abi"
+	v1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1"
+	mockV1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1/mock"
+	"github.com/filecoin-project/venus/venus-shared/types"
+	mtypes "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/mocks"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestNodePool(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	genTipset := func(height abi.ChainEpoch) *types.TipSet {
+		ts, err := testhelper.GenTipset(height, 1, nil)
+		assert.NoError(t, err)
+		return ts
+	}
+
+	mainNode := mockV1.NewMockFullNode(ctrl)
+	mainNode.EXPECT().ChainHead(gomock.Any()).Return(genTipset(10), nil).AnyTimes()
+	node1 := mockV1.NewMockFullNode(ctrl)
+	node1.EXPECT().ChainHead(gomock.Any()).Return(genTipset(10), nil).AnyTimes()
+	// out of sync
+	node2 := mockV1.NewMockFullNode(ctrl)
+	node2.EXPECT().ChainHead(gomock.Any()).Return(genTipset(5), nil).AnyTimes()
+
+	nodeProvider := mocks.NewMockNodeRepo(ctrl)
+	nodeProvider.EXPECT().ListNode().Return([]*mtypes.Node{
+		{ID: types.NewUUID(), Name: "node1", URL: "node1"},
+		{ID: types.NewUUID(), Name: "node2", URL: "node2"},
+		// the same as node1
+		{ID: types.NewUUID(), Name: "node3", URL: "node1"},
+	}, nil).AnyTimes()
+
+	cfg := config.DefaultConfig().Node
+	cfg.Pool.Enable = true
+	cfg.Pool.Weights = map[string]int{"node1": 1000, "node2": 1000}
+	pool := NewNodePool(&cfg, mainNode, nodeProvider)
+	pool.dial = func(_ context.Context, addr, _ string) (v1.FullNode, jsonrpc.ClientCloser, error) {
+		switch addr {
+		case "node1":
+			return node1, func() {}, nil
+		case "node2":
+			return node2, func() {}, nil
+		}
+		return nil, nil, fmt.Errorf("unknown node %s", addr)
+	}
+	full := pool.FullNode()
+
+	// route to the main node before checking
+	mainNode.EXPECT().StateNetworkName(ctx).Return(types.NetworkName("main"), nil).Times(1)
+	name, err := full.StateNetworkName(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NetworkName("main"), name)
+
+	pool.checkNodes(ctx)
+	status := pool.Status()
+	assert.Len(t, status, 3)
+	assert.Equal(t, "node1", status[0].Name)
+	assert.True(t, status[0].Selected)
+	nodes := pool.Nodes()
+	assert.Len(t, nodes, 3)
+	assert.Equal(t, MainNodeName, nodes[0].Name)
+	assert.Equal(t, mainNode, nodes[0].Client)
+	assert.True(t, status[0].InSync)
+	for _, s := range status {
+		if s.Name == "node2" {
+			assert.True(t, s.Healthy)
+			assert.False(t, s.InSync)
+		}
+	}
+
+	node1.EXPECT().StateNetworkName(ctx).Return(types.NetworkName("node1"), nil).Times(1)
+	name, err = full.StateNetworkName(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NetworkName("node1"), name)
+
+	// not fail over for the errors returned by node
+	node1.EXPECT().StateNetworkName(ctx).Return(types.NetworkName(""), fmt.Errorf("actor not found")).Times(1)
+	_, err = full.StateNetworkName(ctx)
+	assert.EqualError(t, err, "actor not found")
+
+	// messages are pushed to the selected node
+	msgs := testhelper.NewShareSignedMessages(1)
+	node1.EXPECT().MpoolBatchPushUntrusted(ctx, msgs).Return(nil, nil).Times(1)
+	_, err = full.MpoolBatchPushUntrusted(ctx, msgs)
+	assert.NoError(t, err)
+
+	// the subscription is opened on the selected node
+	var subCtx context.Context
+	node1.EXPECT().ChainNotify(gomock.Any()).DoAndReturn(func(ctx context.Context) (<-chan []*types.HeadChange, error) {
+		subCtx = ctx
+		return nil, nil
+	}).Times(1)
+	_, err = full.ChainNotify(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, subCtx.Err())
+	assert.Equal(t, "node1", pool.Status()[0].Name)
+
+	// fail over to the main node when the connection failed
+	node1.EXPECT().StateNetworkName(ctx).Return(types.NetworkName(""), &jsonrpc.RPCConnectionError{}).Times(1)
+	mainNode.EXPECT().StateNetworkName(ctx).Return(types.NetworkName("main"), nil).Times(1)
+	name, err = full.StateNetworkName(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NetworkName("main"), name)
+	status = pool.Status()
+	assert.Equal(t, MainNodeName, status[0].Name)
+	assert.False(t, status[len(status)-1].Healthy)
+	// the subscription on node1 is closed to be opened on the main node again
+	assert.Error(t, subCtx.Err())
+	mainNode.EXPECT().ChainNotify(gomock.Any()).Return(nil, nil).Times(1)
+	_, err = full.ChainNotify(ctx)
+	assert.NoError(t, err)
+	mainNode.EXPECT().MpoolBatchPushUntrusted(ctx, msgs).Return(nil, nil).Times(1)
+	_, err = full.MpoolBatchPushUntrusted(ctx, msgs)
+	assert.NoError(t, err)
+
+	// node1 is back after checking
+	pool.checkNodes(ctx)
+	assert.Equal(t, "node1", pool.Status()[0].Name)
+
+	// messages are not pushed again to the other node when the connection failed, but the next calls go there
+	node1.EXPECT().MpoolBatchPushUntrusted(ctx, msgs).Return(nil, &jsonrpc.RPCConnectionError{}).Times(1)
+	_, err = full.MpoolBatchPushUntrusted(ctx, msgs)
+	assert.Error(t, err)
+	assert.Equal(t, MainNodeName, pool.Status()[0].Name)
+
+	// node1 is back after checking
+	pool.checkNodes(ctx)
+	assert.Equal(t, "node1", pool.Status()[0].Name)
+
+	// removed from NodeRepo
+	nodeProvider = mocks.NewMockNodeRepo(ctrl)
+	nodeProvider.EXPECT().ListNode().Return(nil, nil).AnyTimes()
+	pool.nodeProvider = nodeProvider
+	pool.checkNodes(ctx)
+	status = pool.Status()
+	assert.Len(t, status, 1)
+	assert.Equal(t, MainNodeName, status[0].Name)
+
+	// stop checking after closed
+	pool.cfg.CheckInterval = time.Millisecond * 10
+	pool.Start(ctx)
+	pool.Close()
+	nodeProvider = mocks.NewMockNodeRepo(ctrl)
+	nodeProvider.EXPECT().ListNode().Times(0)
+	pool.nodeProvider = nodeProvider
+	time.Sleep(pool.cfg.CheckInterval * 3)
+}
@@ -0,0 +1,80 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/filecoin-project/venus/venus-shared/types"
+)
+
+const (
+	fileSinkFormatJSON = "json"
+	fileSinkFormatHex  = "hex"
+)
+
+// FileSinkPublisher append messages to a file, one message per line,
+// in json or hex of the cbor encoded message
+type FileSinkPublisher struct {
+	path   string
+	format string
+
+	lk sync.Mutex
+}
+
+func NewFileSinkPublisher(path, format string) (*FileSinkPublisher, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("path is required")
+	}
+	if format != fileSinkFormatJSON && format != fileSinkFormatHex {
+		return nil, fmt.Errorf("unsupported format %s, should be %s or %s", format, fileSinkFormatJSON, fileSinkFormatHex)
+	}
+	return &FileSinkPublisher{path: path, format: format}, nil
+}
+
+// newFileSinkStage supports options: path and format
+func newFileSinkStage(_ context.Context, _ *StageDeps, opts StageOptions) (IMsgPublisher, error) {
+	return NewFileSinkPublisher(opts.String("path", ""), opts.String("format", fileSinkFormatJSON))
+}
+
+func (p *FileSinkPublisher) PublishMessages(_ context.Context, msgs []*types.SignedMessage) error {
+	buf := bytes.Buffer{}
+	for _, msg := range msgs {
+		switch p.format {
+		case fileSinkFormatHex:
+			data, err := msg.Serialize()
+			if err != nil {
+				return err
+			}
+			buf.WriteString(hex.EncodeToString(data))
+		default:
+			data, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			buf.Write(data)
+		}
+		buf.WriteByte('\n')
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	f, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
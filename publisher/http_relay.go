@@ -0,0 +1,78 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/venus/venus-shared/types"
+)
+
+const maxRelayErrorBody = 512
+
+// HTTPRelayPublisher post messages to a http endpoint as a json array
+type HTTPRelayPublisher struct {
+	url    string
+	header http.Header
+	client *http.Client
+}
+
+func NewHTTPRelayPublisher(url string, header http.Header, timeout time.Duration) (*HTTPRelayPublisher, error) {
+	if len(url) == 0 {
+		return nil, fmt.Errorf("url is required")
+	}
+	return &HTTPRelayPublisher{
+		url:    url,
+		header: header,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// newHTTPRelayStage supports options: url, timeout and header.<Name>
+func newHTTPRelayStage(_ context.Context, _ *StageDeps, opts StageOptions) (IMsgPublisher, error) {
+	timeout, err := opts.Duration("timeout", 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	for k, v := range opts {
+		if name, ok := strings.CutPrefix(k, "header."); ok {
+			header.Set(name, v)
+		}
+	}
+	return NewHTTPRelayPublisher(opts.String("url", ""), header, timeout)
+}
+
+func (p *HTTPRelayPublisher) PublishMessages(ctx context.Context, msgs []*types.SignedMessage) error {
+	body, err := json.Marshal(msgs)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range p.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("relay messages to %s: %w", p.url, err)
+	}
+	defer resp.Body.Close() // nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxRelayErrorBody))
+		return fmt.Errorf("relay messages to %s: status %d: %s", p.url, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/ipfs/go-cid"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+const (
+	// the interval of checking the messages due to retry
+	outboxPollInterval     = time.Second
+	maxOutboxRetryInterval = 10 * time.Minute
+)
+
+// MessageReceiver receive the signed messages to publish.
+type MessageReceiver interface {
+	PushMessages(ctx context.Context, msgs []*types.SignedMessage) error
+}
+
+var _ MessageReceiver = (*Outbox)(nil)
+
+// Outbox save the messages to publish in database before publishing, and remove them after published,
+// so the messages are published at least once across restarts and not dropped when the publisher is busy.
+type Outbox struct {
+	repo      repo.OutboxRepo
+	publisher IMsgPublisher
+	cfg       *config.PublisherConfig
+	notify    chan struct{}
+}
+
+func NewOutbox(ctx context.Context, cfg *config.PublisherConfig, outboxRepo repo.OutboxRepo, p IMsgPublisher) *Outbox {
+	o := &Outbox{
+		repo:      outboxRepo,
+		publisher: p,
+		cfg:       cfg,
+		notify:    make(chan struct{}, 1),
+	}
+	go o.run(ctx)
+	return o
+}
+
+func (o *Outbox) PushMessages(ctx context.Context, msgs []*types.SignedMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	now := time.Now()
+	list := make([]*mtypes.OutboxMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		list = append(list, &mtypes.OutboxMessage{
+			Cid:           msg.Cid(),
+			From:          msg.Message.From,
+			Nonce:         msg.Message.Nonce,
+			Msg:           msg,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+	if err := o.repo.SaveOutboxMessages(ctx, list); err != nil {
+		return fmt.Errorf("save messages to outbox failed: %w", err)
+	}
+
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (o *Outbox) run(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		o.publishDue(ctx)
+		select {
+		case <-ctx.Done():
+			log.Infof("context done, stop publishing outbox messages")
+			return
+		case <-o.notify:
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDue publish the due messages batch by batch until none left.
+func (o *Outbox) publishDue(ctx context.Context) {
+	for {
+		msgs, err := o.repo.ListDueOutboxMessages(ctx, time.Now(), o.cfg.OutboxBatchSize)
+		if err != nil {
+			log.Warnf("list outbox messages failed: %v", err)
+			return
+		}
+		if len(msgs) == 0 {
+			return
+		}
+		if err := o.publish(ctx, msgs); err != nil {
+			log.Warnf("update outbox failed: %v", err)
+			return
+		}
+		if len(msgs) < o.cfg.OutboxBatchSize {
+			return
+		}
+	}
+}
+
+// publish the messages of each address in parallel, the concurrency is limited by the publisher pipeline.
+// The messages are removed from outbox only if the publisher returned nil, which means at least one backend
+// accepted them, otherwise they are retried later.
+func (o *Outbox) publish(ctx context.Context, msgs []*mtypes.OutboxMessage) error {
+	groups := make(map[address.Address][]*mtypes.OutboxMessage)
+	for _, msg := range msgs {
+		groups[msg.From] = append(groups[msg.From], msg)
+	}
+
+	lk := sync.Mutex{}
+	results := make(map[address.Address]error, len(groups))
+	wg := sync.WaitGroup{}
+	for addr, group := range groups {
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].Nonce < group[j].Nonce
+		})
+		signedMsgs := make([]*types.SignedMessage, 0, len(group))
+		for _, msg := range group {
+			signedMsgs = append(signedMsgs, msg.Msg)
+		}
+
+		wg.Add(1)
+		go func(addr address.Address) {
+			defer wg.Done()
+			err := o.publisher.PublishMessages(ctx, signedMsgs)
+			lk.Lock()
+			results[addr] = err
+			lk.Unlock()
+		}(addr)
+	}
+	wg.Wait()
+
+	var done []cid.Cid
+	for addr, group := range groups {
+		err := results[addr]
+		if err == nil {
+			for _, msg := range group {
+				done = append(done, msg.Cid)
+			}
+			continue
+		}
+		log.Warnw("publish message failed", "addr", addr.String(), "msg len", len(group), "err", err)
+		for _, msg := range group {
+			attempts := msg.Attempts + 1
+			if attempts >= o.cfg.OutboxMaxAttempts {
+				log.Errorf("remove message %s from outbox after %d failed attempts, last error: %v", msg.Cid, attempts, err)
+				done = append(done, msg.Cid)
+				continue
+			}
+			if err := o.repo.UpdateOutboxAttempt(ctx, msg.Cid, attempts, time.Now().Add(o.retryInterval(attempts)), err.Error()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return o.repo.DeleteOutboxMessages(ctx, done)
+}
+
+// retryInterval doubles OutboxRetryInterval for each failed attempt, up to maxOutboxRetryInterval.
+func (o *Outbox) retryInterval(attempts int) time.Duration {
+	interval := o.cfg.OutboxRetryInterval
+	for i := 1; i < attempts && interval < maxOutboxRetryInterval; i++ {
+		interval *= 2
+	}
+	if interval > maxOutboxRetryInterval {
+		interval = maxOutboxRetryInterval
+	}
+	return interval
+}
+
+// Len returns the number of messages waiting to be published.
+func (o *Outbox) Len(ctx context.Context) (int64, error) {
+	return o.repo.CountOutboxMessages(ctx)
+}
@@ -0,0 +1,138 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockV1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1/mock"
+	"github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/golang/mock/gomock"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/filestore"
+	"github.com/ipfs-force-community/sophon-messager/mocks"
+	"github.com/ipfs-force-community/sophon-messager/models/sqlite"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestOutbox(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	fs := filestore.NewMockFileStore(t.TempDir())
+	sqliteRepo, err := sqlite.OpenSqlite(fs)
+	assert.NoError(t, err)
+	assert.NoError(t, sqliteRepo.AutoMigrate())
+
+	cfg := config.DefaultConfig().Publisher
+	cfg.OutboxRetryInterval = time.Hour
+	cfg.OutboxMaxAttempts = 2
+	newOutbox := func(p IMsgPublisher) *Outbox {
+		// publish by calling publishDue rather than the background loop
+		return &Outbox{repo: sqliteRepo.OutboxRepo(), publisher: p, cfg: cfg, notify: make(chan struct{}, 1)}
+	}
+	countOutbox := func(o *Outbox, expect int64) {
+		count, err := o.Len(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, count)
+	}
+
+	msgs := testhelper.NewShareSignedMessages(3)
+	for _, msg := range msgs {
+		msg.Message.From = msgs[0].Message.From
+	}
+	// push in reverse order, publish in nonce order
+	p := mocks.NewMockIMsgPublisher(ctrl)
+	outbox := newOutbox(p)
+	assert.NoError(t, outbox.PushMessages(ctx, []*types.SignedMessage{msgs[2], msgs[1]}))
+	assert.NoError(t, outbox.PushMessages(ctx, []*types.SignedMessage{msgs[0]}))
+	assert.NoError(t, outbox.PushMessages(ctx, nil))
+	countOutbox(outbox, 3)
+
+	p.EXPECT().PublishMessages(ctx, msgs).Return(errors.New("no publisher available")).Times(1)
+	outbox.publishDue(ctx)
+	countOutbox(outbox, 3)
+	// not due until the retry interval passed
+	outbox.publishDue(ctx)
+	due, err := sqliteRepo.OutboxRepo().ListDueOutboxMessages(ctx, time.Now().Add(2*time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 3)
+	for _, msg := range due {
+		assert.Equal(t, 1, msg.Attempts)
+		assert.Equal(t, "no publisher available", msg.LastError)
+	}
+
+	// the messages are still in outbox after restart, push again make them due immediately
+	p = mocks.NewMockIMsgPublisher(ctrl)
+	outbox = newOutbox(p)
+	assert.NoError(t, outbox.PushMessages(ctx, msgs[:1]))
+	p.EXPECT().PublishMessages(ctx, msgs[:1]).Return(nil).Times(1)
+	outbox.publishDue(ctx)
+	countOutbox(outbox, 2)
+
+	// removed after failing OutboxMaxAttempts times
+	assert.NoError(t, outbox.PushMessages(ctx, msgs[1:]))
+	p.EXPECT().PublishMessages(ctx, msgs[1:]).Return(errors.New("no publisher available")).Times(1)
+	outbox.publishDue(ctx)
+	countOutbox(outbox, 0)
+}
+
+func TestOutboxBackendFailed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctrl := gomock.NewController(t)
+
+	fs := filestore.NewMockFileStore(t.TempDir())
+	sqliteRepo, err := sqlite.OpenSqlite(fs)
+	assert.NoError(t, err)
+	assert.NoError(t, sqliteRepo.AutoMigrate())
+
+	cfg := config.DefaultConfig().Publisher
+	cfg.OutboxRetryInterval = time.Hour
+	mainNode := mockV1.NewMockFullNode(ctrl)
+	deps := &StageDeps{
+		Cfg:          cfg,
+		RpcPublisher: NewRpcPublisher(ctx, mainNode, nil, false, sqliteRepo.MessageRepo(), sqliteRepo.PublishReceiptRepo()),
+	}
+	p, err := BuildPipeline(ctx, deps, defaultPipeline(cfg))
+	assert.NoError(t, err)
+	outbox := &Outbox{repo: sqliteRepo.OutboxRepo(), publisher: p, cfg: cfg, notify: make(chan struct{}, 1)}
+
+	// the error of the node is returned through the cache and concurrent stages, the messages stay in outbox
+	msgs := testhelper.NewShareSignedMessages(2)
+	msgs[1].Message.From = msgs[0].Message.From
+	assert.NoError(t, outbox.PushMessages(ctx, msgs))
+	mainNode.EXPECT().MpoolBatchPushUntrusted(gomock.Any(), msgs).Return(nil, errors.New("connection refused")).Times(1)
+	outbox.publishDue(ctx)
+	due, err := sqliteRepo.OutboxRepo().ListDueOutboxMessages(ctx, time.Now().Add(2*time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+	for _, msg := range due {
+		assert.Equal(t, 1, msg.Attempts)
+		assert.Contains(t, msg.LastError, "connection refused")
+	}
+
+	// not skipped by the cache, and removed after the node accepted them
+	assert.NoError(t, outbox.PushMessages(ctx, msgs))
+	mainNode.EXPECT().MpoolBatchPushUntrusted(gomock.Any(), msgs).Return([]cid.Cid{msgs[0].Cid(), msgs[1].Cid()}, nil).Times(1)
+	outbox.publishDue(ctx)
+	count, err := outbox.Len(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestOutboxRetryInterval(t *testing.T) {
+	cfg := config.DefaultConfig().Publisher
+	cfg.OutboxRetryInterval = 10 * time.Second
+	outbox := &Outbox{cfg: cfg}
+
+	assert.Equal(t, 10*time.Second, outbox.retryInterval(1))
+	assert.Equal(t, 20*time.Second, outbox.retryInterval(2))
+	assert.Equal(t, 80*time.Second, outbox.retryInterval(4))
+	assert.Equal(t, maxOutboxRetryInterval, outbox.retryInterval(10))
+	assert.Equal(t, maxOutboxRetryInterval, outbox.retryInterval(100))
+}
@@ -10,7 +10,9 @@ import (
 
 	v1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1"
 	"github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
 	"github.com/ipfs-force-community/sophon-messager/models/repo"
+	"github.com/ipfs-force-community/sophon-messager/nodepool"
 	mpubsub "github.com/ipfs-force-community/sophon-messager/publisher/pubsub"
 	"github.com/ipfs/go-cid"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
@@ -27,18 +29,26 @@ type IMsgPublisher interface {
 }
 
 type P2pPublisher struct {
-	topic *pubsub.Topic
+	topic  *pubsub.Topic
+	pubsub mpubsub.IPubsuber
+
+	lk                 sync.Mutex
+	published          uint64
+	publishFailed      uint64
+	lastPublishAt      time.Time
+	lastPublishErr     error
+	lastPublishErrorAt time.Time
 }
 
 func NewP2pPublisher(pubsub mpubsub.IPubsuber, netName types.NetworkName) (*P2pPublisher, error) {
-	topicName := fmt.Sprintf("/fil/msgs/%s", netName)
-	topic, err := pubsub.GetTopic(topicName)
+	topic, err := pubsub.GetTopic(mpubsub.MessageTopic(netName))
 	if err != nil {
 		return nil, err
 	}
 
 	return &P2pPublisher{
-		topic: topic,
+		topic:  topic,
+		pubsub: pubsub,
 	}, nil
 }
 
@@ -48,18 +58,61 @@ func (p *P2pPublisher) PublishMessages(ctx context.Context, msgs []*types.Signed
 		if err != nil {
 			return fmt.Errorf("marshal message %s failed %w", msg.Cid(), err)
 		}
-		if err := p.topic.Publish(ctx, msgb); err != nil {
+		err = p.topic.Publish(ctx, msgb)
+		p.recordPublish(err)
+		if err != nil {
 			return fmt.Errorf("publish message %s failed %w", msg.Cid(), err)
 		}
 	}
 	return nil
 }
 
+func (p *P2pPublisher) recordPublish(err error) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	if err != nil {
+		p.publishFailed++
+		p.lastPublishErr = err
+		p.lastPublishErrorAt = time.Now()
+		return
+	}
+	p.published++
+	p.lastPublishAt = time.Now()
+}
+
+// TopicStatus returns the mesh and peer scores of the message topic, and the result of publishing to it.
+func (p *P2pPublisher) TopicStatus(_ context.Context) (*mtypes.TopicStatus, error) {
+	status := p.pubsub.TopicStatus(p.topic.String())
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	status.Published = p.published
+	status.PublishFailed = p.publishFailed
+	status.LastPublishAt = p.lastPublishAt
+	if p.lastPublishErr != nil {
+		status.LastPublishError = p.lastPublishErr.Error()
+	}
+	status.LastPublishErrorAt = p.lastPublishErrorAt
+
+	return status, nil
+}
+
+const (
+	// the interval of deleting the expired publish receipts
+	receiptPruneInterval = time.Hour
+	// a node is unhealthy after failing to push messages maxNodeFailures times in a row
+	maxNodeFailures = 3
+	// an unhealthy node is skipped until nodeRetryInterval passed, then try it again
+	nodeRetryInterval = time.Minute
+)
+
 type RpcPublisher struct {
 	ctx             context.Context
 	mainNodeThread  *nodeThread
 	nodeProvider    repo.INodeProvider
 	msgRepo         repo.MessageRepo
+	receiptRepo     repo.PublishReceiptRepo
 	enableMultiNode bool
 
 	nodeThreads map[types.UUID]struct {
@@ -74,13 +127,16 @@ func NewRpcPublisher(ctx context.Context,
 	nodeProvider repo.INodeProvider,
 	enableMultiNode bool,
 	msgRepo repo.MessageRepo,
+	receiptRepo repo.PublishReceiptRepo,
 ) *RpcPublisher {
-	nThread := newNodeThread(ctx, "mainNode", nodeClient, msgRepo)
+	nThread := newNodeThread(nodepool.MainNodeName, nodeClient, msgRepo, receiptRepo)
+	nThread.main = true
 	return &RpcPublisher{
 		ctx:             ctx,
 		mainNodeThread:  nThread,
 		nodeProvider:    nodeProvider,
 		msgRepo:         msgRepo,
+		receiptRepo:     receiptRepo,
 		enableMultiNode: enableMultiNode,
 		nodeThreads: make(map[types.UUID]struct {
 			nodeThread *nodeThread
@@ -91,21 +147,47 @@ func NewRpcPublisher(ctx context.Context,
 	}
 }
 
-func (p *RpcPublisher) PublishMessages(_ context.Context, msgs []*types.SignedMessage) error {
-	p.mainNodeThread.HandleMsg(msgs)
+// PublishMessages push msgs to the main node and the other nodes in parallel, returns nil if any node accepted them.
+func (p *RpcPublisher) PublishMessages(ctx context.Context, msgs []*types.SignedMessage) error {
+	threads := []*nodeThread{p.mainNodeThread}
+	if p.enableMultiNode {
+		others, err := p.listNodeThreads()
+		if err != nil {
+			log.Warnf("push messages to the main node only: %v", err)
+		}
+		threads = append(threads, others...)
+	}
 
-	if !p.enableMultiNode {
-		return nil
+	errs := make([]error, len(threads))
+	wg := sync.WaitGroup{}
+	for i, thread := range threads {
+		wg.Add(1)
+		go func(i int, thread *nodeThread) {
+			defer wg.Done()
+			errs[i] = thread.HandleMsg(ctx, msgs)
+		}(i, thread)
 	}
+	wg.Wait()
 
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("no node accepted the messages: %w", errors.Join(errs...))
+}
+
+// listNodeThreads connect the nodes added to NodeRepo and disconnect the deleted, returns the threads of the nodes.
+func (p *RpcPublisher) listNodeThreads() ([]*nodeThread, error) {
 	nodeList, err := p.nodeProvider.ListNode()
 	if err != nil {
-		return fmt.Errorf("list node fail %w", err)
+		return nil, fmt.Errorf("list node fail %w", err)
 	}
 
 	p.lk.Lock()
 	defer p.lk.Unlock()
 
+	threads := make([]*nodeThread, 0, len(nodeList))
 	nodesRemain := make(map[types.UUID]struct{})
 	for _, node := range nodeList {
 		threadStruct, ok := p.nodeThreads[node.ID]
@@ -124,7 +206,7 @@ func (p *RpcPublisher) PublishMessages(_ context.Context, msgs []*types.SignedMe
 				nodeThread *nodeThread
 				close      func()
 			}{
-				nodeThread: newNodeThread(thrCtx, nodeName, cli, p.msgRepo),
+				nodeThread: newNodeThread(nodeName, cli, p.msgRepo, p.receiptRepo),
 				close: func() {
 					cancel()
 					closer()
@@ -133,7 +215,7 @@ func (p *RpcPublisher) PublishMessages(_ context.Context, msgs []*types.SignedMe
 			}
 			p.nodeThreads[node.ID] = threadStruct
 		}
-		threadStruct.nodeThread.HandleMsg(msgs)
+		threads = append(threads, threadStruct.nodeThread)
 	}
 
 	for id, threadStruct := range p.nodeThreads {
@@ -143,58 +225,147 @@ func (p *RpcPublisher) PublishMessages(_ context.Context, msgs []*types.SignedMe
 		}
 	}
 
-	return nil
+	return threads, nil
 }
 
+var errNodeUnhealthy = errors.New("node is unhealthy")
+
 type nodeThread struct {
-	name       string
-	nodeClient v1.FullNode
-	msgRepo    repo.MessageRepo
-	msgChan    chan []*types.SignedMessage
+	name string
+	// main is true for the node in config file
+	main        bool
+	nodeClient  v1.FullNode
+	msgRepo     repo.MessageRepo
+	receiptRepo repo.PublishReceiptRepo
+
+	lk sync.Mutex
+	// the number of consecutive failures
+	failures      int
+	lastFailureAt time.Time
 }
 
-func newNodeThread(ctx context.Context, name string, nodeClient v1.FullNode, msgRepo repo.MessageRepo) *nodeThread {
-	t := &nodeThread{
-		name:       name,
-		nodeClient: nodeClient,
-		msgRepo:    msgRepo,
-		msgChan:    make(chan []*types.SignedMessage, 30),
+func newNodeThread(name string, nodeClient v1.FullNode, msgRepo repo.MessageRepo, receiptRepo repo.PublishReceiptRepo) *nodeThread {
+	return &nodeThread{
+		name:        name,
+		nodeClient:  nodeClient,
+		msgRepo:     msgRepo,
+		receiptRepo: receiptRepo,
 	}
-	go t.run(ctx)
-	return t
 }
 
-func (n *nodeThread) run(ctx context.Context) {
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case msgs := <-n.msgChan:
-				if msgCIDs, err := n.nodeClient.MpoolBatchPushUntrusted(ctx, msgs); err != nil {
-					// skip error
-					if !strings.Contains(err.Error(), errMinimumNonce.Error()) && !strings.Contains(err.Error(), errExistingNonce.Error()) {
-						var failedMsg []cid.Cid
-						for i := len(msgCIDs); i < len(msgs); i++ {
-							failedMsg = append(failedMsg, msgs[i].Cid())
-						}
-						log.Errorf("failed to push message to node, address: %v, error: %v, msgs: %v",
-							msgs[0].Message.From, err, failedMsg)
-
-						for _, msg := range msgs {
-							n.recordPushMessageError(msg.Cid(), err)
-						}
-					} else {
-						log.Debugf("failed to push message: %v", err)
-					}
-				}
-			}
+// HandleMsg push msgs to the node and returns nil if the node accepted them. The messages are not pushed if the
+// node is unhealthy, except the main node, which is always tried as the last resort.
+func (n *nodeThread) HandleMsg(ctx context.Context, msgs []*types.SignedMessage) error {
+	if !n.main && !n.Healthy() {
+		log.Warnf("skip pushing %d messages of %s to unhealthy node %s", len(msgs), msgs[0].Message.From, n.name)
+		return fmt.Errorf("%w: %s", errNodeUnhealthy, n.name)
+	}
+
+	msgCIDs, err := n.nodeClient.MpoolBatchPushUntrusted(ctx, msgs)
+	if err == nil {
+		n.recordSuccess()
+		n.recordReceipts(ctx, msgs, len(msgCIDs), nil)
+		return nil
+	}
+
+	if strings.Contains(err.Error(), errMinimumNonce.Error()) || strings.Contains(err.Error(), errExistingNonce.Error()) {
+		// the node has messages with the same nonce, they may be these messages pushed before, so the node is not
+		// counted as failed, and pushing again makes no difference. the cids are not returned over rpc on error,
+		// so the whole batch is recorded as not accepted
+		log.Debugf("node %s rejected messages by nonce: %v", n.name, err)
+		n.recordSuccess()
+		n.recordReceipts(ctx, msgs, len(msgCIDs), err)
+		return nil
+	}
+
+	var failedMsg []cid.Cid
+	for i := len(msgCIDs); i < len(msgs); i++ {
+		failedMsg = append(failedMsg, msgs[i].Cid())
+	}
+	log.Errorf("failed to push message to node %s, address: %v, error: %v, msgs: %v",
+		n.name, msgs[0].Message.From, err, failedMsg)
+
+	for _, msg := range msgs {
+		n.recordPushMessageError(msg.Cid(), err)
+	}
+	n.recordFailure()
+	n.recordReceipts(ctx, msgs, len(msgCIDs), err)
+	return fmt.Errorf("push messages to node %s: %w", n.name, err)
+}
+
+// Healthy returns false if the node failed too many times recently.
+func (n *nodeThread) Healthy() bool {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+
+	return n.failures < maxNodeFailures || time.Since(n.lastFailureAt) > nodeRetryInterval
+}
+
+func (n *nodeThread) recordFailure() {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+
+	n.failures++
+	n.lastFailureAt = time.Now()
+	if n.failures == maxNodeFailures && !n.main {
+		log.Warnf("node %s failed %d times in a row, skip it for %v", n.name, n.failures, nodeRetryInterval)
+	}
+}
+
+func (n *nodeThread) recordSuccess() {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+
+	if n.failures >= maxNodeFailures {
+		log.Infof("node %s recovered", n.name)
+	}
+	n.failures = 0
+}
+
+// recordReceipts save the push result of msgs, the first accepted messages are pushed successfully, the others failed with err.
+func (n *nodeThread) recordReceipts(ctx context.Context, msgs []*types.SignedMessage, accepted int, err error) {
+	now := time.Now()
+	receipts := make([]*mtypes.PublishReceipt, 0, len(msgs))
+	for i, msg := range msgs {
+		receipt := &mtypes.PublishReceipt{
+			MsgCid:    msg.Cid(),
+			Node:      n.name,
+			Accepted:  i < accepted || err == nil,
+			CreatedAt: now,
+			UpdatedAt: now,
 		}
-	}()
+		if !receipt.Accepted {
+			receipt.Error = err.Error()
+		}
+		receipts = append(receipts, receipt)
+	}
+	if dbErr := n.receiptRepo.SavePublishReceipts(ctx, receipts); dbErr != nil {
+		log.Warnf("failed to save publish receipts of node %s: %v", n.name, dbErr)
+	}
 }
 
-func (n *nodeThread) HandleMsg(msgs []*types.SignedMessage) {
-	n.msgChan <- msgs
+// PruneReceipts delete the publish receipts older than retention periodically until ctx done,
+// nothing is deleted if retention is not positive.
+func PruneReceipts(ctx context.Context, receiptRepo repo.PublishReceiptRepo, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(receiptPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := receiptRepo.DeletePublishReceiptsBefore(ctx, time.Now().Add(-retention))
+		if err != nil {
+			log.Warnf("failed to delete expired publish receipts: %v", err)
+		} else if deleted > 0 {
+			log.Infof("deleted %d publish receipts older than %v", deleted, retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 func (n *nodeThread) recordPushMessageError(msgCid cid.Cid, err error) {
@@ -218,6 +389,7 @@ func (n *nodeThread) recordPushMessageError(msgCid cid.Cid, err error) {
 	}
 }
 
+// MergePublisher publish messages with all sub publishers in parallel, it succeeds if any of them succeeded.
 type MergePublisher struct {
 	ctx           context.Context
 	subPublishers []IMsgPublisher
@@ -235,25 +407,41 @@ func (p *MergePublisher) PublishMessages(ctx context.Context, msgs []*types.Sign
 	if len(p.subPublishers) == 0 {
 		return fmt.Errorf("no publisher available")
 	}
-	for _, publisher := range p.subPublishers {
-		err := publisher.PublishMessages(ctx, msgs)
-		if err != nil {
-			log.Errorf("MergePublisher publish message with sub publisher failed: %v", err)
+
+	errs := make([]error, len(p.subPublishers))
+	wg := sync.WaitGroup{}
+	for i, publisher := range p.subPublishers {
+		wg.Add(1)
+		go func(i int, publisher IMsgPublisher) {
+			defer wg.Done()
+			errs[i] = publisher.PublishMessages(ctx, msgs)
+			if errs[i] != nil {
+				log.Errorf("MergePublisher publish message with sub publisher failed: %v", errs[i])
+			}
+		}(i, publisher)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (p *MergePublisher) AddPublisher(publisher IMsgPublisher) {
 	p.subPublishers = append(p.subPublishers, publisher)
 }
 
+// CachePublisher skip the messages published successfully in the last cacheReleasePeriod
 type CachePublisher struct {
-	msgCh chan []*types.SignedMessage
-	cache map[cid.Cid]bool
 	// cacheReleasePeriod is the period of cache release
 	cacheReleasePeriod uint64 //seconds
 	subPublisher       IMsgPublisher
+
+	lk    sync.Mutex
+	cache map[cid.Cid]bool
 }
 
 func NewCachePublisher(ctx context.Context, cacheReleasePeriod uint64, subPublisher IMsgPublisher) (*CachePublisher, error) {
@@ -261,7 +449,6 @@ func NewCachePublisher(ctx context.Context, cacheReleasePeriod uint64, subPublis
 		return nil, fmt.Errorf("cache release period should not be zero")
 	}
 	p := &CachePublisher{
-		msgCh:              make(chan []*types.SignedMessage, 30),
 		cache:              make(map[cid.Cid]bool),
 		cacheReleasePeriod: cacheReleasePeriod,
 		subPublisher:       subPublisher,
@@ -270,34 +457,44 @@ func NewCachePublisher(ctx context.Context, cacheReleasePeriod uint64, subPublis
 	return p, nil
 }
 
-func (p *CachePublisher) PublishMessages(_ context.Context, msgs []*types.SignedMessage) error {
-	p.msgCh <- msgs
+// PublishMessages publish the messages not in cache, and cache them only if published successfully,
+// so the failed messages are published again next time.
+func (p *CachePublisher) PublishMessages(ctx context.Context, msgs []*types.SignedMessage) error {
+	p.lk.Lock()
+	newMsgs := make([]*types.SignedMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		if _, ok := p.cache[msg.Cid()]; !ok {
+			newMsgs = append(newMsgs, msg)
+		}
+	}
+	p.lk.Unlock()
+
+	if len(newMsgs) == 0 {
+		return nil
+	}
+	if err := p.subPublisher.PublishMessages(ctx, newMsgs); err != nil {
+		return err
+	}
+
+	p.lk.Lock()
+	defer p.lk.Unlock()
+	for _, msg := range newMsgs {
+		p.cache[msg.Cid()] = true
+	}
 	return nil
 }
 
 func (p *CachePublisher) run(ctx context.Context) {
 	ticker := time.NewTicker(time.Duration(p.cacheReleasePeriod) * time.Second)
 	go func() {
+		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case msgs := <-p.msgCh:
-				newMsgs := make([]*types.SignedMessage, 0, len(msgs)/2)
-				for _, msg := range msgs {
-					c := msg.Cid()
-					if _, ok := p.cache[c]; !ok {
-						newMsgs = append(newMsgs, msg)
-					}
-					p.cache[c] = true
-				}
-				if len(newMsgs) > 0 {
-					if err := p.subPublisher.PublishMessages(ctx, newMsgs); err != nil {
-						log.Errorf("CachePublisher publish message with sub publisher fail %v", err)
-					}
-				}
 			case <-ticker.C:
 				// every cacheReleasePeriod rm old cache ,set new cache to old one
+				p.lk.Lock()
 				for k, v := range p.cache {
 					if v {
 						p.cache[k] = false
@@ -305,55 +502,101 @@ func (p *CachePublisher) run(ctx context.Context) {
 						delete(p.cache, k)
 					}
 				}
+				p.lk.Unlock()
 			}
 		}
 	}()
 }
 
-// ConcurrentPublisher call subPublisher concurrently
+// ConcurrentPublisher limit the number of calling subPublisher at the same time
 type ConcurrentPublisher struct {
-	ctx          context.Context
-	msgCh        chan []*types.SignedMessage
 	subPublisher IMsgPublisher
-	concurrency  uint
+	// explicit is true if the concurrency is set by the stage option rather than publisher.concurrency
+	explicit bool
+
+	lk   sync.Mutex
+	cond *sync.Cond
+	// the max number of calls in progress
+	limit   uint
+	running uint
 }
 
 // NewConcurrentPublisher return a ConcurrentPublisher
 // subPublisher should be thread safe
-func NewConcurrentPublisher(ctx context.Context, concurrency uint, subPublisher IMsgPublisher) (*ConcurrentPublisher, error) {
+func NewConcurrentPublisher(_ context.Context, concurrency uint, subPublisher IMsgPublisher) (*ConcurrentPublisher, error) {
 	if subPublisher == nil {
 		return nil, fmt.Errorf("sub publisher is nil")
 	}
 	c := &ConcurrentPublisher{
-		ctx:          ctx,
-		msgCh:        make(chan []*types.SignedMessage, 30),
 		subPublisher: subPublisher,
-		concurrency:  concurrency,
+		limit:        concurrency,
 	}
-	c.run()
+	c.cond = sync.NewCond(&c.lk)
 	return c, nil
 }
 
-func (p *ConcurrentPublisher) PublishMessages(_ context.Context, msgs []*types.SignedMessage) error {
-	p.msgCh <- msgs
-	return nil
+// PublishMessages wait until the number of calls in progress is less than the concurrency, then call subPublisher.
+func (p *ConcurrentPublisher) PublishMessages(ctx context.Context, msgs []*types.SignedMessage) error {
+	p.lk.Lock()
+	for p.running >= p.limit {
+		p.cond.Wait()
+	}
+	p.running++
+	p.lk.Unlock()
+
+	defer func() {
+		p.lk.Lock()
+		p.running--
+		p.lk.Unlock()
+		p.cond.Signal()
+	}()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return p.subPublisher.PublishMessages(ctx, msgs)
 }
 
-func (p *ConcurrentPublisher) run() {
-	var i uint
-	for i = 0; i < p.concurrency; i++ {
-		go func() {
-			for {
-				select {
-				case <-p.ctx.Done():
-					return
-				case msgs := <-p.msgCh:
-					err := p.subPublisher.PublishMessages(p.ctx, msgs)
-					if err != nil {
-						log.Errorf("ConcurrentPublisher publish message with sub publisher fail %v", err)
-					}
-				}
+// SetConcurrency change the max number of calls in progress, the calls in progress are not interrupted.
+func (p *ConcurrentPublisher) SetConcurrency(concurrency uint) {
+	p.lk.Lock()
+	p.limit = concurrency
+	p.lk.Unlock()
+	p.cond.Broadcast()
+}
+
+func (p *ConcurrentPublisher) Concurrency() uint {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	return p.limit
+}
+
+// UpdateConcurrency change the concurrency of the ConcurrentPublishers in the publisher chain of p, except the ones
+// with the concurrency set by stage option, returns error if there is no ConcurrentPublisher, which means it was
+// disabled when starting.
+func UpdateConcurrency(p IMsgPublisher, concurrency int) error {
+	found := false
+	for p != nil {
+		switch pub := p.(type) {
+		case *ConcurrentPublisher:
+			if concurrency <= 0 {
+				return fmt.Errorf("concurrent publisher can't be disabled without restart")
 			}
-		}()
+			found = true
+			if !pub.explicit {
+				pub.SetConcurrency(uint(concurrency))
+			}
+			p = pub.subPublisher
+		case *CachePublisher:
+			p = pub.subPublisher
+		default:
+			p = nil
+		}
 	}
+	if !found {
+		return fmt.Errorf("concurrent publisher is not enabled")
+	}
+
+	return nil
 }
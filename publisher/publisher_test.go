@@ -4,20 +4,22 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
-	"runtime"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/ipfs-force-community/sophon-messager/filestore"
 	"github.com/ipfs-force-community/sophon-messager/mocks"
+	localtypes "github.com/ipfs-force-community/sophon-messager/models/mtypes"
 	"github.com/ipfs-force-community/sophon-messager/models/sqlite"
+	mpubsub "github.com/ipfs-force-community/sophon-messager/publisher/pubsub"
 	"github.com/ipfs-force-community/sophon-messager/testhelper"
 
 	mockV1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1/mock"
 	"github.com/filecoin-project/venus/venus-shared/types"
 	mtypes "github.com/filecoin-project/venus/venus-shared/types/messager"
 	"github.com/golang/mock/gomock"
+	"github.com/ipfs/go-cid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,15 +34,13 @@ func TestMainNodePublishMessage(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NoError(t, sqliteRepo.AutoMigrate())
 
-	rpcPublisher := NewRpcPublisher(ctx, mainNode, nil, false, sqliteRepo.MessageRepo())
+	rpcPublisher := NewRpcPublisher(ctx, mainNode, nil, false, sqliteRepo.MessageRepo(), sqliteRepo.PublishReceiptRepo())
 	publisher := NewMergePublisher(ctx, rpcPublisher)
 	msgs := testhelper.NewShareSignedMessages(10)
 
 	mainNode.EXPECT().MpoolBatchPushUntrusted(ctx, msgs).Return(nil, nil).Times(1)
 	err = publisher.PublishMessages(ctx, msgs)
 	assert.NoError(t, err)
-	runtime.Gosched()
-	time.Sleep(1 * time.Second)
 }
 
 func TestMultiNodePublishMessage(t *testing.T) {
@@ -74,7 +74,7 @@ func TestMultiNodePublishMessage(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NoError(t, sqliteRepo.AutoMigrate())
 	nodeProvider := mocks.NewMockNodeRepo(ctrl)
-	rpcPublisher := NewRpcPublisher(ctx, mainNode, nodeProvider, true, sqliteRepo.MessageRepo())
+	rpcPublisher := NewRpcPublisher(ctx, mainNode, nodeProvider, true, sqliteRepo.MessageRepo(), sqliteRepo.PublishReceiptRepo())
 
 	t.Run("publish message to multi node", func(t *testing.T) {
 		nodeProvider.EXPECT().ListNode().Return(nodes[:3], nil).Times(1)
@@ -83,12 +83,8 @@ func TestMultiNodePublishMessage(t *testing.T) {
 		}
 		err := rpcPublisher.PublishMessages(ctx, msgs)
 		assert.NoError(t, err)
-		runtime.Gosched()
 	})
 
-	// wait for messager consume
-	time.Sleep(1 * time.Second)
-
 	t.Run("publish message to multi node after delete node", func(t *testing.T) {
 		nodeProvider.EXPECT().ListNode().Return(nodes[1:2], nil).Times(1)
 		for _, srv := range servers[1:2] {
@@ -96,7 +92,6 @@ func TestMultiNodePublishMessage(t *testing.T) {
 		}
 		err := rpcPublisher.PublishMessages(ctx, msgs)
 		assert.NoError(t, err)
-		runtime.Gosched()
 	})
 
 	t.Run("publish message to multi node after add node", func(t *testing.T) {
@@ -106,11 +101,7 @@ func TestMultiNodePublishMessage(t *testing.T) {
 		}
 		err := rpcPublisher.PublishMessages(ctx, msgs)
 		assert.NoError(t, err)
-		runtime.Gosched()
 	})
-
-	// wait goroutine
-	time.Sleep(1 * time.Second)
 }
 
 func TestMergePublisher(t *testing.T) {
@@ -128,6 +119,17 @@ func TestMergePublisher(t *testing.T) {
 
 	err := publisher.PublishMessages(ctx, msgs)
 	assert.NoError(t, err)
+
+	// succeed if any sub publisher succeeded
+	p1.EXPECT().PublishMessages(ctx, msgs).Return(fmt.Errorf("p1 failed")).Times(1)
+	p2.EXPECT().PublishMessages(ctx, msgs).Return(nil).Times(1)
+	assert.NoError(t, publisher.PublishMessages(ctx, msgs))
+
+	p1.EXPECT().PublishMessages(ctx, msgs).Return(fmt.Errorf("p1 failed")).Times(1)
+	p2.EXPECT().PublishMessages(ctx, msgs).Return(fmt.Errorf("p2 failed")).Times(1)
+	err = publisher.PublishMessages(ctx, msgs)
+	assert.ErrorContains(t, err, "p1 failed")
+	assert.ErrorContains(t, err, "p2 failed")
 }
 
 func TestMsgCache(t *testing.T) {
@@ -143,24 +145,24 @@ func TestMsgCache(t *testing.T) {
 	iPublisher.EXPECT().PublishMessages(ctx, msgs[:4]).Return(nil).Times(1)
 	err = publisher.PublishMessages(ctx, msgs[:4])
 	assert.NoError(t, err)
-	runtime.Gosched()
+
+	// not cached if failed
+	iPublisher.EXPECT().PublishMessages(ctx, msgs[4:]).Return(fmt.Errorf("publish failed")).Times(1)
+	err = publisher.PublishMessages(ctx, msgs)
+	assert.Error(t, err)
 
 	iPublisher.EXPECT().PublishMessages(ctx, msgs[4:]).Return(nil).Times(1)
 	err = publisher.PublishMessages(ctx, msgs)
 	assert.NoError(t, err)
-	runtime.Gosched()
 
 	err = publisher.PublishMessages(ctx, msgs)
 	assert.NoError(t, err)
-	runtime.Gosched()
 
 	// wait cache to be expired
 	time.Sleep(3 * time.Second)
 	iPublisher.EXPECT().PublishMessages(ctx, msgs).Return(nil).Times(1)
 	err = publisher.PublishMessages(ctx, msgs)
 	assert.NoError(t, err)
-	runtime.Gosched()
-	time.Sleep(1 * time.Second)
 }
 
 func TestConcurrentPublisher(t *testing.T) {
@@ -173,12 +175,61 @@ func TestConcurrentPublisher(t *testing.T) {
 	assert.NoError(t, err)
 	msgs := testhelper.NewShareSignedMessages(10)
 
-	iPublisher.EXPECT().PublishMessages(ctx, msgs).Return(nil).Times(1)
-	err = publisher.PublishMessages(ctx, msgs)
+	running := make(chan struct{}, 3)
+	release := make(chan struct{})
+	iPublisher.EXPECT().PublishMessages(ctx, msgs).DoAndReturn(func(context.Context, []*types.SignedMessage) error {
+		running <- struct{}{}
+		<-release
+		return nil
+	}).Times(3)
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			errs <- publisher.PublishMessages(ctx, msgs)
+		}()
+	}
+	// only two calls are in progress
+	<-running
+	<-running
+	select {
+	case <-running:
+		assert.Fail(t, "more calls than the concurrency")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	close(release)
+	<-running
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, <-errs)
+	}
+}
+
+func TestUpdateConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctrl := gomock.NewController(t)
+	iPublisher := mocks.NewMockIMsgPublisher(ctrl)
+
+	concurrentPublisher, err := NewConcurrentPublisher(ctx, 2, iPublisher)
+	assert.NoError(t, err)
+	cachePublisher, err := NewCachePublisher(ctx, 5, concurrentPublisher)
 	assert.NoError(t, err)
-	runtime.Gosched()
 
-	time.Sleep(1 * time.Second)
+	assert.NoError(t, UpdateConcurrency(cachePublisher, 5))
+	assert.Equal(t, uint(5), concurrentPublisher.Concurrency())
+	assert.NoError(t, UpdateConcurrency(cachePublisher, 1))
+	assert.Equal(t, uint(1), concurrentPublisher.Concurrency())
+	assert.Error(t, UpdateConcurrency(cachePublisher, 0))
+	assert.Equal(t, uint(1), concurrentPublisher.Concurrency())
+
+	// still works after stopping workers
+	msgs := testhelper.NewShareSignedMessages(10)
+	iPublisher.EXPECT().PublishMessages(gomock.Any(), msgs).Return(nil).Times(1)
+	assert.NoError(t, cachePublisher.PublishMessages(ctx, msgs))
+
+	mergePublisher := NewMergePublisher(ctx, iPublisher)
+	assert.Error(t, UpdateConcurrency(mergePublisher, 2))
 }
 
 func TestIntergrate(t *testing.T) {
@@ -200,8 +251,6 @@ func TestIntergrate(t *testing.T) {
 
 	err = cachePublisher.PublishMessages(ctx, msgs)
 	assert.NoError(t, err)
-	runtime.Gosched()
-	time.Sleep(1 * time.Second)
 }
 
 func TestPublishMessageFailed(t *testing.T) {
@@ -215,7 +264,7 @@ func TestPublishMessageFailed(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NoError(t, sqliteRepo.AutoMigrate())
 
-	rpcPublisher := NewRpcPublisher(ctx, mainNode, nil, false, sqliteRepo.MessageRepo())
+	rpcPublisher := NewRpcPublisher(ctx, mainNode, nil, false, sqliteRepo.MessageRepo(), sqliteRepo.PublishReceiptRepo())
 	publisher := NewMergePublisher(ctx, rpcPublisher)
 
 	msgs := testhelper.NewShareSignedMessages(10)
@@ -235,19 +284,134 @@ func TestPublishMessageFailed(t *testing.T) {
 	balanceToLowErr := fmt.Errorf("not enough funds (required: 0.08343657656301909 FIL, balance: 0.003413734154635385 FIL): not enough funds to execute transaction")
 	mainNode.EXPECT().MpoolBatchPushUntrusted(ctx, msgs).Return(nil, balanceToLowErr).Times(1)
 	err = publisher.PublishMessages(ctx, msgs)
+	assert.ErrorContains(t, err, balanceToLowErr.Error())
+
+	msg, err := sqliteRepo.MessageRepo().GetMessageByCid(msgs[0].Cid())
 	assert.NoError(t, err)
+	assert.Equal(t, balanceToLowErr.Error(), msg.ErrorMsg)
+}
 
-	for i := 0; i < 10; i++ {
-		msg, err := sqliteRepo.MessageRepo().GetMessageByCid(msgs[0].Cid())
-		if err == nil && len(msg.ErrorMsg) != 0 {
-			assert.Equal(t, balanceToLowErr.Error(), msg.ErrorMsg)
-			break
-		}
+func TestPublishReceiptAndNodeHealth(t *testing.T) {
+	ctx := context.Background()
+	// mock api
+	ctrl := gomock.NewController(t)
+	mainNode := mockV1.NewMockFullNode(ctrl)
+
+	fs := filestore.NewMockFileStore(t.TempDir())
+	sqliteRepo, err := sqlite.OpenSqlite(fs)
+	assert.NoError(t, err)
+	assert.NoError(t, sqliteRepo.AutoMigrate())
+	receiptRepo := sqliteRepo.PublishReceiptRepo()
+
+	rpcPublisher := NewRpcPublisher(ctx, mainNode, nil, false, sqliteRepo.MessageRepo(), receiptRepo)
+	msgs := testhelper.NewShareSignedMessages(2)
 
-		if i == 9 {
-			assert.Fail(t, "failed to get message error")
+	checkReceipt := func(msg *types.SignedMessage, node string, accepted bool) {
+		receipts, err := receiptRepo.ListPublishReceipts(ctx, msg.Cid())
+		assert.NoError(t, err)
+		for _, r := range receipts {
+			if r.Node == node {
+				assert.Equal(t, accepted, r.Accepted)
+				return
+			}
 		}
+		assert.Fail(t, "failed to get publish receipt")
+	}
 
-		time.Sleep(1 * time.Second)
+	// the first message is accepted
+	mainNode.EXPECT().MpoolBatchPushUntrusted(ctx, msgs).Return([]cid.Cid{msgs[0].Cid()}, fmt.Errorf("connection refused")).Times(1)
+	assert.ErrorContains(t, rpcPublisher.PublishMessages(ctx, msgs), "connection refused")
+	checkReceipt(msgs[0], "mainNode", true)
+	checkReceipt(msgs[1], "mainNode", false)
+	assert.True(t, rpcPublisher.mainNodeThread.Healthy())
+
+	// no cid is returned over rpc when the nonce exists, the whole batch is recorded, and the node is still healthy
+	otherMsgs := testhelper.NewShareSignedMessages(2)
+	mainNode.EXPECT().MpoolBatchPushUntrusted(ctx, otherMsgs).Return(nil, fmt.Errorf("%w: 3", errExistingNonce)).Times(1)
+	assert.NoError(t, rpcPublisher.PublishMessages(ctx, otherMsgs))
+	checkReceipt(otherMsgs[0], "mainNode", false)
+	checkReceipt(otherMsgs[1], "mainNode", false)
+	assert.True(t, rpcPublisher.mainNodeThread.Healthy())
+
+	// unhealthy after failing maxNodeFailures times, but messages are still pushed to the main node
+	mainNode.EXPECT().MpoolBatchPushUntrusted(ctx, msgs).Return(nil, fmt.Errorf("connection refused")).Times(maxNodeFailures + 1)
+	for i := 0; i < maxNodeFailures+1; i++ {
+		assert.Error(t, rpcPublisher.PublishMessages(ctx, msgs))
 	}
+	checkReceipt(msgs[0], "mainNode", false)
+	assert.False(t, rpcPublisher.mainNodeThread.Healthy())
+
+	// recover when succeeded
+	mainNode.EXPECT().MpoolBatchPushUntrusted(ctx, msgs).Return([]cid.Cid{msgs[0].Cid(), msgs[1].Cid()}, nil).Times(1)
+	assert.NoError(t, rpcPublisher.PublishMessages(ctx, msgs))
+	checkReceipt(msgs[0], "mainNode", true)
+	checkReceipt(msgs[1], "mainNode", true)
+	assert.True(t, rpcPublisher.mainNodeThread.Healthy())
+
+	// other nodes are skipped when unhealthy until nodeRetryInterval passed
+	node2 := mockV1.NewMockFullNode(ctrl)
+	node2Thread := newNodeThread("node2", node2, sqliteRepo.MessageRepo(), receiptRepo)
+	node2.EXPECT().MpoolBatchPushUntrusted(ctx, msgs).Return(nil, fmt.Errorf("connection refused")).Times(maxNodeFailures)
+	for i := 0; i < maxNodeFailures; i++ {
+		assert.Error(t, node2Thread.HandleMsg(ctx, msgs))
+	}
+	assert.False(t, node2Thread.Healthy())
+	assert.ErrorIs(t, node2Thread.HandleMsg(ctx, msgs), errNodeUnhealthy)
+
+	node2Thread.lk.Lock()
+	node2Thread.lastFailureAt = time.Now().Add(-nodeRetryInterval)
+	node2Thread.lk.Unlock()
+	assert.True(t, node2Thread.Healthy())
+	node2.EXPECT().MpoolBatchPushUntrusted(ctx, msgs).Return([]cid.Cid{msgs[0].Cid(), msgs[1].Cid()}, nil).Times(1)
+	assert.NoError(t, node2Thread.HandleMsg(ctx, msgs))
+	checkReceipt(msgs[0], "node2", true)
+}
+
+func TestPruneReceipts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fs := filestore.NewMockFileStore(t.TempDir())
+	sqliteRepo, err := sqlite.OpenSqlite(fs)
+	assert.NoError(t, err)
+	assert.NoError(t, sqliteRepo.AutoMigrate())
+	receiptRepo := sqliteRepo.PublishReceiptRepo()
+
+	msgs := testhelper.NewShareSignedMessages(2)
+	old := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, receiptRepo.SavePublishReceipts(ctx, []*localtypes.PublishReceipt{
+		{MsgCid: msgs[0].Cid(), Node: "mainNode", Accepted: true, CreatedAt: old, UpdatedAt: old},
+		{MsgCid: msgs[1].Cid(), Node: "mainNode", Accepted: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+	}))
+
+	go PruneReceipts(ctx, receiptRepo, time.Hour)
+	assert.Eventually(t, func() bool {
+		receipts, err := receiptRepo.ListPublishReceipts(ctx, msgs[0].Cid())
+		return err == nil && len(receipts) == 0
+	}, 5*time.Second, 50*time.Millisecond)
+	receipts, err := receiptRepo.ListPublishReceipts(ctx, msgs[1].Cid())
+	assert.NoError(t, err)
+	assert.Len(t, receipts, 1)
+}
+
+func TestP2pPublisherTopicStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ps, err := mpubsub.NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", []string{}, 0, 0, 0, nil, nil)
+	assert.NoError(t, err)
+	p2pPublisher, err := NewP2pPublisher(ps, "test_net_name")
+	assert.NoError(t, err)
+
+	msgs := testhelper.NewShareSignedMessages(3)
+	assert.NoError(t, p2pPublisher.PublishMessages(ctx, msgs))
+
+	status, err := p2pPublisher.TopicStatus(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, mpubsub.MessageTopic("test_net_name"), status.Topic)
+	assert.Equal(t, uint64(3), status.Published)
+	assert.Equal(t, uint64(0), status.PublishFailed)
+	assert.False(t, status.LastPublishAt.IsZero())
+	assert.Empty(t, status.LastPublishError)
+	assert.Empty(t, status.MeshPeers)
 }
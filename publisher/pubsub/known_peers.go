@@ -0,0 +1,89 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// the max number of connected peers saved to redial after restart
+const maxSavedPeers = 100
+
+// the ttl of the addresses of saved peers in peerstore
+const knownPeerAddrTTL = time.Hour
+
+// HostRepo persists the identity and the known peers of the libp2p host
+type HostRepo interface {
+	Libp2pIdentity() (crypto.PrivKey, error)
+	LoadPeers() ([]peer.AddrInfo, error)
+	SavePeers(peers []peer.AddrInfo) error
+}
+
+// loadKnownPeers add the saved peers to peerstore, they are dialed when starting
+func (m *PubSub) loadKnownPeers() {
+	if m.hostRepo == nil {
+		return
+	}
+	peers, err := m.hostRepo.LoadPeers()
+	if err != nil {
+		log.Warnf("failed to load known peers: %s", err)
+		return
+	}
+	for _, info := range peers {
+		if info.ID == m.host.ID() || len(info.Addrs) == 0 {
+			continue
+		}
+		m.host.Peerstore().AddAddrs(info.ID, info.Addrs, knownPeerAddrTTL)
+		m.knownPeers = append(m.knownPeers, info)
+	}
+	log.Infof("loaded %d known peers", len(m.knownPeers))
+}
+
+// connectKnownPeers dial the saved peers concurrently, the unreachable ones are ignored
+func (m *PubSub) connectKnownPeers(ctx context.Context) {
+	if len(m.knownPeers) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, info := range m.knownPeers {
+		wg.Add(1)
+		go func(info peer.AddrInfo) {
+			defer wg.Done()
+			if err := m.host.Connect(ctx, info); err != nil {
+				log.Debugf("failed to connect to known peer %s: %s", info.ID, err)
+			}
+		}(info)
+	}
+	wg.Wait()
+}
+
+// saveKnownPeers save the connected peers, nothing is saved when no peer is connected,
+// so the known peers are kept when the network is down.
+func (m *PubSub) saveKnownPeers() {
+	if m.hostRepo == nil {
+		return
+	}
+	peers := make([]peer.AddrInfo, 0, maxSavedPeers)
+	for _, p := range m.host.Network().Peers() {
+		if len(peers) >= maxSavedPeers {
+			break
+		}
+		info := m.host.Peerstore().PeerInfo(p)
+		if len(info.Addrs) == 0 {
+			continue
+		}
+		peers = append(peers, info)
+	}
+	if len(peers) == 0 {
+		return
+	}
+	if err := m.hostRepo.SavePeers(peers); err != nil {
+		log.Warnf("failed to save known peers: %s", err)
+	}
+}
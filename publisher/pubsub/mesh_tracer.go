@@ -0,0 +1,88 @@
+package pubsub
+
+import (
+	"sort"
+	"sync"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+var _ pubsub.RawTracer = (*meshTracer)(nil)
+
+// meshTracer track the gossipsub mesh of each topic by the graft and prune events.
+type meshTracer struct {
+	lk   sync.RWMutex
+	mesh map[string]map[peer.ID]struct{}
+}
+
+func newMeshTracer() *meshTracer {
+	return &meshTracer{mesh: make(map[string]map[peer.ID]struct{})}
+}
+
+func (t *meshTracer) meshPeers(topic string) []peer.ID {
+	t.lk.RLock()
+	defer t.lk.RUnlock()
+
+	peers := make([]peer.ID, 0, len(t.mesh[topic]))
+	for p := range t.mesh[topic] {
+		peers = append(peers, p)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i] < peers[j]
+	})
+	return peers
+}
+
+func (t *meshTracer) meshSize(topic string) int {
+	t.lk.RLock()
+	defer t.lk.RUnlock()
+
+	return len(t.mesh[topic])
+}
+
+func (t *meshTracer) Graft(p peer.ID, topic string) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	if _, ok := t.mesh[topic]; !ok {
+		t.mesh[topic] = make(map[peer.ID]struct{})
+	}
+	t.mesh[topic][p] = struct{}{}
+}
+
+func (t *meshTracer) Prune(p peer.ID, topic string) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	delete(t.mesh[topic], p)
+}
+
+func (t *meshTracer) RemovePeer(p peer.ID) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	for _, peers := range t.mesh {
+		delete(peers, p)
+	}
+}
+
+func (t *meshTracer) Leave(topic string) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+
+	delete(t.mesh, topic)
+}
+
+func (t *meshTracer) AddPeer(peer.ID, protocol.ID)          {}
+func (t *meshTracer) Join(string)                           {}
+func (t *meshTracer) ValidateMessage(*pubsub.Message)       {}
+func (t *meshTracer) DeliverMessage(*pubsub.Message)        {}
+func (t *meshTracer) RejectMessage(*pubsub.Message, string) {}
+func (t *meshTracer) DuplicateMessage(*pubsub.Message)      {}
+func (t *meshTracer) ThrottlePeer(peer.ID)                  {}
+func (t *meshTracer) RecvRPC(*pubsub.RPC)                   {}
+func (t *meshTracer) SendRPC(*pubsub.RPC, peer.ID)          {}
+func (t *meshTracer) DropRPC(*pubsub.RPC, peer.ID)          {}
+func (t *meshTracer) UndeliverableMessage(*pubsub.Message)  {}
@@ -4,18 +4,24 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/filecoin-project/venus/fixtures/networks"
 	"github.com/filecoin-project/venus/venus-shared/types"
 	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/filestore"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/libp2p/go-libp2p"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	routedhost "github.com/libp2p/go-libp2p/p2p/host/routed"
+	"github.com/libp2p/go-libp2p/p2p/net/connmgr"
 	swarm "github.com/libp2p/go-libp2p/p2p/net/swarm"
 	ma "github.com/multiformats/go-multiaddr"
 	"github.com/pelletier/go-toml"
@@ -35,6 +41,16 @@ type INet interface {
 
 type IPubsuber interface {
 	GetTopic(topic string) (*pubsub.Topic, error)
+	// TopicStatus returns the mesh and the peer scores of the topic
+	TopicStatus(topic string) *mtypes.TopicStatus
+}
+
+// the period of taking snapshots of peer scores
+var scoreInspectPeriod = 10 * time.Second
+
+// MessageTopic returns the pubsub topic of messages of the network.
+func MessageTopic(networkName types.NetworkName) string {
+	return fmt.Sprintf("/fil/msgs/%s", networkName)
 }
 
 var _ INet = &PubSub{}
@@ -45,10 +61,21 @@ type PubSub struct {
 	pubsub           *pubsub.PubSub
 	dht              *dht.IpfsDHT
 	bootstrappers    []peer.AddrInfo
+	staticPeers      []peer.AddrInfo
+	knownPeers       []peer.AddrInfo
+	hostRepo         HostRepo
 	period           time.Duration
 	timeout          time.Duration
 	minPeerThreshold int
+	minMeshPeers     int
 	expanding        chan struct{}
+	reconnecting     chan struct{}
+	tracer           *meshTracer
+
+	lk                  sync.Mutex
+	topics              map[string]*pubsub.Topic
+	scores              map[peer.ID]*pubsub.PeerScoreSnapshot
+	bootstrapReconnects uint64
 }
 
 func NewPubsub(ctx context.Context,
@@ -57,6 +84,9 @@ func NewPubsub(ctx context.Context,
 	bootstrap []string,
 	period time.Duration,
 	threshold int,
+	minMeshPeers int,
+	peering *Peering,
+	hostRepo HostRepo,
 ) (*PubSub, error) {
 	finalTimeout, finalPeriod, finalThreshold := time.Second*30, time.Second*30, 1
 
@@ -65,8 +95,14 @@ func NewPubsub(ctx context.Context,
 		log.Errorf("failed to get default network config: %s", err)
 	}
 	if netconfig != nil {
-		bootstrap = append(bootstrap, netconfig.Bootstrap.Addresses...)
 		_ = toml.Unmarshal([]byte(netconfig.Bootstrap.Period), &finalPeriod)
+		if !peering.isPrivate() {
+			bootstrap = append(bootstrap, netconfig.Bootstrap.Addresses...)
+		}
+	}
+	if peering.isPrivate() && len(bootstrap) > 0 {
+		log.Warnf("bootstrap addresses are ignored in private peering: %v", bootstrap)
+		bootstrap = nil
 	}
 	if period != 0 {
 		finalPeriod = period
@@ -78,7 +114,14 @@ func NewPubsub(ctx context.Context,
 		finalTimeout = finalPeriod
 	}
 
-	rawHost, err := buildHost(ctx, listenAddress)
+	// a new identity is used for each start without repo
+	var identity crypto.PrivKey
+	if hostRepo != nil {
+		if identity, err = hostRepo.Libp2pIdentity(); err != nil {
+			return nil, fmt.Errorf("failed to load libp2p identity: %w", err)
+		}
+	}
+	rawHost, err := buildHost(ctx, listenAddress, peering, identity)
 	if err != nil {
 		return nil, err
 	}
@@ -91,13 +134,37 @@ func NewPubsub(ctx context.Context,
 		}
 		bootstrapPeersres[i] = *peerInfo
 	}
-	router, err := makeDHT(ctx, rawHost, string(networkName), bootstrapPeersres)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create DHT: %s", err)
+	var router *dht.IpfsDHT
+	var peerHost types.RawHost = rawHost
+	if peering.isPrivate() {
+		// the static peers take the place of bootstrappers to reconnect
+		bootstrapPeersres = peering.staticPeers()
+	} else {
+		router, err = makeDHT(ctx, rawHost, string(networkName), bootstrapPeersres)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create DHT: %s", err)
+		}
+		peerHost = routedhost.Wrap(rawHost, router)
 	}
 
-	peerHost := routedhost.Wrap(rawHost, router)
+	ps := &PubSub{
+		host:             peerHost,
+		bootstrappers:    bootstrapPeersres,
+		staticPeers:      peering.staticPeers(),
+		hostRepo:         hostRepo,
+		dht:              router,
+		expanding:        make(chan struct{}, 1),
+		reconnecting:     make(chan struct{}, 1),
+		minPeerThreshold: finalThreshold,
+		minMeshPeers:     minMeshPeers,
+		period:           finalPeriod,
+		timeout:          finalTimeout,
+		tracer:           newMeshTracer(),
+		topics:           make(map[string]*pubsub.Topic),
+		scores:           make(map[peer.ID]*pubsub.PeerScoreSnapshot),
+	}
 
+	msgTopic := MessageTopic(networkName)
 	pubsub.GossipSubHeartbeatInterval = 100 * time.Millisecond
 	options := []pubsub.Option{
 		// Gossipsubv1.1 configuration
@@ -109,30 +176,125 @@ func NewPubsub(ctx context.Context,
 		//  goroutine, 8K -> 16K
 		pubsub.WithValidateThrottle(16 << 10),
 		pubsub.WithMessageSigning(true),
+		pubsub.WithPeerScore(peerScoreParams(msgTopic), peerScoreThresholds()),
+		pubsub.WithPeerScoreInspect(ps.inspectScores, scoreInspectPeriod),
+		pubsub.WithRawTracer(ps.tracer),
 	}
 
 	gsub, err := pubsub.NewGossipSub(ctx, peerHost, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pubsub: %w", err)
 	}
+	ps.pubsub = gsub
+	ps.addStaticPeers()
+	if !peering.isPrivate() {
+		ps.loadKnownPeers()
+	}
 
-	pubsub := PubSub{
-		host:             peerHost,
-		pubsub:           gsub,
-		bootstrappers:    bootstrapPeersres,
-		dht:              router,
-		expanding:        make(chan struct{}, 1),
-		minPeerThreshold: finalThreshold,
-		period:           finalPeriod,
-		timeout:          finalTimeout,
+	if err := ps.joinMessageTopic(ctx, msgTopic); err != nil {
+		return nil, err
 	}
 
-	go pubsub.run(ctx)
-	return &pubsub, nil
+	go ps.run(ctx)
+	return ps, nil
 }
 
+// joinMessageTopic subscribe the message topic to join its mesh, so the published messages are forwarded by
+// the mesh peers and the mesh is observable. messager can't validate the messages from peers like chain nodes,
+// so they are ignored rather than delivered or forwarded.
+func (m *PubSub) joinMessageTopic(ctx context.Context, msgTopic string) error {
+	err := m.pubsub.RegisterTopicValidator(msgTopic, func(_ context.Context, from peer.ID, _ *pubsub.Message) pubsub.ValidationResult {
+		if from == m.host.ID() {
+			return pubsub.ValidationAccept
+		}
+		return pubsub.ValidationIgnore
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register validator of topic %s: %w", msgTopic, err)
+	}
+	topic, err := m.GetTopic(msgTopic)
+	if err != nil {
+		return fmt.Errorf("failed to join topic %s: %w", msgTopic, err)
+	}
+	sub, err := topic.Subscribe()
+	if err != nil {
+		return fmt.Errorf("failed to subscribe topic %s: %w", msgTopic, err)
+	}
+	go func() {
+		defer sub.Cancel()
+		for {
+			if _, err := sub.Next(ctx); err != nil {
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// GetTopic join the topic, the topic joined before is returned directly.
 func (m *PubSub) GetTopic(topic string) (*pubsub.Topic, error) {
-	return m.pubsub.Join(topic)
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	if t, ok := m.topics[topic]; ok {
+		return t, nil
+	}
+	t, err := m.pubsub.Join(topic)
+	if err != nil {
+		return nil, err
+	}
+	m.topics[topic] = t
+	return t, nil
+}
+
+func (m *PubSub) inspectScores(scores map[peer.ID]*pubsub.PeerScoreSnapshot) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+
+	m.scores = scores
+}
+
+func (m *PubSub) TopicStatus(topic string) *mtypes.TopicStatus {
+	meshPeers := m.tracer.meshPeers(topic)
+	inMesh := make(map[peer.ID]struct{}, len(meshPeers))
+	for _, p := range meshPeers {
+		inMesh[p] = struct{}{}
+	}
+
+	m.lk.Lock()
+	scores := m.scores
+	reconnects := m.bootstrapReconnects
+	m.lk.Unlock()
+
+	topicPeers := m.pubsub.ListPeers(topic)
+	peers := make([]*mtypes.PeerScore, 0, len(topicPeers))
+	for _, p := range topicPeers {
+		ps := &mtypes.PeerScore{ID: p}
+		_, ps.InMesh = inMesh[p]
+		if score, ok := scores[p]; ok {
+			ps.Score = score.Score
+			if ts, ok := score.Topics[topic]; ok {
+				ps.TimeInMesh = ts.TimeInMesh
+				ps.FirstMessageDeliveries = ts.FirstMessageDeliveries
+				ps.InvalidMessageDeliveries = ts.InvalidMessageDeliveries
+			}
+		}
+		peers = append(peers, ps)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].Score != peers[j].Score {
+			return peers[i].Score > peers[j].Score
+		}
+		return peers[i].ID < peers[j].ID
+	})
+
+	return &mtypes.TopicStatus{
+		Topic:               topic,
+		MeshPeers:           meshPeers,
+		Peers:               peers,
+		BootstrapReconnects: reconnects,
+	}
 }
 
 func (m *PubSub) run(ctx context.Context) {
@@ -140,25 +302,73 @@ func (m *PubSub) run(ctx context.Context) {
 	if err != nil {
 		log.Errorf("connect bootstrap failed %s", err)
 	}
+	m.connectStaticPeers(ctx)
+	m.connectKnownPeers(ctx)
 
 	ticker := time.NewTicker(m.period)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
+			m.connectStaticPeers(ctx)
+			m.saveKnownPeers()
 			pcount := len(m.host.Network().Peers())
 			if pcount <= m.minPeerThreshold {
 				log.Debug("peer count %d is less than threshold %d, expanding", pcount, m.minPeerThreshold)
 				m.expandPeers()
 			}
+			m.checkMesh()
 
 		case <-ctx.Done():
 			log.Warnf("stop expand peers: %v", ctx.Err())
+			m.saveKnownPeers()
+			return
+		}
+	}
+}
+
+// checkMesh reconnect to bootstrap peers if the mesh of any subscribed topic has less than minMeshPeers peers.
+func (m *PubSub) checkMesh() {
+	if m.minMeshPeers <= 0 {
+		return
+	}
+	for _, topic := range m.pubsub.GetTopics() {
+		if size := m.tracer.meshSize(topic); size < m.minMeshPeers {
+			log.Infof("mesh of topic %s has %d peers, less than %d, reconnect to bootstrap peers", topic, size, m.minMeshPeers)
+			m.reconnectBootstrap()
 			return
 		}
 	}
 }
 
+func (m *PubSub) reconnectBootstrap() {
+	if len(m.bootstrappers) == 0 {
+		log.Debug("no bootstrappers configured")
+		return
+	}
+	select {
+	case m.reconnecting <- struct{}{}:
+	default:
+		return
+	}
+
+	m.lk.Lock()
+	m.bootstrapReconnects++
+	m.lk.Unlock()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.TODO(), m.timeout)
+		defer cancel()
+
+		for _, bsp := range m.bootstrappers {
+			if err := m.Connect(ctx, bsp); err != nil {
+				log.Warnf("failed to reconnect to bootstrap peer: %s %s", bsp, err)
+			}
+		}
+
+		<-m.reconnecting
+	}()
+}
+
 func (m *PubSub) Connect(ctx context.Context, p peer.AddrInfo) error {
 	if swarm, ok := m.host.Network().(*swarm.Swarm); ok {
 		swarm.Backoff().Clear(p.ID)
@@ -181,6 +391,14 @@ func (m *PubSub) Peers(_ context.Context) ([]peer.AddrInfo, error) {
 
 // FindPeer searches the libp2p router for a given peer id
 func (m *PubSub) FindPeer(ctx context.Context, peerID peer.ID) (peer.AddrInfo, error) {
+	if m.dht == nil {
+		// only the static peers are known in private peering
+		info := m.host.Peerstore().PeerInfo(peerID)
+		if len(info.Addrs) == 0 {
+			return peer.AddrInfo{}, fmt.Errorf("peer %s not found, dht is disabled in private peering", peerID)
+		}
+		return info, nil
+	}
 	return m.dht.FindPeer(ctx, peerID)
 }
 
@@ -233,6 +451,9 @@ func (m *PubSub) doExpand(ctx context.Context) {
 		return
 	}
 
+	if m.dht == nil {
+		return
+	}
 	// if we already have some peers and need more, the dht is really good at connecting to most peers. Use that for now until something better comes along.
 	if err := m.dht.Bootstrap(ctx); err != nil {
 		log.Warnf("dht bootstrapping failed: %s", err)
@@ -265,19 +486,34 @@ func makeDHT(ctx context.Context, h types.RawHost, networkName string, bootNodes
 	return r, nil
 }
 
-func buildHost(_ context.Context, address string) (types.RawHost, error) {
+func buildHost(_ context.Context, address string, peering *Peering, identity crypto.PrivKey) (types.RawHost, error) {
+	// trim connections like lotus, but never the static peers
+	cm, err := connmgr.NewConnManager(150, 180, connmgr.WithGracePeriod(20*time.Second))
+	if err != nil {
+		return nil, err
+	}
 	opts := []libp2p.Option{
 		libp2p.UserAgent("sophon-messager"),
 		libp2p.ListenAddrStrings(address),
-		// libp2p.Identity(secret),
 		libp2p.Ping(true),
 		libp2p.DisableRelay(),
+		libp2p.ConnectionManager(cm),
+	}
+	if identity != nil {
+		opts = append(opts, libp2p.Identity(identity))
+	}
+	if gater := peering.gater(); gater != nil {
+		opts = append(opts, libp2p.ConnectionGater(gater))
 	}
 	return libp2p.New(opts...)
 }
 
-func ProvidePubsub(ctx context.Context, networkName types.NetworkName, net *config.Libp2pNetConfig) (*PubSub, error) {
-	return NewPubsub(ctx, net.ListenAddress, networkName, net.BootstrapAddresses, net.ExpandPeriod, net.MinPeerThreshold)
+func ProvidePubsub(ctx context.Context, networkName types.NetworkName, net *config.Libp2pNetConfig, fsRepo filestore.FSRepo) (*PubSub, error) {
+	peering, err := ParsePeering(net)
+	if err != nil {
+		return nil, err
+	}
+	return NewPubsub(ctx, net.ListenAddress, networkName, net.BootstrapAddresses, net.ExpandPeriod, net.MinPeerThreshold, net.MinMeshPeers, peering, fsRepo)
 }
 
 func NewINet(p *PubSub) INet {
@@ -295,3 +531,50 @@ func Options() fx.Option {
 		fx.Provide(NewIPubsuber),
 	)
 }
+
+// peerScoreParams score peers by the behaviour penalty, ip colocation and the deliveries in the message topic,
+// the parameters of message topic follow the chain nodes.
+func peerScoreParams(msgTopic string) *pubsub.PeerScoreParams {
+	return &pubsub.PeerScoreParams{
+		AppSpecificScore:  func(peer.ID) float64 { return 0 },
+		AppSpecificWeight: 1,
+
+		IPColocationFactorThreshold: 5,
+		IPColocationFactorWeight:    -100,
+
+		BehaviourPenaltyThreshold: 6,
+		BehaviourPenaltyWeight:    -10,
+		BehaviourPenaltyDecay:     pubsub.ScoreParameterDecay(time.Hour),
+
+		DecayInterval: pubsub.DefaultDecayInterval,
+		DecayToZero:   pubsub.DefaultDecayToZero,
+		RetainScore:   6 * time.Hour,
+
+		Topics: map[string]*pubsub.TopicScoreParams{
+			msgTopic: {
+				TopicWeight: 0.1,
+
+				TimeInMeshWeight:  0.0002778,
+				TimeInMeshQuantum: time.Second,
+				TimeInMeshCap:     1,
+
+				FirstMessageDeliveriesWeight: 0.5,
+				FirstMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(10 * time.Minute),
+				FirstMessageDeliveriesCap:    100,
+
+				InvalidMessageDeliveriesWeight: -1000,
+				InvalidMessageDeliveriesDecay:  pubsub.ScoreParameterDecay(time.Hour),
+			},
+		},
+	}
+}
+
+func peerScoreThresholds() *pubsub.PeerScoreThresholds {
+	return &pubsub.PeerScoreThresholds{
+		GossipThreshold:             -500,
+		PublishThreshold:            -1000,
+		GraylistThreshold:           -2500,
+		AcceptPXThreshold:           1000,
+		OpportunisticGraftThreshold: 3.5,
+	}
+}
@@ -5,13 +5,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/filestore"
 )
 
 func TestMessagePubSub(t *testing.T) {
 	ctx := context.Background()
-	ps1, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", []string{}, 0, 0)
+	ps1, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", []string{}, 0, 0, 0, nil, nil)
 	assert.Nil(t, err)
 	addressInfo1 := peer.AddrInfo{
 		ID:    ps1.host.ID(),
@@ -26,7 +30,7 @@ func TestMessagePubSub(t *testing.T) {
 		multiaddr[i] = addr.String()
 	}
 
-	ps2, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", multiaddr, 0, 0)
+	ps2, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", multiaddr, 0, 0, 0, nil, nil)
 	assert.Nil(t, err)
 
 	topic, err := ps1.GetTopic("test")
@@ -60,3 +64,156 @@ func TestMessagePubSub(t *testing.T) {
 	err = ps2.Connect(ctx, pi1)
 	assert.Nil(t, err)
 }
+
+func TestTopicStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	period := scoreInspectPeriod
+	scoreInspectPeriod = 100 * time.Millisecond
+	defer func() { scoreInspectPeriod = period }()
+
+	ps1, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", []string{}, 0, 0, 0, nil, nil)
+	assert.NoError(t, err)
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: ps1.host.ID(), Addrs: ps1.host.Addrs()})
+	assert.NoError(t, err)
+	bootstrap := make([]string, len(addrs))
+	for i, addr := range addrs {
+		bootstrap[i] = addr.String()
+	}
+	// a negative threshold disable expanding peers, so only the mesh check reconnect to ps1
+	ps2, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", bootstrap, 200*time.Millisecond, -1, 1, nil, nil)
+	assert.NoError(t, err)
+
+	msgTopic := MessageTopic("test_net_name")
+	inMesh := func() bool {
+		status := ps2.TopicStatus(msgTopic)
+		return len(status.MeshPeers) == 1 && status.MeshPeers[0] == ps1.host.ID()
+	}
+	assert.Eventually(t, inMesh, 10*time.Second, 50*time.Millisecond)
+
+	topic, err := ps2.GetTopic(msgTopic)
+	assert.NoError(t, err)
+	assert.NoError(t, topic.Publish(ctx, []byte("msg")))
+
+	assert.Eventually(t, func() bool {
+		status := ps2.TopicStatus(msgTopic)
+		return len(status.Peers) == 1 && status.Peers[0].InMesh && status.Peers[0].TimeInMesh > 0
+	}, 10*time.Second, 50*time.Millisecond)
+	status := ps2.TopicStatus(msgTopic)
+	assert.Equal(t, ps1.host.ID(), status.Peers[0].ID)
+	assert.Equal(t, uint64(0), status.BootstrapReconnects)
+	// the message from ps2 is ignored, ps1 can't get a delivery score from it
+	assert.Equal(t, float64(0), ps1.TopicStatus(msgTopic).Peers[0].FirstMessageDeliveries)
+
+	// the mesh is empty after disconnecting, reconnect to bootstrap peers
+	assert.NoError(t, ps2.host.Network().ClosePeer(ps1.host.ID()))
+	assert.Eventually(t, func() bool {
+		return inMesh() && ps2.TopicStatus(msgTopic).BootstrapReconnects > 0
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestPrivatePeering(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ps1, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", []string{}, 0, 0, 0, nil, nil)
+	assert.NoError(t, err)
+	ps3, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", []string{}, 0, 0, 0, nil, nil)
+	assert.NoError(t, err)
+	pi1, err := ps1.AddrListen(ctx)
+	assert.NoError(t, err)
+	pi3, err := ps3.AddrListen(ctx)
+	assert.NoError(t, err)
+
+	peering := &Peering{Private: true, StaticPeers: []peer.AddrInfo{pi1}, Gater: true}
+	ps2, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", []string{"/ip4/127.0.0.1/tcp/1/p2p/" + pi3.ID.String()},
+		200*time.Millisecond, -1, 0, peering, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, ps2.dht)
+	assert.Equal(t, []peer.AddrInfo{pi1}, ps2.bootstrappers)
+
+	connected := func() bool {
+		return ps2.host.Network().Connectedness(pi1.ID) == network.Connected
+	}
+	assert.Eventually(t, connected, 10*time.Second, 50*time.Millisecond)
+	assert.True(t, ps2.host.ConnManager().IsProtected(pi1.ID, staticPeerTag))
+	found, err := ps2.FindPeer(ctx, pi1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, pi1.ID, found.ID)
+
+	// reconnect to the static peer after disconnecting
+	assert.NoError(t, ps2.host.Network().ClosePeer(pi1.ID))
+	assert.Eventually(t, connected, 10*time.Second, 50*time.Millisecond)
+
+	// the peers out of allowlist are rejected in both directions
+	assert.Error(t, ps2.Connect(ctx, pi3))
+	pi2, err := ps2.AddrListen(ctx)
+	assert.NoError(t, err)
+	// the inbound connection is rejected after the security handshake, the dialer may not notice it
+	_ = ps3.Connect(ctx, pi2)
+	assert.Never(t, func() bool {
+		return ps2.host.Network().Connectedness(pi3.ID) == network.Connected
+	}, 500*time.Millisecond, 50*time.Millisecond)
+
+	unknown, err := peer.Decode("12D3KooWEBB6Mn8qtNtA2AE4kL7Bn2nPeA5N8eWJ5cVAfRy2vY4Z")
+	assert.NoError(t, err)
+	_, err = ps2.FindPeer(ctx, unknown)
+	assert.Error(t, err)
+}
+
+func TestParsePeering(t *testing.T) {
+	cfg := config.DefaultConfig().Libp2pNet
+	peering, err := ParsePeering(cfg)
+	assert.NoError(t, err)
+	assert.Nil(t, peering)
+
+	id := "12D3KooWJmuHYtPwbZTj5Y6mE5EkNQ5PoqpbTEgyGKyfBv5xKpsb"
+	cfg.StaticPeers = []string{"/ip4/127.0.0.1/tcp/34567/p2p/" + id}
+	cfg.EnableConnectionGater = true
+	cfg.AllowedPeers = []string{"12D3KooWEBB6Mn8qtNtA2AE4kL7Bn2nPeA5N8eWJ5cVAfRy2vY4Z"}
+	peering, err = ParsePeering(cfg)
+	assert.NoError(t, err)
+	assert.False(t, peering.Private)
+	assert.Equal(t, id, peering.StaticPeers[0].ID.String())
+	g := peering.gater().(*allowlistGater)
+	assert.Len(t, g.allowed, 2)
+	assert.True(t, g.InterceptPeerDial(peering.StaticPeers[0].ID))
+	assert.True(t, g.InterceptPeerDial(peering.AllowedPeers[0]))
+
+	cfg.PrivatePeering = true
+	cfg.StaticPeers = nil
+	_, err = ParsePeering(cfg)
+	assert.Error(t, err)
+}
+
+func TestPersistedIdentityAndPeers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ps1, err := NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", []string{}, 0, 0, 0, nil, nil)
+	assert.NoError(t, err)
+	pi1, err := ps1.AddrListen(ctx)
+	assert.NoError(t, err)
+
+	repo := filestore.NewMockFileStore(t.TempDir())
+	ctx2, cancel2 := context.WithCancel(ctx)
+	ps2, err := NewPubsub(ctx2, "/ip4/127.0.0.1/tcp/0", "test_net_name", []string{}, 200*time.Millisecond, -1, 0, nil, repo)
+	assert.NoError(t, err)
+	assert.NoError(t, ps2.Connect(ctx, pi1))
+	assert.Eventually(t, func() bool {
+		peers, err := repo.LoadPeers()
+		return err == nil && len(peers) == 1 && peers[0].ID == pi1.ID
+	}, 10*time.Second, 50*time.Millisecond)
+	id := ps2.host.ID()
+	cancel2()
+	assert.NoError(t, ps2.host.Close())
+
+	// restart with the same repo, the identity is kept and the saved peer is redialed
+	ps2, err = NewPubsub(ctx, "/ip4/127.0.0.1/tcp/0", "test_net_name", []string{}, 200*time.Millisecond, -1, 0, nil, repo)
+	assert.NoError(t, err)
+	assert.Equal(t, id, ps2.host.ID())
+	assert.Eventually(t, func() bool {
+		return ps2.host.Network().Connectedness(pi1.ID) == network.Connected
+	}, 10*time.Second, 50*time.Millisecond)
+}
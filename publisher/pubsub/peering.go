@@ -0,0 +1,142 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/connmgr"
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+)
+
+// the tag of static peers in the connection manager
+const staticPeerTag = "static-peer"
+
+// Peering restricts the peers which messager connects to
+type Peering struct {
+	// Private connect to StaticPeers only, without DHT and bootstrap peers
+	Private bool
+	// StaticPeers are always connected and protected
+	StaticPeers []peer.AddrInfo
+	// Gater reject the connections with peers out of StaticPeers and AllowedPeers
+	Gater        bool
+	AllowedPeers []peer.ID
+}
+
+// ParsePeering returns the peering of the config, nil if there is nothing to restrict
+func ParsePeering(net *config.Libp2pNetConfig) (*Peering, error) {
+	if !net.PrivatePeering && !net.EnableConnectionGater && len(net.StaticPeers) == 0 {
+		return nil, nil
+	}
+
+	peering := &Peering{
+		Private: net.PrivatePeering,
+		Gater:   net.EnableConnectionGater,
+	}
+	for _, addr := range net.StaticPeers {
+		info, err := peer.AddrInfoFromString(addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse static peer %s: %w", addr, err)
+		}
+		peering.StaticPeers = append(peering.StaticPeers, *info)
+	}
+	for _, id := range net.AllowedPeers {
+		pid, err := peer.Decode(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse allowed peer %s: %w", id, err)
+		}
+		peering.AllowedPeers = append(peering.AllowedPeers, pid)
+	}
+	if peering.Private && len(peering.StaticPeers) == 0 {
+		return nil, fmt.Errorf("no static peers for private peering")
+	}
+
+	return peering, nil
+}
+
+func (p *Peering) isPrivate() bool {
+	return p != nil && p.Private
+}
+
+func (p *Peering) staticPeers() []peer.AddrInfo {
+	if p == nil {
+		return nil
+	}
+	return p.StaticPeers
+}
+
+// gater returns nil if the connection gater is not enabled
+func (p *Peering) gater() connmgr.ConnectionGater {
+	if p == nil || !p.Gater {
+		return nil
+	}
+	g := &allowlistGater{allowed: make(map[peer.ID]struct{})}
+	for _, info := range p.StaticPeers {
+		g.allowed[info.ID] = struct{}{}
+	}
+	for _, id := range p.AllowedPeers {
+		g.allowed[id] = struct{}{}
+	}
+	return g
+}
+
+// allowlistGater allows the connections with the peers in the allowlist only,
+// inbound connections are checked after the security handshake when the remote peer is known.
+type allowlistGater struct {
+	allowed map[peer.ID]struct{}
+}
+
+var _ connmgr.ConnectionGater = (*allowlistGater)(nil)
+
+func (g *allowlistGater) isAllowed(p peer.ID) bool {
+	_, ok := g.allowed[p]
+	if !ok {
+		log.Debugf("connection gater rejects peer %s", p)
+	}
+	return ok
+}
+
+func (g *allowlistGater) InterceptPeerDial(p peer.ID) bool {
+	return g.isAllowed(p)
+}
+
+func (g *allowlistGater) InterceptAddrDial(p peer.ID, _ ma.Multiaddr) bool {
+	return g.isAllowed(p)
+}
+
+func (g *allowlistGater) InterceptAccept(network.ConnMultiaddrs) bool {
+	return true
+}
+
+func (g *allowlistGater) InterceptSecured(_ network.Direction, p peer.ID, _ network.ConnMultiaddrs) bool {
+	return g.isAllowed(p)
+}
+
+func (g *allowlistGater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+// addStaticPeers remember the addresses of static peers and protect them from being trimmed
+func (m *PubSub) addStaticPeers() {
+	for _, info := range m.staticPeers {
+		m.host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+		m.host.ConnManager().Protect(info.ID, staticPeerTag)
+	}
+}
+
+// connectStaticPeers connect to the static peers which are not connected
+func (m *PubSub) connectStaticPeers(ctx context.Context) {
+	for _, info := range m.staticPeers {
+		if m.host.Network().Connectedness(info.ID) == network.Connected {
+			continue
+		}
+		if err := m.Connect(ctx, info); err != nil {
+			log.Warnf("failed to connect to static peer %s: %s", info, err)
+		}
+	}
+}
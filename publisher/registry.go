@@ -0,0 +1,214 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/venus/venus-shared/types"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+)
+
+// StageDeps contains the components can be used by stages of the publisher pipeline
+type StageDeps struct {
+	NetParams    *types.NetworkParams
+	Cfg          *config.PublisherConfig
+	RpcPublisher *RpcPublisher
+	P2pPublisher *P2pPublisher
+}
+
+// StageOptions is the options of a stage in config
+type StageOptions map[string]string
+
+func (o StageOptions) String(key, def string) string {
+	if v, ok := o[key]; ok && len(v) > 0 {
+		return v
+	}
+	return def
+}
+
+func (o StageOptions) Int(key string, def int) (int, error) {
+	v, ok := o[key]
+	if !ok || len(v) == 0 {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid option %s: %w", key, err)
+	}
+	return i, nil
+}
+
+func (o StageOptions) Duration(key string, def time.Duration) (time.Duration, error) {
+	v, ok := o[key]
+	if !ok || len(v) == 0 {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid option %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// BackendFactory create a publisher which sends messages to somewhere
+type BackendFactory func(ctx context.Context, deps *StageDeps, opts StageOptions) (IMsgPublisher, error)
+
+// WrapperFactory create a publisher which passes messages to next
+type WrapperFactory func(ctx context.Context, deps *StageDeps, opts StageOptions, next IMsgPublisher) (IMsgPublisher, error)
+
+var (
+	registryLk sync.RWMutex
+	backends   = map[string]BackendFactory{}
+	wrappers   = map[string]WrapperFactory{}
+)
+
+// RegisterBackend register a backend stage type, panic if the type has been registered
+func RegisterBackend(typ string, f BackendFactory) {
+	registryLk.Lock()
+	defer registryLk.Unlock()
+
+	mustNotRegistered(typ)
+	backends[typ] = f
+}
+
+// RegisterWrapper register a wrapper stage type, panic if the type has been registered
+func RegisterWrapper(typ string, f WrapperFactory) {
+	registryLk.Lock()
+	defer registryLk.Unlock()
+
+	mustNotRegistered(typ)
+	wrappers[typ] = f
+}
+
+func mustNotRegistered(typ string) {
+	_, isBackend := backends[typ]
+	_, isWrapper := wrappers[typ]
+	if isBackend || isWrapper {
+		panic(fmt.Sprintf("publisher stage %s has been registered", typ))
+	}
+}
+
+func init() {
+	RegisterBackend("rpc", func(_ context.Context, deps *StageDeps, _ StageOptions) (IMsgPublisher, error) {
+		if deps.RpcPublisher == nil {
+			return nil, fmt.Errorf("rpc publisher is not available")
+		}
+		return deps.RpcPublisher, nil
+	})
+	RegisterBackend("pubsub", func(_ context.Context, deps *StageDeps, _ StageOptions) (IMsgPublisher, error) {
+		if deps.P2pPublisher == nil {
+			return nil, fmt.Errorf("pubsub publisher is not available")
+		}
+		return deps.P2pPublisher, nil
+	})
+	RegisterBackend("http", newHTTPRelayStage)
+	RegisterBackend("file", newFileSinkStage)
+
+	RegisterWrapper("cache", func(ctx context.Context, deps *StageDeps, opts StageOptions, next IMsgPublisher) (IMsgPublisher, error) {
+		// 1/3 of the block delay by default, same as cacheReleasePeriod = 0
+		period := uint64(1)
+		if deps.NetParams != nil && deps.NetParams.BlockDelaySecs/3 > 1 {
+			period = deps.NetParams.BlockDelaySecs / 3
+		}
+		d, err := opts.Duration("period", time.Duration(period)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("cache period %v should not be less than 1s", d)
+		}
+		return NewCachePublisher(ctx, uint64(d/time.Second), next)
+	})
+	RegisterWrapper("concurrent", func(ctx context.Context, deps *StageDeps, opts StageOptions, next IMsgPublisher) (IMsgPublisher, error) {
+		def := 0
+		if deps.Cfg != nil {
+			def = deps.Cfg.Concurrency
+		}
+		concurrency, err := opts.Int("concurrency", def)
+		if err != nil {
+			return nil, err
+		}
+		if concurrency <= 0 {
+			return nil, fmt.Errorf("concurrency should be positive, got %d", concurrency)
+		}
+		pub, err := NewConcurrentPublisher(ctx, uint(concurrency), next)
+		if err != nil {
+			return nil, err
+		}
+		pub.explicit = len(opts["concurrency"]) > 0
+		return pub, nil
+	})
+}
+
+// BuildPipeline create the publisher from stages, wrappers should be in front of backends,
+// the first stage is the outermost one, and the backends publish messages in parallel.
+func BuildPipeline(ctx context.Context, deps *StageDeps, stages []config.PublisherStage) (IMsgPublisher, error) {
+	registryLk.RLock()
+	defer registryLk.RUnlock()
+
+	var backendPubs []IMsgPublisher
+	var wrapperIdx []int
+	for i, stage := range stages {
+		if f, ok := backends[stage.Type]; ok {
+			pub, err := f(ctx, deps, stage.Options)
+			if err != nil {
+				return nil, fmt.Errorf("create publisher stage %s: %w", stage.StageName(), err)
+			}
+			backendPubs = append(backendPubs, pub)
+			continue
+		}
+		if _, ok := wrappers[stage.Type]; ok {
+			if len(backendPubs) > 0 {
+				return nil, fmt.Errorf("wrapper stage %s should be in front of backend stages", stage.StageName())
+			}
+			wrapperIdx = append(wrapperIdx, i)
+			continue
+		}
+		return nil, fmt.Errorf("unknown type %s of publisher stage %s", stage.Type, stage.StageName())
+	}
+	if len(backendPubs) == 0 {
+		return nil, fmt.Errorf("no backend stage in publisher pipeline")
+	}
+
+	var ret IMsgPublisher
+	if len(backendPubs) == 1 {
+		ret = backendPubs[0]
+	} else {
+		ret = NewMergePublisher(ctx, backendPubs...)
+	}
+	for i := len(wrapperIdx) - 1; i >= 0; i-- {
+		stage := stages[wrapperIdx[i]]
+		pub, err := wrappers[stage.Type](ctx, deps, stage.Options, ret)
+		if err != nil {
+			return nil, fmt.Errorf("create publisher stage %s: %w", stage.StageName(), err)
+		}
+		ret = pub
+	}
+
+	return ret, nil
+}
+
+// defaultPipeline returns the pipeline built from the legacy options
+func defaultPipeline(cfg *config.PublisherConfig) []config.PublisherStage {
+	var stages []config.PublisherStage
+	if cfg.CacheReleasePeriod == 0 {
+		stages = append(stages, config.PublisherStage{Type: "cache"})
+	}
+	if cfg.CacheReleasePeriod > 0 {
+		stages = append(stages, config.PublisherStage{Type: "cache", Options: map[string]string{
+			"period": (time.Duration(cfg.CacheReleasePeriod) * time.Second).String(),
+		}})
+	}
+	if cfg.Concurrency > 0 {
+		stages = append(stages, config.PublisherStage{Type: "concurrent"})
+	}
+	stages = append(stages, config.PublisherStage{Type: "rpc"})
+	if cfg.EnableP2P {
+		stages = append(stages, config.PublisherStage{Type: "pubsub"})
+	}
+	return stages
+}
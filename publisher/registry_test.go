@@ -0,0 +1,183 @@
+package publisher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestDefaultPipeline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	netParams := &types.NetworkParams{BlockDelaySecs: 30}
+	deps := func(cfg *config.PublisherConfig) *StageDeps {
+		return &StageDeps{NetParams: netParams, Cfg: cfg, RpcPublisher: &RpcPublisher{}}
+	}
+
+	cfg := config.DefaultConfig().Publisher
+	p, err := BuildPipeline(ctx, deps(cfg), defaultPipeline(cfg))
+	require.NoError(t, err)
+	cache, ok := p.(*CachePublisher)
+	require.True(t, ok)
+	assert.Equal(t, uint64(10), cache.cacheReleasePeriod)
+	concurrent, ok := cache.subPublisher.(*ConcurrentPublisher)
+	require.True(t, ok)
+	assert.Equal(t, uint(cfg.Concurrency), concurrent.Concurrency())
+	assert.IsType(t, &RpcPublisher{}, concurrent.subPublisher)
+	assert.NoError(t, UpdateConcurrency(p, 2))
+	assert.Equal(t, uint(2), concurrent.Concurrency())
+
+	// the stages without concurrency option use publisher.concurrency
+	p, err = BuildPipeline(ctx, deps(cfg), []config.PublisherStage{
+		{Type: "concurrent", Options: map[string]string{"concurrency": "3"}},
+		{Type: "cache"},
+		{Type: "concurrent"},
+		{Type: "rpc"},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, UpdateConcurrency(p, 7))
+	outer := p.(*ConcurrentPublisher)
+	assert.Equal(t, uint(3), outer.Concurrency())
+	inner := outer.subPublisher.(*CachePublisher).subPublisher.(*ConcurrentPublisher)
+	assert.Equal(t, uint(7), inner.Concurrency())
+
+	cfg.CacheReleasePeriod = -1
+	cfg.Concurrency = 0
+	p, err = BuildPipeline(ctx, deps(cfg), defaultPipeline(cfg))
+	require.NoError(t, err)
+	assert.IsType(t, &RpcPublisher{}, p)
+
+	// pubsub is enabled but not available
+	cfg.EnableP2P = true
+	_, err = BuildPipeline(ctx, deps(cfg), defaultPipeline(cfg))
+	assert.Error(t, err)
+}
+
+func TestCustomPipeline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan []*types.SignedMessage, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var msgs []*types.SignedMessage
+		if err := json.NewDecoder(r.Body).Decode(&msgs); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- msgs
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "msgs.json")
+	hexPath := filepath.Join(dir, "msgs.hex")
+	stages := []config.PublisherStage{
+		{Type: "concurrent", Options: map[string]string{"concurrency": "2"}},
+		{Name: "relay", Type: "http", Options: map[string]string{"url": srv.URL, "header.Authorization": "Bearer token"}},
+		{Type: "file", Options: map[string]string{"path": jsonPath}},
+		{Name: "hex", Type: "file", Options: map[string]string{"path": hexPath, "format": "hex"}},
+	}
+	p, err := BuildPipeline(ctx, &StageDeps{Cfg: config.DefaultConfig().Publisher}, stages)
+	require.NoError(t, err)
+	// the concurrency of stage option is not changed by publisher.concurrency
+	assert.NoError(t, UpdateConcurrency(p, 5))
+	assert.Equal(t, uint(2), p.(*ConcurrentPublisher).Concurrency())
+
+	msgs := testhelper.NewShareSignedMessages(3)
+	require.NoError(t, p.PublishMessages(ctx, msgs))
+
+	select {
+	case got := <-received:
+		assert.Len(t, got, len(msgs))
+		for i := range msgs {
+			assert.Equal(t, msgs[i].Cid(), got[i].Cid())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for relayed messages")
+	}
+
+	readLines := func(path string) []string {
+		var lines []string
+		assert.Eventually(t, func() bool {
+			f, err := os.Open(path)
+			if err != nil {
+				return false
+			}
+			defer f.Close() // nolint:errcheck
+			lines = lines[:0]
+			scanner := bufio.NewScanner(f)
+			scanner.Buffer(nil, 1<<20)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			return len(lines) == len(msgs)
+		}, 5*time.Second, 50*time.Millisecond)
+		return lines
+	}
+	for i, line := range readLines(jsonPath) {
+		var msg types.SignedMessage
+		require.NoError(t, json.Unmarshal([]byte(line), &msg))
+		assert.Equal(t, msgs[i].Cid(), msg.Cid())
+	}
+	for i, line := range readLines(hexPath) {
+		data, err := hex.DecodeString(line)
+		require.NoError(t, err)
+		var msg types.SignedMessage
+		require.NoError(t, msg.UnmarshalCBOR(bytes.NewReader(data)))
+		assert.Equal(t, msgs[i].Cid(), msg.Cid())
+	}
+}
+
+func TestHTTPRelayPublisherError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("busy"))
+	}))
+	defer srv.Close()
+
+	p, err := NewHTTPRelayPublisher(srv.URL, nil, time.Second)
+	require.NoError(t, err)
+	err = p.PublishMessages(context.Background(), testhelper.NewShareSignedMessages(1))
+	assert.ErrorContains(t, err, "status 503: busy")
+}
+
+func TestBuildPipelineError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deps := &StageDeps{Cfg: config.DefaultConfig().Publisher, RpcPublisher: &RpcPublisher{}}
+
+	cases := map[string][]config.PublisherStage{
+		"unknown type":          {{Type: "kafka"}},
+		"no backend":            {{Type: "cache"}},
+		"wrapper after backend": {{Type: "rpc"}, {Type: "cache"}},
+		"missing url":           {{Type: "http"}},
+		"missing path":          {{Type: "file"}},
+		"bad format":            {{Type: "file", Options: map[string]string{"path": "a", "format": "xml"}}},
+		"bad period":            {{Type: "cache", Options: map[string]string{"period": "100ms"}}, {Type: "rpc"}},
+		"bad concurrency":       {{Type: "concurrent", Options: map[string]string{"concurrency": "0"}}, {Type: "rpc"}},
+	}
+	for name, stages := range cases {
+		_, err := BuildPipeline(ctx, deps, stages)
+		assert.Error(t, err, name)
+	}
+}
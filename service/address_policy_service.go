@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-cid"
+
+	v1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+var ErrAddressPolicyDenied = errors.New("address policy denied")
+
+type IAddressPolicyService interface {
+	SaveAddressPolicy(ctx context.Context, policy *mtypes.AddressPolicy) (venusTypes.UUID, error)
+	GetAddressPolicy(ctx context.Context, id venusTypes.UUID) (*mtypes.AddressPolicy, error)
+	ListAddressPolicy(ctx context.Context, from address.Address) ([]*mtypes.AddressPolicy, error)
+	DeleteAddressPolicy(ctx context.Context, id venusTypes.UUID) error
+	CheckMessage(ctx context.Context, msg *venusTypes.Message) error
+}
+
+// AddressPolicyService manage the destination rules of sending addresses.
+// An address without any rule can send to anywhere, once it has rules, deny rules are checked first,
+// and if there is at least one allow rule, the message must match one of them.
+type AddressPolicyService struct {
+	repo       repo.Repo
+	nodeClient v1.FullNode
+}
+
+var _ IAddressPolicyService = (*AddressPolicyService)(nil)
+
+func NewAddressPolicyService(repo repo.Repo, nodeClient v1.FullNode) *AddressPolicyService {
+	return &AddressPolicyService{
+		repo:       repo,
+		nodeClient: nodeClient,
+	}
+}
+
+func (aps *AddressPolicyService) SaveAddressPolicy(ctx context.Context, policy *mtypes.AddressPolicy) (venusTypes.UUID, error) {
+	if policy == nil {
+		return venusTypes.UUID{}, errors.New("policy is nil")
+	}
+	if policy.From.Empty() {
+		return venusTypes.UUID{}, errors.New("from address is empty")
+	}
+	if policy.Action != mtypes.PolicyAllow && policy.Action != mtypes.PolicyDeny {
+		return venusTypes.UUID{}, fmt.Errorf("unexpected policy action %d", policy.Action)
+	}
+	// store the ID address if the actor exists, so that rules match however the destination is written in message
+	if !policy.To.Empty() && policy.To.Protocol() != address.ID {
+		if idAddr, err := aps.nodeClient.StateLookupID(ctx, policy.To, venusTypes.EmptyTSK); err == nil {
+			log.Infof("resolve policy destination %s to %s", policy.To, idAddr)
+			policy.To = idAddr
+		}
+	}
+
+	if policy.ID == (venusTypes.UUID{}) {
+		policy.ID = venusTypes.NewUUID()
+		policy.CreatedAt = time.Now()
+	}
+	policy.UpdatedAt = time.Now()
+	if err := aps.repo.AddressPolicyRepo().SaveAddressPolicy(ctx, policy); err != nil {
+		return venusTypes.UUID{}, err
+	}
+	log.Infof("save address policy %s: %s %s", policy.ID, policy.From, policy.Action)
+
+	return policy.ID, nil
+}
+
+func (aps *AddressPolicyService) GetAddressPolicy(ctx context.Context, id venusTypes.UUID) (*mtypes.AddressPolicy, error) {
+	policy, err := aps.repo.AddressPolicyRepo().GetAddressPolicy(ctx, id)
+	if errors.Is(err, repo.ErrRecordNotFound) {
+		return nil, fmt.Errorf("address policy %s not found", id)
+	}
+	return policy, err
+}
+
+func (aps *AddressPolicyService) ListAddressPolicy(ctx context.Context, from address.Address) ([]*mtypes.AddressPolicy, error) {
+	if from.Empty() {
+		return aps.repo.AddressPolicyRepo().ListAddressPolicy(ctx)
+	}
+	return aps.repo.AddressPolicyRepo().ListAddressPolicyByFrom(ctx, from)
+}
+
+func (aps *AddressPolicyService) DeleteAddressPolicy(ctx context.Context, id venusTypes.UUID) error {
+	if _, err := aps.GetAddressPolicy(ctx, id); err != nil {
+		return err
+	}
+	if err := aps.repo.AddressPolicyRepo().DelAddressPolicy(ctx, id); err != nil {
+		return err
+	}
+	log.Infof("delete address policy %s", id)
+
+	return nil
+}
+
+// CheckMessage returns an error wrapping ErrAddressPolicyDenied if the rules of msg.From forbid the message.
+func (aps *AddressPolicyService) CheckMessage(ctx context.Context, msg *venusTypes.Message) error {
+	return aps.checkMessage(ctx, msg, nil)
+}
+
+// policyTarget is the destination of a message resolved for matching the rules
+type policyTarget struct {
+	addrs []address.Address
+	code  cid.Cid
+}
+
+// policyTargetCache caches the resolved destinations during a round of message selection, so the same destination
+// is not looked up for each message
+type policyTargetCache map[address.Address]*policyTarget
+
+// checkMessage is CheckMessage with the destinations cached in cache if it's not nil
+func (aps *AddressPolicyService) checkMessage(ctx context.Context, msg *venusTypes.Message, cache policyTargetCache) error {
+	policies, err := aps.repo.AddressPolicyRepo().ListAddressPolicyByFrom(ctx, msg.From)
+	if err != nil {
+		return fmt.Errorf("list address policy of %s failed: %w", msg.From, err)
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	target, ok := cache[msg.To]
+	if !ok {
+		target, err = aps.resolveTarget(ctx, msg.To)
+		if err != nil {
+			return err
+		}
+		if cache != nil {
+			cache[msg.To] = target
+		}
+	}
+
+	hasAllow := false
+	allowed := false
+	for _, p := range policies {
+		switch p.Action {
+		case mtypes.PolicyDeny:
+			if p.Match(target.addrs, target.code, msg.Method) {
+				return fmt.Errorf("%w: %s to %s method %d matched deny rule %s", ErrAddressPolicyDenied, msg.From, msg.To, msg.Method, p.ID)
+			}
+		case mtypes.PolicyAllow:
+			hasAllow = true
+			if !allowed && p.Match(target.addrs, target.code, msg.Method) {
+				allowed = true
+			}
+		}
+	}
+	if hasAllow && !allowed {
+		return fmt.Errorf("%w: %s to %s method %d not matched any allow rule", ErrAddressPolicyDenied, msg.From, msg.To, msg.Method)
+	}
+
+	return nil
+}
+
+// resolveTarget returns the destination with its ID address and actor code, the code is undefined if the actor
+// doesn't exist yet
+func (aps *AddressPolicyService) resolveTarget(ctx context.Context, to address.Address) (*policyTarget, error) {
+	target := &policyTarget{addrs: []address.Address{to}, code: cid.Undef}
+	actor, err := aps.nodeClient.StateGetActor(ctx, to, venusTypes.EmptyTSK)
+	if err != nil {
+		if !strings.Contains(err.Error(), venusTypes.ErrActorNotFound.Error()) {
+			return nil, fmt.Errorf("get actor %s failed: %w", to, err)
+		}
+		return target, nil
+	}
+	target.code = actor.Code
+	if to.Protocol() != address.ID {
+		idAddr, err := aps.nodeClient.StateLookupID(ctx, to, venusTypes.EmptyTSK)
+		if err != nil {
+			return nil, fmt.Errorf("lookup id of %s failed: %w", to, err)
+		}
+		target.addrs = append(target.addrs, idAddr)
+	}
+	return target, nil
+}
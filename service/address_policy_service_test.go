@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestAddressPolicyCheckMessage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msh := newMessageServiceHelper(ctx, t)
+	ps := msh.MessageService.policyService
+
+	from := testhelper.RandAddresses(t, 1)[0]
+	to1, err := address.NewIDAddress(2001)
+	assert.NoError(t, err)
+	to2, err := address.NewIDAddress(2002)
+	assert.NoError(t, err)
+	assert.NoError(t, msh.fullNode.AddActors([]address.Address{to1, to2}))
+
+	newMsg := func(to address.Address, method abi.MethodNum) *venusTypes.Message {
+		return &venusTypes.Message{From: from, To: to, Method: method}
+	}
+
+	// no rule, anything is allowed
+	assert.NoError(t, ps.CheckMessage(ctx, newMsg(to1, builtin.MethodSend)))
+
+	denyID, err := ps.SaveAddressPolicy(ctx, &mtypes.AddressPolicy{From: from, Action: mtypes.PolicyDeny, To: to1})
+	assert.NoError(t, err)
+	err = ps.CheckMessage(ctx, newMsg(to1, builtin.MethodSend))
+	assert.True(t, errors.Is(err, ErrAddressPolicyDenied))
+	assert.NoError(t, ps.CheckMessage(ctx, newMsg(to2, builtin.MethodSend)))
+
+	_, err = ps.SaveAddressPolicy(ctx, &mtypes.AddressPolicy{
+		From:    from,
+		Action:  mtypes.PolicyAllow,
+		To:      to2,
+		Methods: []abi.MethodNum{builtin.MethodSend},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, ps.CheckMessage(ctx, newMsg(to2, builtin.MethodSend)))
+	err = ps.CheckMessage(ctx, newMsg(to2, 2))
+	assert.True(t, errors.Is(err, ErrAddressPolicyDenied))
+
+	// deny rule removed, but to1 is still not in allow list
+	assert.NoError(t, ps.DeleteAddressPolicy(ctx, denyID))
+	assert.ErrorContains(t, ps.DeleteAddressPolicy(ctx, denyID), "not found")
+	err = ps.CheckMessage(ctx, newMsg(to1, builtin.MethodSend))
+	assert.True(t, errors.Is(err, ErrAddressPolicyDenied))
+
+	policies, err := ps.ListAddressPolicy(ctx, from)
+	assert.NoError(t, err)
+	assert.Len(t, policies, 1)
+
+	// the resolved destinations are cached during a round of selection
+	targets := policyTargetCache{}
+	assert.NoError(t, ps.checkMessage(ctx, newMsg(to2, builtin.MethodSend), targets))
+	assert.Len(t, targets, 1)
+	targets[to2] = &policyTarget{addrs: []address.Address{to1}}
+	err = ps.checkMessage(ctx, newMsg(to2, builtin.MethodSend), targets)
+	assert.True(t, errors.Is(err, ErrAddressPolicyDenied))
+}
@@ -15,6 +15,7 @@ import (
 	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
 	types "github.com/filecoin-project/venus/venus-shared/types/messager"
 
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
 	"github.com/ipfs-force-community/sophon-messager/models/repo"
 )
 
@@ -39,6 +40,8 @@ type IAddressService interface {
 	SetFeeParams(ctx context.Context, params *types.AddressSpec) error
 	ActiveAddresses(ctx context.Context) map[address.Address]struct{}
 	GetAccountsOfSigner(ctx context.Context, addr address.Address) ([]string, error)
+	ListWalletInventory(ctx context.Context) (*mtypes.WalletInventory, error)
+	ListSignerHealth(ctx context.Context) ([]*mtypes.SignerHealth, error)
 }
 
 type AddressService struct {
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+// defAuditLogLimit is used when listing audit logs without a limit
+const defAuditLogLimit = 100
+
+type AuditService struct {
+	repo repo.Repo
+}
+
+func NewAuditService(repo repo.Repo) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+func (as *AuditService) Record(ctx context.Context, log *mtypes.AuditLog) error {
+	if log.ID == (venusTypes.UUID{}) {
+		log.ID = venusTypes.NewUUID()
+	}
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	return as.repo.AuditLogRepo().CreateAuditLog(ctx, log)
+}
+
+func (as *AuditService) ListAuditLog(ctx context.Context, query *mtypes.AuditLogQuery) ([]*mtypes.AuditLog, error) {
+	if query == nil {
+		query = &mtypes.AuditLogQuery{}
+	}
+	if query.Limit <= 0 {
+		query.Limit = defAuditLogLimit
+	}
+	return as.repo.AuditLogRepo().ListAuditLog(ctx, query)
+}
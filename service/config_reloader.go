@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/fx"
+
+	gatewayAPI "github.com/filecoin-project/venus/venus-shared/api/gateway/v2"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/filestore"
+	"github.com/ipfs-force-community/sophon-messager/publisher"
+)
+
+// wait for the config file to be written completely before reloading
+const configChangeDelay = time.Second
+
+type walletConfigUpdater interface {
+	UpdateConfig(ctx context.Context, cfg *config.GatewayConfig) error
+}
+
+// ConfigReloader apply the hot reloadable fields of config file to the running services.
+type ConfigReloader struct {
+	fsRepo       filestore.FSRepo
+	msgService   *MessageService
+	msgPublisher publisher.IMsgPublisher
+	walletClient gatewayAPI.IWalletClient
+
+	lk sync.Mutex
+	// the config file when starting and the last reloading, with environment variables applied, values overridden
+	// by command line flags are only replaced when the field changed in config file
+	startFileCfg, lastFileCfg *config.Config
+}
+
+func NewConfigReloader(fsRepo filestore.FSRepo,
+	msgService *MessageService,
+	msgPublisher publisher.IMsgPublisher,
+	walletClient gatewayAPI.IWalletClient,
+) (*ConfigReloader, error) {
+	fileCfg, err := loadFileConfig(fsRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigReloader{
+		fsRepo:       fsRepo,
+		msgService:   msgService,
+		msgPublisher: msgPublisher,
+		walletClient: walletClient,
+		startFileCfg: fileCfg,
+		lastFileCfg:  fileCfg,
+	}, nil
+}
+
+// loadFileConfig read the config file and apply environment variables, as they take precedence over the file.
+func loadFileConfig(fsRepo filestore.FSRepo) (*config.Config, error) {
+	cfg, err := fsRepo.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load config failed: %w", err)
+	}
+	if _, err := config.ApplyEnv(cfg); err != nil {
+		return nil, fmt.Errorf("apply environment variables failed: %w", err)
+	}
+	return cfg, nil
+}
+
+// Config returns the config in use.
+func (cr *ConfigReloader) Config() *config.Config {
+	return cr.fsRepo.Config()
+}
+
+// Reload read the config file and apply the changed fields which can take effect without restart,
+// the other changed fields are only reported.
+func (cr *ConfigReloader) Reload(ctx context.Context) (*config.ReloadResult, error) {
+	cr.lk.Lock()
+	defer cr.lk.Unlock()
+
+	newCfg, err := loadFileConfig(cr.fsRepo)
+	if err != nil {
+		return nil, err
+	}
+	if err := newCfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	oldCfg := cr.fsRepo.Config()
+	result := &config.ReloadResult{}
+	changed := make(map[string]struct{})
+	for _, path := range config.DiffConfig(cr.lastFileCfg, newCfg) {
+		if config.IsHotReloadable(path) {
+			changed[path] = struct{}{}
+		}
+	}
+	for _, path := range config.DiffConfig(cr.startFileCfg, newCfg) {
+		if !config.IsHotReloadable(path) {
+			result.NeedRestart = append(result.NeedRestart, path)
+		}
+	}
+	isChanged := func(paths ...string) bool {
+		for _, path := range paths {
+			if _, ok := changed[path]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	applied := func(paths ...string) {
+		for _, path := range paths {
+			if isChanged(path) {
+				result.Applied = append(result.Applied, path)
+			}
+		}
+	}
+	needRestart := func(reason error, paths ...string) {
+		for _, path := range paths {
+			if isChanged(path) {
+				log.Warnf("apply %s failed: %v", path, reason)
+				result.NeedRestart = append(result.NeedRestart, path)
+			}
+		}
+	}
+
+	// the cold fields keep the values in use
+	cfg := *oldCfg
+
+	// apply gateway first, as it is the most likely to fail, and nothing has been changed yet
+	gatewayFields := []string{
+		"gateway.url",
+		"gateway.token",
+		"gateway.health.failureThreshold",
+		"gateway.health.openDuration",
+		"gateway.health.unreachableAlert",
+		"gateway.health.quarantineDuration",
+	}
+	if isChanged(gatewayFields...) {
+		if updater, ok := cr.walletClient.(walletConfigUpdater); ok {
+			if err := updater.UpdateConfig(ctx, &newCfg.Gateway); err != nil {
+				return nil, fmt.Errorf("update gateway failed: %w", err)
+			}
+			cfg.Gateway = newCfg.Gateway
+			applied(gatewayFields...)
+		} else {
+			needRestart(fmt.Errorf("wallet client %T not support update", cr.walletClient), gatewayFields...)
+		}
+	}
+
+	if isChanged("publisher.concurrency") {
+		if err := publisher.UpdateConcurrency(cr.msgPublisher, newCfg.Publisher.Concurrency); err != nil {
+			needRestart(err, "publisher.concurrency")
+		} else {
+			publisherCfg := *oldCfg.Publisher
+			publisherCfg.Concurrency = newCfg.Publisher.Concurrency
+			cfg.Publisher = &publisherCfg
+			applied("publisher.concurrency")
+		}
+	}
+
+	msgServiceFields := []string{
+		"messageService.WaitingChainHeadStableDuration",
+		"messageService.DefaultTimeout",
+		"messageService.SignMessageTimeout",
+		"messageService.EstimateMessageTimeout",
+		"messageService.signConcurrency",
+		"messageService.skipProcessHead",
+	}
+	if isChanged(msgServiceFields...) {
+		cfg.MessageService = newCfg.MessageService
+		cfg.MessageService.SkipPushMessage = oldCfg.MessageService.SkipPushMessage
+		cr.msgService.msgSelectMgr.UpdateConfig(&cfg.MessageService)
+		applied(msgServiceFields...)
+	}
+
+	if isChanged("log.level") {
+		if err := logging.SetLogLevel("*", newCfg.Log.Level); err != nil {
+			needRestart(err, "log.level")
+		} else {
+			cfg.Log.Level = newCfg.Log.Level
+			applied("log.level")
+		}
+	}
+
+	cr.fsRepo.SetConfig(&cfg)
+	cr.lastFileCfg = newCfg
+
+	sort.Strings(result.Applied)
+	sort.Strings(result.NeedRestart)
+	if len(result.Applied) > 0 {
+		log.Infof("config reloaded, applied: %v", result.Applied)
+	}
+	if len(result.NeedRestart) > 0 {
+		log.Warnf("config changed, but need restart to take effect: %v", result.NeedRestart)
+	}
+
+	return result, nil
+}
+
+// StartConfigReloader reload config when the config file changed or receiving SIGHUP.
+func StartConfigReloader(lc fx.Lifecycle, ctx context.Context, cr *ConfigReloader) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// watch the directory, as editors may replace the config file instead of writing it
+	if err := watcher.Add(cr.fsRepo.Path()); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	cfgPath := filepath.Join(cr.fsRepo.Path(), filestore.ConfigFile)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	reload := func(reason string) {
+		log.Infof("reload config: %s", reason)
+		if _, err := cr.Reload(ctx); err != nil {
+			log.Errorf("reload config failed: %v", err)
+		}
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				var delay <-chan time.Time
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-sigCh:
+						reload("received SIGHUP")
+					case e, ok := <-watcher.Events:
+						if !ok {
+							return
+						}
+						if filepath.Clean(e.Name) == cfgPath && e.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+							delay = time.After(configChangeDelay)
+						}
+					case err, ok := <-watcher.Errors:
+						if !ok {
+							return
+						}
+						log.Warnf("watch config file failed: %v", err)
+					case <-delay:
+						delay = nil
+						reload("config file changed")
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			signal.Stop(sigCh)
+			return watcher.Close()
+		},
+	})
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/filestore"
+	"github.com/ipfs-force-community/sophon-messager/publisher"
+	"github.com/ipfs-force-community/sophon-messager/utils"
+)
+
+func TestConfigReloader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msh := newMessageServiceHelper(ctx, t)
+	fsRepo, err := filestore.InitFSRepo(t.TempDir(), config.DefaultConfig())
+	assert.NoError(t, err)
+	concurrentPublisher, err := publisher.NewConcurrentPublisher(ctx, 5, publisher.NewMergePublisher(ctx))
+	assert.NoError(t, err)
+
+	cr, err := NewConfigReloader(fsRepo, msh.MessageService, concurrentPublisher, msh.walletProxy)
+	assert.NoError(t, err)
+
+	writeConfig := func(cfg *config.Config) {
+		assert.NoError(t, utils.WriteConfig(filepath.Join(fsRepo.Path(), filestore.ConfigFile), cfg))
+	}
+
+	res, err := cr.Reload(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, res.Applied)
+	assert.Empty(t, res.NeedRestart)
+
+	cfg := config.DefaultConfig()
+	cfg.MessageService.SignMessageTimeout = time.Second * 10
+	cfg.Publisher.Concurrency = 2
+	cfg.Gateway.Url = []string{"/ip4/127.0.0.1/tcp/45133"}
+	cfg.DB.Type = "mysql"
+	cfg.DB.MySql.ConnectionString = "root:pwd@tcp(127.0.0.1:3306)/messager"
+	writeConfig(cfg)
+
+	res, err = cr.Reload(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"messageService.SignMessageTimeout", "publisher.concurrency"}, res.Applied)
+	// mock wallet proxy can't be updated
+	assert.Equal(t, []string{"db.mysql.connectionString", "db.type", "gateway.url"}, res.NeedRestart)
+
+	assert.Equal(t, time.Second*10, fsRepo.Config().MessageService.SignMessageTimeout)
+	assert.Equal(t, 2, fsRepo.Config().Publisher.Concurrency)
+	assert.Equal(t, "sqlite", fsRepo.Config().DB.Type)
+	assert.Equal(t, config.DefaultConfig().Gateway.Url, fsRepo.Config().Gateway.Url)
+	assert.Equal(t, uint(2), concurrentPublisher.Concurrency())
+	msh.msgSelectMgr.lk.Lock()
+	assert.Equal(t, time.Second*10, msh.msgSelectMgr.cfg.SignMessageTimeout)
+	msh.msgSelectMgr.lk.Unlock()
+
+	// the cold fields are reported until restart
+	res, err = cr.Reload(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, res.Applied)
+	assert.Equal(t, []string{"db.mysql.connectionString", "db.type"}, res.NeedRestart)
+
+	// invalid config is rejected as a whole
+	cfg.MessageService.DefaultTimeout = time.Second * 5
+	cfg.Publisher.Concurrency = -1
+	writeConfig(cfg)
+	_, err = cr.Reload(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, config.DefaultTimeout, fsRepo.Config().MessageService.DefaultTimeout)
+}
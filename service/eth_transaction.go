@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/venus/pkg/constants"
+	actorsTypes "github.com/filecoin-project/venus/venus-shared/actors/types"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/ipfs-force-community/sophon-auth/core"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+// ethTxHash returns the eth tx hash of a message signed by a delegated address
+func ethTxHash(msg *venusTypes.SignedMessage) (venusTypes.EthHash, error) {
+	tx, err := venusTypes.EthTransactionFromSignedFilecoinMessage(msg)
+	if err != nil {
+		return venusTypes.EmptyEthHash, err
+	}
+	return tx.TxHash()
+}
+
+// saveEthTransactions record the eth tx hashes of the signed messages from delegated addresses, so they can be
+// looked up by the hashes
+func saveEthTransactions(ctx context.Context, txRepo repo.TxRepo, msgs ...*types.Message) error {
+	for _, msg := range msgs {
+		if msg.From.Protocol() != address.Delegated || msg.Signature == nil {
+			continue
+		}
+		txHash, err := ethTxHash(&venusTypes.SignedMessage{Message: msg.Message, Signature: *msg.Signature})
+		if err != nil {
+			log.Warnf("get eth tx hash of message %s failed: %v", msg.ID, err)
+			continue
+		}
+		if err := txRepo.EthTransactionRepo().SaveEthTransaction(ctx, &mtypes.EthTransaction{
+			TxHash:    txHash,
+			MsgID:     msg.ID,
+			CreatedAt: time.Now(),
+		}); err != nil {
+			return fmt.Errorf("save eth tx hash of message %s: %w", msg.ID, err)
+		}
+	}
+	return nil
+}
+
+// PushEthTransaction push an EIP-1559 transaction, a signed one is pushed like PushSignedMessage, an unsigned one is
+// signed by messager right away, so the tx hash is returned for both.
+func (ms *MessageService) PushEthTransaction(ctx context.Context, params *mtypes.EthTxParams) (*mtypes.EthTxResult, error) {
+	if params == nil {
+		return nil, errors.New("params is nil")
+	}
+	if len(params.Raw) > 0 {
+		if params.From != nil {
+			return nil, errors.New("can't set both raw and from")
+		}
+		return ms.pushRawEthTransaction(ctx, params.Raw)
+	}
+	if params.From == nil {
+		return nil, errors.New("either raw or from should be set")
+	}
+
+	from, err := params.From.ToFilecoinAddress()
+	if err != nil {
+		return nil, err
+	}
+	if from.Protocol() != address.Delegated {
+		return nil, fmt.Errorf("from %s is not a delegated address", params.From)
+	}
+	tx := &venusTypes.Eth1559TxArgs{
+		ChainID:              actorsTypes.Eip155ChainID,
+		To:                   params.To,
+		Value:                orZero(params.Value),
+		MaxFeePerGas:         orZero(params.MaxFeePerGas),
+		MaxPriorityFeePerGas: orZero(params.MaxPriorityFeePerGas),
+		GasLimit:             int(params.Gas),
+		Input:                params.Input,
+	}
+	msg, err := tx.ToUnsignedFilecoinMessage(from)
+	if err != nil {
+		return nil, fmt.Errorf("convert transaction to message: %w", err)
+	}
+
+	return ms.pushUnsignedEthTransaction(ctx, msg)
+}
+
+// pushUnsignedEthTransaction sign the message converted from an unsigned transaction instead of waiting for it to be
+// selected, as the tx hash depends on the nonce and gas. The nonce is assigned with the address locked like
+// PushSignedMessage, then the message is saved as FillMsg and published.
+func (ms *MessageService) pushUnsignedEthTransaction(ctx context.Context, msg *venusTypes.Message) (*mtypes.EthTxResult, error) {
+	from := msg.From
+	accounts, err := ms.addressService.GetAccountsOfSigner(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("get accounts for %s: %w", from, err)
+	}
+	has, err := ms.walletClient.WalletHas(ctx, from, accounts)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, fmt.Errorf("signer address %s not exists", from)
+	}
+	if err := ms.policyService.CheckMessage(ctx, msg); err != nil {
+		return nil, err
+	}
+	sharedParams, err := ms.sps.GetSharedParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := ms.nodeClient.StateGetActor(ctx, from, venusTypes.EmptyTSK)
+	if err != nil {
+		return nil, fmt.Errorf("get actor %s failed: %w", from, err)
+	}
+
+	unlock, err := ms.msgSelectMgr.lockAddress(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var addrInfo *types.Address
+	if err := ms.repo.Transaction(func(txRepo repo.TxRepo) error {
+		addrInfo, err = getOrCreateAddress(ctx, txRepo, from)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if addrInfo.State == types.AddressStateForbbiden {
+		return nil, fmt.Errorf("address(%s) is forbidden", from)
+	}
+
+	account, _ := core.CtxGetName(ctx)
+	dbMsg := &types.Message{
+		ID:         venusTypes.NewUUID().String(),
+		Message:    *msg,
+		Meta:       &types.SendSpec{},
+		WalletName: account,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	dbMsg.Nonce = addrInfo.Nonce
+	if actor.Nonce > dbMsg.Nonce {
+		dbMsg.Nonce = actor.Nonce
+	}
+	gasSpec := mergeMsgSpec(sharedParams, dbMsg.Meta, addrInfo, nil, dbMsg)
+	if dbMsg.GasFeeCap.NilOrZero() && !gasSpec.GasFeeCap.NilOrZero() {
+		dbMsg.GasFeeCap = gasSpec.GasFeeCap
+	}
+	estimateMsg, err := ms.nodeClient.GasEstimateMessageGas(ctx, &dbMsg.Message, &venusTypes.MessageSendSpec{
+		MaxFee:            gasSpec.MaxFee,
+		GasOverEstimation: gasSpec.GasOverEstimation,
+		GasOverPremium:    gasSpec.GasOverPremium,
+	}, venusTypes.EmptyTSK)
+	if err != nil {
+		return nil, fmt.Errorf("%s%w", gasEstimate, err)
+	}
+	if estimateMsg.GasLimit > constants.BlockGasLimit {
+		return nil, fmt.Errorf("%s gas limit %d over limit %d", gasEstimate, estimateMsg.GasLimit, constants.BlockGasLimit)
+	}
+	dbMsg.Message = *estimateMsg
+
+	signedMsg, err := ToSignedMsg(ctx, ms.walletClient, dbMsg, accounts)
+	if err != nil {
+		return nil, err
+	}
+	txHash, err := ethTxHash(&signedMsg)
+	if err != nil {
+		return nil, fmt.Errorf("get eth tx hash: %w", err)
+	}
+	err = ms.repo.Transaction(func(txRepo repo.TxRepo) error {
+		if err := txRepo.MessageRepo().CreateMessage(dbMsg); err != nil {
+			return err
+		}
+		if err := saveEthTransactions(ctx, txRepo, dbMsg); err != nil {
+			return err
+		}
+		_, err := txRepo.AddressRepo().UpdateNonce(from, dbMsg.Nonce+1)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("save eth transaction %s from %s with nonce %d as %s", txHash, from, dbMsg.Nonce, dbMsg.ID)
+
+	// the selector will push it again if failed
+	if err := ms.msgReceiver.PushMessages(ctx, []*venusTypes.SignedMessage{&signedMsg}); err != nil {
+		log.Warnf("push eth transaction %s failed: %v", dbMsg.ID, err)
+	}
+
+	return &mtypes.EthTxResult{ID: dbMsg.ID, TxHash: &txHash}, nil
+}
+
+func (ms *MessageService) pushRawEthTransaction(ctx context.Context, raw []byte) (*mtypes.EthTxResult, error) {
+	tx, err := venusTypes.ParseEthTransaction(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse transaction: %w", err)
+	}
+	if tx.Type() != venusTypes.EIP1559TxType {
+		return nil, fmt.Errorf("transaction type %d is not supported, only EIP-1559 transactions are supported", tx.Type())
+	}
+	txHash, err := tx.TxHash()
+	if err != nil {
+		return nil, err
+	}
+	msg, err := venusTypes.ToSignedFilecoinMessage(tx)
+	if err != nil {
+		return nil, fmt.Errorf("convert transaction to message: %w", err)
+	}
+
+	id, err := ms.PushSignedMessage(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	return &mtypes.EthTxResult{ID: id, TxHash: &txHash}, nil
+}
+
+// GetMessageByEthTxHash returns the message of the eth tx hash, which is pushed by PushEthTransaction or signed by
+// a delegated address
+func (ms *MessageService) GetMessageByEthTxHash(ctx context.Context, txHash venusTypes.EthHash) (*types.Message, error) {
+	tx, err := ms.repo.EthTransactionRepo().GetEthTransaction(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("get eth transaction %s: %w", txHash, err)
+	}
+	return ms.GetMessageByUid(ctx, tx.MsgID)
+}
+
+// GetEthTxHash returns the latest eth tx hash of the message, the message should be signed by a delegated address.
+// The message pushed by PushMessage is signed when it's selected, so the hash is unknown until then.
+func (ms *MessageService) GetEthTxHash(ctx context.Context, id string) (venusTypes.EthHash, error) {
+	tx, err := ms.repo.EthTransactionRepo().GetEthTransactionByMsgID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if msg, msgErr := ms.repo.MessageRepo().GetMessageByUid(id); msgErr == nil && msg.Signature == nil {
+				return venusTypes.EmptyEthHash, fmt.Errorf("message %s is not signed yet, the tx hash is known after it is selected", id)
+			}
+		}
+		return venusTypes.EmptyEthHash, fmt.Errorf("get eth transaction of message %s: %w", id, err)
+	}
+	return tx.TxHash, nil
+}
+
+func orZero(v venusTypes.EthBigInt) big.Int {
+	if v.Int == nil {
+		return big.Zero()
+	}
+	return big.Int(v)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/crypto"
+	vcrypto "github.com/filecoin-project/venus/pkg/crypto"
+	actorsTypes "github.com/filecoin-project/venus/venus-shared/actors/types"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/wallet"
+)
+
+func newDelegatedKey(t *testing.T) ([]byte, venusTypes.EthAddress, address.Address) {
+	pk, err := vcrypto.Generate(crypto.SigTypeDelegated)
+	require.NoError(t, err)
+	pub, err := vcrypto.ToPublic(crypto.SigTypeDelegated, pk)
+	require.NoError(t, err)
+	data, err := venusTypes.EthAddressFromPubKey(pub)
+	require.NoError(t, err)
+	ethAddr, err := venusTypes.CastEthAddress(data)
+	require.NoError(t, err)
+	addr, err := ethAddr.ToFilecoinAddress()
+	require.NoError(t, err)
+	return pk, ethAddr, addr
+}
+
+func TestPushEthTransaction(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msh := newMessageServiceHelper(ctx, t)
+	pk, ethAddr, addr := newDelegatedKey(t)
+	msh.addAddresses([]address.Address{addr})
+	ms := msh.MessageService
+	_, to, _ := newDelegatedKey(t)
+
+	t.Run("invalid params", func(t *testing.T) {
+		_, err := ms.PushEthTransaction(ctx, &mtypes.EthTxParams{})
+		assert.ErrorContains(t, err, "either raw or from should be set")
+		_, err = ms.PushEthTransaction(ctx, &mtypes.EthTxParams{Raw: []byte{2, 1}, From: &ethAddr})
+		assert.ErrorContains(t, err, "can't set both raw and from")
+		_, err = ms.PushEthTransaction(ctx, &mtypes.EthTxParams{Raw: []byte{1, 2}})
+		assert.ErrorContains(t, err, "EIP-2930 transaction is not supported")
+		_, err = ms.PushEthTransaction(ctx, &mtypes.EthTxParams{From: &venusTypes.EthAddress{0xff}})
+		assert.ErrorContains(t, err, "is not a delegated address")
+	})
+
+	t.Run("raw transaction", func(t *testing.T) {
+		tx := &venusTypes.Eth1559TxArgs{
+			ChainID:              actorsTypes.Eip155ChainID,
+			To:                   &to,
+			Value:                big.NewInt(1),
+			MaxFeePerGas:         big.NewInt(10000),
+			MaxPriorityFeePerGas: big.NewInt(1000),
+			GasLimit:             1000000,
+			Input:                []byte{0xa9, 0x05, 0x9c, 0xbb},
+		}
+		unsigned, err := tx.ToRlpUnsignedMsg()
+		require.NoError(t, err)
+		sig, err := vcrypto.Sign(unsigned, pk, crypto.SigTypeDelegated)
+		require.NoError(t, err)
+		require.NoError(t, tx.InitialiseSignature(*sig))
+		raw, err := tx.ToRlpSignedMsg()
+		require.NoError(t, err)
+		txHash, err := tx.TxHash()
+		require.NoError(t, err)
+
+		res, err := ms.PushEthTransaction(ctx, &mtypes.EthTxParams{Raw: raw})
+		require.NoError(t, err)
+		assert.Equal(t, &txHash, res.TxHash)
+
+		msg, err := ms.GetMessageByEthTxHash(ctx, txHash)
+		require.NoError(t, err)
+		assert.Equal(t, res.ID, msg.ID)
+		assert.Equal(t, types.FillMsg, msg.State)
+		assert.Equal(t, addr, msg.From)
+		assert.Equal(t, builtin.MethodsEVM.InvokeContract, msg.Method)
+		hash, err := ms.GetEthTxHash(ctx, res.ID)
+		require.NoError(t, err)
+		assert.Equal(t, txHash, hash)
+
+		// a bad signature
+		tx.Value = big.NewInt(2)
+		raw, err = tx.ToRlpSignedMsg()
+		require.NoError(t, err)
+		_, err = ms.PushEthTransaction(ctx, &mtypes.EthTxParams{Raw: raw})
+		assert.Error(t, err)
+	})
+
+	t.Run("unsigned transaction", func(t *testing.T) {
+		// the signatures of the mock wallet can't be converted to eth transactions, sign with the key
+		ks, err := wallet.OpenKeystore(t.TempDir(), []byte("passphrase"))
+		require.NoError(t, err)
+		_, err = ks.Import(&venusTypes.KeyInfo{Type: venusTypes.KTDelegated, PrivateKey: pk})
+		require.NoError(t, err)
+		ms.walletClient, err = wallet.NewLocalWallet(ks)
+		require.NoError(t, err)
+
+		res, err := ms.PushEthTransaction(ctx, &mtypes.EthTxParams{
+			From:  &ethAddr,
+			To:    &to,
+			Value: venusTypes.EthBigInt(big.NewInt(1)),
+		})
+		require.NoError(t, err)
+		require.NotNil(t, res.TxHash)
+
+		// signed with the next nonce right away
+		msg, err := ms.GetMessageByUid(ctx, res.ID)
+		require.NoError(t, err)
+		assert.Equal(t, types.FillMsg, msg.State)
+		assert.Equal(t, addr, msg.From)
+		assert.Equal(t, uint64(1), msg.Nonce)
+		assert.Equal(t, builtin.MethodsEVM.InvokeContract, msg.Method)
+		assert.Equal(t, big.NewInt(1), msg.Value)
+		assert.NotZero(t, msg.GasLimit)
+		txHash, err := ethTxHash(&venusTypes.SignedMessage{Message: msg.Message, Signature: *msg.Signature})
+		require.NoError(t, err)
+		assert.Equal(t, txHash, *res.TxHash)
+		addrInfo, err := ms.addressService.GetAddress(ctx, addr)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), addrInfo.Nonce)
+
+		hash, err := ms.GetEthTxHash(ctx, res.ID)
+		require.NoError(t, err)
+		assert.Equal(t, txHash, hash)
+		found, err := ms.GetMessageByEthTxHash(ctx, txHash)
+		require.NoError(t, err)
+		assert.Equal(t, res.ID, found.ID)
+
+		// the message pushed by PushMessage is signed when it's selected
+		unsignedMsg, err := (&venusTypes.Eth1559TxArgs{ChainID: actorsTypes.Eip155ChainID, To: &to, Value: big.NewInt(1),
+			MaxFeePerGas: big.Zero(), MaxPriorityFeePerGas: big.Zero()}).ToUnsignedFilecoinMessage(addr)
+		require.NoError(t, err)
+		id, err := ms.PushMessage(ctx, unsignedMsg, nil)
+		require.NoError(t, err)
+		_, err = ms.GetEthTxHash(ctx, id)
+		assert.ErrorContains(t, err, "is not signed yet")
+	})
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	jwt3 "github.com/gbrlsnchs/jwt/v3"
+	"github.com/ipfs-force-community/sophon-auth/auth"
+	"github.com/ipfs-force-community/sophon-auth/core"
+	"github.com/ipfs-force-community/sophon-auth/jwtclient"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+var errNotSupportedInLocalAuth = errors.New("not supported in local auth mode")
+
+// LocalAuthService is used instead of sophon-auth in the local auth mode, the users, their tokens and signers are
+// stored in the database of messager, and managed by `auth user` commands.
+type LocalAuthService struct {
+	repo repo.Repo
+}
+
+var _ jwtclient.IAuthClient = (*LocalAuthService)(nil)
+
+func NewLocalAuthService(repo repo.Repo) *LocalAuthService {
+	return &LocalAuthService{repo: repo}
+}
+
+// AddUser add a user with a new token, the token is a JWT like the ones of sophon-auth, so the name can be got from
+// it, but it's verified by looking up the hash of it in the database instead of the secret. The token is only
+// returned here.
+func (las *LocalAuthService) AddUser(ctx context.Context, name string, perm core.Permission) (*mtypes.AuthUser, error) {
+	if len(name) == 0 {
+		return nil, errors.New("user name is empty")
+	}
+	if !core.IsValid(perm) {
+		return nil, fmt.Errorf("invalid permission %s, want one of %v", perm, core.PermArr)
+	}
+	if _, err := las.repo.AuthRepo().GetAuthUser(ctx, name); err == nil {
+		return nil, fmt.Errorf("user %s exists", name)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	token, err := jwt3.Sign(auth.JWTPayload{Name: name, Perm: perm}, jwt3.NewHS256(secret))
+	if err != nil {
+		return nil, fmt.Errorf("generate token: %w", err)
+	}
+
+	now := time.Now()
+	user := &mtypes.AuthUser{
+		Name:      name,
+		Perm:      perm,
+		TokenHash: hashToken(string(token)),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := las.repo.AuthRepo().CreateAuthUser(ctx, user); err != nil {
+		return nil, err
+	}
+	// the token can't be got again, only the hash is stored
+	user.Token = string(token)
+	return user, nil
+}
+
+// hashToken returns the hex of the sha256 hash of token, the tokens are stored and looked up by the hashes
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func (las *LocalAuthService) ListAuthUser(ctx context.Context) ([]*mtypes.AuthUser, error) {
+	return las.repo.AuthRepo().ListAuthUser(ctx)
+}
+
+// BindSigners bind the signers to the user, the signers bound already are ignored
+func (las *LocalAuthService) BindSigners(ctx context.Context, name string, signers []address.Address) error {
+	if _, err := las.getUser(ctx, name); err != nil {
+		return err
+	}
+	return las.repo.Transaction(func(txRepo repo.TxRepo) error {
+		for _, signer := range signers {
+			if err := txRepo.AuthRepo().BindAuthSigner(ctx, &mtypes.AuthSigner{
+				User:      name,
+				Signer:    signer,
+				CreatedAt: time.Now(),
+			}); err != nil {
+				return fmt.Errorf("bind signer %s: %w", signer, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (las *LocalAuthService) ListAuthSigner(ctx context.Context, name string) ([]*mtypes.AuthSigner, error) {
+	if _, err := las.getUser(ctx, name); err != nil {
+		return nil, err
+	}
+	return las.repo.AuthRepo().ListAuthSignerByUser(ctx, name)
+}
+
+func (las *LocalAuthService) UnbindSigners(ctx context.Context, name string, signers []address.Address) error {
+	return las.repo.Transaction(func(txRepo repo.TxRepo) error {
+		for _, signer := range signers {
+			if err := txRepo.AuthRepo().UnbindAuthSigner(ctx, name, signer); err != nil {
+				return fmt.Errorf("unbind signer %s: %w", signer, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (las *LocalAuthService) getUser(ctx context.Context, name string) (*mtypes.AuthUser, error) {
+	user, err := las.repo.AuthRepo().GetAuthUser(ctx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("user %s not found", name)
+	}
+	return user, err
+}
+
+func (las *LocalAuthService) Verify(ctx context.Context, token string) (*auth.VerifyResponse, error) {
+	user, err := las.repo.AuthRepo().GetAuthUserByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("token not found")
+		}
+		return nil, err
+	}
+	return &auth.VerifyResponse{Name: user.Name, Perm: user.Perm}, nil
+}
+
+func (las *LocalAuthService) VerifyUsers(ctx context.Context, names []string) error {
+	for _, name := range names {
+		if _, err := las.getUser(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (las *LocalAuthService) HasUser(ctx context.Context, name string) (bool, error) {
+	_, err := las.repo.AuthRepo().GetAuthUser(ctx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (las *LocalAuthService) GetUser(ctx context.Context, name string) (*auth.OutputUser, error) {
+	user, err := las.getUser(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return toOutputUser(user), nil
+}
+
+func (las *LocalAuthService) GetUserByMiner(_ context.Context, _ address.Address) (*auth.OutputUser, error) {
+	return nil, errNotSupportedInLocalAuth
+}
+
+func (las *LocalAuthService) GetUserBySigner(ctx context.Context, signer address.Address) (auth.ListUsersResponse, error) {
+	bindings, err := las.repo.AuthRepo().ListAuthSignerBySigner(ctx, signer)
+	if err != nil {
+		return nil, err
+	}
+	users := make(auth.ListUsersResponse, 0, len(bindings))
+	for _, b := range bindings {
+		user, err := las.getUser(ctx, b.User)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, toOutputUser(user))
+	}
+	return users, nil
+}
+
+func (las *LocalAuthService) ListUsers(ctx context.Context, skip, limit int64, state core.UserState) (auth.ListUsersResponse, error) {
+	// all the users are enabled
+	if state == core.UserStateDisabled {
+		return auth.ListUsersResponse{}, nil
+	}
+	list, err := las.repo.AuthRepo().ListAuthUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if skip > int64(len(list)) {
+		skip = int64(len(list))
+	}
+	list = list[skip:]
+	if limit > 0 && limit < int64(len(list)) {
+		list = list[:limit]
+	}
+
+	users := make(auth.ListUsersResponse, 0, len(list))
+	for _, user := range list {
+		users = append(users, toOutputUser(user))
+	}
+	return users, nil
+}
+
+func (las *LocalAuthService) ListUsersWithMiners(ctx context.Context, skip, limit int64, state core.UserState) (auth.ListUsersResponse, error) {
+	return las.ListUsers(ctx, skip, limit, state)
+}
+
+func (las *LocalAuthService) GetUserRateLimit(_ context.Context, _, _ string) (auth.GetUserRateLimitResponse, error) {
+	return auth.GetUserRateLimitResponse{}, nil
+}
+
+func (las *LocalAuthService) MinerExistInUser(_ context.Context, _ string, _ address.Address) (bool, error) {
+	return false, nil
+}
+
+func (las *LocalAuthService) SignerExistInUser(ctx context.Context, user string, signer address.Address) (bool, error) {
+	bindings, err := las.repo.AuthRepo().ListAuthSignerBySigner(ctx, signer)
+	if err != nil {
+		return false, err
+	}
+	for _, b := range bindings {
+		if b.User == user {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (las *LocalAuthService) HasMiner(_ context.Context, _ address.Address) (bool, error) {
+	return false, nil
+}
+
+func (las *LocalAuthService) ListMiners(_ context.Context, _ string) (auth.ListMinerResp, error) {
+	return auth.ListMinerResp{}, nil
+}
+
+func (las *LocalAuthService) UpsertMiner(_ context.Context, _, _ string, _ bool) (bool, error) {
+	return false, errNotSupportedInLocalAuth
+}
+
+func (las *LocalAuthService) HasSigner(ctx context.Context, signer address.Address) (bool, error) {
+	bindings, err := las.repo.AuthRepo().ListAuthSignerBySigner(ctx, signer)
+	if err != nil {
+		return false, err
+	}
+	return len(bindings) > 0, nil
+}
+
+func (las *LocalAuthService) ListSigners(ctx context.Context, user string) (auth.ListSignerResp, error) {
+	bindings, err := las.repo.AuthRepo().ListAuthSignerByUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	signers := make(auth.ListSignerResp, 0, len(bindings))
+	for _, b := range bindings {
+		signers = append(signers, &auth.OutputSigner{
+			Signer:    b.Signer,
+			User:      b.User,
+			CreatedAt: b.CreatedAt,
+			UpdatedAt: b.CreatedAt,
+		})
+	}
+	return signers, nil
+}
+
+func (las *LocalAuthService) RegisterSigners(ctx context.Context, user string, addrs []address.Address) error {
+	return las.BindSigners(ctx, user, addrs)
+}
+
+func (las *LocalAuthService) UnregisterSigners(ctx context.Context, user string, addrs []address.Address) error {
+	return las.UnbindSigners(ctx, user, addrs)
+}
+
+func toOutputUser(user *mtypes.AuthUser) *auth.OutputUser {
+	return &auth.OutputUser{
+		Id:         user.Name,
+		Name:       user.Name,
+		State:      core.UserStateEnabled,
+		CreateTime: user.CreatedAt.Unix(),
+		UpdateTime: user.UpdatedAt.Unix(),
+	}
+}
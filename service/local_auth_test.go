@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs-force-community/sophon-auth/auth"
+	"github.com/ipfs-force-community/sophon-auth/core"
+	"github.com/ipfs-force-community/sophon-auth/jwtclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/filestore"
+	"github.com/ipfs-force-community/sophon-messager/models"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestLocalAuthService(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	fsRepo := filestore.NewMockFileStore(t.TempDir())
+	require.NoError(t, fsRepo.ReplaceConfig(cfg))
+
+	repo, err := models.SetDataBase(fsRepo)
+	require.NoError(t, err)
+	require.NoError(t, repo.AutoMigrate())
+
+	las := NewLocalAuthService(repo)
+	addrs := testhelper.RandAddresses(t, 3)
+
+	_, err = las.AddUser(ctx, "alice", "root")
+	assert.ErrorContains(t, err, "invalid permission")
+	alice, err := las.AddUser(ctx, "alice", core.PermWrite)
+	require.NoError(t, err)
+	_, err = las.AddUser(ctx, "alice", core.PermRead)
+	assert.ErrorContains(t, err, "exists")
+	bob, err := las.AddUser(ctx, "bob", core.PermRead)
+	require.NoError(t, err)
+	assert.NotEqual(t, alice.Token, bob.Token)
+	// only the hash of token is stored
+	stored, err := repo.AuthRepo().GetAuthUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Empty(t, stored.Token)
+	assert.Equal(t, hashToken(alice.Token), stored.TokenHash)
+	users, err := las.ListAuthUser(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	for _, user := range users {
+		assert.Empty(t, user.Token)
+	}
+
+	t.Run("verify", func(t *testing.T) {
+		// the name is parsed from the token by the auth mux
+		name, err := auth.JwtUserFromToken(alice.Token)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", name)
+
+		res, err := las.Verify(ctx, alice.Token)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", res.Name)
+		assert.Equal(t, core.PermWrite, res.Perm)
+
+		_, err = las.Verify(ctx, "bad token")
+		assert.Error(t, err)
+
+		perm, err := jwtclient.WarpIJwtAuthClient(las).Verify(ctx, bob.Token)
+		require.NoError(t, err)
+		assert.Equal(t, core.PermRead, perm)
+	})
+
+	t.Run("signers", func(t *testing.T) {
+		assert.ErrorContains(t, las.BindSigners(ctx, "carol", addrs), "not found")
+		require.NoError(t, las.BindSigners(ctx, "alice", addrs[:2]))
+		// binding again is ignored
+		require.NoError(t, las.BindSigners(ctx, "alice", addrs[:1]))
+		require.NoError(t, las.BindSigners(ctx, "bob", addrs[1:]))
+
+		signers, err := las.ListSigners(ctx, "alice")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, addrs[:2], []address.Address{signers[0].Signer, signers[1].Signer})
+
+		addressService := NewAddressService(repo, nil, las)
+		accounts, err := addressService.GetAccountsOfSigner(ctx, addrs[1])
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"alice", "bob"}, accounts)
+		accounts, err = addressService.GetAccountsOfSigner(ctx, addrs[2])
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bob"}, accounts)
+
+		aliceCtx := core.CtxWithPerm(core.CtxWithName(ctx, "alice"), core.PermWrite)
+		assert.NoError(t, jwtclient.CheckPermissionBySigner(aliceCtx, las, addrs[:2]...))
+		assert.ErrorIs(t, jwtclient.CheckPermissionBySigner(aliceCtx, las, addrs[2]), jwtclient.ErrorPermissionDeny)
+
+		require.NoError(t, las.UnbindSigners(ctx, "alice", addrs[1:2]))
+		has, err := las.SignerExistInUser(ctx, "alice", addrs[1])
+		require.NoError(t, err)
+		assert.False(t, has)
+		has, err = las.HasSigner(ctx, addrs[1])
+		require.NoError(t, err)
+		assert.True(t, has)
+	})
+
+	t.Run("users", func(t *testing.T) {
+		has, err := las.HasUser(ctx, "carol")
+		require.NoError(t, err)
+		assert.False(t, has)
+		user, err := las.GetUser(ctx, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, core.UserStateEnabled, user.State)
+
+		users, err := las.ListUsers(ctx, 1, 10, core.UserStateUndefined)
+		require.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, "bob", users[0].Name)
+		assert.NoError(t, las.VerifyUsers(ctx, []string{"alice", "bob"}))
+		assert.Error(t, las.VerifyUsers(ctx, []string{"alice", "carol"}))
+	})
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+)
+
+// CancelMessage cancel a message which is not on chain, an unfilled message is marked as cancelled directly,
+// a filled message is replaced by a zero-value self-send with the same nonce, it is no longer pushed again and
+// it is marked as cancelled once the replacement lands. returns the id of the replacement, empty for an unfilled message.
+func (ms *MessageService) CancelMessage(ctx context.Context, id string) (string, error) {
+	msg, err := ms.repo.MessageRepo().GetMessageByUid(id)
+	if err != nil {
+		return "", err
+	}
+
+	// avoid the message being selected or the nonce being used while cancelling
+	unlock, err := ms.msgSelectMgr.lockAddress(ctx, msg.From)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	msg, err = ms.repo.MessageRepo().GetMessageByUid(id)
+	if err != nil {
+		return "", err
+	}
+	switch msg.State {
+	case types.UnFillMsg:
+		if err := ms.repo.MessageRepo().UpdateMessageStateByID(id, mtypes.CancelledMsg); err != nil {
+			return "", err
+		}
+		log.Infof("cancel unfilled message %s", id)
+		return "", nil
+	case types.FillMsg:
+		return ms.replaceWithSelfSend(ctx, msg)
+	default:
+		return "", fmt.Errorf("message in state %s can not be cancelled", mtypes.MessageStateString(msg.State))
+	}
+}
+
+func (ms *MessageService) replaceWithSelfSend(ctx context.Context, msg *types.Message) (string, error) {
+	if cancel, err := ms.repo.MessageCancelRepo().GetMessageCancel(ctx, msg.ID); err == nil {
+		log.Infof("message %s has been cancelled by %s", msg.ID, cancel.ReplaceID)
+		return cancel.ReplaceID, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	cfg, err := ms.nodeClient.MpoolGetConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to lookup the message pool config: %w", err)
+	}
+	estimated, err := ms.nodeClient.GasEstimateMessageGas(ctx, &venusTypes.Message{
+		From:       msg.From,
+		To:         msg.From,
+		Value:      big.Zero(),
+		Method:     builtin.MethodSend,
+		GasFeeCap:  big.Zero(),
+		GasPremium: big.Zero(),
+	}, nil, venusTypes.EmptyTSK)
+	if err != nil {
+		return "", fmt.Errorf("failed to estimate gas values: %w", err)
+	}
+
+	replaceMsg := &types.Message{
+		ID:         venusTypes.NewUUID().String(),
+		Message:    *estimated,
+		Meta:       &types.SendSpec{},
+		WalletName: msg.WalletName,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	replaceMsg.Nonce = msg.Nonce
+	replaceMsg.GasPremium = big.Max(estimated.GasPremium, computeRBF(msg.GasPremium, cfg.ReplaceByFeeRatio))
+	replaceMsg.GasFeeCap = big.Max(estimated.GasFeeCap, replaceMsg.GasPremium)
+
+	accounts, err := ms.addressService.GetAccountsOfSigner(ctx, msg.From)
+	if err != nil {
+		return "", err
+	}
+	signedMsg, err := ToSignedMsg(ctx, ms.walletClient, replaceMsg, accounts)
+	if err != nil {
+		return "", err
+	}
+
+	err = ms.repo.Transaction(func(txRepo repo.TxRepo) error {
+		if err := txRepo.MessageRepo().CreateMessage(replaceMsg); err != nil {
+			return err
+		}
+		if err := saveEthTransactions(ctx, txRepo, replaceMsg); err != nil {
+			return err
+		}
+		return txRepo.MessageCancelRepo().SaveMessageCancel(ctx, &mtypes.MessageCancel{
+			MsgID:     msg.ID,
+			ReplaceID: replaceMsg.ID,
+			From:      msg.From,
+			Nonce:     msg.Nonce,
+			CreatedAt: time.Now(),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	log.Infof("cancel message %s by %s, nonce %d, gas premium %v", msg.ID, replaceMsg.ID, msg.Nonce, replaceMsg.GasPremium)
+
+	// the selector will push it again if failed
+	if err := ms.msgReceiver.PushMessages(ctx, []*venusTypes.SignedMessage{&signedMsg}); err != nil {
+		log.Warnf("push message %s failed: %v", replaceMsg.ID, err)
+	}
+
+	return replaceMsg.ID, nil
+}
+
+// excludeCancellingMessage drop the filled messages being cancelled, they are kept in FillMsg until the message
+// or its replacement lands, and pushing them again may get the original packed instead of the replacement.
+func excludeCancellingMessage(ctx context.Context, r repo.Repo, from address.Address, msgs []*types.Message) ([]*types.Message, error) {
+	if len(msgs) == 0 {
+		return msgs, nil
+	}
+	minNonce := msgs[0].Nonce
+	for _, msg := range msgs {
+		if msg.Nonce < minNonce {
+			minNonce = msg.Nonce
+		}
+	}
+	cancels, err := r.MessageCancelRepo().ListMessageCancelByAddress(ctx, from, minNonce)
+	if err != nil {
+		return nil, err
+	}
+	if len(cancels) == 0 {
+		return msgs, nil
+	}
+	cancelling := make(map[string]struct{}, len(cancels))
+	for _, cancel := range cancels {
+		cancelling[cancel.MsgID] = struct{}{}
+	}
+	res := make([]*types.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if _, ok := cancelling[msg.ID]; ok {
+			continue
+		}
+		res = append(res, msg)
+	}
+	return res, nil
+}
+
+// applyCancelledMessage update the state of the messages being cancelled at the nonce of the applied message,
+// returns false if the nonce is not being cancelled.
+func applyCancelledMessage(txRepo repo.TxRepo, msg applyMessage) (bool, error) {
+	cancel, err := txRepo.MessageCancelRepo().GetMessageCancelByNonce(context.Background(), msg.msg.From, msg.msg.Nonce)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	replaceMsg, err := txRepo.MessageRepo().GetMessageByUid(cancel.ReplaceID)
+	if err != nil {
+		return false, err
+	}
+	// the nonce has been processed
+	if replaceMsg.State != types.FillMsg {
+		return false, nil
+	}
+	origin, err := txRepo.MessageRepo().GetMessageByUid(cancel.MsgID)
+	if err != nil {
+		return false, err
+	}
+
+	applyCid := msg.msg.Cid()
+	update := func(m *types.Message, state types.MessageState) error {
+		m.State = state
+		m.Receipt = msg.receipt
+		m.Height = int64(msg.height)
+		m.TipSetKey = msg.tsk
+		return txRepo.MessageRepo().UpdateMessage(m)
+	}
+	switch {
+	case replaceMsg.UnsignedCid != nil && replaceMsg.UnsignedCid.Equals(applyCid):
+		msgStateLog.Infof("message %s is cancelled by %s", origin.ID, replaceMsg.ID)
+		err = update(replaceMsg, types.OnChainMsg)
+		if err == nil {
+			err = update(origin, mtypes.CancelledMsg)
+		}
+	case origin.UnsignedCid != nil && origin.UnsignedCid.Equals(applyCid):
+		msgStateLog.Warnf("message %s lands before being cancelled by %s", origin.ID, replaceMsg.ID)
+		err = update(origin, types.OnChainMsg)
+		if err == nil {
+			err = update(replaceMsg, types.NonceConflictMsg)
+		}
+	default:
+		msgStateLog.Warnf("nonce %d of %s is used by message %s out of messager", msg.msg.Nonce, msg.msg.From, msg.signedCID)
+		err = update(origin, types.NonceConflictMsg)
+		if err == nil {
+			err = update(replaceMsg, types.NonceConflictMsg)
+		}
+	}
+	if err != nil {
+		return false, fmt.Errorf("update cancelled message failed, cid:%s failed:%v", msg.signedCID, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
+	vcrypto "github.com/filecoin-project/venus/pkg/crypto"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestCancelMessage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msh := newMessageServiceHelper(ctx, t)
+	pk, err := vcrypto.Generate(crypto.SigTypeSecp256k1)
+	require.NoError(t, err)
+	pub, err := vcrypto.ToPublic(crypto.SigTypeSecp256k1, pk)
+	require.NoError(t, err)
+	addr, err := address.NewSecp256k1Address(pub)
+	require.NoError(t, err)
+	msh.addAddresses([]address.Address{addr})
+	ms := msh.MessageService
+
+	rawMsg := testhelper.NewUnsignedMessage()
+	rawMsg.From = addr
+	rawMsg.To = addr
+
+	// cancel an unfilled message before it's selected
+	unfilledID, err := ms.PushMessage(ctx, &rawMsg, nil)
+	require.NoError(t, err)
+	replaceID, err := ms.CancelMessage(ctx, unfilledID)
+	require.NoError(t, err)
+	assert.Empty(t, replaceID)
+	msg, err := ms.GetMessageByUid(ctx, unfilledID)
+	require.NoError(t, err)
+	assert.Equal(t, mtypes.CancelledMsg, msg.State)
+	_, err = ms.CancelMessage(ctx, unfilledID)
+	assert.ErrorContains(t, err, "CancelledMsg can not be cancelled")
+
+	msh.start()
+	defer msh.stop()
+
+	// the premium is too low to be packed
+	stuckMsg := rawMsg
+	stuckMsg.GasLimit = 1000000
+	stuckMsg.GasFeeCap = big.NewInt(10000)
+	stuckMsg.GasPremium = big.NewInt(100)
+	stuckID, err := ms.PushSignedMessage(ctx, signMessage(t, pk, stuckMsg))
+	require.NoError(t, err)
+
+	replaceID, err = ms.CancelMessage(ctx, stuckID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, replaceID)
+	// cancelling again returns the same replacement
+	replaceID2, err := ms.CancelMessage(ctx, stuckID)
+	require.NoError(t, err)
+	assert.Equal(t, replaceID, replaceID2)
+
+	replaceMsg, err := ms.GetMessageByUid(ctx, replaceID)
+	require.NoError(t, err)
+	assert.Equal(t, addr, replaceMsg.To)
+	assert.Equal(t, stuckMsg.Nonce, replaceMsg.Nonce)
+	assert.True(t, big.Zero().Equals(replaceMsg.Value))
+	assert.True(t, replaceMsg.GasPremium.GreaterThanEqual(computeRBF(stuckMsg.GasPremium, testhelper.DefReplaceByFeePercent)))
+
+	ctx, cancel = context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	res, err := waitMsgWithTimeout(ctx, ms, replaceID)
+	require.NoError(t, err)
+	assert.Equal(t, types.OnChainMsg, res.State)
+	res, err = waitMsgWithTimeout(ctx, ms, stuckID)
+	require.NoError(t, err)
+	assert.Equal(t, mtypes.CancelledMsg, res.State)
+	assert.Equal(t, replaceMsg.Nonce, res.Nonce)
+
+	_, err = ms.CancelMessage(ctx, replaceID)
+	assert.ErrorContains(t, err, "can not be cancelled")
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/filecoin-project/go-state-types/network"
@@ -23,7 +24,6 @@ import (
 	"modernc.org/mathutil"
 
 	"github.com/filecoin-project/venus/pkg/constants"
-	"github.com/filecoin-project/venus/pkg/crypto"
 	"github.com/filecoin-project/venus/venus-shared/actors/builtin"
 	v1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1"
 	gatewayAPI "github.com/filecoin-project/venus/venus-shared/api/gateway/v2"
@@ -50,6 +50,7 @@ type MsgSelectMgr struct {
 	cfg            *config.MessageServiceConfig
 	fullNode       v1.FullNode
 	addressService *AddressService
+	policyService  *AddressPolicyService
 	sps            *SharedParamsService
 	walletClient   gatewayAPI.IWalletClient
 
@@ -63,6 +64,7 @@ func newMsgSelectMgr(ctx context.Context,
 	cfg *config.MessageServiceConfig,
 	fullNode v1.FullNode,
 	addressService *AddressService,
+	policyService *AddressPolicyService,
 	sps *SharedParamsService,
 	walletClient gatewayAPI.IWalletClient,
 	msgReceiver publisher.MessageReceiver,
@@ -73,6 +75,7 @@ func newMsgSelectMgr(ctx context.Context,
 		cfg:            cfg,
 		fullNode:       fullNode,
 		addressService: addressService,
+		policyService:  policyService,
 		sps:            sps,
 		walletClient:   walletClient,
 
@@ -121,6 +124,17 @@ func (msgSelectMgr *MsgSelectMgr) SelectMessage(ctx context.Context, ts *venusTy
 	return nil
 }
 
+// UpdateConfig replace the config used by all works, the selecting rounds already started are not affected.
+func (msgSelectMgr *MsgSelectMgr) UpdateConfig(cfg *config.MessageServiceConfig) {
+	msgSelectMgr.lk.Lock()
+	defer msgSelectMgr.lk.Unlock()
+
+	msgSelectMgr.cfg = cfg
+	for _, w := range msgSelectMgr.works {
+		w.cfg.Store(cfg)
+	}
+}
+
 func (msgSelectMgr *MsgSelectMgr) getNonceInTipset(ctx context.Context, ts *venusTypes.TipSet) (*utils.NonceMap, error) {
 	applied := utils.NewNonceMap()
 	selectMsg := func(m *venusTypes.Message) error {
@@ -158,7 +172,8 @@ func (msgSelectMgr *MsgSelectMgr) tryUpdateWorks(addrInfos map[address.Address]*
 		w, ok := msgSelectMgr.works[addrInfo.Addr]
 		if !ok {
 			msgSelectLog.Infof("add a work %v", addrInfo.Addr)
-			ws[addrInfo.Addr] = newWork(msgSelectMgr.ctx, addrInfo.Addr, msgSelectMgr.cfg, msgSelectMgr.fullNode, msgSelectMgr.repo, msgSelectMgr.addressService, msgSelectMgr.walletClient, msgSelectMgr.msgReceiver)
+			ws[addrInfo.Addr] = newWork(msgSelectMgr.ctx, addrInfo.Addr, msgSelectMgr.cfg, msgSelectMgr.fullNode, msgSelectMgr.repo, msgSelectMgr.addressService,
+				msgSelectMgr.policyService, msgSelectMgr.walletClient, msgSelectMgr.msgReceiver)
 		} else {
 			ws[addrInfo.Addr] = w
 			delete(msgSelectMgr.works, addrInfo.Addr)
@@ -181,6 +196,34 @@ func (msgSelectMgr *MsgSelectMgr) tryUpdateWorks(addrInfos map[address.Address]*
 	return nil
 }
 
+// lockAddress blocks the message selection of addr until unlock is called, so the nonce of addr can be changed safely.
+func (msgSelectMgr *MsgSelectMgr) lockAddress(ctx context.Context, addr address.Address) (func(), error) {
+	for {
+		// a work is closed only after taking its controlChan with lk held, so it's safe to send to controlChan with lk held
+		msgSelectMgr.lk.Lock()
+		w, ok := msgSelectMgr.works[addr]
+		if !ok {
+			w = newWork(msgSelectMgr.ctx, addr, msgSelectMgr.cfg, msgSelectMgr.fullNode, msgSelectMgr.repo, msgSelectMgr.addressService,
+				msgSelectMgr.policyService, msgSelectMgr.walletClient, msgSelectMgr.msgReceiver)
+			msgSelectMgr.works[addr] = w
+			msgSelectLog.Infof("add a work %v", addr)
+		}
+		select {
+		case w.controlChan <- struct{}{}:
+			msgSelectMgr.lk.Unlock()
+			return w.finish, nil
+		default:
+		}
+		msgSelectMgr.lk.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
 func addressMap(addrList []*types.Address) map[address.Address]*types.Address {
 	addrs := make(map[address.Address]*types.Address, len(addrList))
 	for i, addr := range addrList {
@@ -212,16 +255,18 @@ func recordMetric(ctx context.Context, addr address.Address, selectResult *MsgSe
 
 	metrics.SelectedMsgNumOfLastRound.Set(ctx, int64(len(selectResult.SelectMsg)))
 	metrics.ToPushMsgNumOfLastRound.Set(ctx, int64(len(selectResult.ToPushMsg)))
-	metrics.ErrMsgNumOfLastRound.Set(ctx, int64(len(selectResult.ErrMsg)))
+	metrics.ErrMsgNumOfLastRound.Set(ctx, int64(len(selectResult.ErrMsg)+len(selectResult.DeniedMsg)))
 }
 
-var errSingMessage = errors.New("sign message failed")
+var errSignMessage = errors.New("sign message failed")
 
 type MsgSelectResult struct {
 	Address   *types.Address
 	SelectMsg []*types.Message
 	ToPushMsg []*venusTypes.SignedMessage
 	ErrMsg    []msgErrInfo
+	// DeniedMsg are the messages forbidden by the address policy, they are marked as failed
+	DeniedMsg []msgErrInfo
 }
 
 type msgErrInfo struct {
@@ -234,10 +279,11 @@ type work struct {
 	cancel context.CancelFunc
 
 	addr           address.Address
-	cfg            *config.MessageServiceConfig
+	cfg            atomic.Pointer[config.MessageServiceConfig]
 	fullNode       v1.FullNode
 	repo           repo.Repo
 	addressService *AddressService
+	policyService  *AddressPolicyService
 	walletClient   gatewayAPI.IWalletClient
 	msgReceiver    publisher.MessageReceiver
 
@@ -255,17 +301,18 @@ func newWork(ctx context.Context,
 	fullNode v1.FullNode,
 	repo repo.Repo,
 	addressService *AddressService,
+	policyService *AddressPolicyService,
 	walletClient gatewayAPI.IWalletClient,
 	msgReceiver publisher.MessageReceiver,
 ) *work {
 	ctx, cancel := context.WithCancel(ctx)
 	cache, _ := lru.NewARC(100)
-	return &work{
+	w := &work{
 		ctx:            ctx,
 		cancel:         cancel,
 		addr:           addr,
-		cfg:            cfg,
 		addressService: addressService,
+		policyService:  policyService,
 		fullNode:       fullNode,
 		repo:           repo,
 		walletClient:   walletClient,
@@ -274,6 +321,9 @@ func newWork(ctx context.Context,
 		actorCache:     cache,
 		log:            msgSelectLog.With("address", addr),
 	}
+	w.cfg.Store(cfg)
+
+	return w
 }
 
 func (w *work) startSelectMessage(
@@ -299,23 +349,38 @@ func (w *work) startSelectMessage(
 	}
 
 	w.start = time.Now()
-	ctx, cancel := context.WithTimeout(w.ctx, w.cfg.SignMessageTimeout+w.cfg.EstimateMessageTimeout)
+	cfg := w.cfg.Load()
+	ctx, cancel := context.WithTimeout(w.ctx, cfg.SignMessageTimeout+cfg.EstimateMessageTimeout)
 	defer w.finish()
 	defer cancel()
 
+	// the nonce may have been changed by PushSignedMessage since addrInfo was read
+	latest, err := w.repo.AddressRepo().GetAddress(ctx, w.addr)
+	if err != nil {
+		w.log.Errorf("get address failed: %v", err)
+		return
+	}
+	if latest.State != types.AddressStateAlive {
+		w.log.Infof("address state is %v, skip select message", latest.State)
+		return
+	}
+	addrInfo = latest
+
 	selectResult, err := w.selectMessage(ctx, appliedNonce, addrInfo, ts, maxAllowPendingMessage, sharedParams)
 	if err != nil {
 		w.log.Errorf("select message failed: %v", err)
 		return
 	}
 
-	if len(selectResult.SelectMsg) != 0 || len(selectResult.ToPushMsg) != 0 || len(selectResult.ErrMsg) != 0 {
-		w.log.Infof("select message result | SelectMsg: %d | ToPushMsg: %d | ErrMsg: %d | took: %v", len(selectResult.SelectMsg),
-			len(selectResult.ToPushMsg), len(selectResult.ErrMsg), time.Since(w.start))
+	if len(selectResult.SelectMsg) != 0 || len(selectResult.ToPushMsg) != 0 || len(selectResult.ErrMsg) != 0 ||
+		len(selectResult.DeniedMsg) != 0 {
+		w.log.Infof("select message result | SelectMsg: %d | ToPushMsg: %d | ErrMsg: %d | DeniedMsg: %d | took: %v",
+			len(selectResult.SelectMsg), len(selectResult.ToPushMsg), len(selectResult.ErrMsg), len(selectResult.DeniedMsg),
+			time.Since(w.start))
 
 		recordMetric(ctx, w.addr, selectResult)
 
-		if len(selectResult.SelectMsg) > 0 || len(selectResult.ErrMsg) > 0 {
+		if len(selectResult.SelectMsg) > 0 || len(selectResult.ErrMsg) > 0 || len(selectResult.DeniedMsg) > 0 {
 			if err := w.saveSelectedMessages(selectResult); err != nil {
 				w.log.Errorf("failed to save selected messages to db %v", err)
 				return
@@ -332,10 +397,8 @@ func (w *work) startSelectMessage(
 
 	if len(selectResult.ToPushMsg) > 0 {
 		// send messages to push
-		select {
-		case w.msgReceiver <- selectResult.ToPushMsg:
-		default:
-			w.log.Errorf("message receiver channel is full, skip %d messages", len(selectResult.ToPushMsg))
+		if err := w.msgReceiver.PushMessages(w.ctx, selectResult.ToPushMsg); err != nil {
+			w.log.Errorf("push %d messages to outbox failed: %v", len(selectResult.ToPushMsg), err)
 		}
 	}
 }
@@ -399,16 +462,43 @@ func (w *work) selectMessage(ctx context.Context, appliedNonce *utils.NonceMap,
 	w.log.Infof("state actor nonce %d, latest nonce in ts %d, assigned nonce %d, nonce gap %d, want %d", actorNonce,
 		nonceInLatestTs, addrInfo.Nonce, nonceGap, wantCount)
 
-	var errMsg []msgErrInfo
+	var errMsg, deniedMsg []msgErrInfo
 	count := uint64(0)
 	selectMsg := make([]*types.Message, 0, len(messages))
 
+	// check address policy again, rules may have changed after the message was pushed, the denied messages are
+	// marked as failed so that they leave the queue, others are checked again in the next round
+	allowedMsgs := make([]*types.Message, 0, len(messages))
+	targets := policyTargetCache{}
+	for _, msg := range messages {
+		if err := w.policyService.checkMessage(ctx, &msg.Message, targets); err != nil {
+			if errors.Is(err, ErrAddressPolicyDenied) {
+				deniedMsg = append(deniedMsg, msgErrInfo{id: msg.ID, err: err.Error()})
+			} else {
+				errMsg = append(errMsg, msgErrInfo{id: msg.ID, err: err.Error()})
+			}
+			w.log.Errorf("msg: %v, error: %v", msg.ID, err)
+			continue
+		}
+		allowedMsgs = append(allowedMsgs, msg)
+	}
+	messages = allowedMsgs
+	if len(messages) == 0 {
+		return &MsgSelectResult{
+			ToPushMsg: toPushMessage,
+			Address:   addrInfo,
+			ErrMsg:    errMsg,
+			DeniedMsg: deniedMsg,
+		}, nil
+	}
+
 	estimateResult, candidateMessages, err := w.estimateMessage(ctx, ts, messages, sharedParams, addrInfo)
 	if err != nil {
 		return nil, fmt.Errorf("estimate message failed: %v", err)
 	}
 
-	// sign
+	// assign nonce in order, then sign them together
+	toSignMsgs := make([]*toSignMessage, 0, len(candidateMessages))
 	for index, msg := range candidateMessages {
 		// if error print error message
 		if len(estimateResult[index].Err) != 0 {
@@ -430,7 +520,7 @@ func (w *work) selectMessage(ctx context.Context, appliedNonce *utils.NonceMap,
 		}
 
 		// 分配nonce
-		msg.Nonce = addrInfo.Nonce
+		msg.Nonce = addrInfo.Nonce + count
 		msg.GasFeeCap = estimateMsg.GasFeeCap
 		msg.GasPremium = estimateMsg.GasPremium
 		msg.GasLimit = estimateMsg.GasLimit
@@ -438,19 +528,27 @@ func (w *work) selectMessage(ctx context.Context, appliedNonce *utils.NonceMap,
 		unsignedCid := msg.Message.Cid()
 		msg.UnsignedCid = &unsignedCid
 
-		// 签名
-		sig, err := w.signMessage(ctx, msg, accounts)
+		toSign, err := newToSignMessage(msg)
 		if err != nil {
-			if strings.Contains(err.Error(), errSingMessage.Error()) {
-				errMsg = append(errMsg, msgErrInfo{id: msg.ID, err: err.Error()})
-				w.log.Errorf("msg: %v, error: %v", msg.ID, err)
-				break
-			}
 			w.log.Errorf("msg: %v, error: %v", msg.ID, err)
 			continue
 		}
+		toSignMsgs = append(toSignMsgs, toSign)
+		count++
+	}
+
+	// 签名
+	sigs, errs := w.signMessages(ctx, toSignMsgs, accounts)
+	for index, toSign := range toSignMsgs {
+		msg := toSign.msg
+		// the nonce of the following messages can't be used
+		if errs[index] != nil {
+			errMsg = append(errMsg, msgErrInfo{id: msg.ID, err: errs[index].Error()})
+			w.log.Errorf("msg: %v, error: %v", msg.ID, errs[index])
+			break
+		}
 
-		msg.Signature = sig
+		msg.Signature = sigs[index]
 		msg.State = types.FillMsg
 
 		// signed cid for t1 address
@@ -468,7 +566,6 @@ func (w *work) selectMessage(ctx context.Context, appliedNonce *utils.NonceMap,
 
 		selectMsg = append(selectMsg, msg)
 		addrInfo.Nonce++
-		count++
 	}
 
 	return &MsgSelectResult{
@@ -476,11 +573,12 @@ func (w *work) selectMessage(ctx context.Context, appliedNonce *utils.NonceMap,
 		ToPushMsg: toPushMessage,
 		Address:   addrInfo,
 		ErrMsg:    errMsg,
+		DeniedMsg: deniedMsg,
 	}, nil
 }
 
 func (w *work) getNonce(ctx context.Context, ts *venusTypes.TipSet, appliedNonce *utils.NonceMap) (uint64, uint64, error) {
-	timeoutCtx, cancel := context.WithTimeout(ctx, w.cfg.DefaultTimeout)
+	timeoutCtx, cancel := context.WithTimeout(ctx, w.cfg.Load().DefaultTimeout)
 	defer cancel()
 	actorI, err := handleTimeout(timeoutCtx, w.fullNode.StateGetActor, []interface{}{w.addr, ts.Key()})
 	if err != nil {
@@ -539,6 +637,11 @@ func (w *work) getFilledMessage(nonceInLatestTs uint64) []*venusTypes.SignedMess
 	if err != nil {
 		w.log.Warnf("list filled message %v", err)
 	}
+	filledMessage, err = excludeCancellingMessage(w.ctx, w.repo, w.addr, filledMessage)
+	if err != nil {
+		w.log.Warnf("exclude cancelling message %v", err)
+		return nil
+	}
 	msgs := make([]*venusTypes.SignedMessage, 0, len(filledMessage))
 	for _, msg := range filledMessage {
 		if nonceInLatestTs > msg.Nonce {
@@ -598,7 +701,7 @@ func (w *work) estimateMessage(ctx context.Context,
 			newMsgMeta.MaxFee, newMsgMeta.GasOverEstimation, newMsgMeta.GasOverPremium, newMsgMeta.GasFeeCap)
 	}
 
-	estimateMsgCtx, estimateMsgCancel := context.WithTimeout(ctx, w.cfg.EstimateMessageTimeout)
+	estimateMsgCtx, estimateMsgCancel := context.WithTimeout(ctx, w.cfg.Load().EstimateMessageTimeout)
 	defer estimateMsgCancel()
 
 	estimateResult, err := w.fullNode.GasBatchEstimateMessageGas(estimateMsgCtx, estimateMessages, addrInfo.Nonce, ts.Key())
@@ -606,30 +709,6 @@ func (w *work) estimateMessage(ctx context.Context,
 	return estimateResult, candidateMessages, err
 }
 
-func (w *work) signMessage(ctx context.Context, msg *types.Message, accounts []string) (*crypto.Signature, error) {
-	data, err := msg.Message.ToStorageBlock()
-	if err != nil {
-		return nil, fmt.Errorf("serialize message failed: %v", err)
-	}
-
-	sb, err := msg.Message.SigningBytes(venusTypes.AddressProtocol2SignType(msg.Message.From.Protocol()))
-	if err != nil {
-		return nil, fmt.Errorf("get signing bytes failed: %v", err)
-	}
-
-	signMsgCtx, signMsgCancel := context.WithTimeout(ctx, w.cfg.SignMessageTimeout)
-	sigI, err := handleTimeout(signMsgCtx, w.walletClient.WalletSign, []interface{}{w.addr, accounts, sb, venusTypes.MsgMeta{
-		Type:  venusTypes.MTChainMsg,
-		Extra: data.RawData(),
-	}})
-	signMsgCancel()
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", errSingMessage.Error(), err)
-	}
-
-	return sigI.(*crypto.Signature), nil
-}
-
 func (w *work) saveSelectedMessages(selectResult *MsgSelectResult) error {
 	startSaveDB := time.Now()
 	w.log.Infof("start save messages to database")
@@ -638,6 +717,9 @@ func (w *work) saveSelectedMessages(selectResult *MsgSelectResult) error {
 			if err := txRepo.MessageRepo().BatchSaveMessage(selectResult.SelectMsg); err != nil {
 				return err
 			}
+			if err := saveEthTransactions(context.Background(), txRepo, selectResult.SelectMsg...); err != nil {
+				return err
+			}
 
 			addrInfo := selectResult.Address
 			row, err := txRepo.AddressRepo().UpdateNonce(addrInfo.Addr, addrInfo.Nonce)
@@ -653,6 +735,15 @@ func (w *work) saveSelectedMessages(selectResult *MsgSelectResult) error {
 				return err
 			}
 		}
+		for _, m := range selectResult.DeniedMsg {
+			w.log.Infof("mark message %s failed with error %s", m.id, m.err)
+			if err := txRepo.MessageRepo().UpdateErrMsg(m.id, m.err); err != nil {
+				return err
+			}
+			if err := txRepo.MessageRepo().MarkBadMessage(m.id); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 	w.log.Infof("end save messages to database, took %v, err %v", time.Since(startSaveDB), err)
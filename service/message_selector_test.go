@@ -15,6 +15,7 @@ import (
 
 	"github.com/ipfs-force-community/sophon-messager/filestore"
 	"github.com/ipfs-force-community/sophon-messager/models"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
 	"github.com/ipfs-force-community/sophon-messager/models/repo"
 	"github.com/ipfs-force-community/sophon-messager/testhelper"
 
@@ -266,7 +267,7 @@ func TestSelectNum(t *testing.T) {
 	ts, err := msh.fullNode.ChainHead(ctx)
 	assert.NoError(t, err)
 	selectResult := selectMsgWithAddress(ctx, t, msh, addrs, ts)
-	ms.msgSelectMgr.msgReceiver <- selectResult.ToPushMsg
+	assert.NoError(t, ms.msgSelectMgr.msgReceiver.PushMessages(ctx, selectResult.ToPushMsg))
 	assert.Len(t, selectResult.SelectMsg, len(addrs)*defSelectedNum)
 	checkSelectNum(selectResult.SelectMsg, map[address.Address]int{}, defSelectedNum)
 	sort.SliceIsSorted(selectResult.SelectMsg, func(i, j int) bool {
@@ -286,7 +287,7 @@ func TestSelectNum(t *testing.T) {
 	ts, err = msh.fullNode.ChainHead(ctx)
 	assert.NoError(t, err)
 	selectResult = selectMsgWithAddress(ctx, t, msh, addrs, ts)
-	ms.msgSelectMgr.msgReceiver <- selectResult.ToPushMsg
+	assert.NoError(t, ms.msgSelectMgr.msgReceiver.PushMessages(ctx, selectResult.ToPushMsg))
 	assert.Len(t, selectResult.SelectMsg, expectNum)
 	checkSelectNum(selectResult.SelectMsg, addrNum, defSelectedNum)
 	checkMsgs(ctx, t, ms, msgs, selectResult.SelectMsg)
@@ -416,7 +417,7 @@ func TestBaseFee(t *testing.T) {
 			assert.Len(t, selectResult.SelectMsg, 0)
 		}
 	}
-	ms.msgSelectMgr.msgReceiver <- selectResult.ToPushMsg
+	assert.NoError(t, ms.msgSelectMgr.msgReceiver.PushMessages(ctx, selectResult.ToPushMsg))
 	checkMsgs(ctx, t, ms, msgs, selectResult.SelectMsg)
 }
 
@@ -448,7 +449,7 @@ func TestSignMessageFailed(t *testing.T) {
 	assert.Len(t, selectResult.ErrMsg, len(removedAddrs))
 	assert.Len(t, selectResult.ToPushMsg, len(aliveAddrs)*10)
 
-	ms.msgSelectMgr.msgReceiver <- selectResult.ToPushMsg
+	assert.NoError(t, ms.msgSelectMgr.msgReceiver.PushMessages(ctx, selectResult.ToPushMsg))
 	checkMsgs(ctx, t, ms, msgs, selectResult.SelectMsg)
 
 	removedAddrMap := make(map[address.Address]struct{})
@@ -458,7 +459,7 @@ func TestSignMessageFailed(t *testing.T) {
 	for _, errInfo := range selectResult.ErrMsg {
 		res, err := ms.GetMessageByUid(ctx, errInfo.id)
 		assert.NoError(t, err)
-		assert.Contains(t, res.ErrorMsg, errSingMessage.Error())
+		assert.Contains(t, res.ErrorMsg, errSignMessage.Error())
 
 		_, ok := removedAddrMap[res.From]
 		assert.True(t, ok)
@@ -533,6 +534,55 @@ func TestErrorMsg(t *testing.T) {
 	}
 }
 
+func TestPolicyDeniedMsg(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msh := newMessageServiceHelper(ctx, t, skipPushMessage())
+	addrs := msh.genAddresses()
+	ms := msh.MessageService
+	msh.start()
+	defer msh.stop()
+
+	to, err := address.NewIDAddress(3001)
+	assert.NoError(t, err)
+	assert.NoError(t, msh.fullNode.AddActors([]address.Address{to}))
+	msgs := genMessages(addrs, len(addrs)*10)
+	denied := make(map[string]struct{})
+	for _, msg := range msgs {
+		if msg.From == addrs[0] {
+			msg.To = to
+			denied[msg.ID] = struct{}{}
+		}
+	}
+	assert.NoError(t, pushMessage(ctx, ms, msgs))
+
+	// the rule is added after the messages are pushed
+	_, err = ms.policyService.SaveAddressPolicy(ctx, &mtypes.AddressPolicy{From: addrs[0], Action: mtypes.PolicyDeny, To: to})
+	assert.NoError(t, err)
+
+	ts, err := msh.fullNode.ChainHead(ctx)
+	assert.NoError(t, err)
+	selectResult := selectMsgWithAddress(ctx, t, msh, addrs, ts)
+	assert.Len(t, selectResult.SelectMsg, len(msgs)-len(denied))
+	assert.Len(t, selectResult.ErrMsg, 0)
+	assert.Len(t, selectResult.DeniedMsg, len(denied))
+
+	for id := range denied {
+		msg, err := ms.GetMessageByUid(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, types.FailedMsg, msg.State)
+		assert.Contains(t, msg.ErrorMsg, ErrAddressPolicyDenied.Error())
+	}
+
+	// the denied messages leave the queue
+	ts, err = msh.fullNode.ChainHead(ctx)
+	assert.NoError(t, err)
+	selectResult = selectMsgWithAddress(ctx, t, msh, addrs, ts)
+	assert.Len(t, selectResult.SelectMsg, 0)
+	assert.Len(t, selectResult.DeniedMsg, 0)
+}
+
 func TestGasLimitOverLimit(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -700,7 +750,7 @@ func selectMsgWithAddress(ctx context.Context,
 	addrSelMsgNum := addrSelectMsgNum(activeAddrs, sharedParams.SelMsgNum)
 	allSelectRes := &MsgSelectResult{}
 	for _, addr := range addrs {
-		work := newWork(ctx, addr, ms.msgSelectMgr.cfg, msh.fullNode, ms.repo, ms.addressService, ms.walletClient, ms.msgReceiver)
+		work := newWork(ctx, addr, ms.msgSelectMgr.cfg, msh.fullNode, ms.repo, ms.addressService, ms.policyService, ms.walletClient, ms.msgReceiver)
 		appliedNonce, err := ms.msgSelectMgr.getNonceInTipset(ctx, ts)
 		assert.NoError(t, err)
 		addrInfo, err := ms.addressService.GetAddress(ctx, addr)
@@ -717,6 +767,7 @@ func selectMsgWithAddress(ctx context.Context,
 			})
 		}
 		allSelectRes.ErrMsg = append(allSelectRes.ErrMsg, selectResult.ErrMsg...)
+		allSelectRes.DeniedMsg = append(allSelectRes.DeniedMsg, selectResult.DeniedMsg...)
 
 		assert.NoError(t, work.saveSelectedMessages(selectResult))
 	}
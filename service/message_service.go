@@ -27,6 +27,7 @@ import (
 
 	"github.com/ipfs-force-community/sophon-messager/filestore"
 	"github.com/ipfs-force-community/sophon-messager/metrics"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
 	"github.com/ipfs-force-community/sophon-messager/models/repo"
 	"github.com/ipfs-force-community/sophon-messager/publisher"
 )
@@ -41,6 +42,12 @@ type IMessageService interface {
 	PushMessageWithId(ctx context.Context, id string, msg *venusTypes.Message, meta *types.SendSpec) (string, error)
 	HasMessageByUid(ctx context.Context, id string) (bool, error)
 	GetMessageByUid(ctx context.Context, id string) (*types.Message, error)
+	GetMessagePublishStatus(ctx context.Context, id string) ([]*mtypes.PublishReceipt, error)
+	PushSignedMessage(ctx context.Context, msg *venusTypes.SignedMessage) (string, error)
+	PushEthTransaction(ctx context.Context, params *mtypes.EthTxParams) (*mtypes.EthTxResult, error)
+	GetMessageByEthTxHash(ctx context.Context, txHash venusTypes.EthHash) (*types.Message, error)
+	GetEthTxHash(ctx context.Context, id string) (venusTypes.EthHash, error)
+	CancelMessage(ctx context.Context, id string) (string, error)
 	GetMessageByCid(ctx context.Context, cid cid.Cid) (*types.Message, error)
 	GetMessageByFromAndNonce(ctx context.Context, from address.Address, nonce uint64) (*types.Message, error)
 	WaitMessage(ctx context.Context, id string, confidence uint64) (*types.Message, error)
@@ -72,6 +79,7 @@ type MessageService struct {
 	fsRepo         filestore.FSRepo
 	nodeClient     v1.FullNode
 	addressService *AddressService
+	policyService  *AddressPolicyService
 	walletClient   gatewayAPI.IWalletClient
 
 	triggerPush chan *venusTypes.TipSet
@@ -108,11 +116,12 @@ func NewMessageService(ctx context.Context,
 	nc v1.FullNode,
 	fsRepo filestore.FSRepo,
 	addressService *AddressService,
+	policyService *AddressPolicyService,
 	sps *SharedParamsService,
 	walletClient gatewayAPI.IWalletClient,
 	msgReceiver publisher.MessageReceiver,
 ) (*MessageService, error) {
-	msgSelectMgr, err := newMsgSelectMgr(ctx, repo, &fsRepo.Config().MessageService, nc, addressService, sps, walletClient, msgReceiver)
+	msgSelectMgr, err := newMsgSelectMgr(ctx, repo, &fsRepo.Config().MessageService, nc, addressService, policyService, sps, walletClient, msgReceiver)
 	if err != nil {
 		return nil, err
 	}
@@ -123,6 +132,7 @@ func NewMessageService(ctx context.Context,
 		msgSelectMgr:       msgSelectMgr,
 		headChans:          make(chan *headChan, MaxHeadChangeProcess),
 		addressService:     addressService,
+		policyService:      policyService,
 		walletClient:       walletClient,
 		tsCache:            newTipsetCache(),
 		triggerPush:        make(chan *venusTypes.TipSet, 20),
@@ -180,6 +190,12 @@ func (ms *MessageService) pushMessage(ctx context.Context, msg *types.Message) e
 		log.Warnf("Push from ID address (%s), adjusting to %s", msg.From, fromA)
 		msg.From = fromA
 	}
+	// the message of a delegated address is signed as an eth transaction, reject the ones can't be converted early
+	if msg.From.Protocol() == address.Delegated {
+		if _, err := venusTypes.Eth1559TxArgsFromUnsignedFilecoinMessage(&msg.Message); err != nil {
+			return fmt.Errorf("message from %s can't be converted to an eth transaction: %w", msg.From, err)
+		}
+	}
 
 	accounts, err := ms.addressService.GetAccountsOfSigner(ctx, msg.From)
 	if err != nil {
@@ -194,39 +210,51 @@ func (ms *MessageService) pushMessage(ctx context.Context, msg *types.Message) e
 	}
 	var addrInfo *types.Address
 	if err := ms.repo.Transaction(func(txRepo repo.TxRepo) error {
-		addrInfo, err = txRepo.AddressRepo().GetAddress(ctx, msg.From)
-		if err == nil {
-			return nil
-		}
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			if err = txRepo.AddressRepo().SaveAddress(ctx, &types.Address{
-				ID:        venusTypes.NewUUID(),
-				Addr:      msg.From,
-				Nonce:     0,
-				SelMsgNum: 0,
-				State:     types.AddressStateAlive,
-				IsDeleted: repo.NotDeleted,
-				CreatedAt: time.Now(),
-				UpdatedAt: time.Now(),
-			}); err != nil {
-				return fmt.Errorf("save address %s failed %v", msg.From.String(), err)
-			}
-			log.Infof("add new address %s", msg.From.String())
-		}
+		addrInfo, err = getOrCreateAddress(ctx, txRepo, msg.From)
 		return err
 	}); err != nil {
 		return err
 	}
-	if addrInfo != nil && addrInfo.State == types.AddressStateForbbiden {
+	if addrInfo.State == types.AddressStateForbbiden {
 		log.Errorf("address(%s) is forbidden", msg.From.String())
 		return fmt.Errorf("address(%s) is forbidden", msg.From.String())
 	}
+	if err := ms.policyService.CheckMessage(ctx, &msg.Message); err != nil {
+		return err
+	}
 
 	msg.Nonce = 0
 
 	return ms.repo.MessageRepo().CreateMessage(msg)
 }
 
+// getOrCreateAddress returns the address, a new one is saved if not found
+func getOrCreateAddress(ctx context.Context, txRepo repo.TxRepo, addr address.Address) (*types.Address, error) {
+	addrInfo, err := txRepo.AddressRepo().GetAddress(ctx, addr)
+	if err == nil {
+		return addrInfo, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	addrInfo = &types.Address{
+		ID:        venusTypes.NewUUID(),
+		Addr:      addr,
+		Nonce:     0,
+		SelMsgNum: 0,
+		State:     types.AddressStateAlive,
+		IsDeleted: repo.NotDeleted,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err = txRepo.AddressRepo().SaveAddress(ctx, addrInfo); err != nil {
+		return nil, fmt.Errorf("save address %s failed %v", addr.String(), err)
+	}
+	log.Infof("add new address %s", addr.String())
+
+	return addrInfo, nil
+}
+
 func (ms *MessageService) PushMessage(ctx context.Context, msg *venusTypes.Message, meta *types.SendSpec) (string, error) {
 	return ms.PushMessageWithId(ctx, venusTypes.NewUUID().String(), msg, meta)
 }
@@ -288,6 +316,8 @@ func (ms *MessageService) WaitMessage(ctx context.Context, id string, confidence
 			// Error
 			case types.FailedMsg:
 				return msg, nil
+			case mtypes.CancelledMsg:
+				return msg, nil
 			}
 
 		case <-tm.C:
@@ -313,6 +343,19 @@ func (ms *MessageService) GetMessageByUid(ctx context.Context, id string) (*type
 	return msg, nil
 }
 
+// GetMessagePublishStatus returns the result of the last publishing of the message to each node.
+func (ms *MessageService) GetMessagePublishStatus(ctx context.Context, id string) ([]*mtypes.PublishReceipt, error) {
+	msg, err := ms.repo.MessageRepo().GetMessageByUid(id)
+	if err != nil {
+		return nil, err
+	}
+	if msg.SignedCid == nil {
+		return []*mtypes.PublishReceipt{}, nil
+	}
+
+	return ms.repo.PublishReceiptRepo().ListPublishReceipts(ctx, *msg.SignedCid)
+}
+
 func isChainMsg(msgState types.MessageState) bool {
 	return msgState == types.OnChainMsg || msgState == types.NonceConflictMsg
 }
@@ -854,6 +897,9 @@ func (ms *MessageService) ReplaceMessage(ctx context.Context, params *types.Repl
 	if err := ms.repo.MessageRepo().UpdateMessageByState(msg, types.FillMsg); err != nil {
 		return cid.Undef, err
 	}
+	if err := saveEthTransactions(ctx, ms.repo, msg); err != nil {
+		return cid.Undef, err
+	}
 	log.Infof("new message, gas fee cap: %v, gas premium: %v, gas limit: %d", msg.GasFeeCap, msg.GasPremium, msg.GasLimit)
 
 	return signedMsg.Cid(), ms.RepublishMessage(ctx, params.ID)
@@ -904,29 +950,24 @@ func (ms *MessageService) RepublishMessage(ctx context.Context, id string) error
 		Message:   msg.Message,
 		Signature: *msg.Signature,
 	}
-	select {
-	case ms.msgReceiver <- []*venusTypes.SignedMessage{signedMsg}:
-	default:
-		return fmt.Errorf("message receiver channel is full")
-	}
-	return nil
+	return ms.msgReceiver.PushMessages(ctx, []*venusTypes.SignedMessage{signedMsg})
 }
 
 func ToSignedMsg(ctx context.Context, walletCli gatewayAPI.IWalletClient, msg *types.Message, accounts []string) (venusTypes.SignedMessage, error) {
 	unsignedCid := msg.Message.Cid()
 	msg.UnsignedCid = &unsignedCid
 	// 签名
-	data, err := msg.Message.ToStorageBlock()
+	toSign, err := newToSignMessage(msg)
 	if err != nil {
 		return venusTypes.SignedMessage{}, fmt.Errorf("calc message unsigned message id %s fail %v", msg.ID, err)
 	}
-	sig, err := walletCli.WalletSign(ctx, msg.From, accounts, unsignedCid.Bytes(), venusTypes.MsgMeta{
-		Type:  venusTypes.MTChainMsg,
-		Extra: data.RawData(),
-	})
+	sig, err := walletCli.WalletSign(ctx, msg.From, accounts, toSign.data, toSign.meta)
 	if err != nil {
 		return venusTypes.SignedMessage{}, fmt.Errorf("wallet sign failed %s fail %v", msg.ID, err)
 	}
+	if err := checkSignature(ctx, walletCli, toSign, sig); err != nil {
+		return venusTypes.SignedMessage{}, fmt.Errorf("message %s: %w", msg.ID, err)
+	}
 
 	msg.Signature = sig
 	// state
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"sort"
 	"sync"
 	"testing"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
 
 	"github.com/stretchr/testify/assert"
 
@@ -30,6 +32,7 @@ import (
 	"github.com/ipfs-force-community/sophon-messager/models/repo"
 	"github.com/ipfs-force-community/sophon-messager/publisher"
 	"github.com/ipfs-force-community/sophon-messager/testhelper"
+	"github.com/ipfs-force-community/sophon-messager/utils"
 
 	"github.com/filecoin-project/venus/pkg/constants"
 	"github.com/filecoin-project/venus/venus-shared/testutil"
@@ -94,7 +97,7 @@ func TestReplaceMessage(t *testing.T) {
 			notBlockedMsgs = append(notBlockedMsgs, msg)
 		}
 	}
-	ms.msgSelectMgr.msgReceiver <- selectResult.ToPushMsg
+	assert.NoError(t, ms.msgSelectMgr.msgReceiver.PushMessages(ctx, selectResult.ToPushMsg))
 	checkMsgs(ctx, t, ms, msgs, notBlockedMsgs)
 
 	replacedMsgs := make([]*types.Message, 0, len(blockedMsgs))
@@ -509,6 +512,14 @@ func TestMessageService_PushMessage(t *testing.T) {
 		pushFailedMsg := testhelper.NewUnsignedMessage()
 		_, err = ms.PushMessage(ctx, &pushFailedMsg, nil)
 		assert.Error(t, err)
+		// the message of a delegated address must be able to be converted to an eth transaction
+		ethMsg := testhelper.NewUnsignedMessage()
+		ethMsg.From, err = utils.ParseAddress("0xd4c5fb16488aa48081296299d54b0c648c9333da")
+		assert.NoError(t, err)
+		ethMsg.To = addr
+		ethMsg.Method = builtin.MethodsMiner.ChangeWorkerAddress
+		_, err = ms.PushMessage(ctx, &ethMsg, nil)
+		assert.ErrorContains(t, err, "can't be converted to an eth transaction")
 		// msg with uuid not exists, expect an error
 		_, err = ms.GetMessageByUid(ctx, shared.NewUUID().String())
 		assert.Error(t, err)
@@ -603,7 +614,10 @@ func newMessageServiceHelper(ctx context.Context, t *testing.T, opts ...opt) *me
 		cfg.MessageService.SkipPushMessage = opt.skipPushMessage
 	}
 
-	fsRepo := filestore.NewMockFileStore(t.TempDir())
+	// not t.TempDir, the background loops may still hold the database for a while after the test
+	dir, err := os.MkdirTemp("", "messager-test")
+	assert.NoError(t, err)
+	fsRepo := filestore.NewMockFileStore(dir)
 	assert.NoError(t, fsRepo.ReplaceConfig(cfg))
 
 	fullNode, err := testhelper.NewMockFullNode(ctx, blockDelay)
@@ -612,21 +626,32 @@ func newMessageServiceHelper(ctx context.Context, t *testing.T, opts ...opt) *me
 	repo, err := models.SetDataBase(fsRepo)
 	assert.NoError(t, err)
 	assert.NoError(t, repo.AutoMigrate())
+	t.Cleanup(func() {
+		_ = repo.DbClose()
+		// the connection in use is closed once the running query is done
+		for i := 0; i < 10; i++ {
+			if err := os.RemoveAll(dir); err == nil {
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	})
 
 	authClient := testhelper.NewMockAuthClient(t)
 	walletProxy := gateway.NewMockWalletProxy()
 	addressService := NewAddressService(repo, walletProxy, authClient)
+	policyService := NewAddressPolicyService(repo, fullNode)
 	sharedParamsService, err := NewSharedParamsService(ctx, repo)
 	assert.NoError(t, err)
 
-	rpcPublisher := publisher.NewRpcPublisher(ctx, fullNode, repo.NodeRepo(), false, repo.MessageRepo())
+	rpcPublisher := publisher.NewRpcPublisher(ctx, fullNode, repo.NodeRepo(), false, repo.MessageRepo(), repo.PublishReceiptRepo())
 	networkParams := &shared.NetworkParams{BlockDelaySecs: 30}
 	msgPublisher, err := publisher.NewIMsgPublisher(ctx, networkParams, cfg.Publisher, nil, rpcPublisher)
 	assert.NoError(t, err)
 
-	msgReceiver, err := publisher.NewMessageReceiver(ctx, msgPublisher)
+	msgReceiver, err := publisher.NewMessageReceiver(ctx, cfg.Publisher, repo, msgPublisher)
 	assert.NoError(t, err)
-	ms, err := NewMessageService(ctx, repo, fullNode, fsRepo, addressService, sharedParamsService,
+	ms, err := NewMessageService(ctx, repo, fullNode, fsRepo, addressService, policyService, sharedParamsService,
 		walletProxy, msgReceiver)
 	assert.NoError(t, err)
 
@@ -681,6 +706,7 @@ func newMessageService(msh *messageServiceHelper) *MessageService {
 		fsRepo:         filestore.NewMockFileStore(msh.t.TempDir()),
 		nodeClient:     msh.fullNode,
 		addressService: msh.MessageService.addressService,
+		policyService:  msh.MessageService.policyService,
 		walletClient:   msh.walletProxy,
 		triggerPush:    msh.MessageService.triggerPush,
 		headChans:      make(chan *headChan, 10),
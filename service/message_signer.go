@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/ipfs-force-community/sophon-messager/metrics"
+	"github.com/ipfs-force-community/sophon-messager/wallet"
+)
+
+var (
+	errSignSkipped     = errors.New("skipped as a message with smaller nonce failed to sign")
+	errVerifySignature = errors.New("verify signature failed")
+)
+
+// walletBatchSigner is implemented by the wallet client which signs several messages of an address in one call,
+// the signatures and errors are in the order of toSign.
+type walletBatchSigner interface {
+	WalletSignBatch(ctx context.Context, addr address.Address, accounts []string, toSign [][]byte, metas []venusTypes.MsgMeta) ([]*crypto.Signature, []error)
+}
+
+type toSignMessage struct {
+	msg  *types.Message
+	data []byte
+	meta venusTypes.MsgMeta
+}
+
+func newToSignMessage(msg *types.Message) (*toSignMessage, error) {
+	data, err := msg.Message.ToStorageBlock()
+	if err != nil {
+		return nil, fmt.Errorf("serialize message failed: %v", err)
+	}
+
+	sb, err := msg.Message.SigningBytes(venusTypes.AddressProtocol2SignType(msg.Message.From.Protocol()))
+	if err != nil {
+		return nil, fmt.Errorf("get signing bytes failed: %v", err)
+	}
+
+	return &toSignMessage{
+		msg:  msg,
+		data: sb,
+		meta: venusTypes.MsgMeta{
+			Type:  venusTypes.MTChainMsg,
+			Extra: data.RawData(),
+		},
+	}, nil
+}
+
+// checkSignature verify the signature returned by the wallet client against the from address before saving it
+func checkSignature(ctx context.Context, walletCli interface{}, msg *toSignMessage, sig *crypto.Signature) error {
+	if err := wallet.VerifySignatureBy(walletCli, sig, msg.msg.From, msg.data); err != nil {
+		ctx, _ = tag.New(ctx, tag.Upsert(metrics.WalletAddress, msg.msg.From.String()))
+		stats.Record(ctx, metrics.SignInvalid.M(1))
+		return fmt.Errorf("%w: %w", errVerifySignature, err)
+	}
+	return nil
+}
+
+// signMessages sign the messages and verify the signatures, the messages are signed in one call if the wallet client
+// supports, otherwise concurrently by at most SignConcurrency workers, the signatures and errors are in the order of msgs.
+func (w *work) signMessages(ctx context.Context, msgs []*toSignMessage, accounts []string) ([]*crypto.Signature, []error) {
+	sigs, errs := w.doSignMessages(ctx, msgs, accounts)
+	for i, msg := range msgs {
+		if errs[i] == nil {
+			errs[i] = checkSignature(ctx, w.walletClient, msg, sigs[i])
+		}
+	}
+	return sigs, errs
+}
+
+func (w *work) doSignMessages(ctx context.Context, msgs []*toSignMessage, accounts []string) ([]*crypto.Signature, []error) {
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+	cfg := w.cfg.Load()
+
+	if signer, ok := w.walletClient.(walletBatchSigner); ok {
+		toSign := make([][]byte, len(msgs))
+		metas := make([]venusTypes.MsgMeta, len(msgs))
+		for i, msg := range msgs {
+			toSign[i] = msg.data
+			metas[i] = msg.meta
+		}
+
+		signCtx, cancel := context.WithTimeout(ctx, cfg.SignMessageTimeout)
+		defer cancel()
+		sigs, errs := signer.WalletSignBatch(signCtx, w.addr, accounts, toSign, metas)
+		if len(sigs) != len(msgs) || len(errs) != len(msgs) {
+			err := fmt.Errorf("%s: expect %d results, got %d signatures and %d errors", errSignMessage.Error(), len(msgs), len(sigs), len(errs))
+			sigs, errs = make([]*crypto.Signature, len(msgs)), make([]error, len(msgs))
+			for i := range errs {
+				errs[i] = err
+			}
+			return sigs, errs
+		}
+		for i, err := range errs {
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", errSignMessage.Error(), err)
+			}
+		}
+		return sigs, errs
+	}
+
+	sigs := make([]*crypto.Signature, len(msgs))
+	errs := make([]error, len(msgs))
+
+	var lk sync.Mutex
+	failed := len(msgs)
+	// the messages after a failed one will be dropped, no need to sign them
+	shouldSkip := func(index int) bool {
+		lk.Lock()
+		defer lk.Unlock()
+		return index > failed
+	}
+
+	workers := make(chan struct{}, cfg.SignConcurrency)
+	wg := sync.WaitGroup{}
+	for i, msg := range msgs {
+		workers <- struct{}{}
+		if shouldSkip(i) {
+			<-workers
+			errs[i] = errSignSkipped
+			continue
+		}
+		wg.Add(1)
+		go func(index int, msg *toSignMessage) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			signCtx, cancel := context.WithTimeout(ctx, cfg.SignMessageTimeout)
+			defer cancel()
+			sig, err := handleTimeout(signCtx, w.walletClient.WalletSign, []interface{}{w.addr, accounts, msg.data, msg.meta})
+			if err != nil {
+				errs[index] = fmt.Errorf("%s: %w", errSignMessage.Error(), err)
+				lk.Lock()
+				if index < failed {
+					failed = index
+				}
+				lk.Unlock()
+				return
+			}
+			sigs[index] = sig.(*crypto.Signature)
+		}(i, msg)
+	}
+	wg.Wait()
+
+	return sigs, errs
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/venus/venus-shared/testutil"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/gateway"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+	"github.com/ipfs-force-community/sophon-messager/wallet"
+)
+
+// slowWalletClient sign slowly to observe the concurrency, and fails to sign failData
+type slowWalletClient struct {
+	*gateway.MockWalletProxy
+	failData []byte
+
+	running    atomic.Int64
+	maxRunning atomic.Int64
+	signs      atomic.Int64
+}
+
+func (c *slowWalletClient) WalletSign(ctx context.Context, addr address.Address, accounts []string, toSign []byte, meta venusTypes.MsgMeta) (*crypto.Signature, error) {
+	c.signs.Add(1)
+	running := c.running.Add(1)
+	defer c.running.Add(-1)
+	for {
+		maxRunning := c.maxRunning.Load()
+		if running <= maxRunning || c.maxRunning.CompareAndSwap(maxRunning, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if bytes.Equal(toSign, c.failData) {
+		return nil, errors.New("mock sign error")
+	}
+	return c.MockWalletProxy.WalletSign(ctx, addr, accounts, toSign, meta)
+}
+
+// invalidWalletClient returns the signatures of a wrong type for invalidData
+type invalidWalletClient struct {
+	*gateway.MockWalletProxy
+	invalidData []byte
+}
+
+func (c *invalidWalletClient) WalletSign(ctx context.Context, addr address.Address, accounts []string, toSign []byte, meta venusTypes.MsgMeta) (*crypto.Signature, error) {
+	sig, err := c.MockWalletProxy.WalletSign(ctx, addr, accounts, toSign, meta)
+	if err == nil && bytes.Equal(toSign, c.invalidData) {
+		sig.Type = crypto.SigTypeBLS
+	}
+	return sig, err
+}
+
+type batchWalletClient struct {
+	*slowWalletClient
+	batches atomic.Int64
+}
+
+func (c *batchWalletClient) WalletSignBatch(ctx context.Context, addr address.Address, accounts []string, toSign [][]byte, metas []venusTypes.MsgMeta) ([]*crypto.Signature, []error) {
+	c.batches.Add(1)
+	sigs := make([]*crypto.Signature, len(toSign))
+	errs := make([]error, len(toSign))
+	for i := range toSign {
+		sigs[i], errs[i] = c.slowWalletClient.WalletSign(ctx, addr, accounts, toSign[i], metas[i])
+	}
+	return sigs, errs
+}
+
+func TestSignMessages(t *testing.T) {
+	ctx := context.Background()
+	addr := testutil.SecpAddressProvider(32)(t)
+	account := "a"
+
+	newMsgs := func(count int) []*toSignMessage {
+		msgs := make([]*toSignMessage, 0, count)
+		for i := 0; i < count; i++ {
+			msg := testhelper.NewMessage()
+			msg.From = addr
+			msg.Nonce = uint64(i)
+			toSign, err := newToSignMessage(msg)
+			require.NoError(t, err)
+			msgs = append(msgs, toSign)
+		}
+		return msgs
+	}
+	newWalletClient := func(failData []byte) *slowWalletClient {
+		c := &slowWalletClient{MockWalletProxy: gateway.NewMockWalletProxy(), failData: failData}
+		require.NoError(t, c.AddAddress(account, []address.Address{addr}))
+		return c
+	}
+	cfg := config.DefaultConfig().MessageService
+	cfg.SignConcurrency = 3
+
+	t.Run("concurrent signing", func(t *testing.T) {
+		msgs := newMsgs(20)
+		walletClient := newWalletClient(nil)
+		w := newWork(ctx, addr, &cfg, nil, nil, nil, nil, walletClient, nil)
+
+		sigs, errs := w.signMessages(ctx, msgs, []string{account})
+		for i, msg := range msgs {
+			require.NoError(t, errs[i])
+			// the signature of mock wallet is the data appended with address
+			assert.Equal(t, append(msg.data, addr.Bytes()...), sigs[i].Data)
+		}
+		assert.Equal(t, int64(cfg.SignConcurrency), walletClient.maxRunning.Load())
+	})
+
+	t.Run("the messages after the failed are skipped", func(t *testing.T) {
+		msgs := newMsgs(20)
+		walletClient := newWalletClient(msgs[5].data)
+		w := newWork(ctx, addr, &cfg, nil, nil, nil, nil, walletClient, nil)
+
+		sigs, errs := w.signMessages(ctx, msgs, []string{account})
+		for i := 0; i < 5; i++ {
+			require.NoError(t, errs[i])
+			assert.NotNil(t, sigs[i])
+		}
+		assert.ErrorContains(t, errs[5], errSignMessage.Error())
+		assert.ErrorContains(t, errs[5], "mock sign error")
+		assert.ErrorIs(t, errs[len(msgs)-1], errSignSkipped)
+		assert.Less(t, walletClient.signs.Load(), int64(len(msgs)))
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		msgs := newMsgs(5)
+		walletClient := &invalidWalletClient{MockWalletProxy: newWalletClient(nil).MockWalletProxy, invalidData: msgs[2].data}
+		w := newWork(ctx, addr, &cfg, nil, nil, nil, nil, walletClient, nil)
+
+		sigs, errs := w.signMessages(ctx, msgs, []string{account})
+		for i := range msgs {
+			if i == 2 {
+				assert.ErrorIs(t, errs[i], errVerifySignature)
+				assert.ErrorIs(t, errs[i], wallet.ErrInvalidSignature)
+				continue
+			}
+			require.NoError(t, errs[i])
+			assert.NotNil(t, sigs[i])
+		}
+
+		_, err := ToSignedMsg(ctx, walletClient, msgs[2].msg, []string{account})
+		assert.ErrorIs(t, err, errVerifySignature)
+		assert.Nil(t, msgs[2].msg.SignedCid)
+		signed, err := ToSignedMsg(ctx, walletClient, msgs[3].msg, []string{account})
+		require.NoError(t, err)
+		assert.Equal(t, signed.Cid(), *msgs[3].msg.SignedCid)
+	})
+
+	t.Run("batch signing", func(t *testing.T) {
+		msgs := newMsgs(5)
+		walletClient := &batchWalletClient{slowWalletClient: newWalletClient(msgs[3].data)}
+		w := newWork(ctx, addr, &cfg, nil, nil, nil, nil, walletClient, nil)
+
+		sigs, errs := w.signMessages(ctx, msgs, []string{account})
+		assert.Equal(t, int64(1), walletClient.batches.Load())
+		for i := range msgs {
+			if i == 3 {
+				assert.ErrorContains(t, errs[i], errSignMessage.Error())
+				continue
+			}
+			require.NoError(t, errs[i])
+			assert.NotNil(t, sigs[i])
+		}
+	})
+}
+
+var _ walletBatchSigner = (*batchWalletClient)(nil)
+
+func TestSelectMessageWithBatchSigner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msh := newMessageServiceHelper(ctx, t, skipPushMessage())
+	addrs := msh.genAddresses()
+	ms := msh.MessageService
+	walletClient := &batchWalletClient{slowWalletClient: &slowWalletClient{MockWalletProxy: msh.walletProxy}}
+	ms.walletClient = walletClient
+	msh.start()
+	defer msh.stop()
+
+	msgs := msh.genAndPushMessages(len(addrs) * 10)
+
+	ts, err := msh.fullNode.ChainHead(ctx)
+	require.NoError(t, err)
+	selectResult := selectMsgWithAddress(ctx, t, msh, addrs, ts)
+	assert.Len(t, selectResult.SelectMsg, len(msgs))
+	assert.Empty(t, selectResult.ErrMsg)
+	assert.Equal(t, int64(len(addrs)), walletClient.batches.Load())
+
+	assert.NoError(t, ms.msgSelectMgr.msgReceiver.PushMessages(ctx, selectResult.ToPushMsg))
+	checkMsgs(ctx, t, ms, msgs, selectResult.SelectMsg)
+}
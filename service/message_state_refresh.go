@@ -90,6 +90,11 @@ func (ms *MessageService) updateMessageState(applyMsgs []applyMessage, revertMsg
 		}
 
 		for _, msg := range applyMsgs {
+			if applied, err := applyCancelledMessage(txRepo, msg); err != nil {
+				return err
+			} else if applied {
+				continue
+			}
 			// 两个 `nonce` 都为 `0` 的消息，第一条消息预估gas失败了，第二条消息成功上链，
 			// 若只按 `from` 和 `nonce` 查询，查到的是第一条消息，这样第二条消息一直是 `FillMsg`
 			localMsg, err := txRepo.MessageRepo().GetMessageByFromNonceAndState(msg.msg.From, msg.msg.Nonce, types.FillMsg)
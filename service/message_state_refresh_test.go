@@ -165,7 +165,7 @@ func TestDoRefreshMessageState(t *testing.T) {
 		defer calcel()
 
 		go func() {
-			ms.msgSelectMgr.msgReceiver <- selectResult.ToPushMsg
+			assert.NoError(t, ms.msgSelectMgr.msgReceiver.PushMessages(ctx, selectResult.ToPushMsg))
 		}()
 		for i, msg := range cm.srcMsgs {
 			res, err := waitMsgWithTimeout(ctx, ms, msg.ID)
@@ -215,7 +215,7 @@ func TestDoRefreshMessageState(t *testing.T) {
 		ctx, calcel := context.WithTimeout(ctx, time.Minute*3)
 		defer calcel()
 		go func() {
-			ms.msgSelectMgr.msgReceiver <- selectResult.ToPushMsg
+			assert.NoError(t, ms.msgSelectMgr.msgReceiver.PushMessages(ctx, selectResult.ToPushMsg))
 		}()
 
 		fillMsg := selectResult.SelectMsg[0]
@@ -18,9 +18,13 @@ func MessagerService() fx.Option {
 	return fx.Options(
 		fx.Provide(NewMessageService),
 		fx.Provide(NewAddressService),
+		fx.Provide(NewAddressPolicyService),
+		fx.Provide(NewAuditService),
+		fx.Provide(NewConfigReloader),
 		fx.Provide(NewSharedParamsService),
 		fx.Provide(NewINodeService),
 		fx.Provide(NewNodeService),
+		fx.Provide(NewMpoolReconciler),
 	)
 }
 
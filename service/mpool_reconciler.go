@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-jsonrpc"
+	v1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/ipfs/go-cid"
+	"go.uber.org/fx"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+	"github.com/ipfs-force-community/sophon-messager/nodepool"
+)
+
+type nodeDialer func(ctx context.Context, addr, token string) (v1.FullNode, jsonrpc.ClientCloser, error)
+
+type reconcileNode struct {
+	name   string
+	url    string
+	token  string
+	client v1.FullNode
+	closer jsonrpc.ClientCloser
+	err    error
+}
+
+// MpoolReconciler compare the filled messages with the pending messages in the mpool of the main node and
+// the nodes in NodeRepo, push the missing messages again and report the foreign messages from local addresses.
+// The connections of the node pool are reused when it is enabled, otherwise the nodes are connected once and
+// kept until they are changed in NodeRepo, the nodes with the same url are only reconciled once.
+type MpoolReconciler struct {
+	repo       repo.Repo
+	nodeClient v1.FullNode
+	mainURL    string
+	pool       *nodepool.NodePool
+	dial       nodeDialer
+
+	lk    sync.Mutex
+	nodes map[string]*reconcileNode
+}
+
+type MpoolReconcilerParams struct {
+	fx.In
+
+	Repo       repo.Repo
+	NodeClient v1.FullNode
+	NodeCfg    *config.NodeConfig
+	// NodePool is nil if not enabled
+	NodePool *nodepool.NodePool `optional:"true"`
+}
+
+func NewMpoolReconciler(params MpoolReconcilerParams) *MpoolReconciler {
+	return &MpoolReconciler{
+		repo:       params.Repo,
+		nodeClient: params.NodeClient,
+		mainURL:    params.NodeCfg.Url,
+		pool:       params.NodePool,
+		dial: func(ctx context.Context, addr, token string) (v1.FullNode, jsonrpc.ClientCloser, error) {
+			return v1.DialFullNodeRPC(ctx, addr, token, http.Header{})
+		},
+		nodes: make(map[string]*reconcileNode),
+	}
+}
+
+// connectNodes returns the main node and the nodes in NodeRepo, only the new or changed nodes are connected.
+func (r *MpoolReconciler) connectNodes(ctx context.Context) ([]*reconcileNode, error) {
+	if r.pool != nil {
+		poolNodes := r.pool.Nodes()
+		nodes := make([]*reconcileNode, 0, len(poolNodes))
+		for _, n := range poolNodes {
+			nodes = append(nodes, &reconcileNode{name: n.Name, url: n.URL, client: n.Client})
+		}
+		return nodes, nil
+	}
+
+	list, err := r.repo.NodeRepo().ListNode()
+	if err != nil {
+		return nil, fmt.Errorf("list node failed: %w", err)
+	}
+
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	nodes := []*reconcileNode{{name: nodepool.MainNodeName, url: r.mainURL, client: r.nodeClient}}
+	exists := r.nodes
+	r.nodes = make(map[string]*reconcileNode, len(list))
+	for _, node := range list {
+		if _, ok := r.nodes[node.URL]; ok || node.URL == r.mainURL {
+			continue
+		}
+		n, ok := exists[node.URL]
+		if ok && n.token == node.Token {
+			n.name = node.Name
+			delete(exists, node.URL)
+		} else {
+			n = &reconcileNode{name: node.Name, url: node.URL, token: node.Token}
+			n.client, n.closer, n.err = r.dial(ctx, node.URL, node.Token)
+			if n.err != nil {
+				// connect again next time
+				nodes = append(nodes, n)
+				continue
+			}
+		}
+		r.nodes[node.URL] = n
+		nodes = append(nodes, n)
+	}
+	for _, n := range exists {
+		n.closer()
+	}
+
+	return nodes, nil
+}
+
+// Close disconnect the nodes connected by the reconciler.
+func (r *MpoolReconciler) Close() {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+
+	for _, n := range r.nodes {
+		n.closer()
+	}
+	r.nodes = make(map[string]*reconcileNode)
+}
+
+// Diff compare the mpool of nodes without pushing the missing messages.
+func (r *MpoolReconciler) Diff(ctx context.Context) ([]*mtypes.MpoolDiff, error) {
+	return r.reconcile(ctx, false)
+}
+
+// Reconcile compare the mpool of nodes and push the missing messages to the node.
+func (r *MpoolReconciler) Reconcile(ctx context.Context) ([]*mtypes.MpoolDiff, error) {
+	return r.reconcile(ctx, true)
+}
+
+func (r *MpoolReconciler) reconcile(ctx context.Context, repush bool) ([]*mtypes.MpoolDiff, error) {
+	addrs, err := r.repo.AddressRepo().ListActiveAddress(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list address failed: %w", err)
+	}
+	localAddrs := make(map[address.Address]struct{}, len(addrs))
+	filled := make(map[address.Address][]*types.Message)
+	filledCids := make(map[cid.Cid]struct{})
+	for _, addr := range addrs {
+		localAddrs[addr.Addr] = struct{}{}
+		msgs, err := r.repo.MessageRepo().ListFilledMessageByAddress(addr.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("list filled message of %s failed: %w", addr.Addr, err)
+		}
+		// the replacement of a cancelling message takes the nonce, don't push the original again
+		msgs, err = excludeCancellingMessage(ctx, r.repo, addr.Addr, msgs)
+		if err != nil {
+			return nil, fmt.Errorf("exclude cancelling message of %s failed: %w", addr.Addr, err)
+		}
+		for _, msg := range msgs {
+			if msg.SignedCid == nil || msg.Signature == nil {
+				continue
+			}
+			filled[addr.Addr] = append(filled[addr.Addr], msg)
+			filledCids[*msg.SignedCid] = struct{}{}
+		}
+	}
+
+	nodes, err := r.connectNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	diffs := make([]*mtypes.MpoolDiff, 0, len(nodes))
+	for _, node := range nodes {
+		if node.err != nil {
+			diffs = append(diffs, &mtypes.MpoolDiff{Node: node.name, Error: fmt.Sprintf("connect node failed: %v", node.err)})
+			continue
+		}
+		diffs = append(diffs, r.reconcileNode(ctx, node.name, node.client, localAddrs, filled, filledCids, repush))
+	}
+
+	return diffs, nil
+}
+
+func (r *MpoolReconciler) reconcileNode(ctx context.Context,
+	name string,
+	client v1.FullNode,
+	localAddrs map[address.Address]struct{},
+	filled map[address.Address][]*types.Message,
+	filledCids map[cid.Cid]struct{},
+	repush bool,
+) *mtypes.MpoolDiff {
+	diff := &mtypes.MpoolDiff{Node: name, Missing: []*mtypes.MpoolMessage{}, Foreign: []*mtypes.MpoolMessage{}}
+
+	pending, err := client.MpoolPending(ctx, venusTypes.EmptyTSK)
+	if err != nil {
+		diff.Error = fmt.Sprintf("get pending messages failed: %v", err)
+		return diff
+	}
+	pendingCids := make(map[cid.Cid]struct{}, len(pending))
+	for _, msg := range pending {
+		c := msg.Cid()
+		pendingCids[c] = struct{}{}
+		if _, ok := localAddrs[msg.Message.From]; !ok {
+			continue
+		}
+		if _, ok := filledCids[c]; ok {
+			continue
+		}
+		// the message may be replaced or on chain
+		if _, err := r.repo.MessageRepo().GetMessageBySignedCid(c); err == nil || !errors.Is(err, repo.ErrRecordNotFound) {
+			continue
+		}
+		log.Warnf("found foreign message %s in the mpool of node %s, from: %s, nonce: %d", c, name, msg.Message.From, msg.Message.Nonce)
+		diff.Foreign = append(diff.Foreign, &mtypes.MpoolMessage{Cid: c, From: msg.Message.From, Nonce: msg.Message.Nonce})
+	}
+
+	for addr, msgs := range filled {
+		// the messages below the nonce of actor are on chain, but the state has not been updated yet
+		var nonce uint64
+		if actor, err := client.StateGetActor(ctx, addr, venusTypes.EmptyTSK); err == nil {
+			nonce = actor.Nonce
+		}
+		var missing []*venusTypes.SignedMessage
+		for _, msg := range msgs {
+			if msg.Nonce < nonce {
+				continue
+			}
+			if _, ok := pendingCids[*msg.SignedCid]; ok {
+				continue
+			}
+			diff.Missing = append(diff.Missing, &mtypes.MpoolMessage{ID: msg.ID, Cid: *msg.SignedCid, From: msg.From, Nonce: msg.Nonce})
+			missing = append(missing, &venusTypes.SignedMessage{Message: msg.Message, Signature: *msg.Signature})
+		}
+		if !repush || len(missing) == 0 {
+			continue
+		}
+		sort.Slice(missing, func(i, j int) bool {
+			return missing[i].Message.Nonce < missing[j].Message.Nonce
+		})
+		log.Infof("push %d messages of %s missing in the mpool of node %s", len(missing), addr, name)
+		if _, err := client.MpoolBatchPushUntrusted(ctx, missing); err != nil {
+			log.Warnf("push missing messages of %s to node %s failed: %v", addr, name, err)
+		}
+	}
+	sort.Slice(diff.Missing, func(i, j int) bool {
+		if diff.Missing[i].From != diff.Missing[j].From {
+			return diff.Missing[i].From.String() < diff.Missing[j].From.String()
+		}
+		return diff.Missing[i].Nonce < diff.Missing[j].Nonce
+	})
+
+	return diff
+}
+
+// StartMpoolReconciler reconcile the mpool of nodes every MpoolReconcileInterval.
+func StartMpoolReconciler(lc fx.Lifecycle, ctx context.Context, r *MpoolReconciler, cfg *config.MessageServiceConfig) {
+	if cfg.MpoolReconcileInterval <= 0 || cfg.SkipPushMessage {
+		log.Infof("mpool reconciler is disabled")
+		return
+	}
+	interval := cfg.MpoolReconcileInterval
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			if _, err := r.connectNodes(ctx); err != nil {
+				log.Warnf("connect nodes failed: %v", err)
+			}
+			go func() {
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if _, err := r.Reconcile(ctx); err != nil {
+							log.Warnf("reconcile mpool failed: %v", err)
+						}
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			r.Close()
+			return nil
+		},
+	})
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-jsonrpc"
+	v1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1"
+	mockV1 "github.com/filecoin-project/venus/venus-shared/api/chain/v1/mock"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+	"github.com/ipfs-force-community/sophon-messager/filestore"
+	"github.com/ipfs-force-community/sophon-messager/models"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+	"github.com/ipfs-force-community/sophon-messager/nodepool"
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func TestMpoolReconciler(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	fsRepo := filestore.NewMockFileStore(t.TempDir())
+	repo, err := models.SetDataBase(fsRepo)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.AutoMigrate())
+
+	addrs := testhelper.RandAddresses(t, 2)
+	from, other := addrs[0], addrs[1]
+	assert.NoError(t, repo.AddressRepo().SaveAddress(ctx, &types.Address{
+		ID:        venusTypes.NewUUID(),
+		Addr:      from,
+		State:     types.AddressStateAlive,
+		IsDeleted: -1,
+	}))
+
+	// nonce 0 is on chain, but the state has not been updated
+	msgs := testhelper.NewSignedMessages(4)
+	for _, msg := range msgs {
+		msg.From = from
+		signedCid := (&venusTypes.SignedMessage{Message: msg.Message, Signature: *msg.Signature}).Cid()
+		msg.SignedCid = &signedCid
+		msg.State = types.FillMsg
+	}
+	assert.NoError(t, repo.MessageRepo().BatchSaveMessage(msgs[:3]))
+	toSigned := func(msg *types.Message) *venusTypes.SignedMessage {
+		return &venusTypes.SignedMessage{Message: msg.Message, Signature: *msg.Signature}
+	}
+	// not created by messager
+	foreign := toSigned(msgs[3])
+	otherMsg := testhelper.NewShareSignedMessage()
+	otherMsg.Message.From = other
+
+	mainNode := mockV1.NewMockFullNode(ctrl)
+	mainNode.EXPECT().MpoolPending(ctx, venusTypes.EmptyTSK).Return([]*venusTypes.SignedMessage{
+		toSigned(msgs[1]), foreign, otherMsg,
+	}, nil).Times(2)
+	mainNode.EXPECT().StateGetActor(ctx, from, venusTypes.EmptyTSK).Return(&venusTypes.Actor{Nonce: 1}, nil).Times(2)
+
+	reconciler := NewMpoolReconciler(MpoolReconcilerParams{
+		Repo:       repo,
+		NodeClient: mainNode,
+		NodeCfg:    &config.NodeConfig{Url: "/ip4/127.0.0.1/tcp/3453"},
+	})
+	expect := []*mtypes.MpoolDiff{{
+		Node:    nodepool.MainNodeName,
+		Missing: []*mtypes.MpoolMessage{{ID: msgs[2].ID, Cid: *msgs[2].SignedCid, From: from, Nonce: 2}},
+		Foreign: []*mtypes.MpoolMessage{{Cid: foreign.Cid(), From: from, Nonce: 3}},
+	}}
+	diffs, err := reconciler.Diff(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, expect, diffs)
+
+	// push the missing messages
+	mainNode.EXPECT().MpoolBatchPushUntrusted(ctx, []*venusTypes.SignedMessage{toSigned(msgs[2])}).Return(nil, nil).Times(1)
+	diffs, err = reconciler.Reconcile(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, expect, diffs)
+
+	// the message being cancelled is not pushed again until it or its replacement lands
+	assert.NoError(t, repo.MessageCancelRepo().SaveMessageCancel(ctx, &mtypes.MessageCancel{
+		MsgID:     msgs[2].ID,
+		ReplaceID: venusTypes.NewUUID().String(),
+		From:      from,
+		Nonce:     msgs[2].Nonce,
+		CreatedAt: time.Now(),
+	}))
+	mainNode.EXPECT().MpoolPending(ctx, venusTypes.EmptyTSK).Return([]*venusTypes.SignedMessage{toSigned(msgs[1])}, nil).Times(1)
+	mainNode.EXPECT().StateGetActor(ctx, from, venusTypes.EmptyTSK).Return(&venusTypes.Actor{Nonce: 1}, nil).Times(1)
+	diffs, err = reconciler.Reconcile(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, diffs, 1)
+	assert.Empty(t, diffs[0].Missing)
+	assert.Empty(t, diffs[0].Foreign)
+
+	// the node is not reachable
+	assert.NoError(t, repo.NodeRepo().SaveNode(&types.Node{ID: venusTypes.NewUUID(), Name: "node1", URL: "/ip4/127.0.0.1/tcp/1"}))
+	mainNode.EXPECT().MpoolPending(ctx, venusTypes.EmptyTSK).Return(nil, nil).Times(1)
+	mainNode.EXPECT().StateGetActor(ctx, from, venusTypes.EmptyTSK).Return(&venusTypes.Actor{Nonce: 3}, nil).Times(1)
+	diffs, err = reconciler.Diff(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, diffs, 2)
+	assert.Empty(t, diffs[0].Missing)
+	assert.Empty(t, diffs[0].Foreign)
+	assert.Equal(t, "node1", diffs[1].Node)
+	assert.NotEmpty(t, diffs[1].Error)
+	assert.NoError(t, repo.NodeRepo().DelNode("node1"))
+
+	// the nodes with the same url are reconciled once, and connected once
+	for _, node := range []*types.Node{
+		{ID: venusTypes.NewUUID(), Name: "main", URL: "/ip4/127.0.0.1/tcp/3453"},
+		{ID: venusTypes.NewUUID(), Name: "node2", URL: "/ip4/127.0.0.1/tcp/2"},
+		{ID: venusTypes.NewUUID(), Name: "node3", URL: "/ip4/127.0.0.1/tcp/2"},
+	} {
+		assert.NoError(t, repo.NodeRepo().SaveNode(node))
+	}
+	node2 := mockV1.NewMockFullNode(ctrl)
+	dialed := 0
+	reconciler.dial = func(_ context.Context, addr, _ string) (v1.FullNode, jsonrpc.ClientCloser, error) {
+		dialed++
+		return node2, func() {}, nil
+	}
+	for _, node := range []*mockV1.MockFullNode{mainNode, node2} {
+		node.EXPECT().MpoolPending(ctx, venusTypes.EmptyTSK).Return(nil, nil).Times(2)
+		node.EXPECT().StateGetActor(ctx, from, venusTypes.EmptyTSK).Return(&venusTypes.Actor{Nonce: 3}, nil).Times(2)
+	}
+	for i := 0; i < 2; i++ {
+		diffs, err = reconciler.Diff(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, diffs, 2)
+		assert.Equal(t, nodepool.MainNodeName, diffs[0].Node)
+		assert.Equal(t, "node2", diffs[1].Node)
+		assert.Empty(t, diffs[1].Error)
+	}
+	assert.Equal(t, 1, dialed)
+	reconciler.Close()
+
+}
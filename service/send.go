@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 
 	cbg "github.com/whyrusleeping/cbor-gen"
 
@@ -97,7 +98,7 @@ func (ms *MessageService) decodeTypedParamsFromJSON(ctx context.Context, to addr
 	}
 
 	p := reflect.New(methodMeta.Params.Elem()).Interface().(cbg.CBORMarshaler)
-	if err := json.Unmarshal([]byte(paramStr), p); err != nil {
+	if err := unmarshalParams(paramStr, p); err != nil {
 		return nil, fmt.Errorf("unmarshaling input into params type: %w", err)
 	}
 
@@ -107,3 +108,19 @@ func (ms *MessageService) decodeTypedParamsFromJSON(ctx context.Context, to addr
 	}
 	return buf.Bytes(), nil
 }
+
+// unmarshalParams unmarshal json params, the calldata of evm is also accepted in hex like eth, eg. "0xa9059cbb..."
+func unmarshalParams(paramStr string, p cbg.CBORMarshaler) error {
+	if calldata, ok := p.(*abi.CborBytes); ok {
+		var hexStr string
+		if err := json.Unmarshal([]byte(paramStr), &hexStr); err == nil && strings.HasPrefix(hexStr, "0x") {
+			data, err := venusTypes.DecodeHexString(hexStr)
+			if err != nil {
+				return err
+			}
+			*calldata = data
+			return nil
+		}
+	}
+	return json.Unmarshal([]byte(paramStr), p)
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v10/eam"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalParams(t *testing.T) {
+	var calldata abi.CborBytes
+	assert.NoError(t, unmarshalParams(`"0xa9059cbb"`, &calldata))
+	assert.Equal(t, abi.CborBytes{0xa9, 0x05, 0x9c, 0xbb}, calldata)
+
+	// base64 is still accepted
+	assert.NoError(t, unmarshalParams(`"AQI="`, &calldata))
+	assert.Equal(t, abi.CborBytes{1, 2}, calldata)
+
+	assert.Error(t, unmarshalParams(`"0xzz"`, &calldata))
+
+	var createParams eam.CreateParams
+	assert.NoError(t, unmarshalParams(`{"Initcode":"AQI=","Nonce":1}`, &createParams))
+	assert.Equal(t, []byte{1, 2}, createParams.Initcode)
+}
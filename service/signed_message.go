@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/ipfs-force-community/sophon-auth/core"
+	"gorm.io/gorm"
+
+	"github.com/ipfs-force-community/sophon-messager/models/repo"
+	"github.com/ipfs-force-community/sophon-messager/wallet"
+)
+
+// PushSignedMessage save a message signed outside messager as FillMsg, and publish it.
+// the nonce should be the next nonce of the address, it is reserved to avoid conflicting with the messages
+// signed by messager, then the message is published and tracked like other messages.
+func (ms *MessageService) PushSignedMessage(ctx context.Context, msg *venusTypes.SignedMessage) (string, error) {
+	if msg == nil {
+		return "", errors.New("message is nil")
+	}
+	from := msg.Message.From
+	if from.Protocol() == address.ID {
+		return "", fmt.Errorf("from address %s should not be an ID address", from)
+	}
+	data, err := msg.Message.SigningBytes(msg.Signature.Type)
+	if err != nil {
+		return "", err
+	}
+	if err := wallet.VerifySignature(&msg.Signature, from, data); err != nil {
+		return "", fmt.Errorf("verify signature failed: %w", err)
+	}
+
+	signedCid := msg.Cid()
+	if existed, err := ms.repo.MessageRepo().GetMessageBySignedCid(signedCid); err == nil {
+		log.Infof("signed message %s has been pushed as %s", signedCid, existed.ID)
+		return existed.ID, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	if err := ms.policyService.CheckMessage(ctx, &msg.Message); err != nil {
+		return "", err
+	}
+	nv, err := ms.nodeClient.StateNetworkVersion(ctx, venusTypes.EmptyTSK)
+	if err != nil {
+		return "", err
+	}
+	if err := msg.Message.ValidForBlockInclusion(0, nv); err != nil {
+		return "", fmt.Errorf("invalid message: %w", err)
+	}
+	actor, err := ms.nodeClient.StateGetActor(ctx, from, venusTypes.EmptyTSK)
+	if err != nil {
+		return "", fmt.Errorf("get actor %s failed: %w", from, err)
+	}
+
+	unlock, err := ms.msgSelectMgr.lockAddress(ctx, from)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	account, _ := core.CtxGetName(ctx)
+	unsignedCid := msg.Message.Cid()
+	dbMsg := &types.Message{
+		ID:          venusTypes.NewUUID().String(),
+		UnsignedCid: &unsignedCid,
+		SignedCid:   &signedCid,
+		Message:     msg.Message,
+		Signature:   &msg.Signature,
+		Meta:        &types.SendSpec{},
+		WalletName:  account,
+		State:       types.FillMsg,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	err = ms.repo.Transaction(func(txRepo repo.TxRepo) error {
+		addrInfo, err := getOrCreateAddress(ctx, txRepo, from)
+		if err != nil {
+			return err
+		}
+		if addrInfo.State == types.AddressStateForbbiden {
+			return fmt.Errorf("address(%s) is forbidden", from)
+		}
+
+		nextNonce := addrInfo.Nonce
+		if actor.Nonce > nextNonce {
+			nextNonce = actor.Nonce
+		}
+		if msg.Message.Nonce != nextNonce {
+			return fmt.Errorf("nonce %d doesn't match the next nonce %d of %s", msg.Message.Nonce, nextNonce, from)
+		}
+		// unfilled messages have no nonce yet
+		if _, err := txRepo.MessageRepo().GetMessageByFromNonceAndState(from, msg.Message.Nonce, types.FillMsg); err == nil {
+			return fmt.Errorf("nonce %d of %s has been used", msg.Message.Nonce, from)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := txRepo.MessageRepo().CreateMessage(dbMsg); err != nil {
+			return err
+		}
+		if err := saveEthTransactions(ctx, txRepo, dbMsg); err != nil {
+			return err
+		}
+		_, err = txRepo.AddressRepo().UpdateNonce(from, nextNonce+1)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	log.Infof("save signed message %s from %s with nonce %d as %s", signedCid, from, msg.Message.Nonce, dbMsg.ID)
+
+	// the selector will push it again if failed
+	if err := ms.msgReceiver.PushMessages(ctx, []*venusTypes.SignedMessage{msg}); err != nil {
+		log.Warnf("push signed message %s failed: %v", dbMsg.ID, err)
+	}
+
+	return dbMsg.ID, nil
+}
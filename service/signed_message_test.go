@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/circl/sign/bls"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/crypto"
+	vcrypto "github.com/filecoin-project/venus/pkg/crypto"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/testhelper"
+)
+
+func signMessage(t *testing.T, pk []byte, msg venusTypes.Message) *venusTypes.SignedMessage {
+	sig, err := vcrypto.Sign(msg.Cid().Bytes(), pk, crypto.SigTypeSecp256k1)
+	require.NoError(t, err)
+	return &venusTypes.SignedMessage{Message: msg, Signature: *sig}
+}
+
+// newBLSKey generate a bls key in go, as signing by venus requires filecoin-ffi
+func newBLSKey(t *testing.T) (*bls.PrivateKey[bls.KeyG1SigG2], address.Address) {
+	ikm := make([]byte, 32)
+	_, err := rand.Read(ikm)
+	require.NoError(t, err)
+	key, err := bls.KeyGen[bls.KeyG1SigG2](ikm, nil, nil)
+	require.NoError(t, err)
+	pub, err := key.PublicKey().MarshalBinary()
+	require.NoError(t, err)
+	addr, err := address.NewBLSAddress(pub)
+	require.NoError(t, err)
+	return key, addr
+}
+
+func signBLSMessage(key *bls.PrivateKey[bls.KeyG1SigG2], msg venusTypes.Message) *venusTypes.SignedMessage {
+	sig := crypto.Signature{Type: crypto.SigTypeBLS, Data: bls.Sign(key, msg.Cid().Bytes())}
+	return &venusTypes.SignedMessage{Message: msg, Signature: sig}
+}
+
+func TestPushSignedMessage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msh := newMessageServiceHelper(ctx, t)
+	pk, err := vcrypto.Generate(crypto.SigTypeSecp256k1)
+	require.NoError(t, err)
+	pub, err := vcrypto.ToPublic(crypto.SigTypeSecp256k1, pk)
+	require.NoError(t, err)
+	addr, err := address.NewSecp256k1Address(pub)
+	require.NoError(t, err)
+	blsKey, blsAddr := newBLSKey(t)
+	msh.addAddresses([]address.Address{addr, blsAddr})
+	ms := msh.MessageService
+	msh.start()
+	defer msh.stop()
+
+	rawMsg := testhelper.NewUnsignedMessage()
+	rawMsg.From = addr
+	rawMsg.To = addr
+	rawMsg.GasLimit = 1000000
+	rawMsg.GasFeeCap = big.NewInt(10000)
+	rawMsg.GasPremium = testhelper.MinPackedPremium
+
+	t.Run("invalid message", func(t *testing.T) {
+		gapMsg := rawMsg
+		gapMsg.Nonce = 1
+		_, err := ms.PushSignedMessage(ctx, signMessage(t, pk, gapMsg))
+		assert.ErrorContains(t, err, "doesn't match the next nonce 0")
+
+		badSig := signMessage(t, pk, rawMsg)
+		badSig.Message.Value = big.NewInt(1)
+		_, err = ms.PushSignedMessage(ctx, badSig)
+		assert.ErrorContains(t, err, "verify signature failed")
+
+		blsSig := signMessage(t, pk, rawMsg)
+		blsSig.Signature.Type = crypto.SigTypeBLS
+		_, err = ms.PushSignedMessage(ctx, blsSig)
+		assert.ErrorContains(t, err, "doesn't match the address")
+	})
+
+	t.Run("bls message", func(t *testing.T) {
+		blsMsg := rawMsg
+		blsMsg.From = blsAddr
+		otherKey, _ := newBLSKey(t)
+		_, err := ms.PushSignedMessage(ctx, signBLSMessage(otherKey, blsMsg))
+		assert.ErrorContains(t, err, "verify signature failed")
+
+		id, err := ms.PushSignedMessage(ctx, signBLSMessage(blsKey, blsMsg))
+		require.NoError(t, err)
+		msg, err := ms.GetMessageByUid(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, types.FillMsg, msg.State)
+	})
+
+	signedMsg := signMessage(t, pk, rawMsg)
+	id, err := ms.PushSignedMessage(ctx, signedMsg)
+	require.NoError(t, err)
+	// pushing again returns the same message
+	id2, err := ms.PushSignedMessage(ctx, signedMsg)
+	require.NoError(t, err)
+	assert.Equal(t, id, id2)
+
+	msg, err := ms.GetMessageByUid(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, types.FillMsg, msg.State)
+	assert.Equal(t, signedMsg.Cid(), *msg.SignedCid)
+	addrInfo, err := ms.addressService.GetAddress(ctx, addr)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), addrInfo.Nonce)
+
+	// the message signed by messager takes the next nonce
+	unsignedMsg := rawMsg
+	unsignedMsg.GasLimit = 0
+	otherID, err := ms.PushMessage(ctx, &unsignedMsg, nil)
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	res, err := waitMsgWithTimeout(ctx, ms, id)
+	require.NoError(t, err)
+	assert.Equal(t, types.OnChainMsg, res.State)
+	res, err = waitMsgWithTimeout(ctx, ms, otherID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), res.Nonce)
+}
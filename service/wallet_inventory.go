@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/ipfs-force-community/sophon-messager/gateway"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+// gatewayWalletsLister is implemented by the wallet client which connects to several gateways
+type gatewayWalletsLister interface {
+	ListGatewayWallets(ctx context.Context) []*gateway.GatewayWallets
+}
+
+// signerHealthLister is implemented by the wallet client which tracks the health of gateways
+type signerHealthLister interface {
+	SignerHealth() []*mtypes.SignerHealth
+}
+
+// ListSignerHealth returns the health of each gateway for each address which has been signed for
+func (addressService *AddressService) ListSignerHealth(_ context.Context) ([]*mtypes.SignerHealth, error) {
+	lister, ok := addressService.walletClient.(signerHealthLister)
+	if !ok {
+		return nil, fmt.Errorf("wallet client %T doesn't track the health of signers", addressService.walletClient)
+	}
+	return lister.SignerHealth(), nil
+}
+
+// ListWalletInventory compares the addresses in messager with the addresses signable by the online wallets,
+// the gateway of signer is empty if the wallet client doesn't connect to gateways, eg. the local signer.
+func (addressService *AddressService) ListWalletInventory(ctx context.Context) (*mtypes.WalletInventory, error) {
+	addrList, err := addressService.ListAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[address.Address]struct{}, len(addrList))
+	for _, addr := range addrList {
+		known[addr.Addr] = struct{}{}
+	}
+
+	var gws []*gateway.GatewayWallets
+	if lister, ok := addressService.walletClient.(gatewayWalletsLister); ok {
+		gws = lister.ListGatewayWallets(ctx)
+	} else {
+		wallets, err := addressService.walletClient.ListWalletInfo(ctx)
+		gws = []*gateway.GatewayWallets{{Wallets: wallets, Err: err}}
+	}
+
+	inventory := &mtypes.WalletInventory{
+		Signers:  []*mtypes.AddressSigner{},
+		NoSigner: []address.Address{},
+		Unknown:  []address.Address{},
+		Errors:   map[string]string{},
+	}
+	signable := make(map[address.Address]struct{})
+	for _, gw := range gws {
+		if gw.Err != nil {
+			inventory.Errors[gw.Gateway] = gw.Err.Error()
+			continue
+		}
+		for _, wallet := range gw.Wallets {
+			if wallet == nil {
+				continue
+			}
+			for _, state := range wallet.ConnectStates {
+				for _, addr := range state.Addrs {
+					_, isKnown := known[addr]
+					inventory.Signers = append(inventory.Signers, &mtypes.AddressSigner{
+						Address:   addr,
+						Known:     isKnown,
+						Gateway:   gw.Gateway,
+						Account:   wallet.Account,
+						WalletIP:  state.IP,
+						ChannelID: state.ChannelID.String(),
+					})
+					if !isKnown {
+						if _, ok := signable[addr]; !ok {
+							inventory.Unknown = append(inventory.Unknown, addr)
+						}
+					}
+					signable[addr] = struct{}{}
+				}
+			}
+		}
+	}
+	for _, addr := range addrList {
+		if _, ok := signable[addr.Addr]; !ok {
+			inventory.NoSigner = append(inventory.NoSigner, addr.Addr)
+		}
+	}
+
+	sort.SliceStable(inventory.Signers, func(i, j int) bool {
+		return inventory.Signers[i].Address.String() < inventory.Signers[j].Address.String()
+	})
+	sortAddrs := func(addrs []address.Address) {
+		sort.Slice(addrs, func(i, j int) bool {
+			return addrs[i].String() < addrs[j].String()
+		})
+	}
+	sortAddrs(inventory.NoSigner)
+	sortAddrs(inventory.Unknown)
+
+	return inventory, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/venus/venus-shared/testutil"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	types "github.com/filecoin-project/venus/venus-shared/types/messager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ipfs-force-community/sophon-messager/filestore"
+	"github.com/ipfs-force-community/sophon-messager/gateway"
+	"github.com/ipfs-force-community/sophon-messager/models"
+	"github.com/ipfs-force-community/sophon-messager/models/mtypes"
+)
+
+type mockGatewayWallets struct {
+	*gateway.MockWalletProxy
+	gws []*gateway.GatewayWallets
+}
+
+func (m *mockGatewayWallets) ListGatewayWallets(context.Context) []*gateway.GatewayWallets {
+	return m.gws
+}
+
+func TestListWalletInventory(t *testing.T) {
+	ctx := context.Background()
+
+	fsRepo := filestore.NewMockFileStore(t.TempDir())
+	repo, err := models.SetDataBase(fsRepo)
+	require.NoError(t, err)
+	require.NoError(t, repo.AutoMigrate())
+
+	// addrs[0] is served, addrs[1] has no signer, addrs[2] is unknown
+	addrs := make([]address.Address, 3)
+	for i := range addrs {
+		addrs[i] = testutil.SecpAddressProvider(32)(t)
+	}
+	for _, addr := range addrs[:2] {
+		require.NoError(t, repo.AddressRepo().SaveAddress(ctx, &types.Address{
+			ID:        venusTypes.NewUUID(),
+			Addr:      addr,
+			State:     types.AddressStateAlive,
+			IsDeleted: -1,
+		}))
+	}
+
+	t.Run("wallet client without gateway", func(t *testing.T) {
+		walletProxy := gateway.NewMockWalletProxy()
+		require.NoError(t, walletProxy.AddAddress("a", []address.Address{addrs[0], addrs[2]}))
+		addrSrv := NewAddressService(repo, walletProxy, nil)
+
+		inventory, err := addrSrv.ListWalletInventory(ctx)
+		require.NoError(t, err)
+		require.Len(t, inventory.Signers, 2)
+		for _, signer := range inventory.Signers {
+			assert.Equal(t, "a", signer.Account)
+			assert.Empty(t, signer.Gateway)
+			assert.Equal(t, signer.Address == addrs[0], signer.Known)
+		}
+		assert.Equal(t, []address.Address{addrs[1]}, inventory.NoSigner)
+		assert.Equal(t, []address.Address{addrs[2]}, inventory.Unknown)
+		assert.Empty(t, inventory.Errors)
+	})
+
+	t.Run("wallet client with gateways", func(t *testing.T) {
+		gw1 := gateway.NewMockWalletProxy()
+		require.NoError(t, gw1.AddAddress("a", addrs[:1]))
+		gw2 := gateway.NewMockWalletProxy()
+		require.NoError(t, gw2.AddAddress("b", addrs[:1]))
+		wallets1, err := gw1.ListWalletInfo(ctx)
+		require.NoError(t, err)
+		wallets2, err := gw2.ListWalletInfo(ctx)
+		require.NoError(t, err)
+
+		walletClient := &mockGatewayWallets{
+			MockWalletProxy: gateway.NewMockWalletProxy(),
+			gws: []*gateway.GatewayWallets{
+				{Gateway: "gw1", Wallets: wallets1},
+				{Gateway: "gw2", Wallets: wallets2},
+				{Gateway: "gw3", Err: errors.New("mock error")},
+			},
+		}
+		addrSrv := NewAddressService(repo, walletClient, nil)
+
+		inventory, err := addrSrv.ListWalletInventory(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*mtypes.AddressSigner{
+			{Address: addrs[0], Known: true, Gateway: "gw1", Account: "a", WalletIP: "127.0.0.1", ChannelID: venusTypes.UUID{}.String()},
+			{Address: addrs[0], Known: true, Gateway: "gw2", Account: "b", WalletIP: "127.0.0.1", ChannelID: venusTypes.UUID{}.String()},
+		}, inventory.Signers)
+		assert.Equal(t, []address.Address{addrs[1]}, inventory.NoSigner)
+		assert.Empty(t, inventory.Unknown)
+		assert.Equal(t, map[string]string{"gw3": "mock error"}, inventory.Errors)
+	})
+}
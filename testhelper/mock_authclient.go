@@ -18,20 +18,27 @@ type AuthClient struct {
 }
 
 func (m *AuthClient) Init(account string, addrs []address.Address) {
+	m.InitAccounts(map[string][]address.Address{account: addrs})
+}
+
+// InitAccounts bind the signers to each of the accounts, it should be called only once
+func (m *AuthClient) InitAccounts(accounts map[string][]address.Address) {
 	signers := make(map[address.Address]map[string]struct{})
-	for _, signer := range addrs {
-		if signer.Protocol() == address.ID {
-			signer, _ = ResolveIDAddr(signer)
-		}
+	for account, addrs := range accounts {
+		for _, signer := range addrs {
+			if signer.Protocol() == address.ID {
+				signer, _ = ResolveIDAddr(signer)
+			}
 
-		users, ok := signers[signer]
-		if !ok {
-			newUsers := make(map[string]struct{})
-			newUsers[account] = struct{}{}
-			signers[signer] = newUsers
-		} else {
-			if _, ok := users[account]; !ok {
-				users[account] = struct{}{}
+			users, ok := signers[signer]
+			if !ok {
+				newUsers := make(map[string]struct{})
+				newUsers[account] = struct{}{}
+				signers[signer] = newUsers
+			} else {
+				if _, ok := users[account]; !ok {
+					users[account] = struct{}{}
+				}
 			}
 		}
 	}
@@ -369,7 +369,7 @@ func (f *MockFullNode) StateNetworkName(_ context.Context) (types.NetworkName, e
 }
 
 func (f *MockFullNode) StateNetworkVersion(_ context.Context, _ types.TipSetKey) (network.Version, error) {
-	return network.Version17, nil
+	return network.Version18, nil
 }
 func (f *MockFullNode) StateGetNetworkParams(_ context.Context) (*types.NetworkParams, error) {
 	return &types.NetworkParams{
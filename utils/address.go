@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/venus/venus-shared/types"
+)
+
+// IsEthAddress returns whether s is an eth address with the prefix 0x
+func IsEthAddress(s string) bool {
+	return strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")
+}
+
+// ParseAddress parse a filecoin address, or an eth address with the prefix 0x, which is converted to the f410 address,
+// or the ID address if it's a masked ID address.
+func ParseAddress(s string) (address.Address, error) {
+	if !IsEthAddress(s) {
+		return address.NewFromString(s)
+	}
+	ethAddr, err := types.ParseEthAddress(s)
+	if err != nil {
+		return address.Undef, err
+	}
+	return ethAddr.ToFilecoinAddress()
+}
+
+// EthAddressString returns the eth address of a delegated address managed by the EAM, empty for other addresses
+func EthAddressString(addr address.Address) string {
+	if addr.Protocol() != address.Delegated {
+		return ""
+	}
+	ethAddr, err := types.EthAddressFromFilecoinAddress(addr)
+	if err != nil {
+		return ""
+	}
+	return ethAddr.String()
+}
+
+// FormatAddress returns the address with its eth address if it has one, eg. f410f...(0x...)
+func FormatAddress(addr address.Address) string {
+	if ethAddr := EthAddressString(addr); len(ethAddr) > 0 {
+		return addr.String() + "(" + ethAddr + ")"
+	}
+	return addr.String()
+}
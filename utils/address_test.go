@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddress(t *testing.T) {
+	ethAddr, err := types.ParseEthAddress("0xd4c5fb16488aa48081296299d54b0c648c9333da")
+	require.NoError(t, err)
+	f4Addr, err := ethAddr.ToFilecoinAddress()
+	require.NoError(t, err)
+	idAddr, err := address.NewIDAddress(1024)
+	require.NoError(t, err)
+
+	for _, c := range []struct {
+		in     string
+		expect address.Address
+		err    bool
+	}{
+		{in: "0xd4c5fb16488aa48081296299d54b0c648c9333da", expect: f4Addr},
+		{in: "0XD4C5FB16488AA48081296299D54B0C648C9333DA", expect: f4Addr},
+		{in: f4Addr.String(), expect: f4Addr},
+		{in: "0xff00000000000000000000000000000000000400", expect: idAddr},
+		{in: "f01024", expect: idAddr},
+		{in: "0x1234", err: true},
+		{in: "abc", err: true},
+	} {
+		addr, err := ParseAddress(c.in)
+		if c.err {
+			assert.Error(t, err, c.in)
+			continue
+		}
+		assert.NoError(t, err, c.in)
+		assert.Equal(t, c.expect, addr, c.in)
+	}
+
+	assert.Equal(t, "0xd4c5fb16488aa48081296299d54b0c648c9333da", EthAddressString(f4Addr))
+	assert.Equal(t, f4Addr.String()+"(0xd4c5fb16488aa48081296299d54b0c648c9333da)", FormatAddress(f4Addr))
+	assert.Empty(t, EthAddressString(idAddr))
+	assert.Equal(t, "f01024", FormatAddress(idAddr))
+}
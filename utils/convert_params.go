@@ -3,12 +3,14 @@ package utils
 import (
 	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math"
 	"reflect"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/venus/venus-shared/types"
 )
 
@@ -17,6 +19,10 @@ var (
 	bytesTyp      = reflect.TypeOf([]byte{})
 	ethAddressTyp = reflect.TypeOf(types.EthAddress{})
 	addrTyp       = reflect.TypeOf(address.Address{})
+	// the calldata and return of evm
+	cborBytesTyp = reflect.TypeOf(abi.CborBytes{})
+	// the types marshal themselves, eg. cid.Cid, big.Int
+	jsonMarshalerTyp = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
 )
 
 func TryConvertParams(in interface{}) (interface{}, error) {
@@ -32,7 +38,7 @@ func isNeedConvert(v reflect.Value) bool {
 		v = v.Elem()
 	}
 	if v.IsValid() {
-		if v.Type().AssignableTo(bitFieldTyp) || v.Type().AssignableTo(ethAddressTyp) {
+		if v.Type().AssignableTo(bitFieldTyp) || v.Type().AssignableTo(ethAddressTyp) || v.Type() == cborBytesTyp {
 			return true
 		}
 	}
@@ -80,6 +86,18 @@ func convertParams(rv reflect.Value) (any, error) {
 		if rv.Type().AssignableTo(addrTyp) {
 			return rv.Interface(), nil
 		}
+		// []byte is assignable to abi.CborBytes, so compare the type
+		if rv.Type() == cborBytesTyp {
+			return "0x" + hex.EncodeToString(rv.Bytes()), nil
+		}
+		if rv.Type().Implements(jsonMarshalerTyp) {
+			return rv.Interface(), nil
+		}
+		if reflect.PointerTo(rv.Type()).Implements(jsonMarshalerTyp) {
+			ptr := reflect.New(rv.Type())
+			ptr.Elem().Set(rv)
+			return ptr.Interface(), nil
+		}
 	}
 	switch rv.Kind() {
 	case reflect.Slice:
@@ -98,6 +116,9 @@ func convertParams(rv reflect.Value) (any, error) {
 	case reflect.Struct:
 		vals := make(map[string]interface{}, rv.NumField())
 		for i := 0; i < rv.NumField(); i++ {
+			if !rv.Type().Field(i).IsExported() {
+				continue
+			}
 			val, err := convertParams(rv.Field(i))
 			if err != nil {
 				return nil, err
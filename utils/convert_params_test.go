@@ -8,6 +8,7 @@ import (
 	"github.com/filecoin-project/go-bitfield"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/builtin/v10/eam"
+	"github.com/filecoin-project/go-state-types/builtin/v10/evm"
 	"github.com/filecoin-project/go-state-types/proof"
 	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
 	"github.com/filecoin-project/venus/venus-shared/testutil"
@@ -278,6 +279,23 @@ func TestTryConvertParams(t *testing.T) {
 	})
 }
 
+func TestConvertEVMParams(t *testing.T) {
+	calldata := abi.CborBytes{0xa9, 0x05, 0x9c, 0xbb}
+	res, err := TryConvertParams(&calldata)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xa9059cbb", res)
+
+	// other bytes are not converted
+	params := &evm.DelegateCallParams{Input: []byte{1, 2}, Caller: types.EthAddress{1}, Value: abi.NewTokenAmount(1)}
+	res, err = TryConvertParams(params)
+	assert.NoError(t, err)
+	val, ok := res.(map[string]interface{})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{1, 2}, val["Input"])
+	assert.Equal(t, "0x0100000000000000000000000000000000000000", val["Caller"])
+	equalMarshal(t, params.Value, val["Value"])
+}
+
 func equalMarshal(t *testing.T, expect, actual interface{}) {
 	d, err := json.Marshal(expect)
 	assert.NoError(t, err)
@@ -305,6 +323,8 @@ func TestHasBitfield(t *testing.T) {
 		{&types.ActiveBeneficiary{}, false},
 		{&types.ActivateDealsParams{}, false},
 		{&eam.CreateReturn{}, true},
+		{new(abi.CborBytes), true},
+		{&evm.DelegateCallParams{}, true},
 	}
 	for _, c := range cases {
 		testutil.Provide(t, c.typ, testutil.BytesFixedProvider(20))
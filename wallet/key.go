@@ -0,0 +1,91 @@
+package wallet
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	vcrypto "github.com/filecoin-project/venus/pkg/crypto"
+	_ "github.com/filecoin-project/venus/pkg/crypto/delegated"
+	_ "github.com/filecoin-project/venus/pkg/crypto/secp"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/ipfs/go-cid"
+)
+
+func sigType(kt venusTypes.KeyType) (crypto.SigType, error) {
+	switch kt {
+	case venusTypes.KTSecp256k1:
+		return crypto.SigTypeSecp256k1, nil
+	case venusTypes.KTBLS:
+		// bls signing requires filecoin-ffi
+		return crypto.SigTypeUnknown, fmt.Errorf("bls key is not supported by the local wallet, sign by sophon-gateway instead")
+	case venusTypes.KTDelegated:
+		return crypto.SigTypeDelegated, nil
+	default:
+		return crypto.SigTypeUnknown, fmt.Errorf("key type %s is not supported", kt)
+	}
+}
+
+// keyAddress returns the address of the key
+func keyAddress(ki *venusTypes.KeyInfo) (address.Address, error) {
+	typ, err := sigType(ki.Type)
+	if err != nil {
+		return address.Undef, err
+	}
+	pub, err := vcrypto.ToPublic(typ, ki.PrivateKey)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	switch typ {
+	case crypto.SigTypeSecp256k1:
+		return address.NewSecp256k1Address(pub)
+	default:
+		ethAddr, err := venusTypes.EthAddressFromPubKey(pub)
+		if err != nil {
+			return address.Undef, fmt.Errorf("failed to calculate eth address from public key: %w", err)
+		}
+		ea, err := venusTypes.CastEthAddress(ethAddr)
+		if err != nil {
+			return address.Undef, err
+		}
+		return ea.ToFilecoinAddress()
+	}
+}
+
+// signingBytes returns the bytes to be signed, a delegated key signs the rlp of the eth transaction
+// converted from the message, toSign can be the cid or the rlp, other keys sign toSign as is.
+func signingBytes(typ crypto.SigType, toSign []byte, meta venusTypes.MsgMeta) ([]byte, error) {
+	if typ != crypto.SigTypeDelegated || meta.Type != venusTypes.MTChainMsg {
+		return toSign, nil
+	}
+	msg, err := venusTypes.DecodeMessage(meta.Extra)
+	if err != nil {
+		return nil, fmt.Errorf("decode message: %w", err)
+	}
+	tx, err := venusTypes.Eth1559TxArgsFromUnsignedFilecoinMessage(msg)
+	if err != nil {
+		return nil, fmt.Errorf("convert message to eth transaction: %w", err)
+	}
+	rlp, err := tx.ToRlpUnsignedMsg()
+	if err != nil {
+		return nil, err
+	}
+	if c, err := cid.Cast(toSign); (err != nil || !c.Equals(msg.Cid())) && !bytes.Equal(toSign, rlp) {
+		return nil, fmt.Errorf("message in meta doesn't match the bytes to sign")
+	}
+	return rlp, nil
+}
+
+func sign(ki *venusTypes.KeyInfo, toSign []byte, meta venusTypes.MsgMeta) (*crypto.Signature, error) {
+	typ, err := sigType(ki.Type)
+	if err != nil {
+		return nil, err
+	}
+	data, err := signingBytes(typ, toSign, meta)
+	if err != nil {
+		return nil, err
+	}
+	return vcrypto.Sign(data, ki.PrivateKey, typ)
+}
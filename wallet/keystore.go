@@ -0,0 +1,256 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/ipfs-force-community/sophon-messager/config"
+)
+
+const (
+	// metaFile keeps the salt of the passphrase and a sealed text to check the passphrase
+	metaFile  = "keystore.json"
+	keySuffix = ".key"
+
+	checkText = "sophon-messager keystore"
+)
+
+// ErrKeyNotFound is returned when the key of the address is not in the keystore
+var ErrKeyNotFound = errors.New("key not found")
+
+type scryptParams struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt []byte `json:"salt"`
+}
+
+// default params of scrypt, it takes about 100ms to derive the key
+var defaultScryptParams = scryptParams{N: 1 << 15, R: 8, P: 1}
+
+type keystoreMeta struct {
+	Scrypt scryptParams `json:"scrypt"`
+	Check  []byte       `json:"check"`
+}
+
+type sealedKey struct {
+	Type venusTypes.KeyType `json:"type"`
+	// Sealed is the private key encrypted by AES-GCM, prefixed with the nonce
+	Sealed []byte `json:"sealed"`
+}
+
+// Keystore saves the private keys in a directory, each key is encrypted by the key derived from the passphrase.
+type Keystore struct {
+	dir  string
+	aead cipher.AEAD
+
+	lk sync.Mutex
+}
+
+// OpenKeystore open the keystore in dir with passphrase, the keystore is initialized with the passphrase at the first time,
+// an error is returned if the passphrase doesn't match the one used at initializing.
+func OpenKeystore(dir string, passphrase []byte) (*Keystore, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase is empty")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	var meta keystoreMeta
+	data, err := os.ReadFile(filepath.Join(dir, metaFile))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("decode keystore meta: %w", err)
+		}
+	case os.IsNotExist(err):
+		meta.Scrypt = defaultScryptParams
+		meta.Scrypt.Salt = make([]byte, 32)
+		if _, err := rand.Read(meta.Scrypt.Salt); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	derived, err := scrypt.Key(passphrase, meta.Scrypt.Salt, meta.Scrypt.N, meta.Scrypt.R, meta.Scrypt.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive key from passphrase: %w", err)
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	ks := &Keystore{dir: dir, aead: aead}
+
+	if len(meta.Check) > 0 {
+		if text, err := ks.open(meta.Check); err != nil || string(text) != checkText {
+			return nil, errors.New("wrong passphrase")
+		}
+		return ks, nil
+	}
+
+	if meta.Check, err = ks.seal([]byte(checkText)); err != nil {
+		return nil, err
+	}
+	data, err = json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, metaFile), data); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+func (ks *Keystore) seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, ks.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return ks.aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+func (ks *Keystore) open(sealed []byte) ([]byte, error) {
+	if len(sealed) < ks.aead.NonceSize() {
+		return nil, errors.New("sealed data is too short")
+	}
+	nonce, text := sealed[:ks.aead.NonceSize()], sealed[ks.aead.NonceSize():]
+	return ks.aead.Open(nil, nonce, text, nil)
+}
+
+// keyFile returns the file of the key named by the hex of address bytes, so it doesn't depend on the network prefix
+func (ks *Keystore) keyFile(addr address.Address) string {
+	return filepath.Join(ks.dir, hex.EncodeToString(addr.Bytes())+keySuffix)
+}
+
+// Import save the key into keystore, returns the address of the key
+func (ks *Keystore) Import(ki *venusTypes.KeyInfo) (address.Address, error) {
+	addr, err := keyAddress(ki)
+	if err != nil {
+		return address.Undef, err
+	}
+	sealed, err := ks.seal(ki.PrivateKey)
+	if err != nil {
+		return address.Undef, err
+	}
+	data, err := json.MarshalIndent(sealedKey{Type: ki.Type, Sealed: sealed}, "", "  ")
+	if err != nil {
+		return address.Undef, err
+	}
+
+	ks.lk.Lock()
+	defer ks.lk.Unlock()
+	if _, err := os.Stat(ks.keyFile(addr)); err == nil {
+		return address.Undef, fmt.Errorf("key of %s has been imported", addr)
+	}
+	return addr, writeFile(ks.keyFile(addr), data)
+}
+
+// Has returns whether the key of addr is in keystore
+func (ks *Keystore) Has(addr address.Address) (bool, error) {
+	_, err := os.Stat(ks.keyFile(addr))
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// Get returns the decrypted key of addr
+func (ks *Keystore) Get(addr address.Address) (*venusTypes.KeyInfo, error) {
+	data, err := os.ReadFile(ks.keyFile(addr))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, addr)
+		}
+		return nil, err
+	}
+	var key sealedKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("decode key of %s: %w", addr, err)
+	}
+	pk, err := ks.open(key.Sealed)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt key of %s: %w", addr, err)
+	}
+
+	return &venusTypes.KeyInfo{Type: key.Type, PrivateKey: pk}, nil
+}
+
+// List returns the addresses in keystore, sorted
+func (ks *Keystore) List() ([]address.Address, error) {
+	return ListKeys(ks.dir)
+}
+
+// ListKeys returns the addresses of the keys in dir without the passphrase, sorted
+func ListKeys(dir string) ([]address.Address, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	addrs := make([]address.Address, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), keySuffix) {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), keySuffix)
+		data, err := hex.DecodeString(name)
+		if err != nil {
+			log.Warnf("ignore invalid key file %s: %v", name, err)
+			continue
+		}
+		addr, err := address.NewFromBytes(data)
+		if err != nil {
+			log.Warnf("ignore invalid key file %s: %v", name, err)
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].String() < addrs[j].String()
+	})
+	return addrs, nil
+}
+
+// writeFile write data to a temporary file and rename it, so the file is never partially written
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// KeystoreDir returns the directory of keystore in cfg, a relative path is joined to the repo
+func KeystoreDir(repoPath string, cfg *config.SignerConfig) string {
+	if filepath.IsAbs(cfg.Keystore) {
+		return cfg.Keystore
+	}
+	return filepath.Join(repoPath, cfg.Keystore)
+}
@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	gatewayAPI "github.com/filecoin-project/venus/venus-shared/api/gateway/v2"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	gtypes "github.com/filecoin-project/venus/venus-shared/types/gateway"
+	logging "github.com/ipfs/go-log/v2"
+)
+
+var log = logging.Logger("local-wallet")
+
+// LocalAccount is the account of the wallet detail of local wallet
+const LocalAccount = "local"
+
+// LocalWallet sign messages with the keys in local keystore, the accounts are ignored as all keys belong to the messager.
+// The keys imported after starting are loaded at the first use.
+type LocalWallet struct {
+	ks *Keystore
+
+	lk   sync.RWMutex
+	keys map[address.Address]*venusTypes.KeyInfo
+}
+
+var _ gatewayAPI.IWalletClient = (*LocalWallet)(nil)
+
+// NewLocalWallet load all keys in keystore, so the broken keys are found at startup
+func NewLocalWallet(ks *Keystore) (*LocalWallet, error) {
+	w := &LocalWallet{ks: ks, keys: make(map[address.Address]*venusTypes.KeyInfo)}
+	addrs, err := ks.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		if _, err := w.getKey(addr); err != nil {
+			return nil, err
+		}
+	}
+	log.Infof("loaded %d keys from local keystore", len(addrs))
+
+	return w, nil
+}
+
+func (w *LocalWallet) getKey(addr address.Address) (*venusTypes.KeyInfo, error) {
+	w.lk.RLock()
+	ki, ok := w.keys[addr]
+	w.lk.RUnlock()
+	if ok {
+		return ki, nil
+	}
+
+	ki, err := w.ks.Get(addr)
+	if err != nil {
+		return nil, err
+	}
+	if actual, err := keyAddress(ki); err != nil {
+		return nil, err
+	} else if actual != addr {
+		return nil, fmt.Errorf("key of %s belongs to %s", addr, actual)
+	}
+	w.lk.Lock()
+	w.keys[addr] = ki
+	w.lk.Unlock()
+
+	return ki, nil
+}
+
+func (w *LocalWallet) WalletHas(_ context.Context, addr address.Address, _ []string) (bool, error) {
+	return w.ks.Has(addr)
+}
+
+func (w *LocalWallet) WalletSign(_ context.Context, addr address.Address, _ []string, toSign []byte, meta venusTypes.MsgMeta) (*crypto.Signature, error) {
+	ki, err := w.getKey(addr)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil, fmt.Errorf("can't find a wallet, address: %s", addr)
+		}
+		return nil, err
+	}
+	return sign(ki, toSign, meta)
+}
+
+// WalletSignBatch sign the messages of addr with the key loaded once, the signatures and errors are in the order of toSign
+func (w *LocalWallet) WalletSignBatch(ctx context.Context, addr address.Address, _ []string, toSign [][]byte, metas []venusTypes.MsgMeta) ([]*crypto.Signature, []error) {
+	sigs := make([]*crypto.Signature, len(toSign))
+	errs := make([]error, len(toSign))
+	if len(metas) != len(toSign) {
+		for i := range errs {
+			errs[i] = fmt.Errorf("expect %d metas, got %d", len(toSign), len(metas))
+		}
+		return sigs, errs
+	}
+
+	ki, err := w.getKey(addr)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			err = fmt.Errorf("can't find a wallet, address: %s", addr)
+		}
+		for i := range errs {
+			errs[i] = err
+		}
+		return sigs, errs
+	}
+	for i := range toSign {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		sigs[i], errs[i] = sign(ki, toSign[i], metas[i])
+	}
+
+	return sigs, errs
+}
+
+func (w *LocalWallet) ListWalletInfo(ctx context.Context) ([]*gtypes.WalletDetail, error) {
+	detail, err := w.ListWalletInfoByWallet(ctx, LocalAccount)
+	if err != nil {
+		return nil, err
+	}
+	return []*gtypes.WalletDetail{detail}, nil
+}
+
+func (w *LocalWallet) ListWalletInfoByWallet(_ context.Context, wallet string) (*gtypes.WalletDetail, error) {
+	if wallet != LocalAccount {
+		return nil, fmt.Errorf("wallet %s not found", wallet)
+	}
+	addrs, err := w.ks.List()
+	if err != nil {
+		return nil, err
+	}
+	return &gtypes.WalletDetail{
+		Account:       LocalAccount,
+		ConnectStates: []gtypes.ConnectState{{Addrs: addrs}},
+	}, nil
+}
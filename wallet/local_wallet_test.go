@@ -0,0 +1,223 @@
+package wallet
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/circl/sign/bls"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/crypto"
+	vcrypto "github.com/filecoin-project/venus/pkg/crypto"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T, kt venusTypes.KeyType) *venusTypes.KeyInfo {
+	typ, err := sigType(kt)
+	require.NoError(t, err)
+	pk, err := vcrypto.Generate(typ)
+	require.NoError(t, err)
+	return &venusTypes.KeyInfo{Type: kt, PrivateKey: pk}
+}
+
+func TestKeystore(t *testing.T) {
+	dir := t.TempDir()
+	_, err := OpenKeystore(dir, nil)
+	assert.Error(t, err)
+	ks, err := OpenKeystore(dir, []byte("passphrase"))
+	require.NoError(t, err)
+
+	secpKey := newKey(t, venusTypes.KTSecp256k1)
+	secpAddr, err := ks.Import(secpKey)
+	require.NoError(t, err)
+	assert.Equal(t, address.SECP256K1, secpAddr.Protocol())
+	_, err = ks.Import(secpKey)
+	assert.ErrorContains(t, err, "has been imported")
+
+	delegatedKey := newKey(t, venusTypes.KTDelegated)
+	delegatedAddr, err := ks.Import(delegatedKey)
+	require.NoError(t, err)
+	assert.Equal(t, address.Delegated, delegatedAddr.Protocol())
+
+	_, err = ks.Import(&venusTypes.KeyInfo{Type: venusTypes.KTBLS, PrivateKey: make([]byte, 32)})
+	assert.ErrorContains(t, err, "bls key is not supported")
+
+	// reopen with the passphrase
+	_, err = OpenKeystore(dir, []byte("wrong"))
+	assert.ErrorContains(t, err, "wrong passphrase")
+	ks, err = OpenKeystore(dir, []byte("passphrase"))
+	require.NoError(t, err)
+
+	addrs, err := ks.List()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []address.Address{secpAddr, delegatedAddr}, addrs)
+	has, err := ks.Has(secpAddr)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	ki, err := ks.Get(delegatedAddr)
+	require.NoError(t, err)
+	assert.Equal(t, delegatedKey, ki)
+	_, err = ks.Get(newAddress(t))
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func newAddress(t *testing.T) address.Address {
+	addr, err := keyAddress(newKey(t, venusTypes.KTSecp256k1))
+	require.NoError(t, err)
+	return addr
+}
+
+func TestLocalWallet(t *testing.T) {
+	ctx := context.Background()
+	ks, err := OpenKeystore(t.TempDir(), []byte("passphrase"))
+	require.NoError(t, err)
+	secpAddr, err := ks.Import(newKey(t, venusTypes.KTSecp256k1))
+	require.NoError(t, err)
+
+	w, err := NewLocalWallet(ks)
+	require.NoError(t, err)
+
+	// the key imported after starting is available
+	delegatedAddr, err := ks.Import(newKey(t, venusTypes.KTDelegated))
+	require.NoError(t, err)
+
+	for _, addr := range []address.Address{secpAddr, delegatedAddr} {
+		has, err := w.WalletHas(ctx, addr, nil)
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+	has, err := w.WalletHas(ctx, newAddress(t), nil)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	t.Run("secp", func(t *testing.T) {
+		msg := venusTypes.Message{From: secpAddr, To: secpAddr, Value: big.Zero(), GasFeeCap: big.Zero(), GasPremium: big.Zero()}
+		sig, err := w.WalletSign(ctx, secpAddr, nil, msg.Cid().Bytes(), venusTypes.MsgMeta{Type: venusTypes.MTChainMsg})
+		require.NoError(t, err)
+		assert.Equal(t, crypto.SigTypeSecp256k1, sig.Type)
+		assert.NoError(t, vcrypto.Verify(sig, secpAddr, msg.Cid().Bytes()))
+	})
+
+	t.Run("delegated", func(t *testing.T) {
+		msg := venusTypes.Message{
+			From:       delegatedAddr,
+			To:         delegatedAddr,
+			Value:      big.NewInt(1),
+			Method:     builtin.MethodsEVM.InvokeContract,
+			GasLimit:   1000000,
+			GasFeeCap:  big.NewInt(1000),
+			GasPremium: big.NewInt(100),
+		}
+		data, err := msg.ToStorageBlock()
+		require.NoError(t, err)
+		sig, err := w.WalletSign(ctx, delegatedAddr, nil, msg.Cid().Bytes(), venusTypes.MsgMeta{Type: venusTypes.MTChainMsg, Extra: data.RawData()})
+		require.NoError(t, err)
+		assert.Equal(t, crypto.SigTypeDelegated, sig.Type)
+
+		tx, err := venusTypes.Eth1559TxArgsFromUnsignedFilecoinMessage(&msg)
+		require.NoError(t, err)
+		rlp, err := tx.ToRlpUnsignedMsg()
+		require.NoError(t, err)
+		assert.NoError(t, vcrypto.Verify(sig, delegatedAddr, rlp))
+		assert.NoError(t, VerifySignature(sig, delegatedAddr, rlp))
+
+		// the rlp can be signed as is
+		sig, err = w.WalletSign(ctx, delegatedAddr, nil, rlp, venusTypes.MsgMeta{Type: venusTypes.MTChainMsg, Extra: data.RawData()})
+		require.NoError(t, err)
+		assert.NoError(t, VerifySignature(sig, delegatedAddr, rlp))
+
+		// the message in meta should match the bytes to sign
+		_, err = w.WalletSign(ctx, delegatedAddr, nil, msg.Cid().Bytes()[1:], venusTypes.MsgMeta{Type: venusTypes.MTChainMsg, Extra: data.RawData()})
+		assert.Error(t, err)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		toSign := [][]byte{[]byte("data0"), []byte("data1")}
+		sigs, errs := w.WalletSignBatch(ctx, secpAddr, nil, toSign, make([]venusTypes.MsgMeta, len(toSign)))
+		require.Len(t, sigs, 2)
+		for i := range toSign {
+			require.NoError(t, errs[i])
+			assert.NoError(t, vcrypto.Verify(sigs[i], secpAddr, toSign[i]))
+		}
+
+		_, errs = w.WalletSignBatch(ctx, newAddress(t), nil, toSign, make([]venusTypes.MsgMeta, len(toSign)))
+		for _, err := range errs {
+			assert.ErrorContains(t, err, "can't find a wallet")
+		}
+	})
+
+	_, err = w.WalletSign(ctx, newAddress(t), nil, []byte("data"), venusTypes.MsgMeta{})
+	assert.ErrorContains(t, err, "can't find a wallet")
+
+	details, err := w.ListWalletInfo(ctx)
+	require.NoError(t, err)
+	require.Len(t, details, 1)
+	assert.Equal(t, LocalAccount, details[0].Account)
+	assert.ElementsMatch(t, []address.Address{secpAddr, delegatedAddr}, details[0].ConnectStates[0].Addrs)
+}
+
+func TestVerifySignature(t *testing.T) {
+	secpKey := newKey(t, venusTypes.KTSecp256k1)
+	secpAddr, err := keyAddress(secpKey)
+	require.NoError(t, err)
+	data := []byte("data")
+	sig, err := sign(secpKey, data, venusTypes.MsgMeta{})
+	require.NoError(t, err)
+
+	assert.NoError(t, VerifySignature(sig, secpAddr, data))
+	assert.ErrorIs(t, VerifySignature(sig, secpAddr, []byte("other")), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature(sig, newAddress(t), data), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature(nil, secpAddr, data), ErrInvalidSignature)
+	// a wrong signature type is rejected before verifying
+	assert.ErrorContains(t, VerifySignature(&crypto.Signature{Type: crypto.SigTypeBLS, Data: sig.Data}, secpAddr, data), "doesn't match the address")
+
+	idAddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	err = VerifySignature(sig, idAddr, data)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidSignature)
+
+	t.Run("bls", func(t *testing.T) {
+		newBLSKey := func() (*bls.PrivateKey[bls.KeyG1SigG2], address.Address) {
+			ikm := make([]byte, 32)
+			_, err := rand.Read(ikm)
+			require.NoError(t, err)
+			key, err := bls.KeyGen[bls.KeyG1SigG2](ikm, nil, nil)
+			require.NoError(t, err)
+			pub, err := key.PublicKey().MarshalBinary()
+			require.NoError(t, err)
+			addr, err := address.NewBLSAddress(pub)
+			require.NoError(t, err)
+			return key, addr
+		}
+		blsKey, blsAddr := newBLSKey()
+		sig := &crypto.Signature{Type: crypto.SigTypeBLS, Data: bls.Sign(blsKey, data)}
+		assert.Len(t, sig.Data, blsSignatureLen)
+		assert.NoError(t, VerifySignature(sig, blsAddr, data))
+		assert.ErrorIs(t, VerifySignature(sig, blsAddr, []byte("other")), ErrInvalidSignature)
+
+		// forged signatures of 96 bytes are rejected
+		otherKey, _ := newBLSKey()
+		assert.ErrorIs(t, VerifySignature(&crypto.Signature{Type: crypto.SigTypeBLS, Data: bls.Sign(otherKey, data)}, blsAddr, data), ErrInvalidSignature)
+		forged := make([]byte, blsSignatureLen)
+		copy(forged, sig.Data)
+		forged[blsSignatureLen-1] ^= 1
+		assert.ErrorIs(t, VerifySignature(&crypto.Signature{Type: crypto.SigTypeBLS, Data: forged}, blsAddr, data), ErrInvalidSignature)
+		assert.ErrorIs(t, VerifySignature(&crypto.Signature{Type: crypto.SigTypeBLS, Data: make([]byte, blsSignatureLen)}, blsAddr, data), ErrInvalidSignature)
+		assert.ErrorIs(t, VerifySignature(&crypto.Signature{Type: crypto.SigTypeBLS, Data: make([]byte, 10)}, blsAddr, data), ErrInvalidSignature)
+
+		// the identity as the public key is rejected
+		infinity := make([]byte, 48)
+		infinity[0] = 0xc0
+		idtAddr, err := address.NewBLSAddress(infinity)
+		require.NoError(t, err)
+		infSig := make([]byte, blsSignatureLen)
+		infSig[0] = 0xc0
+		assert.ErrorIs(t, VerifySignature(&crypto.Signature{Type: crypto.SigTypeBLS, Data: infSig}, idtAddr, data), ErrInvalidSignature)
+	})
+}
@@ -0,0 +1,37 @@
+package wallet
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// PassphraseEnv is the environment variable of the passphrase of local keystore
+	PassphraseEnv = "SOPHON_MESSAGER_KEYSTORE_PASSPHRASE"
+	// PassphraseFileEnv is the environment variable of the file contains the passphrase
+	PassphraseFileEnv = PassphraseEnv + "_FILE"
+)
+
+// ReadPassphrase read the passphrase from the environment variables, or prompt for it if they are not set
+func ReadPassphrase(in io.Reader, out io.Writer) ([]byte, error) {
+	if v, ok := os.LookupEnv(PassphraseEnv); ok {
+		return []byte(v), nil
+	}
+	if path, ok := os.LookupEnv(PassphraseFileEnv); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read passphrase file: %w", err)
+		}
+		return []byte(strings.TrimRight(string(data), "\r\n")), nil
+	}
+
+	_, _ = fmt.Fprint(out, "Enter passphrase of keystore: ")
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return nil, fmt.Errorf("read passphrase: %w", err)
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
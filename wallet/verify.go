@@ -0,0 +1,69 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudflare/circl/sign/bls"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/crypto"
+	vcrypto "github.com/filecoin-project/venus/pkg/crypto"
+	venusTypes "github.com/filecoin-project/venus/venus-shared/types"
+)
+
+// the length of a bls signature
+const blsSignatureLen = 96
+
+// ErrInvalidSignature is the error of a signature which doesn't match the address or the data
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// SignatureVerifier is implemented by the signers verifying their signatures in their own way, eg. the mocks whose
+// signatures are not real.
+type SignatureVerifier interface {
+	VerifySignature(sig *crypto.Signature, addr address.Address, data []byte) error
+}
+
+// VerifySignature check sig is signed by addr for data, data is the signing bytes of the message, which is the rlp of
+// the eth transaction for a delegated address.
+func VerifySignature(sig *crypto.Signature, addr address.Address, data []byte) error {
+	if sig == nil {
+		return fmt.Errorf("%w: signature is nil", ErrInvalidSignature)
+	}
+	if addr.Protocol() == address.ID {
+		return fmt.Errorf("resolve the ID address %s before verifying", addr)
+	}
+	if expect := venusTypes.AddressProtocol2SignType(addr.Protocol()); sig.Type != expect {
+		return fmt.Errorf("%w: signature type %d doesn't match the address %s", ErrInvalidSignature, sig.Type, addr)
+	}
+	verify := vcrypto.Verify
+	if sig.Type == crypto.SigTypeBLS {
+		verify = verifyBLS
+	}
+	if err := verify(sig, addr, data); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// verifyBLS verify the bls signature without filecoin-ffi, which is only required to sign with bls keys.
+func verifyBLS(sig *crypto.Signature, addr address.Address, data []byte) error {
+	if len(sig.Data) != blsSignatureLen {
+		return fmt.Errorf("length of bls signature is %d", len(sig.Data))
+	}
+	var pub bls.PublicKey[bls.KeyG1SigG2]
+	if err := pub.UnmarshalBinary(addr.Payload()); err != nil {
+		return fmt.Errorf("invalid bls public key: %w", err)
+	}
+	if !bls.Verify(&pub, data, sig.Data) {
+		return fmt.Errorf("bls signature failed to verify")
+	}
+	return nil
+}
+
+// VerifySignatureBy verify sig by signer if it implements SignatureVerifier, otherwise by VerifySignature
+func VerifySignatureBy(signer interface{}, sig *crypto.Signature, addr address.Address, data []byte) error {
+	if v, ok := signer.(SignatureVerifier); ok {
+		return v.VerifySignature(sig, addr, data)
+	}
+	return VerifySignature(sig, addr, data)
+}